@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/batch"
+	"github.com/metapox/mcp-voicevox-go/pkg/config"
+	"github.com/metapox/mcp-voicevox-go/pkg/mcp"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchOutputDir   string
+	batchConcurrency int
+	batchFormat      string
+	batchForce       bool
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <file>",
+	Short: "CSV・JSON Lines・YAML の行をまとめて音声ファイルにする",
+	Long: `ファイルの行ごとに音声を合成し、出力ディレクトリに音声と manifest.json を保存します。
+行には id, text, speaker, speaker_id, speed_scale, pitch_scale, intonation_scale, volume_scale, output を書けます（text 以外は省略可）。
+同じ出力ディレクトリで再実行すると、前回合成して変わっていない行は飛ばし、失敗した行や変更した行だけを合成します。`,
+	Example: `  mcp-voicevox batch lines.csv -o out/ -j 4
+  mcp-voicevox batch script.yaml --output-format mp3 --default-speaker "ずんだもん ノーマル"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runBatch(cmd, args[0])
+	},
+}
+
+func init() {
+	batchCmd.Flags().StringVarP(&batchOutputDir, "output-dir", "o", "", "音声とマニフェストを保存するディレクトリ（省略時は入力ファイル名から拡張子を除いたディレクトリ）")
+	batchCmd.Flags().IntVarP(&batchConcurrency, "concurrency", "j", batch.DefaultConcurrency, fmt.Sprintf("同時に合成する行の数（1-%d）", batch.MaxConcurrency))
+	batchCmd.Flags().StringVar(&batchFormat, "format", "", "入力ファイルの形式（csv, jsonl, yaml。省略時は拡張子から判定）")
+	batchCmd.Flags().BoolVar(&batchForce, "force", false, "前回の結果を使わずに全ての行を合成し直す")
+	addVoiceFlags(batchCmd)
+	addSynthesisFlags(batchCmd)
+	addPreprocessFlags(batchCmd)
+	addOutputFlags(batchCmd)
+}
+
+func runBatch(cmd *cobra.Command, file string) error {
+	cfg, err := config.New()
+	if err != nil {
+		return err
+	}
+
+	// コマンドラインフラグで設定を上書き。バッチでは音声を再生しない
+	applyVoiceFlags(cmd, cfg)
+	if err := applyPreprocessFlags(cmd, cfg); err != nil {
+		return err
+	}
+	applySynthesisFlags(cmd, cfg)
+	if err := applyOutputFlags(cmd, cfg); err != nil {
+		return err
+	}
+	cfg.EnablePlayback = false
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	rows, err := batch.Load(file, batchFormat)
+	if err != nil {
+		return err
+	}
+	dir := batchOutputDir
+	if dir == "" {
+		dir = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+
+	handler, err := mcp.NewHandler(cfg)
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
+
+	// Ctrl+C で止めた場合も、終わった行はマニフェストに残して次の実行で飛ばす
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.SetOutput(os.Stderr)
+	log.Printf("バッチ合成を開始します: %s（%d行）→ %s, VOICEVOX URL: %s, 同時実行数: %d",
+		file, len(rows), dir, cfg.VoicevoxURL, batchConcurrency)
+	summary, err := handler.RunBatch(ctx, rows, mcp.BatchOptions{
+		Dir:         dir,
+		Concurrency: batchConcurrency,
+		Force:       batchForce,
+		Progress: func(entry batch.Entry, finished, total int) {
+			switch entry.Status {
+			case batch.StatusFailed:
+				log.Printf("[%d/%d] 失敗 %s: %s", finished, total, entry.ID, entry.Error)
+			default:
+				log.Printf("[%d/%d] 完了 %s → %s（%.2f秒）", finished, total, entry.ID, entry.Output, entry.Duration)
+			}
+		},
+	})
+	if summary == nil {
+		return err
+	}
+
+	fmt.Printf("出力先: %s\nマニフェスト: %s\n行数: %d（合成: %d, 前回の結果を使用: %d, 失敗: %d）\n",
+		summary.Dir, summary.Manifest, summary.Total, summary.Done, summary.Skipped, summary.Failed)
+	if cfg.OutputFormat != audio.FormatWAV {
+		fmt.Printf("形式: %s\n", cfg.OutputFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("batch interrupted: %w (run the same command again to resume)", err)
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed (run the same command again to retry them)", summary.Failed, summary.Total)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package cmd
+
+// diskFree はこのOSでは空き容量を調べられないため、errDiskFreeUnsupported を返します
+func diskFree(path string) (uint64, error) {
+	return 0, errDiskFreeUnsupported
+}
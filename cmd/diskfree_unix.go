@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package cmd
+
+import "syscall"
+
+// diskFree は path があるファイルシステムで、一般ユーザーが使える空き容量（バイト）を返します
+func diskFree(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
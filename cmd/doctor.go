@@ -0,0 +1,607 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/config"
+	"github.com/metapox/mcp-voicevox-go/pkg/mcp"
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// doctorMinFree と doctorLowFree は一時ディレクトリの空き容量の下限（失敗）と、警告する容量です
+	doctorMinFree = 10 * 1024 * 1024
+	doctorLowFree = 100 * 1024 * 1024
+	// doctorDialTimeout はリモートの再生先に接続できるかを確認するときの待ち時間です
+	doctorDialTimeout = 3 * time.Second
+	// doctorDefaultTimeout はエンジンへの接続とテスト合成をそれぞれ待つ既定の時間です
+	doctorDefaultTimeout = 60 * time.Second
+)
+
+// errDiskFreeUnsupported は空き容量を調べられないOSで diskFree が返すエラーです
+var errDiskFreeUnsupported = errors.New("checking free space is not supported on this platform")
+
+var (
+	doctorText    string
+	doctorPlay    bool
+	doctorTimeout time.Duration
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "エンジン・再生・一時ディレクトリ・設定を診断する",
+	Long: `VOICEVOXエンジンへの接続、話者、再生バックエンド、一時ディレクトリ、設定を順に確認し、テスト合成を行って結果を表示します。
+設定は stdio と同じフラグ・環境変数から読み込み、値ごとにどこで指定されたか（フラグ・環境変数・既定値）を表示します。
+失敗した項目がある場合は終了コード 1 で終了します。`,
+	Example: `  mcp-voicevox doctor
+  mcp-voicevox doctor --voicevox-url http://192.168.0.10:50021 --default-speaker "ずんだもん あまあま"
+  mcp-voicevox doctor --play`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runDoctor(cmd)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().StringVar(&doctorText, "text", "音声合成のテストです。", "テスト合成するテキスト")
+	doctorCmd.Flags().BoolVar(&doctorPlay, "play", false, "テスト合成した音声を再生バックエンドで再生する")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", doctorDefaultTimeout, "エンジンへの接続とテスト合成をそれぞれ待つ時間")
+	doctorCmd.Flags().StringVarP(&tempDir, "temp-dir", "t", "", "一時ファイルを保存するディレクトリ")
+	doctorCmd.Flags().BoolVar(&saveAudio, "save-audio", true, "合成した音声をWAVファイルとして一時ディレクトリに保存する")
+	doctorCmd.Flags().IntSliceVar(&warmupStyles, "warmup-styles", nil, "起動時に事前初期化するスタイルID（カンマ区切り）")
+	addVoiceFlags(doctorCmd)
+	addPlaybackFlags(doctorCmd)
+	addSynthesisFlags(doctorCmd)
+	addPreprocessFlags(doctorCmd)
+	addOutputFlags(doctorCmd)
+}
+
+// checkStatus は診断の1項目の結果です
+type checkStatus string
+
+const (
+	checkPass checkStatus = " OK "
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+	checkSkip checkStatus = "SKIP"
+)
+
+// doctorReport は診断の結果を表示しながら数えます
+type doctorReport struct {
+	counts map[checkStatus]int
+}
+
+// section は診断の区切りの見出しを表示します
+func (r *doctorReport) section(title string) {
+	fmt.Printf("\n%s\n", title)
+}
+
+// add は1項目の結果を表示します。detail の2行目以降は字下げして表示します
+func (r *doctorReport) add(status checkStatus, name, detail string) {
+	r.counts[status]++
+	line := fmt.Sprintf("  [%s] %s", status, name)
+	if detail != "" {
+		line += ": " + strings.ReplaceAll(detail, "\n", "\n         ")
+	}
+	fmt.Println(line)
+}
+
+// note は結果に数えない補足を字下げして表示します
+func (r *doctorReport) note(text string) {
+	fmt.Printf("         %s\n", text)
+}
+
+func runDoctor(cmd *cobra.Command) error {
+	report := &doctorReport{counts: map[checkStatus]int{}}
+	fmt.Println("mcp-voicevox doctor")
+
+	cfg, configOK := checkConfig(cmd, report)
+
+	report.section("VOICEVOXエンジン")
+	// 応答しないエンジンを待ち続けないように、クライアントの既定より短い時間で打ち切る
+	client := voicevox.NewClient(cfg.VoicevoxURL)
+	client.HTTPClient.Timeout = doctorTimeout
+	style, engineOK := checkEngine(client, cfg, report)
+
+	report.section("再生")
+	player := checkPlayer(cfg, report)
+
+	report.section("一時ディレクトリ")
+	tempOK := checkTempDir(cfg, report)
+
+	report.section("テスト合成")
+	switch {
+	case !configOK:
+		report.add(checkSkip, "テスト合成", "設定が正しくないため行いません")
+	case !engineOK:
+		report.add(checkSkip, "テスト合成", "エンジンまたはデフォルトの話者を確認できないため行いません")
+	case !tempOK:
+		report.add(checkSkip, "テスト合成", "一時ディレクトリを使えないため行いません")
+	default:
+		checkSynthesis(cfg, style, player, report)
+	}
+
+	passed, warnings, failed, skipped := report.counts[checkPass], report.counts[checkWarn], report.counts[checkFail], report.counts[checkSkip]
+	fmt.Println()
+	if failed > 0 {
+		fmt.Printf("結果: 不合格（失敗 %d, 警告 %d, 成功 %d, 省略 %d）\n", failed, warnings, passed, skipped)
+		return fmt.Errorf("%d of %d checks failed", failed, passed+warnings+failed)
+	}
+	fmt.Printf("結果: 合格（成功 %d, 警告 %d, 省略 %d）\n", passed, warnings, skipped)
+	return nil
+}
+
+// checkConfig は stdio と同じ順（既定値、環境変数、フラグ）で設定を作り、値とその指定元を表示して検証します。
+// 環境変数やフラグが読めない場合も、読めた分の設定で残りの診断を続けます
+func checkConfig(cmd *cobra.Command, report *doctorReport) (*config.Config, bool) {
+	report.section("設定")
+	ok := true
+	cfg := config.DefaultConfig()
+	env, err := cfg.LoadEnv()
+	if err != nil {
+		report.add(checkFail, "環境変数", err.Error())
+		ok = false
+	}
+
+	applyVoiceFlags(cmd, cfg)
+	if cmd.Flags().Changed("temp-dir") {
+		cfg.TempDir = tempDir
+	}
+	if cmd.Flags().Changed("save-audio") {
+		cfg.SaveAudio = saveAudio
+	}
+	if cmd.Flags().Changed("warmup-styles") {
+		cfg.WarmupStyles = warmupStyles
+	}
+	applyPlaybackFlags(cmd, cfg)
+	applySynthesisFlags(cmd, cfg)
+	if err := applyPreprocessFlags(cmd, cfg); err != nil {
+		report.add(checkFail, "フラグ", err.Error())
+		ok = false
+	}
+	if err := applyOutputFlags(cmd, cfg); err != nil {
+		report.add(checkFail, "フラグ", err.Error())
+		ok = false
+	}
+
+	if err := cfg.Validate(); err != nil {
+		report.add(checkFail, "設定の検証", err.Error())
+		ok = false
+	} else {
+		report.add(checkPass, "設定の検証", "")
+	}
+	for _, s := range doctorSettings(cfg) {
+		source := "既定値"
+		envVar, _ := config.LookupEnvVar(s.name)
+		switch {
+		case cmd.Flags().Changed(doctorFlagName(s.name)):
+			source = "フラグ --" + doctorFlagName(s.name)
+		case env.Applied[envVar.Name]:
+			source = "環境変数 " + envVar.Name
+		}
+		pad := strings.Repeat(" ", max(0, 24-displayWidth(s.value)))
+		report.note(fmt.Sprintf("%-28s %s%s （%s）", s.name, s.value, pad, source))
+	}
+	// 範囲外のため読み込まれなかった環境変数は、フラグで上書きしていない場合に警告する
+	for _, v := range config.EnvVars {
+		if reason := env.Ignored[v.Name]; reason != nil && !cmd.Flags().Changed(doctorFlagName(v.Setting)) {
+			report.add(checkWarn, v.Name, fmt.Sprintf("%s は使われず、既定値を使います: %v", os.Getenv(v.Name), reason))
+		}
+	}
+	for _, env := range unknownEnvVars() {
+		report.add(checkWarn, env, "知らない環境変数のため使われません（名前の誤りではありませんか）")
+	}
+
+	// 辞書は stdio の起動時と同じく読み込んで確かめる
+	if cfg.EnglishDictionary != "" {
+		if _, err := preprocess.LoadDictionary(cfg.EnglishDictionary); err != nil {
+			report.add(checkFail, "english_dictionary", err.Error())
+			ok = false
+		}
+	}
+	if cfg.EmojiDictionary != "" {
+		if _, err := preprocess.LoadEmojiTable(cfg.EmojiDictionary); err != nil {
+			report.add(checkFail, "emoji_dictionary", err.Error())
+			ok = false
+		}
+	}
+	if cfg.AssetDir != "" {
+		if info, err := os.Stat(cfg.AssetDir); err != nil {
+			report.add(checkFail, "asset_dir", err.Error())
+			ok = false
+		} else if !info.IsDir() {
+			report.add(checkFail, "asset_dir", fmt.Sprintf("%s is not a directory", cfg.AssetDir))
+			ok = false
+		}
+	}
+
+	if format, err := audio.ParseOutputFormat(cfg.OutputFormat); err == nil {
+		if err := format.Available(); err != nil {
+			report.add(checkFail, "出力形式", fmt.Sprintf("%s に変換できません: %v", format.Name, err))
+			ok = false
+		} else {
+			report.add(checkPass, "出力形式", format.Name)
+		}
+	}
+	return cfg, ok
+}
+
+// doctorSetting は表示する設定の1項目です。name は設定の名前（Config のJSONの名前）です
+type doctorSetting struct {
+	name, value string
+}
+
+// doctorSettings は表示する設定の一覧を作ります
+func doctorSettings(cfg *config.Config) []doctorSetting {
+	speaker := strconv.Itoa(cfg.DefaultSpeaker)
+	if cfg.DefaultSpeakerName != "" {
+		speaker = cfg.DefaultSpeakerName
+	}
+	// SetupTempDir と同じく、既定の一時ディレクトリの場合は専用のディレクトリを使う
+	temp := cfg.TempDir
+	if temp == os.TempDir() {
+		temp = filepath.Join(os.TempDir(), "mcp-voicevox")
+	}
+	warmup := make([]string, len(cfg.WarmupStyles))
+	for i, id := range cfg.WarmupStyles {
+		warmup[i] = strconv.Itoa(id)
+	}
+	samplingRate := strconv.Itoa(cfg.DefaultOutputSamplingRate)
+	if cfg.DefaultOutputSamplingRate == 0 {
+		samplingRate = "0（話者の既定）"
+	}
+	token := ""
+	if cfg.AudioSinkToken != "" {
+		token = "（設定済み）"
+	}
+	float := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+	return []doctorSetting{
+		{"voicevox_url", cfg.VoicevoxURL},
+		{"default_speaker", speaker},
+		{"default_speed_scale", float(cfg.DefaultSpeedScale)},
+		{"default_pitch_scale", float(cfg.DefaultPitchScale)},
+		{"default_intonation_scale", float(cfg.DefaultIntonationScale)},
+		{"default_volume_scale", float(cfg.DefaultVolumeScale)},
+		{"default_pre_phoneme_length", float(cfg.DefaultPrePhonemeLength)},
+		{"default_post_phoneme_length", float(cfg.DefaultPostPhonemeLength)},
+		{"default_output_sampling_rate", samplingRate},
+		{"default_output_stereo", strconv.FormatBool(cfg.DefaultOutputStereo)},
+		{"warmup_styles", strings.Join(warmup, ",")},
+		{"temp_dir", temp},
+		{"save_audio", strconv.FormatBool(cfg.SaveAudio)},
+		{"output_format", cfg.OutputFormat},
+		{"asset_dir", cfg.AssetDir},
+		{"preprocess", formatPreprocess(cfg)},
+		{"english_dictionary", cfg.EnglishDictionary},
+		{"emoji_dictionary", cfg.EmojiDictionary},
+		{"postprocess", formatPostProcess(cfg.PostProcess)},
+		{"enable_playback", strconv.FormatBool(cfg.EnablePlayback)},
+		{"audio_player", cfg.AudioPlayer},
+		{"audio_player_command", cfg.AudioPlayerCommand},
+		{"audio_sink", cfg.AudioSink},
+		{"audio_sink_token", token},
+		{"audio_device", cfg.AudioDevice},
+		{"playback_volume", float(cfg.PlaybackVolume)},
+	}
+}
+
+// formatPreprocess は前処理のルールを --preprocess と同じ書き方で返します
+func formatPreprocess(cfg *config.Config) string {
+	o := cfg.Preprocess
+	if !o.Enabled {
+		return "off"
+	}
+	return fmt.Sprintf("markdown=%t,code_blocks=%s,urls=%s,tables=%s,normalize=%t,english=%t,emoji=%s",
+		o.Markdown, o.CodeBlocks, o.URLs, o.Tables, o.Normalize, o.English, o.Emoji)
+}
+
+// formatPostProcess は有効な後処理を --postprocess と同じ書き方で返します
+func formatPostProcess(o audio.PostProcessOptions) string {
+	if !o.Active() {
+		return "off"
+	}
+	var parts []string
+	if o.Trim {
+		parts = append(parts, fmt.Sprintf("trim=true,trim_threshold=%g", o.TrimThreshold))
+	}
+	if o.SampleRate != 0 {
+		parts = append(parts, fmt.Sprintf("sample_rate=%d", o.SampleRate))
+	}
+	if o.Loudness != 0 {
+		parts = append(parts, fmt.Sprintf("loudness=%g", o.Loudness))
+	}
+	if o.Limiter {
+		parts = append(parts, fmt.Sprintf("limit=%g", o.PeakLimit))
+	}
+	if o.FadeIn > 0 {
+		parts = append(parts, "fade_in="+o.FadeIn.String())
+	}
+	if o.FadeOut > 0 {
+		parts = append(parts, "fade_out="+o.FadeOut.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// doctorFlagName は設定の名前に対応するフラグ名（voicevox_url なら voicevox-url）を返します
+func doctorFlagName(setting string) string {
+	return strings.ReplaceAll(setting, "_", "-")
+}
+
+// unknownEnvVars は MCP_VOICEVOX_ で始まるが mcp-voicevox が読まない環境変数の名前を返します
+func unknownEnvVars() []string {
+	known := map[string]bool{sinkTokenEnv: true}
+	for _, v := range config.EnvVars {
+		known[v.Name] = true
+	}
+	var unknown []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "MCP_VOICEVOX_") && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// checkEngine はエンジンに接続してバージョンを表示し、話者一覧からデフォルトの話者と事前初期化するスタイルを解決します。
+// テスト合成に使うデフォルトの話者のスタイルと、解決できたかを返します
+func checkEngine(client *voicevox.Client, cfg *config.Config, report *doctorReport) (voicevox.StyleInfo, bool) {
+	version, err := client.GetVersion()
+	if err != nil {
+		report.add(checkFail, "接続", fmt.Sprintf("%s に接続できません: %v\nVOICEVOXを起動するか、--voicevox-url または MCP_VOICEVOX_URL を確認してください", cfg.VoicevoxURL, err))
+		report.add(checkSkip, "話者", "エンジンに接続できないため確認しません")
+		return voicevox.StyleInfo{}, false
+	}
+	report.add(checkPass, "接続", fmt.Sprintf("%s（バージョン %s）", cfg.VoicevoxURL, version))
+
+	speakers, err := client.GetSpeakers()
+	if err != nil {
+		report.add(checkFail, "話者", fmt.Sprintf("話者の一覧を取得できません: %v", err))
+		return voicevox.StyleInfo{}, false
+	}
+	styles := voicevox.FlattenSpeakers(speakers)
+	if len(styles) == 0 {
+		report.add(checkFail, "話者", "エンジンに話者がいません")
+		return voicevox.StyleInfo{}, false
+	}
+	report.add(checkPass, "話者", fmt.Sprintf("%dキャラクター・%dスタイル", len(speakers), len(styles)))
+
+	ok := true
+	query := cfg.DefaultSpeakerName
+	if query == "" {
+		query = strconv.Itoa(cfg.DefaultSpeaker)
+	}
+	style, err := voicevox.ResolveStyle(speakers, query)
+	switch {
+	case err != nil:
+		report.add(checkFail, "デフォルトの話者", err.Error())
+		ok = false
+	case style.StyleType != voicevox.StyleTypeTalk:
+		report.add(checkFail, "デフォルトの話者", fmt.Sprintf("スタイル %d（%s %s）は %s のスタイルのため読み上げに使えません", style.StyleID, style.SpeakerName, style.StyleName, style.StyleType))
+		ok = false
+	default:
+		report.add(checkPass, "デフォルトの話者", fmt.Sprintf("%s %s（スタイルID: %d）", style.SpeakerName, style.StyleName, style.StyleID))
+	}
+
+	if len(cfg.WarmupStyles) > 0 {
+		known := make(map[int]bool, len(styles))
+		for _, s := range styles {
+			known[s.StyleID] = true
+		}
+		var missing []string
+		for _, id := range cfg.WarmupStyles {
+			if !known[id] {
+				missing = append(missing, strconv.Itoa(id))
+			}
+		}
+		if len(missing) > 0 {
+			report.add(checkWarn, "事前初期化するスタイル", fmt.Sprintf("スタイルID %s はエンジンにありません", strings.Join(missing, ", ")))
+		} else {
+			report.add(checkPass, "事前初期化するスタイル", fmt.Sprintf("%dスタイル", len(cfg.WarmupStyles)))
+		}
+	}
+	return style, ok
+}
+
+// checkPlayer は再生バックエンドを選び、使えるかを確認します。
+// 自動再生が無効の場合、使えないことは警告にとどめます。使えるバックエンドがない場合は nil を返します
+func checkPlayer(cfg *config.Config, report *doctorReport) audio.Player {
+	unusable := checkWarn
+	mode := "自動再生は無効"
+	if cfg.EnablePlayback {
+		unusable = checkFail
+		mode = "自動再生は有効"
+	}
+
+	player, err := mcp.NewPlayer(cfg)
+	if err != nil {
+		report.add(unusable, "再生バックエンド", err.Error())
+		return nil
+	}
+	if checker, ok := player.(audio.Availability); ok {
+		if err := checker.Available(); err != nil {
+			report.add(unusable, "再生バックエンド", fmt.Sprintf("%v\n--audio-player または --audio-player-command で再生方法を指定してください（%s）", err, mode))
+			return nil
+		}
+	}
+	report.add(checkPass, "再生バックエンド", fmt.Sprintf("%s（%s）", player.Name(), mode))
+
+	if cfg.AudioSink != "" {
+		address, err := sinkAddress(cfg.AudioSink)
+		if err == nil {
+			var conn net.Conn
+			if conn, err = net.DialTimeout("tcp", address, doctorDialTimeout); err == nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			report.add(unusable, "再生先", fmt.Sprintf("%s に接続できません: %v", cfg.AudioSink, err))
+			return nil
+		}
+		report.add(checkPass, "再生先", address)
+	}
+	return player
+}
+
+// sinkAddress はリモートの再生先のURLから接続先の host:port を返します
+func sinkAddress(sinkURL string) (string, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	port := map[string]string{"http": "80", "https": "443", "pulse": "4713", "tcp": "4713"}[u.Scheme]
+	if port == "" {
+		return "", fmt.Errorf("unsupported audio sink scheme: %s", u.Scheme)
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// checkTempDir は一時ディレクトリを作成し、ファイルを書けるかと空き容量を確認します
+func checkTempDir(cfg *config.Config, report *doctorReport) bool {
+	if err := cfg.SetupTempDir(); err != nil {
+		report.add(checkFail, "作成", err.Error())
+		return false
+	}
+
+	file, err := os.CreateTemp(cfg.TempDir, ".doctor_*.wav")
+	if err == nil {
+		_, err = file.Write([]byte("mcp-voicevox doctor"))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if removeErr := os.Remove(file.Name()); err == nil {
+			err = removeErr
+		}
+	}
+	if err != nil {
+		report.add(checkFail, "書き込み", fmt.Sprintf("%s にファイルを書けません: %v", cfg.TempDir, err))
+		return false
+	}
+	report.add(checkPass, "書き込み", cfg.TempDir)
+
+	free, err := diskFree(cfg.TempDir)
+	switch {
+	case errors.Is(err, errDiskFreeUnsupported):
+		report.add(checkSkip, "空き容量", err.Error())
+	case err != nil:
+		report.add(checkWarn, "空き容量", fmt.Sprintf("空き容量を確認できません: %v", err))
+	case free < doctorMinFree:
+		report.add(checkFail, "空き容量", fmt.Sprintf("%s しかありません（%s 以上が必要です）", formatBytes(free), formatBytes(doctorMinFree)))
+		return false
+	case free < doctorLowFree:
+		report.add(checkWarn, "空き容量", fmt.Sprintf("%s（残りわずかです）", formatBytes(free)))
+	default:
+		report.add(checkPass, "空き容量", formatBytes(free))
+	}
+	return true
+}
+
+// formatBytes はバイト数を KB・MB・GB の単位で表します
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, next := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
+
+// checkSynthesis はデフォルトの話者で前処理から後処理までを通してテストの文を合成し、
+// 音声として読めるか、出力形式に変換できるかを確認します。--play の場合は再生もします
+func checkSynthesis(cfg *config.Config, style voicevox.StyleInfo, player audio.Player, report *doctorReport) {
+	synthCfg := *cfg
+	synthCfg.EnablePlayback = false
+	synthCfg.SaveAudio = false
+	handler, err := mcp.NewHandler(&synthCfg)
+	if err != nil {
+		report.add(checkFail, "テスト合成", err.Error())
+		return
+	}
+	defer handler.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	synthCtx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	start := time.Now()
+	wav, err := handler.Synthesize(synthCtx, mcp.SpeechRequest{Text: doctorText})
+	switch {
+	case ctx.Err() != nil:
+		report.add(checkSkip, "テスト合成", "中断しました")
+		return
+	case synthCtx.Err() != nil:
+		report.add(checkFail, "テスト合成", fmt.Sprintf("%s 以内に合成が終わりませんでした（--timeout で変更できます）", doctorTimeout))
+		return
+	case err != nil:
+		report.add(checkFail, "テスト合成", err.Error())
+		return
+	}
+	elapsed := time.Since(start)
+	wavFormat, err := audio.ParseWAV(wav)
+	if err == nil && wavFormat.Duration() <= 0 {
+		err = fmt.Errorf("audio is empty")
+	}
+	if err != nil {
+		report.add(checkFail, "テスト合成", fmt.Sprintf("エンジンが返した音声を読めません: %v", err))
+		return
+	}
+	channels := "モノラル"
+	if wavFormat.Channels == 2 {
+		channels = "ステレオ"
+	}
+	report.add(checkPass, "テスト合成", fmt.Sprintf("「%s」を %s %s で %.2f秒の音声（%dHz, %s）に %dms で合成",
+		doctorText, style.SpeakerName, style.StyleName, wavFormat.Duration(), wavFormat.SampleRate, channels, elapsed.Milliseconds()))
+
+	if format, err := audio.ParseOutputFormat(cfg.OutputFormat); err == nil && format.Name != audio.FormatWAV {
+		encoded, err := format.EncodeContext(synthCtx, wav)
+		if err != nil {
+			report.add(checkFail, "形式の変換", fmt.Sprintf("%s に変換できません: %v", format.Name, err))
+		} else {
+			report.add(checkPass, "形式の変換", fmt.Sprintf("%s（%s）", format.Name, formatBytes(uint64(len(encoded)))))
+		}
+	}
+
+	if !doctorPlay {
+		return
+	}
+	if player == nil {
+		report.add(checkSkip, "テスト再生", "使える再生バックエンドがないため再生しません")
+		return
+	}
+	err = audio.PlayAudio(ctx, player, wav, audio.PlayOptions{Device: cfg.AudioDevice, Volume: cfg.PlaybackVolume}, cfg.TempDir)
+	switch {
+	case ctx.Err() != nil:
+		report.add(checkSkip, "テスト再生", "中断しました")
+	case err != nil:
+		report.add(checkFail, "テスト再生", fmt.Sprintf("%s で再生できません: %v", player.Name(), err))
+	default:
+		report.add(checkPass, "テスト再生", player.Name())
+	}
+}
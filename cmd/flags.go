@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"github.com/metapox/mcp-voicevox-go/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	enablePlayback     bool
+	saveAudio          bool
+	audioPlayer        string
+	audioPlayerCommand string
+	audioSink          string
+	audioSinkToken     string
+	audioDevice        string
+	playbackVolume     float64
+	preprocessSpec     string
+	englishDictionary  string
+	emojiDictionary    string
+	outputFormat       string
+	postProcessSpec    string
+	assetDir           string
+	prePhonemeLength   float64
+	postPhonemeLength  float64
+	outputSamplingRate int
+	outputStereo       bool
+)
+
+// addVoiceFlags はVOICEVOXのエンドポイント・デフォルトの話者・合成パラメータのフラグを追加します
+func addVoiceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&voicevoxURL, "voicevox-url", "u", "http://localhost:50021", "VOICEVOXのAPIエンドポイント")
+	cmd.Flags().StringVarP(&defaultSpeaker, "default-speaker", "s", "3", "デフォルトの話者（スタイルID または \"ずんだもん ノーマル\" のような名前）")
+	cmd.Flags().Float64Var(&defaultSpeedScale, "default-speed-scale", 1.0, "デフォルトの話速（0.5-2.0）")
+	cmd.Flags().Float64Var(&defaultPitchScale, "default-pitch-scale", 0.0, "デフォルトの音高（-0.15-0.15）")
+	cmd.Flags().Float64Var(&defaultIntonationScale, "default-intonation-scale", 1.0, "デフォルトの抑揚（0.0-2.0）")
+	cmd.Flags().Float64Var(&defaultVolumeScale, "default-volume-scale", 1.0, "デフォルトの音量（0.0-2.0）")
+}
+
+// applyVoiceFlags は指定されたVOICEVOXのエンドポイント・デフォルトの話者・合成パラメータのフラグで設定を上書きします
+func applyVoiceFlags(cmd *cobra.Command, cfg *config.Config) {
+	if cmd.Flags().Changed("voicevox-url") {
+		cfg.VoicevoxURL = voicevoxURL
+	}
+	if cmd.Flags().Changed("default-speaker") {
+		cfg.SetDefaultSpeaker(defaultSpeaker)
+	}
+	if cmd.Flags().Changed("default-speed-scale") {
+		cfg.DefaultSpeedScale = defaultSpeedScale
+	}
+	if cmd.Flags().Changed("default-pitch-scale") {
+		cfg.DefaultPitchScale = defaultPitchScale
+	}
+	if cmd.Flags().Changed("default-intonation-scale") {
+		cfg.DefaultIntonationScale = defaultIntonationScale
+	}
+	if cmd.Flags().Changed("default-volume-scale") {
+		cfg.DefaultVolumeScale = defaultVolumeScale
+	}
+}
+
+// addPlaybackFlags は音声再生に関するフラグを追加します
+func addPlaybackFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&enablePlayback, "enable-playback", false, "音声の自動再生を有効にする")
+	cmd.Flags().StringVar(&audioPlayer, "audio-player", "auto", "再生バックエンド（auto, afplay, paplay, aplay, mpv, pw-play, ffplay, powershell, null）")
+	cmd.Flags().StringVar(&audioPlayerCommand, "audio-player-command", "", "再生コマンドのテンプレート（{file}, {volume}, {device} を置換）")
+	cmd.Flags().StringVar(&audioSink, "audio-sink", "", "リモートの再生先（http://host:port または pulse://host:port）")
+	cmd.Flags().StringVar(&audioSinkToken, "audio-sink-token", "", "再生先への認証トークン")
+	cmd.Flags().StringVar(&audioDevice, "audio-device", "", "出力先デバイス（list_audio_devices で確認できる名前）")
+	cmd.Flags().Float64Var(&playbackVolume, "playback-volume", 1.0, "再生音量の倍率（0.0より大きく2.0以下、volume_scale とは独立）")
+}
+
+// applyPlaybackFlags は指定された音声再生のフラグで設定を上書きします
+func applyPlaybackFlags(cmd *cobra.Command, cfg *config.Config) {
+	if cmd.Flags().Changed("enable-playback") {
+		cfg.EnablePlayback = enablePlayback
+	}
+	if cmd.Flags().Changed("audio-player") {
+		cfg.AudioPlayer = audioPlayer
+	}
+	if cmd.Flags().Changed("audio-player-command") {
+		cfg.AudioPlayerCommand = audioPlayerCommand
+	}
+	if cmd.Flags().Changed("audio-sink") {
+		cfg.AudioSink = audioSink
+	}
+	if cmd.Flags().Changed("audio-sink-token") {
+		cfg.AudioSinkToken = audioSinkToken
+	}
+	if cmd.Flags().Changed("audio-device") {
+		cfg.AudioDevice = audioDevice
+	}
+	if cmd.Flags().Changed("playback-volume") {
+		cfg.PlaybackVolume = playbackVolume
+	}
+}
+
+// addPreprocessFlags はテキストの前処理に関するフラグを追加します
+func addPreprocessFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&preprocessSpec, "preprocess", "", "テキストの前処理ルール（例: \"code_blocks=skip,urls=domain\"、off で無効）")
+	cmd.Flags().StringVar(&englishDictionary, "english-dictionary", "", "英単語の読み方を上書きする辞書ファイル（1行に「単語<TAB>カタカナ」）")
+	cmd.Flags().StringVar(&emojiDictionary, "emoji-dictionary", "", "絵文字・顔文字の読み方と気分を上書きするファイル（1行に「記号<TAB>読み方<TAB>気分」）")
+}
+
+// applyPreprocessFlags は指定された前処理のフラグで設定を上書きします
+func applyPreprocessFlags(cmd *cobra.Command, cfg *config.Config) error {
+	if cmd.Flags().Changed("english-dictionary") {
+		cfg.EnglishDictionary = englishDictionary
+	}
+	if cmd.Flags().Changed("emoji-dictionary") {
+		cfg.EmojiDictionary = emojiDictionary
+	}
+	if cmd.Flags().Changed("preprocess") {
+		if err := cfg.Preprocess.Apply(preprocessSpec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSynthesisFlags はVOICEVOXが出力する音声のサンプリングレート・チャンネル数・前後の無音に関するフラグを追加します
+func addSynthesisFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64Var(&prePhonemeLength, "default-pre-phoneme-length", 0.1, "デフォルトの音声の前の無音の秒数（0.0-1.5）")
+	cmd.Flags().Float64Var(&postPhonemeLength, "default-post-phoneme-length", 0.1, "デフォルトの音声の後の無音の秒数（0.0-1.5）")
+	cmd.Flags().IntVar(&outputSamplingRate, "default-output-sampling-rate", 0, "デフォルトの出力のサンプリングレート（8000-96000Hz、0 で話者の既定）")
+	cmd.Flags().BoolVar(&outputStereo, "default-output-stereo", false, "デフォルトでステレオの音声を出力する")
+}
+
+// applySynthesisFlags は指定された出力のフラグで設定を上書きします
+func applySynthesisFlags(cmd *cobra.Command, cfg *config.Config) {
+	if cmd.Flags().Changed("default-pre-phoneme-length") {
+		cfg.DefaultPrePhonemeLength = prePhonemeLength
+	}
+	if cmd.Flags().Changed("default-post-phoneme-length") {
+		cfg.DefaultPostPhonemeLength = postPhonemeLength
+	}
+	if cmd.Flags().Changed("default-output-sampling-rate") {
+		cfg.DefaultOutputSamplingRate = outputSamplingRate
+	}
+	if cmd.Flags().Changed("default-output-stereo") {
+		cfg.DefaultOutputStereo = outputStereo
+	}
+}
+
+// addOutputFlags は保存・返却する音声の形式・BGMの素材・後処理に関するフラグを追加します
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputFormat, "output-format", "wav", "保存する音声の形式（wav, flac, mulaw, opus, mp3）")
+	cmd.Flags().StringVar(&assetDir, "asset-dir", "", "BGM・効果音のWAVファイルを置くディレクトリ（mix 引数と mix_audio ツールで使う）")
+	cmd.Flags().StringVar(&postProcessSpec, "postprocess", "", "合成後の音声の後処理（例: \"loudness=-16,limit=-1,trim=true\"、off で無効）")
+}
+
+// applyOutputFlags は指定された音声の形式・BGMの素材・後処理のフラグで設定を上書きします
+func applyOutputFlags(cmd *cobra.Command, cfg *config.Config) error {
+	if cmd.Flags().Changed("output-format") {
+		cfg.OutputFormat = outputFormat
+	}
+	if cmd.Flags().Changed("asset-dir") {
+		cfg.AssetDir = assetDir
+	}
+	if cmd.Flags().Changed("postprocess") {
+		if err := cfg.PostProcess.Apply(postProcessSpec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
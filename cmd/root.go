@@ -13,6 +13,11 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(serverCmd)
 	rootCmd.AddCommand(stdioCmd)
+	rootCmd.AddCommand(sinkCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(sayCmd)
+	rootCmd.AddCommand(speakersCmd)
+	rootCmd.AddCommand(doctorCmd)
 }
 
 // Execute はrootコマンドを実行します
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/config"
+	"github.com/metapox/mcp-voicevox-go/pkg/mcp"
+	"github.com/spf13/cobra"
+)
+
+// maxFollowLine は --follow で読み上げる1行の最大の長さ（バイト）です
+const maxFollowLine = 1024 * 1024
+
+var (
+	sayOutput string
+	sayFollow bool
+)
+
+var sayCmd = &cobra.Command{
+	Use:   "say [text...]",
+	Short: "テキストを読み上げる",
+	Long: `MCPクライアントを使わずに、テキストを合成して再生します。
+引数を省略した場合は標準入力を読み上げます。-o を指定した場合は再生せずにファイルに保存します（--enable-playback で保存と再生の両方）。
+--follow では標準入力の行を届くたびに1行ずつ読み上げます。`,
+	Example: `  mcp-voicevox say "ビルドが完了しました"
+  echo "こんにちは" | mcp-voicevox say -s "ずんだもん あまあま"
+  mcp-voicevox say -o hello.mp3 "こんにちは"
+  tail -f build.log | mcp-voicevox say --follow`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runSay(cmd, args)
+	},
+}
+
+func init() {
+	sayCmd.Flags().StringVarP(&sayOutput, "output", "o", "", "再生せずに音声を保存するファイル（- で標準出力。形式は --output-format、省略時は拡張子から判定）")
+	sayCmd.Flags().BoolVarP(&sayFollow, "follow", "f", false, "標準入力の行を届くたびに読み上げる")
+	sayCmd.Flags().StringVarP(&tempDir, "temp-dir", "t", "", "一時ファイルを保存するディレクトリ")
+	addVoiceFlags(sayCmd)
+	addPlaybackFlags(sayCmd)
+	addSynthesisFlags(sayCmd)
+	addPreprocessFlags(sayCmd)
+	addOutputFlags(sayCmd)
+}
+
+func runSay(cmd *cobra.Command, args []string) error {
+	cfg, err := config.New()
+	if err != nil {
+		return err
+	}
+
+	// コマンドラインフラグで設定を上書き
+	applyVoiceFlags(cmd, cfg)
+	if cmd.Flags().Changed("temp-dir") {
+		cfg.TempDir = tempDir
+	}
+	applyPlaybackFlags(cmd, cfg)
+	if err := applyPreprocessFlags(cmd, cfg); err != nil {
+		return err
+	}
+	applySynthesisFlags(cmd, cfg)
+	if err := applyOutputFlags(cmd, cfg); err != nil {
+		return err
+	}
+	// ファイルに保存する場合は拡張子から形式を決める
+	if sayOutput != "" && sayOutput != "-" && !cmd.Flags().Changed("output-format") {
+		if ext := strings.TrimPrefix(filepath.Ext(sayOutput), "."); ext != "" {
+			if format, err := audio.ParseOutputFormat(ext); err == nil {
+				cfg.OutputFormat = format.Name
+			}
+		}
+	}
+	// 保存しない場合は再生し、保存する場合は --enable-playback を指定したときだけ再生する
+	play := sayOutput == ""
+	if cmd.Flags().Changed("enable-playback") {
+		play = enablePlayback
+	}
+	if !play && sayOutput == "" {
+		return fmt.Errorf("nothing to do: playback is disabled and no --output is given")
+	}
+	if sayFollow && sayOutput != "" {
+		return fmt.Errorf("--follow cannot be used with --output")
+	}
+	if sayFollow && len(args) > 0 {
+		return fmt.Errorf("--follow reads lines from standard input and takes no text arguments")
+	}
+	// ハンドラーの再生キューは使わず、このコマンドで再生キューを作る
+	cfg.EnablePlayback = false
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.SetupTempDir(); err != nil {
+		return err
+	}
+
+	handler, err := mcp.NewHandler(cfg)
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
+
+	var queue *audio.Queue
+	if play {
+		if queue, err = mcp.NewPlaybackQueue(cfg); err != nil {
+			return err
+		}
+		defer queue.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	log.SetOutput(os.Stderr)
+
+	if sayFollow {
+		return followLines(ctx, os.Stdin, handler, queue)
+	}
+
+	text := strings.Join(args, " ")
+	if len(args) == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read standard input: %w", err)
+		}
+		text = string(input)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("no text to speak")
+	}
+
+	wav, err := handler.Synthesize(ctx, mcp.SpeechRequest{Text: text})
+	if err != nil {
+		return err
+	}
+	if sayOutput != "" {
+		if err := saveSpeech(ctx, wav, sayOutput, cfg.OutputFormat); err != nil {
+			return err
+		}
+	}
+	if queue == nil {
+		return nil
+	}
+	ticket := queue.EnqueueAudio(wav, audio.EnqueueOptions{Text: text})
+	select {
+	case <-ticket.Done():
+		return ticket.Wait()
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// saveSpeech は音声を出力形式に変換してファイル（- の場合は標準出力）に書きます
+func saveSpeech(ctx context.Context, wav []byte, path, formatName string) error {
+	format, err := audio.ParseOutputFormat(formatName)
+	if err != nil {
+		return err
+	}
+	encoded, err := format.EncodeContext(ctx, wav)
+	if err != nil {
+		return fmt.Errorf("failed to encode audio as %s: %w", format.Name, err)
+	}
+	if path == "-" {
+		_, err = os.Stdout.Write(encoded)
+		return err
+	}
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		return fmt.Errorf("failed to save audio file: %w", err)
+	}
+	return nil
+}
+
+// followLines は入力の行を届くたびに合成して再生キューに追加し、入力が終わったら再生が終わるまで待ちます。
+// 1行の合成に失敗した場合は記録して次の行を続けます
+func followLines(ctx context.Context, input io.Reader, handler *mcp.Handler, queue *audio.Queue) error {
+	// 入力を待つ間も割り込みで止められるように、行は別のゴルーチンで読む。
+	// lines を閉じる前に必ず readErr を送り、lines が閉じた後の受信が止まらないようにする
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			readErr <- err
+			close(lines)
+		}()
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxFollowLine)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		err = scanner.Err()
+	}()
+
+	var last *audio.Ticket
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if err := <-readErr; err != nil {
+					return fmt.Errorf("failed to read standard input: %w", err)
+				}
+				if last != nil {
+					select {
+					case <-last.Done():
+					case <-ctx.Done():
+					}
+				}
+				return nil
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			wav, err := handler.Synthesize(ctx, mcp.SpeechRequest{Text: line})
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				log.Printf("読み上げに失敗しました（%s）: %v", line, err)
+				continue
+			}
+			last = queue.EnqueueAudio(wav, audio.EnqueueOptions{Text: line})
+		}
+	}
+}
@@ -3,8 +3,11 @@ package cmd
 import (
 	"log"
 
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
 	"github.com/metapox/mcp-voicevox-go/pkg/config"
 	"github.com/metapox/mcp-voicevox-go/pkg/mcp"
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
 	"github.com/spf13/cobra"
 )
 
@@ -12,11 +15,12 @@ var (
 	port                   int
 	voicevoxURL            string
 	tempDir                string
-	defaultSpeaker         int
+	defaultSpeaker         string
 	defaultSpeedScale      float64
 	defaultPitchScale      float64
 	defaultIntonationScale float64
 	defaultVolumeScale     float64
+	warmupStyles           []int
 )
 
 var serverCmd = &cobra.Command{
@@ -30,13 +34,14 @@ var serverCmd = &cobra.Command{
 
 func init() {
 	serverCmd.Flags().IntVarP(&port, "port", "p", 8080, "サーバーのポート番号")
-	serverCmd.Flags().StringVarP(&voicevoxURL, "voicevox-url", "u", "http://localhost:50021", "VOICEVOXのAPIエンドポイント")
 	serverCmd.Flags().StringVarP(&tempDir, "temp-dir", "t", "", "一時ファイルを保存するディレクトリ")
-	serverCmd.Flags().IntVarP(&defaultSpeaker, "default-speaker", "s", 3, "デフォルトの話者ID")
-	serverCmd.Flags().Float64Var(&defaultSpeedScale, "default-speed-scale", 1.0, "デフォルトの話速（0.5-2.0）")
-	serverCmd.Flags().Float64Var(&defaultPitchScale, "default-pitch-scale", 0.0, "デフォルトの音高（-0.15-0.15）")
-	serverCmd.Flags().Float64Var(&defaultIntonationScale, "default-intonation-scale", 1.0, "デフォルトの抑揚（0.0-2.0）")
-	serverCmd.Flags().Float64Var(&defaultVolumeScale, "default-volume-scale", 1.0, "デフォルトの音量（0.0-2.0）")
+	serverCmd.Flags().BoolVar(&saveAudio, "save-audio", true, "合成した音声をファイルとして一時ディレクトリに保存する")
+	serverCmd.Flags().IntSliceVar(&warmupStyles, "warmup-styles", nil, "起動時に事前初期化するスタイルID（カンマ区切り）")
+	addVoiceFlags(serverCmd)
+	addSynthesisFlags(serverCmd)
+	addPreprocessFlags(serverCmd)
+	addOutputFlags(serverCmd)
+	addPlaybackFlags(serverCmd)
 }
 
 func runHTTPServer(cmd *cobra.Command) error {
@@ -50,26 +55,26 @@ func runHTTPServer(cmd *cobra.Command) error {
 	if cmd.Flags().Changed("port") {
 		cfg.Port = port
 	}
-	if cmd.Flags().Changed("voicevox-url") {
-		cfg.VoicevoxURL = voicevoxURL
-	}
+	applyVoiceFlags(cmd, cfg)
 	if cmd.Flags().Changed("temp-dir") {
 		cfg.TempDir = tempDir
 	}
-	if cmd.Flags().Changed("default-speaker") {
-		cfg.DefaultSpeaker = defaultSpeaker
+	if cmd.Flags().Changed("save-audio") {
+		cfg.SaveAudio = saveAudio
 	}
-	if cmd.Flags().Changed("default-speed-scale") {
-		cfg.DefaultSpeedScale = defaultSpeedScale
+	if cmd.Flags().Changed("warmup-styles") {
+		cfg.WarmupStyles = warmupStyles
 	}
-	if cmd.Flags().Changed("default-pitch-scale") {
-		cfg.DefaultPitchScale = defaultPitchScale
+	if err := applyPreprocessFlags(cmd, cfg); err != nil {
+		return err
 	}
-	if cmd.Flags().Changed("default-intonation-scale") {
-		cfg.DefaultIntonationScale = defaultIntonationScale
+	applyPlaybackFlags(cmd, cfg)
+	applySynthesisFlags(cmd, cfg)
+	if err := applyOutputFlags(cmd, cfg); err != nil {
+		return err
 	}
-	if cmd.Flags().Changed("default-volume-scale") {
-		cfg.DefaultVolumeScale = defaultVolumeScale
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
 
 	// 一時ディレクトリのセットアップ
@@ -79,9 +84,59 @@ func runHTTPServer(cmd *cobra.Command) error {
 
 	// サーバー起動
 	server := mcp.NewMCPServer(cfg.Port, cfg.VoicevoxURL, cfg.TempDir, cfg.DefaultSpeaker)
+	server.DefaultSpeakerName = cfg.DefaultSpeakerName
+	server.SaveAudio = cfg.SaveAudio
+	server.PlayerConfig = cfg
+	server.Preprocess = cfg.Preprocess
+	dict, err := preprocess.LoadDictionary(cfg.EnglishDictionary)
+	if err != nil {
+		return err
+	}
+	server.Preprocess.Dictionary = dict
+	emojiTable, err := preprocess.LoadEmojiTable(cfg.EmojiDictionary)
+	if err != nil {
+		return err
+	}
+	server.Preprocess.EmojiTable = emojiTable
+	outputFormat, err := audio.ParseOutputFormat(cfg.OutputFormat)
+	if err != nil {
+		return err
+	}
+	if err := outputFormat.Available(); err != nil {
+		return err
+	}
+	server.OutputFormat = outputFormat
+	server.PostProcess = cfg.PostProcess
+	server.AssetDir = cfg.AssetDir
+	defaults := cfg.AudioQueryOptions()
+	server.OutputOptions = voicevox.AudioQueryOptions{
+		PrePhonemeLength:   defaults.PrePhonemeLength,
+		PostPhonemeLength:  defaults.PostPhonemeLength,
+		OutputSamplingRate: defaults.OutputSamplingRate,
+		OutputStereo:       defaults.OutputStereo,
+	}
+	if len(cfg.WarmupStyles) > 0 {
+		log.Printf("スタイルの事前初期化を開始します: %v", cfg.WarmupStyles)
+	}
+	server.StartWarmup(cfg.WarmupStyles)
+	if cfg.EnablePlayback {
+		playback, err := mcp.NewPlaybackQueue(cfg)
+		if err != nil {
+			return err
+		}
+		defer playback.Close()
+		server.Playback = playback
+		if cfg.AudioSink != "" {
+			log.Printf("音声を再生先に送信します: %s", cfg.AudioSink)
+		}
+	}
 	log.Printf("MCPサーバーを起動します: ポート %d, VOICEVOX URL: %s", cfg.Port, cfg.VoicevoxURL)
 	log.Printf("一時ファイルディレクトリ: %s", cfg.TempDir)
-	log.Printf("デフォルト話者ID: %d", cfg.DefaultSpeaker)
+	if cfg.DefaultSpeakerName != "" {
+		log.Printf("デフォルト話者: %s", cfg.DefaultSpeakerName)
+	} else {
+		log.Printf("デフォルト話者ID: %d", cfg.DefaultSpeaker)
+	}
 	log.Printf("デフォルト音声設定: 話速=%.2f, 音高=%.2f, 抑揚=%.2f, 音量=%.2f", 
 		cfg.DefaultSpeedScale, cfg.DefaultPitchScale, cfg.DefaultIntonationScale, cfg.DefaultVolumeScale)
 
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/spf13/cobra"
+)
+
+// sinkTokenEnv は --token を省略したときに認証トークンを読む環境変数です
+const sinkTokenEnv = "MCP_VOICEVOX_SINK_TOKEN"
+
+var (
+	sinkListen  string
+	sinkToken   string
+	sinkDevice  string
+	sinkTLSCert string
+	sinkTLSKey  string
+)
+
+var sinkCmd = &cobra.Command{
+	Use:   "sink",
+	Short: "受け取った音声を再生する再生先として起動",
+	Long: `HTTPで受け取ったWAVをこのマシンで再生します。
+音声デバイスのない環境（Dockerなど）で動くサーバーから --audio-sink http://<このマシン>:50080 で送信してください。
+接続には Bearer トークンによる認証が必要です。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSink(cmd)
+	},
+}
+
+func init() {
+	sinkCmd.Flags().StringVarP(&sinkListen, "listen", "l", ":50080", "待ち受けるアドレス")
+	sinkCmd.Flags().StringVar(&sinkToken, "token", "", "認証トークン（省略時は MCP_VOICEVOX_SINK_TOKEN、なければ生成して表示）")
+	sinkCmd.Flags().StringVar(&sinkDevice, "device", "", "出力先デバイス（省略時は既定のデバイス）")
+	sinkCmd.Flags().StringVar(&sinkTLSCert, "tls-cert", "", "TLS証明書ファイル")
+	sinkCmd.Flags().StringVar(&sinkTLSKey, "tls-key", "", "TLS秘密鍵ファイル")
+	sinkCmd.Flags().StringVar(&audioPlayer, "audio-player", "auto", "再生バックエンド（auto, afplay, paplay, aplay, mpv, pw-play, ffplay, powershell, null）")
+	sinkCmd.Flags().StringVar(&audioPlayerCommand, "audio-player-command", "", "再生コマンドのテンプレート（{file}, {volume}, {device} を置換）")
+	sinkCmd.Flags().StringVarP(&tempDir, "temp-dir", "t", "", "一時ファイルを保存するディレクトリ")
+}
+
+func runSink(cmd *cobra.Command) error {
+	if (sinkTLSCert == "") != (sinkTLSKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be specified together")
+	}
+
+	token := sinkToken
+	if token == "" {
+		token = os.Getenv(sinkTokenEnv)
+	}
+	if token == "" {
+		generated, err := generateToken()
+		if err != nil {
+			return err
+		}
+		token = generated
+		log.Printf("認証トークンを生成しました: %s", token)
+	}
+
+	player, err := audio.NewPlayer(audioPlayer, audioPlayerCommand)
+	if err != nil {
+		return err
+	}
+
+	sink, err := audio.NewSinkServer(player, token)
+	if err != nil {
+		return err
+	}
+	sink.Device = sinkDevice
+	sink.TempDir = tempDir
+
+	server := &http.Server{
+		Addr:              sinkListen,
+		Handler:           sink,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("再生先を起動します: %s (プレイヤー: %s)", sinkListen, player.Name())
+	if sinkTLSCert != "" {
+		return server.ListenAndServeTLS(sinkTLSCert, sinkTLSKey)
+	}
+	return server.ListenAndServe()
+}
+
+// generateToken はランダムな認証トークンを生成します
+func generateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/config"
+	"github.com/metapox/mcp-voicevox-go/pkg/mcp"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+	"github.com/spf13/cobra"
+)
+
+var (
+	speakersType   string
+	speakersJSON   bool
+	speakersPlay   string
+	speakersSample string
+)
+
+var speakersCmd = &cobra.Command{
+	Use:   "speakers [name]",
+	Short: "話者とスタイルIDの一覧を表示する",
+	Long: `VOICEVOXエンジンの話者（キャラクター）とスタイルIDを表またはJSONで表示します。
+name を指定した場合はキャラクター名で絞り込みます（部分一致、ひらがな・カタカナを区別しない）。
+--play を指定した場合は、そのスタイルでサンプルの文を再生します。`,
+	Example: `  mcp-voicevox speakers
+  mcp-voicevox speakers ずんだもん --json
+  mcp-voicevox speakers --play "ずんだもん あまあま"
+  mcp-voicevox speakers --play 3 --sample "今日もいい天気なのだ"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		return runSpeakers(cmd, name)
+	},
+}
+
+func init() {
+	speakersCmd.Flags().StringVar(&speakersType, "type", "", "スタイルの種類で絞り込む（talk: 読み上げ, singing: 歌唱）")
+	speakersCmd.Flags().BoolVar(&speakersJSON, "json", false, "スタイルごとの一覧をJSONで表示する")
+	speakersCmd.Flags().StringVarP(&speakersPlay, "play", "p", "", "サンプルの文を再生するスタイル（スタイルID または \"ずんだもん あまあま\" のような名前）")
+	speakersCmd.Flags().StringVar(&speakersSample, "sample", "", "再生するサンプルの文（省略時はキャラクター名とスタイル名を読み上げる）")
+	speakersCmd.Flags().StringVarP(&voicevoxURL, "voicevox-url", "u", "http://localhost:50021", "VOICEVOXのAPIエンドポイント")
+	speakersCmd.Flags().StringVarP(&tempDir, "temp-dir", "t", "", "一時ファイルを保存するディレクトリ")
+	addPlaybackFlags(speakersCmd)
+	speakersCmd.Flags().MarkHidden("enable-playback")
+}
+
+func runSpeakers(cmd *cobra.Command, name string) error {
+	cfg, err := config.New()
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("voicevox-url") {
+		cfg.VoicevoxURL = voicevoxURL
+	}
+	if cmd.Flags().Changed("temp-dir") {
+		cfg.TempDir = tempDir
+	}
+	applyPlaybackFlags(cmd, cfg)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if speakersType != "" && speakersType != voicevox.StyleCategoryTalk && speakersType != voicevox.StyleCategorySinging {
+		return fmt.Errorf("unknown style type %q (expected talk or singing)", speakersType)
+	}
+
+	client := voicevox.NewClient(cfg.VoicevoxURL)
+	speakers, err := client.GetSpeakers()
+	if err != nil {
+		return fmt.Errorf("failed to get speakers from %s: %w", cfg.VoicevoxURL, err)
+	}
+
+	if speakersPlay != "" {
+		return playSample(cfg, client, speakers)
+	}
+
+	styles := voicevox.FlattenSpeakers(voicevox.FilterSpeakers(speakers, name, speakersType))
+	if speakersJSON {
+		if styles == nil {
+			styles = []voicevox.StyleInfo{}
+		}
+		data, err := json.MarshalIndent(styles, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(styles) == 0 {
+		return fmt.Errorf("no speakers match (name %q, type %q)", name, speakersType)
+	}
+	fmt.Print(formatStyleTable(styles))
+	return nil
+}
+
+// playSample は --play のスタイルでサンプルの文を合成し、再生バックエンドで再生します
+func playSample(cfg *config.Config, client *voicevox.Client, speakers []voicevox.Speaker) error {
+	style, err := voicevox.ResolveStyle(speakers, speakersPlay)
+	if err != nil {
+		return err
+	}
+	if style.StyleType != voicevox.StyleTypeTalk {
+		return fmt.Errorf("style %d (%s %s) is a %s style and cannot read text", style.StyleID, style.SpeakerName, style.StyleName, style.StyleType)
+	}
+	text := speakersSample
+	if text == "" {
+		text = fmt.Sprintf("%sの、%sです。", style.SpeakerName, style.StyleName)
+	}
+
+	if err := cfg.SetupTempDir(); err != nil {
+		return err
+	}
+	player, err := mcp.NewPlayer(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	query, err := client.CreateAudioQueryContext(ctx, text, style.StyleID, cfg.AudioQueryOptions())
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to create audio query: %w", err)
+	}
+	wav, err := client.SynthesizeVoiceContext(ctx, query, style.StyleID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to synthesize voice: %w", err)
+	}
+
+	fmt.Printf("%s %s（スタイルID: %d）: %s\n", style.SpeakerName, style.StyleName, style.StyleID, text)
+	err = audio.PlayAudio(ctx, player, wav, audio.PlayOptions{Device: cfg.AudioDevice, Volume: cfg.PlaybackVolume}, cfg.TempDir)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to play sample with %s: %w", player.Name(), err)
+	}
+	return nil
+}
+
+// formatStyleTable はスタイルの一覧を、キャラクター名を最初の行だけに書いた表にします
+func formatStyleTable(styles []voicevox.StyleInfo) string {
+	header := []string{"キャラクター", "スタイル", "ID", "種類"}
+	rows := [][]string{header}
+	for i, s := range styles {
+		speaker := s.SpeakerName
+		if i > 0 && styles[i-1].SpeakerUUID == s.SpeakerUUID && styles[i-1].SpeakerName == s.SpeakerName {
+			speaker = ""
+		}
+		rows = append(rows, []string{speaker, s.StyleName, strconv.Itoa(s.StyleID), s.StyleType})
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(row)-1 {
+				b.WriteString(cell)
+				break
+			}
+			// ID の列は右に寄せる
+			pad := strings.Repeat(" ", widths[i]-displayWidth(cell))
+			if i == 2 {
+				b.WriteString(pad + cell + "  ")
+			} else {
+				b.WriteString(cell + pad + "  ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// displayWidth は端末に表示したときの幅を返します。全角の文字は2として数えます
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r >= 0x1100 && (r <= 0x115f || // ハングルの字母
+			unicode.In(r, unicode.Han, unicode.Hangul) ||
+			(r >= 0x3000 && r <= 0x30ff) || // CJKの記号と句読点、ひらがな、カタカナ（長音符を含む）
+			(r >= 0xff01 && r <= 0xff60) || // 全角英数字と記号
+			(r >= 0xffe0 && r <= 0xffe6)):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
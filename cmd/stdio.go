@@ -12,8 +12,6 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var enablePlayback bool
-
 var stdioCmd = &cobra.Command{
 	Use:   "stdio",
 	Short: "Stdio経由で起動（MCP用）",
@@ -24,14 +22,14 @@ var stdioCmd = &cobra.Command{
 }
 
 func init() {
-	stdioCmd.Flags().StringVarP(&voicevoxURL, "voicevox-url", "u", "http://localhost:50021", "VOICEVOXのAPIエンドポイント")
 	stdioCmd.Flags().StringVarP(&tempDir, "temp-dir", "t", "", "一時ファイルを保存するディレクトリ")
-	stdioCmd.Flags().IntVarP(&defaultSpeaker, "default-speaker", "s", 3, "デフォルトの話者ID")
-	stdioCmd.Flags().BoolVar(&enablePlayback, "enable-playback", false, "音声の自動再生を有効にする")
-	stdioCmd.Flags().Float64Var(&defaultSpeedScale, "default-speed-scale", 1.0, "デフォルトの話速（0.5-2.0）")
-	stdioCmd.Flags().Float64Var(&defaultPitchScale, "default-pitch-scale", 0.0, "デフォルトの音高（-0.15-0.15）")
-	stdioCmd.Flags().Float64Var(&defaultIntonationScale, "default-intonation-scale", 1.0, "デフォルトの抑揚（0.0-2.0）")
-	stdioCmd.Flags().Float64Var(&defaultVolumeScale, "default-volume-scale", 1.0, "デフォルトの音量（0.0-2.0）")
+	stdioCmd.Flags().BoolVar(&saveAudio, "save-audio", true, "合成した音声をWAVファイルとして一時ディレクトリに保存する")
+	stdioCmd.Flags().IntSliceVar(&warmupStyles, "warmup-styles", nil, "起動時に事前初期化するスタイルID（カンマ区切り）")
+	addVoiceFlags(stdioCmd)
+	addPlaybackFlags(stdioCmd)
+	addSynthesisFlags(stdioCmd)
+	addPreprocessFlags(stdioCmd)
+	addOutputFlags(stdioCmd)
 }
 
 func runStdioServer(cmd *cobra.Command) error {
@@ -42,29 +40,26 @@ func runStdioServer(cmd *cobra.Command) error {
 	}
 
 	// コマンドラインフラグで設定を上書き
-	if cmd.Flags().Changed("voicevox-url") {
-		cfg.VoicevoxURL = voicevoxURL
-	}
+	applyVoiceFlags(cmd, cfg)
 	if cmd.Flags().Changed("temp-dir") {
 		cfg.TempDir = tempDir
 	}
-	if cmd.Flags().Changed("default-speaker") {
-		cfg.DefaultSpeaker = defaultSpeaker
-	}
-	if cmd.Flags().Changed("enable-playback") {
-		cfg.EnablePlayback = enablePlayback
+	if cmd.Flags().Changed("save-audio") {
+		cfg.SaveAudio = saveAudio
 	}
-	if cmd.Flags().Changed("default-speed-scale") {
-		cfg.DefaultSpeedScale = defaultSpeedScale
+	if cmd.Flags().Changed("warmup-styles") {
+		cfg.WarmupStyles = warmupStyles
 	}
-	if cmd.Flags().Changed("default-pitch-scale") {
-		cfg.DefaultPitchScale = defaultPitchScale
+	applyPlaybackFlags(cmd, cfg)
+	if err := applyPreprocessFlags(cmd, cfg); err != nil {
+		return err
 	}
-	if cmd.Flags().Changed("default-intonation-scale") {
-		cfg.DefaultIntonationScale = defaultIntonationScale
+	applySynthesisFlags(cmd, cfg)
+	if err := applyOutputFlags(cmd, cfg); err != nil {
+		return err
 	}
-	if cmd.Flags().Changed("default-volume-scale") {
-		cfg.DefaultVolumeScale = defaultVolumeScale
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
 
 	// 一時ディレクトリのセットアップ
@@ -73,13 +68,25 @@ func runStdioServer(cmd *cobra.Command) error {
 	}
 
 	// MCPハンドラーを作成
-	handler := mcp.NewHandler(cfg)
+	handler, err := mcp.NewHandler(cfg)
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
+	if len(cfg.WarmupStyles) > 0 {
+		log.Printf("スタイルの事前初期化を開始します: %v", cfg.WarmupStyles)
+	}
+	handler.StartWarmup()
 
 	scanner := bufio.NewScanner(os.Stdin)
 
 	log.SetOutput(os.Stderr)
-	log.Printf("MCP Stdio Server started - VOICEVOX URL: %s, Default Speaker: %d, Playback: %v",
-		cfg.VoicevoxURL, cfg.DefaultSpeaker, cfg.EnablePlayback)
+	defaultSpeakerLabel := fmt.Sprintf("%d", cfg.DefaultSpeaker)
+	if cfg.DefaultSpeakerName != "" {
+		defaultSpeakerLabel = cfg.DefaultSpeakerName
+	}
+	log.Printf("MCP Stdio Server started - VOICEVOX URL: %s, Default Speaker: %s, Playback: %v, Save Audio: %v",
+		cfg.VoicevoxURL, defaultSpeakerLabel, cfg.EnablePlayback, cfg.SaveAudio)
 	log.Printf("デフォルト音声設定: 話速=%.2f, 音高=%.2f, 抑揚=%.2f, 音量=%.2f",
 		cfg.DefaultSpeedScale, cfg.DefaultPitchScale, cfg.DefaultIntonationScale, cfg.DefaultVolumeScale)
 
@@ -0,0 +1,261 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// commandPlayer は外部コマンドで再生する組み込みバックエンドです
+type commandPlayer struct {
+	name   string
+	binary string
+	args   func(path string, opts PlayOptions) []string
+}
+
+// Name はバックエンド名を返します
+func (p *commandPlayer) Name() string {
+	return p.name
+}
+
+// Available は再生コマンドがPATH上にあるかを確認します
+func (p *commandPlayer) Available() error {
+	if _, err := exec.LookPath(p.binary); err != nil {
+		return fmt.Errorf("audio player %s not found: %w", p.binary, err)
+	}
+	return nil
+}
+
+// Play は再生コマンドを実行し、終了するまで待ちます
+func (p *commandPlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	return runCommand(ctx, exec.CommandContext(ctx, p.binary, p.args(path, opts)...))
+}
+
+// runCommand はコマンドを実行します。ctx のキャンセルで終了した場合は ctx のエラーを返します
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+func newAfplay() Player {
+	return &commandPlayer{name: "afplay", binary: "afplay", args: func(path string, opts PlayOptions) []string {
+		return []string{"-v", formatFloat(opts.volume()), path}
+	}}
+}
+
+func newPaplay() Player {
+	base := &commandPlayer{name: "paplay", binary: "paplay", args: func(path string, opts PlayOptions) []string {
+		return append(paplayArgs(opts), path)
+	}}
+	return &streamCommandPlayer{commandPlayer: base, streamArgs: func(wav []byte, opts PlayOptions) ([]string, []byte, error) {
+		// 標準入力からはヘッダーを解釈しないため、フォーマットを指定してPCMデータだけを渡す
+		format, err := ParseWAV(wav)
+		if err != nil {
+			return nil, nil, err
+		}
+		sampleFormat, err := pulseSampleFormat(format)
+		if err != nil {
+			return nil, nil, err
+		}
+		args := append(paplayArgs(opts),
+			"--raw",
+			"--format="+sampleFormat,
+			fmt.Sprintf("--rate=%d", format.SampleRate),
+			fmt.Sprintf("--channels=%d", format.Channels),
+		)
+		return args, wav[format.DataOffset : format.DataOffset+format.DataSize], nil
+	}}
+}
+
+// paplayArgs は paplay の共通引数を返します
+func paplayArgs(opts PlayOptions) []string {
+	// paplay の音量は 0〜65536（65536 が 100%）
+	args := []string{fmt.Sprintf("--volume=%d", int(opts.volume()*65536))}
+	if opts.Device != "" {
+		args = append(args, "--device="+opts.Device)
+	}
+	return args
+}
+
+func newAplay() Player {
+	args := func(opts PlayOptions) []string {
+		args := []string{"-q"}
+		if opts.Device != "" {
+			args = append(args, "-D", opts.Device)
+		}
+		return args
+	}
+	base := &commandPlayer{name: "aplay", binary: "aplay", args: func(path string, opts PlayOptions) []string {
+		return append(args(opts), path)
+	}}
+	return &streamCommandPlayer{commandPlayer: base, streamArgs: wholeWAV(func(opts PlayOptions) []string {
+		return append(args(opts), "-")
+	})}
+}
+
+func newMpv() Player {
+	args := func(opts PlayOptions) []string {
+		args := []string{"--no-video", "--really-quiet", fmt.Sprintf("--volume=%d", int(opts.volume()*100))}
+		if opts.Device != "" {
+			args = append(args, "--audio-device="+opts.Device)
+		}
+		return args
+	}
+	base := &commandPlayer{name: "mpv", binary: "mpv", args: func(path string, opts PlayOptions) []string {
+		return append(args(opts), path)
+	}}
+	return &streamCommandPlayer{commandPlayer: base, streamArgs: wholeWAV(func(opts PlayOptions) []string {
+		return append(args(opts), "-")
+	})}
+}
+
+func newPwPlay() Player {
+	return &commandPlayer{name: "pw-play", binary: "pw-play", args: func(path string, opts PlayOptions) []string {
+		args := []string{"--volume", formatFloat(opts.volume())}
+		if opts.Device != "" {
+			args = append(args, "--target", opts.Device)
+		}
+		return append(args, path)
+	}}
+}
+
+func newFfplay() Player {
+	args := func(opts PlayOptions) []string {
+		return []string{"-nodisp", "-autoexit", "-loglevel", "quiet", "-volume", strconv.Itoa(int(opts.volume() * 100))}
+	}
+	base := &commandPlayer{name: "ffplay", binary: "ffplay", args: func(path string, opts PlayOptions) []string {
+		return append(args(opts), path)
+	}}
+	return &streamCommandPlayer{commandPlayer: base, streamArgs: wholeWAV(func(opts PlayOptions) []string {
+		return append(args(opts), "-i", "pipe:0")
+	})}
+}
+
+func newPowerShell() Player {
+	return &commandPlayer{name: "powershell", binary: "powershell", args: func(path string, opts PlayOptions) []string {
+		quoted := strings.ReplaceAll(path, "'", "''")
+		return []string{"-c", fmt.Sprintf("(New-Object Media.SoundPlayer '%s').PlaySync()", quoted)}
+	}}
+}
+
+// templatePlayer はユーザー指定のコマンドテンプレートで再生するバックエンドです。
+// テンプレート中の {file}、{volume}、{device} は再生時に置き換えられます
+type templatePlayer struct {
+	template string
+	args     []string
+}
+
+// NewCommandPlayer はコマンドテンプレートから再生バックエンドを作成します。
+// 例: "pw-play --target={device} {file}", "ffplay -nodisp -autoexit -volume {volume} {file}"。
+// 引数はシェルと同様に空白で区切られ、シングル・ダブルクォートで囲むことができます。
+// デバイス未指定時は {device} を含む引数（例: "--target={device}"）を、置き換え後に空になった引数とともに取り除きます。
+// {file} を含まないテンプレート（例: "aplay -q -"）には音声データを標準入力で渡します
+func NewCommandPlayer(template string) (Player, error) {
+	args, err := splitCommand(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("audio player command is empty")
+	}
+	player := &templatePlayer{template: template, args: args}
+	if !strings.Contains(template, "{file}") {
+		return &stdinTemplatePlayer{templatePlayer: player}, nil
+	}
+	return player, nil
+}
+
+// Name はバックエンド名を返します
+func (p *templatePlayer) Name() string {
+	return "command"
+}
+
+// Available はテンプレートのコマンドがPATH上にあるかを確認します
+func (p *templatePlayer) Available() error {
+	if _, err := exec.LookPath(p.args[0]); err != nil {
+		return fmt.Errorf("audio player command %s not found: %w", p.args[0], err)
+	}
+	return nil
+}
+
+// Play はプレースホルダーを置き換えたコマンドを実行します
+func (p *templatePlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	args := p.expand(path, opts)
+	return runCommand(ctx, exec.CommandContext(ctx, args[0], args[1:]...))
+}
+
+// expand はテンプレートのプレースホルダーを置き換えた引数を返します
+func (p *templatePlayer) expand(path string, opts PlayOptions) []string {
+	replacer := strings.NewReplacer(
+		"{file}", path,
+		"{volume}", formatFloat(opts.volume()),
+		"{device}", opts.Device,
+	)
+
+	args := make([]string, 0, len(p.args))
+	for _, arg := range p.args {
+		// デバイス未指定時は "--target={device}" のように {device} を含む引数ごと取り除く
+		if opts.Device == "" && strings.Contains(arg, "{device}") {
+			continue
+		}
+		expanded := replacer.Replace(arg)
+		if expanded == "" && arg != "" {
+			continue
+		}
+		args = append(args, expanded)
+	}
+	return args
+}
+
+// splitCommand はコマンド文字列を引数に分割します（シングル・ダブルクォートに対応）
+func splitCommand(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in audio player command: %s", command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// formatFloat は音量などの小数をコマンド引数向けに整形します
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,162 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Device は再生に使える出力デバイスです。Name を PlayOptions.Device に指定します
+type Device struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Backend はデバイスを列挙したバックエンド名です
+	Backend string `json:"backend"`
+	Default bool   `json:"default,omitempty"`
+}
+
+// DeviceLister は出力デバイスを列挙できるバックエンドが実装します
+type DeviceLister interface {
+	Devices(ctx context.Context) ([]Device, error)
+}
+
+// deviceListers は組み込みバックエンドごとのデバイス列挙方法です。
+// pw-play は pipewire-pulse 経由の PulseAudio のシンク名をそのまま --target に指定できます
+var deviceListers = map[string]func(ctx context.Context) ([]Device, error){
+	"paplay":  func(ctx context.Context) ([]Device, error) { return listPulseSinks(ctx, "paplay", nil) },
+	"pw-play": func(ctx context.Context) ([]Device, error) { return listPulseSinks(ctx, "pw-play", nil) },
+	"aplay":   listALSADevices,
+	"mpv":     listMpvDevices,
+}
+
+// ListDevices はバックエンドで選択できる出力デバイスを列挙します。
+// デバイスの選択に対応していないバックエンドではエラーを返します
+func ListDevices(ctx context.Context, player Player) ([]Device, error) {
+	if lister, ok := player.(DeviceLister); ok {
+		return lister.Devices(ctx)
+	}
+	if list, ok := deviceListers[player.Name()]; ok {
+		return list(ctx)
+	}
+	return nil, fmt.Errorf("audio player %s does not support device listing", player.Name())
+}
+
+// Devices はリモートの PulseAudio / PipeWire サーバーのシンクを列挙します
+func (p *pulseSinkPlayer) Devices(ctx context.Context) ([]Device, error) {
+	return listPulseSinks(ctx, p.Name(), p.env())
+}
+
+// listPulseSinks は pactl で PulseAudio / PipeWire のシンクを列挙します
+func listPulseSinks(ctx context.Context, backend string, env []string) ([]Device, error) {
+	sinks, err := commandOutput(ctx, env, "pactl", "list", "sinks")
+	if err != nil {
+		return nil, err
+	}
+	// 既定のシンクが取得できなくても一覧は返す
+	info, _ := commandOutput(ctx, env, "pactl", "info")
+	return parsePactlSinks(sinks, parsePactlDefaultSink(info), backend), nil
+}
+
+// listALSADevices は aplay -L でALSAのPCMデバイスを列挙します
+func listALSADevices(ctx context.Context) ([]Device, error) {
+	output, err := commandOutput(ctx, nil, "aplay", "-L")
+	if err != nil {
+		return nil, err
+	}
+	return parseAplayDevices(output), nil
+}
+
+// listMpvDevices は mpv --audio-device=help で mpv のオーディオデバイスを列挙します
+func listMpvDevices(ctx context.Context) ([]Device, error) {
+	output, err := commandOutput(ctx, nil, "mpv", "--audio-device=help")
+	if err != nil {
+		return nil, err
+	}
+	return parseMpvDevices(output), nil
+}
+
+// commandOutput はコマンドを実行して標準出力を返します。env が nil の場合は現在の環境変数を使います
+func commandOutput(ctx context.Context, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return string(output), nil
+}
+
+// parsePactlSinks は pactl list sinks の出力からシンク名と説明を取り出します
+func parsePactlSinks(output, defaultSink, backend string) []Device {
+	var devices []Device
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "Name: "):
+			name := strings.TrimPrefix(line, "Name: ")
+			devices = append(devices, Device{Name: name, Backend: backend, Default: name == defaultSink})
+		case strings.HasPrefix(line, "Description: ") && len(devices) > 0:
+			devices[len(devices)-1].Description = strings.TrimPrefix(line, "Description: ")
+		}
+	}
+	return devices
+}
+
+// parsePactlDefaultSink は pactl info の出力から既定のシンク名を取り出します
+func parsePactlDefaultSink(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "Default Sink: "); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseAplayDevices は aplay -L の出力を解析します。
+// デバイス名は行頭から、説明はその後のインデントされた行に出力されます
+func parseAplayDevices(output string) []Device {
+	var devices []Device
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			name := strings.TrimSpace(line)
+			devices = append(devices, Device{Name: name, Backend: "aplay", Default: name == "default"})
+			continue
+		}
+		if len(devices) == 0 {
+			continue
+		}
+		last := &devices[len(devices)-1]
+		if last.Description != "" {
+			last.Description += ", "
+		}
+		last.Description += strings.TrimSpace(line)
+	}
+	return devices
+}
+
+// mpvDeviceLine は mpv --audio-device=help の "  'name' (description)" 形式の行です
+var mpvDeviceLine = regexp.MustCompile(`^\s+'(.+)' \((.*)\)\s*$`)
+
+// parseMpvDevices は mpv --audio-device=help の出力を解析します
+func parseMpvDevices(output string) []Device {
+	var devices []Device
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		match := mpvDeviceLine.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		devices = append(devices, Device{Name: match[1], Description: match[2], Backend: "mpv", Default: match[1] == "auto"})
+	}
+	return devices
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const pactlSinksOutput = `Sink #0
+	State: SUSPENDED
+	Name: alsa_output.pci-0000_00_1f.3.analog-stereo
+	Description: Built-in Audio Analog Stereo
+	Driver: PipeWire
+
+Sink #57
+	State: RUNNING
+	Name: bluez_output.AA_BB_CC_DD_EE_FF.1
+	Description: WH-1000XM4
+	Driver: PipeWire
+`
+
+const pactlInfoOutput = `Server String: /run/user/1000/pulse/native
+Server Name: PulseAudio (on PipeWire 1.0.5)
+Default Sink: bluez_output.AA_BB_CC_DD_EE_FF.1
+Default Source: alsa_input.pci-0000_00_1f.3.analog-stereo
+`
+
+func TestParsePactlSinks(t *testing.T) {
+	got := parsePactlSinks(pactlSinksOutput, parsePactlDefaultSink(pactlInfoOutput), "paplay")
+	want := []Device{
+		{Name: "alsa_output.pci-0000_00_1f.3.analog-stereo", Description: "Built-in Audio Analog Stereo", Backend: "paplay"},
+		{Name: "bluez_output.AA_BB_CC_DD_EE_FF.1", Description: "WH-1000XM4", Backend: "paplay", Default: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePactlSinks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseAplayDevices(t *testing.T) {
+	output := `null
+    Discard all samples (playback) or generate zero samples (capture)
+default
+    Default ALSA Output (currently PipeWire Media Server)
+sysdefault:CARD=PCH
+    HDA Intel PCH, ALC3246 Analog
+    Default Audio Device
+`
+	got := parseAplayDevices(output)
+	want := []Device{
+		{Name: "null", Description: "Discard all samples (playback) or generate zero samples (capture)", Backend: "aplay"},
+		{Name: "default", Description: "Default ALSA Output (currently PipeWire Media Server)", Backend: "aplay", Default: true},
+		{Name: "sysdefault:CARD=PCH", Description: "HDA Intel PCH, ALC3246 Analog, Default Audio Device", Backend: "aplay"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAplayDevices() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMpvDevices(t *testing.T) {
+	output := `List of detected audio devices:
+  'auto' (Autoselect device)
+  'pulse' (Default (pulse))
+  'pulse/alsa_output.pci-0000_00_1f.3.analog-stereo' (Built-in Audio Analog Stereo)
+`
+	got := parseMpvDevices(output)
+	want := []Device{
+		{Name: "auto", Description: "Autoselect device", Backend: "mpv", Default: true},
+		{Name: "pulse", Description: "Default (pulse)", Backend: "mpv"},
+		{Name: "pulse/alsa_output.pci-0000_00_1f.3.analog-stereo", Description: "Built-in Audio Analog Stereo", Backend: "mpv"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMpvDevices() = %+v, want %+v", got, want)
+	}
+}
+
+func TestListDevices_Unsupported(t *testing.T) {
+	if _, err := ListDevices(context.Background(), NullPlayer{}); err == nil {
+		t.Error("ListDevices(null) error = nil, want error")
+	}
+}
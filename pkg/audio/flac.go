@@ -0,0 +1,383 @@
+package audio
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// flacBlockSize は1フレームあたりのサンプル数です
+const flacBlockSize = 4096
+
+// flacMaxPartitionOrder はRice符号の区分の最大の分割数（2の累乗の指数）です
+const flacMaxPartitionOrder = 6
+
+// EncodeFLAC はリニアPCMのWAVを可逆圧縮のFLACにします。
+// 各チャンネルを固定予測（0〜4次）とRice符号で圧縮し、圧縮が効かない区間はそのまま格納します
+func EncodeFLAC(wav []byte) ([]byte, error) {
+	format, err := ParseWAV(wav)
+	if err != nil {
+		return nil, err
+	}
+	if format.AudioFormat != 1 || (format.BitsPerSample != 8 && format.BitsPerSample != 16 && format.BitsPerSample != 24) {
+		return nil, fmt.Errorf("FLAC encoding requires 8, 16 or 24 bit linear PCM, got format %d, %d bit", format.AudioFormat, format.BitsPerSample)
+	}
+	if format.Channels < 1 || format.Channels > 8 {
+		return nil, fmt.Errorf("FLAC supports 1 to 8 channels, got %d", format.Channels)
+	}
+	if format.SampleRate <= 0 || format.SampleRate >= 1<<20 {
+		return nil, fmt.Errorf("FLAC does not support a sample rate of %d Hz", format.SampleRate)
+	}
+
+	data := wav[format.DataOffset : format.DataOffset+format.DataSize]
+	channels := splitChannels(format, data)
+	frames := len(channels[0])
+
+	w := &bitWriter{}
+	w.writeBytes([]byte("fLaC"))
+	// STREAMINFO（最後のメタデータブロック）
+	w.write(1, 1)
+	w.write(0, 7)
+	w.write(34, 24)
+	w.write(flacBlockSize, 16)
+	w.write(flacBlockSize, 16)
+	w.write(0, 24)
+	w.write(0, 24)
+	w.write(uint64(format.SampleRate), 20)
+	w.write(uint64(format.Channels-1), 3)
+	w.write(uint64(format.BitsPerSample-1), 5)
+	w.write(uint64(frames), 36)
+	// MD5 はリトルエンディアン・符号付きのサンプル列から求める（8bitのWAVは符号なしのため変換する）
+	signed := data[:frames*format.Channels*format.BitsPerSample/8]
+	if format.BitsPerSample == 8 {
+		signed = make([]byte, len(signed))
+		for i, b := range data[:len(signed)] {
+			signed[i] = b ^ 0x80
+		}
+	}
+	sum := md5.Sum(signed)
+	w.writeBytes(sum[:])
+
+	for number, start := 0, 0; start < frames; number, start = number+1, start+flacBlockSize {
+		end := min(start+flacBlockSize, frames)
+		block := make([][]int64, len(channels))
+		for c := range channels {
+			block[c] = channels[c][start:end]
+		}
+		writeFLACFrame(w, format, number, block)
+	}
+	return w.bytes(), nil
+}
+
+// splitChannels はインターリーブされたPCMをチャンネルごとの符号付き整数にします
+func splitChannels(format WAVFormat, data []byte) [][]int64 {
+	bytesPerSample := format.BitsPerSample / 8
+	frames := len(data) / (bytesPerSample * format.Channels)
+	channels := make([][]int64, format.Channels)
+	for c := range channels {
+		channels[c] = make([]int64, frames)
+	}
+	for i := 0; i < frames; i++ {
+		for c := range channels {
+			b := data[(i*format.Channels+c)*bytesPerSample:]
+			var v int64
+			switch bytesPerSample {
+			case 1:
+				v = int64(b[0]) - 128
+			case 2:
+				v = int64(int16(binary.LittleEndian.Uint16(b)))
+			case 3:
+				v = int64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8)
+			}
+			channels[c][i] = v
+		}
+	}
+	return channels
+}
+
+// writeFLACFrame は1フレームを書き込みます。チャンネルは独立して符号化します
+func writeFLACFrame(w *bitWriter, format WAVFormat, number int, block [][]int64) {
+	start := len(w.buf)
+	size := len(block[0])
+
+	w.write(0x3FFE, 14)
+	w.write(0, 1)
+	w.write(0, 1) // 固定ブロックサイズ
+	if size == flacBlockSize {
+		w.write(0xC, 4) // 4096
+	} else {
+		w.write(0x7, 4) // ヘッダーの末尾に16bitで格納
+	}
+	w.write(0, 4) // サンプリングレートは STREAMINFO と同じ
+	w.write(uint64(format.Channels-1), 4)
+	switch format.BitsPerSample {
+	case 8:
+		w.write(0x1, 3)
+	case 16:
+		w.write(0x4, 3)
+	case 24:
+		w.write(0x6, 3)
+	}
+	w.write(0, 1)
+	w.writeBytes(utf8Number(uint64(number)))
+	if size != flacBlockSize {
+		w.write(uint64(size-1), 16)
+	}
+	w.writeBytes([]byte{crc8(w.buf[start:])})
+
+	for _, samples := range block {
+		writeSubframe(w, samples, format.BitsPerSample)
+	}
+	w.align()
+	crc := crc16(w.buf[start:])
+	w.writeBytes([]byte{byte(crc >> 8), byte(crc)})
+}
+
+// writeSubframe は1チャンネル分のサブフレームを、最も短くなる方式で書き込みます
+func writeSubframe(w *bitWriter, samples []int64, bps int) {
+	constant := true
+	for _, v := range samples[1:] {
+		if v != samples[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		w.write(0, 8)
+		w.writeSigned(samples[0], bps)
+		return
+	}
+
+	bestOrder, bestBits := -1, len(samples)*bps
+	var best residualPlan
+	for order := 0; order <= 4 && order < len(samples); order++ {
+		plan := planResidual(fixedResidual(samples, order), len(samples), order)
+		if plan.bits < 0 {
+			continue
+		}
+		if total := order*bps + plan.bits; total < bestBits {
+			bestOrder, bestBits, best = order, total, plan
+		}
+	}
+
+	if bestOrder < 0 {
+		// 予測しても短くならない場合はそのまま格納する
+		w.write(0x02, 8)
+		for _, v := range samples {
+			w.writeSigned(v, bps)
+		}
+		return
+	}
+
+	w.write(uint64(0x08|bestOrder)<<1, 8)
+	for _, v := range samples[:bestOrder] {
+		w.writeSigned(v, bps)
+	}
+	best.write(w)
+}
+
+// fixedResidual は固定予測（order 次の差分）の残差を求めます
+func fixedResidual(samples []int64, order int) []int64 {
+	residual := make([]int64, len(samples)-order)
+	for i := order; i < len(samples); i++ {
+		var predicted int64
+		switch order {
+		case 1:
+			predicted = samples[i-1]
+		case 2:
+			predicted = 2*samples[i-1] - samples[i-2]
+		case 3:
+			predicted = 3*samples[i-1] - 3*samples[i-2] + samples[i-3]
+		case 4:
+			predicted = 4*samples[i-1] - 6*samples[i-2] + 4*samples[i-3] - samples[i-4]
+		}
+		residual[i-order] = samples[i] - predicted
+	}
+	return residual
+}
+
+// residualPlan はRice符号で残差を書き込むための区分とパラメータです
+type residualPlan struct {
+	residual       []int64
+	partitionOrder int
+	params         []int
+	lengths        []int
+	bits           int
+}
+
+// planResidual は残差を最も短く符号化できる区分の分割数とRiceパラメータを選びます
+func planResidual(residual []int64, blockSize, predictorOrder int) residualPlan {
+	folded := make([]uint64, len(residual))
+	for i, v := range residual {
+		folded[i] = uint64(v<<1) ^ uint64(v>>63)
+	}
+
+	best := residualPlan{bits: -1}
+	for order := 0; order <= flacMaxPartitionOrder; order++ {
+		if blockSize%(1<<order) != 0 || blockSize>>order <= predictorOrder {
+			break
+		}
+		plan := residualPlan{residual: residual, partitionOrder: order, bits: 6}
+		offset := 0
+		for p := 0; p < 1<<order; p++ {
+			n := blockSize >> order
+			if p == 0 {
+				n -= predictorOrder
+			}
+			param, bits := riceParam(folded[offset : offset+n])
+			plan.params = append(plan.params, param)
+			plan.lengths = append(plan.lengths, n)
+			plan.bits += 4 + bits
+			offset += n
+		}
+		if best.bits < 0 || plan.bits < best.bits {
+			best = plan
+		}
+	}
+	return best
+}
+
+// riceParam は区分のRiceパラメータ（0〜14）と符号化後のビット数を求めます
+func riceParam(folded []uint64) (int, int) {
+	var sum uint64
+	for _, u := range folded {
+		sum += u
+	}
+	guess := 0
+	if n := uint64(len(folded)); n > 0 && sum > n {
+		guess = min(bits.Len64(sum/n)-1, 14)
+	}
+
+	bestParam, bestBits := 0, -1
+	for k := max(guess-1, 0); k <= min(guess+1, 14); k++ {
+		total := len(folded) * (k + 1)
+		for _, u := range folded {
+			total += int(u >> uint(k))
+		}
+		if bestBits < 0 || total < bestBits {
+			bestParam, bestBits = k, total
+		}
+	}
+	return bestParam, bestBits
+}
+
+// write は残差をRice符号で書き込みます
+func (p residualPlan) write(w *bitWriter) {
+	w.write(0, 2) // 4bitのRiceパラメータ
+	w.write(uint64(p.partitionOrder), 4)
+	offset := 0
+	for i, param := range p.params {
+		w.write(uint64(param), 4)
+		for _, v := range p.residual[offset : offset+p.lengths[i]] {
+			u := uint64(v<<1) ^ uint64(v>>63)
+			w.writeUnary(u >> uint(param))
+			w.write(u&(1<<uint(param)-1), param)
+		}
+		offset += p.lengths[i]
+	}
+}
+
+// bitWriter は上位ビットから順にビット列を書き込みます
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// write は v の下位 n ビットを書き込みます
+func (w *bitWriter) write(v uint64, n int) {
+	for n > 0 {
+		take := min(n, 56-int(w.nbits))
+		n -= take
+		w.acc = w.acc<<uint(take) | (v>>uint(n))&(1<<uint(take)-1)
+		w.nbits += uint(take)
+		for w.nbits >= 8 {
+			w.nbits -= 8
+			w.buf = append(w.buf, byte(w.acc>>w.nbits))
+		}
+		w.acc &= 1<<w.nbits - 1
+	}
+}
+
+// writeSigned は v を n ビットの2の補数で書き込みます
+func (w *bitWriter) writeSigned(v int64, n int) {
+	w.write(uint64(v)&(1<<uint(n)-1), n)
+}
+
+// writeUnary は v 個の0と終端の1を書き込みます
+func (w *bitWriter) writeUnary(v uint64) {
+	for ; v >= 32; v -= 32 {
+		w.write(0, 32)
+	}
+	w.write(1, int(v)+1)
+}
+
+func (w *bitWriter) writeBytes(b []byte) {
+	for _, c := range b {
+		w.write(uint64(c), 8)
+	}
+}
+
+// align は次のバイト境界まで0を書き込みます
+func (w *bitWriter) align() {
+	if w.nbits > 0 {
+		w.write(0, 8-int(w.nbits))
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	w.align()
+	return w.buf
+}
+
+// utf8Number はフレーム番号をUTF-8と同じ可変長の形式にします
+func utf8Number(v uint64) []byte {
+	if v < 0x80 {
+		return []byte{byte(v)}
+	}
+	// 続くバイト数ごとの先頭バイトに入るビット数
+	n := 1
+	for v >= 1<<uint(6*n+6-n) {
+		n++
+	}
+	out := make([]byte, n+1)
+	for i := n; i > 0; i-- {
+		out[i] = 0x80 | byte(v&0x3F)
+		v >>= 6
+	}
+	out[0] = byte(0xFF<<uint(7-n)) | byte(v)
+	return out
+}
+
+// crc8 はフレームヘッダーのCRC-8（多項式 x^8+x^2+x+1）を求めます
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// crc16 はフレーム全体のCRC-16（多項式 x^16+x^15+x^2+1）を求めます
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
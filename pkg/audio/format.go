@@ -0,0 +1,184 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// 出力形式の名前
+const (
+	FormatWAV   = "wav"
+	FormatFLAC  = "flac"
+	FormatMulaw = "mulaw"
+	FormatOpus  = "opus"
+	FormatMP3   = "mp3"
+)
+
+// OutputFormat は合成した音声を保存・返却するときの形式です
+type OutputFormat struct {
+	// Name は形式の名前（wav / flac / mulaw / opus / mp3）です
+	Name string `json:"name"`
+	// MIMEType は Content-Type に使うメディアタイプです
+	MIMEType string `json:"mime_type"`
+	// Extension はファイルの拡張子（"." を含む）です
+	Extension string `json:"extension"`
+	// Description は形式の説明です
+	Description string `json:"description"`
+
+	// encode は組み込みのエンコーダーです。nil の場合は encoders の外部コマンドを使います
+	encode func(wav []byte) ([]byte, error)
+	// encoders は優先順に試す外部エンコーダーです
+	encoders []externalEncoder
+}
+
+// externalEncoder は標準入力のWAVを標準出力に変換する外部コマンドです
+type externalEncoder struct {
+	binary string
+	args   []string
+}
+
+var outputFormats = []OutputFormat{
+	{
+		Name: FormatWAV, MIMEType: "audio/wav", Extension: ".wav",
+		Description: "VOICEVOXが出力したリニアPCMのWAV",
+		encode:      func(wav []byte) ([]byte, error) { return wav, nil },
+	},
+	{
+		Name: FormatFLAC, MIMEType: "audio/flac", Extension: ".flac",
+		Description: "可逆圧縮のFLAC（組み込みのエンコーダー）",
+		encode:      EncodeFLAC,
+	},
+	{
+		Name: FormatMulaw, MIMEType: "audio/wav", Extension: ".wav",
+		Description: "電話向けの8kHz・モノラルのμ-law（G.711）のWAV（組み込みのエンコーダー）",
+		encode:      EncodeMulaw,
+	},
+	{
+		Name: FormatOpus, MIMEType: "audio/ogg", Extension: ".ogg",
+		Description: "Ogg/Opus（opusenc または ffmpeg が必要）",
+		encoders: []externalEncoder{
+			{binary: "opusenc", args: []string{"--quiet", "--bitrate", "32", "-", "-"}},
+			{binary: "ffmpeg", args: []string{"-hide_banner", "-loglevel", "error", "-f", "wav", "-i", "pipe:0",
+				"-ar", "48000", "-c:a", "libopus", "-b:a", "32k", "-f", "ogg", "pipe:1"}},
+		},
+	},
+	{
+		Name: FormatMP3, MIMEType: "audio/mpeg", Extension: ".mp3",
+		Description: "MP3（ffmpeg または lame が必要）",
+		encoders: []externalEncoder{
+			{binary: "ffmpeg", args: []string{"-hide_banner", "-loglevel", "error", "-f", "wav", "-i", "pipe:0",
+				"-c:a", "libmp3lame", "-q:a", "4", "-f", "mp3", "pipe:1"}},
+			{binary: "lame", args: []string{"--quiet", "-V", "4", "-", "-"}},
+		},
+	},
+}
+
+// formatAliases は出力形式の別名です
+var formatAliases = map[string]string{
+	"wave": FormatWAV,
+	"ulaw": FormatMulaw,
+	"pcmu": FormatMulaw,
+	"ogg":  FormatOpus,
+}
+
+// OutputFormats は対応している出力形式の一覧を返します
+func OutputFormats() []OutputFormat {
+	formats := make([]OutputFormat, len(outputFormats))
+	copy(formats, outputFormats)
+	return formats
+}
+
+// OutputFormatNames は出力形式の名前の一覧を返します
+func OutputFormatNames() []string {
+	names := make([]string, len(outputFormats))
+	for i, f := range outputFormats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// ParseOutputFormat は名前から出力形式を返します。空の場合は WAV です。
+// 大文字・小文字は区別せず、ulaw・pcmu・ogg などの別名も受け付けます
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = FormatWAV
+	}
+	if alias, ok := formatAliases[name]; ok {
+		name = alias
+	}
+	for _, f := range outputFormats {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return OutputFormat{}, fmt.Errorf("unknown output format %q (expected %s)", name, strings.Join(OutputFormatNames(), ", "))
+}
+
+// Encoder はこの形式の変換に使うエンコーダーの名前を返します。
+// 組み込みの場合は "builtin"、外部コマンドが見つからない場合は空文字列です
+func (f OutputFormat) Encoder() string {
+	if f.encode != nil {
+		return "builtin"
+	}
+	if e, ok := f.externalEncoder(); ok {
+		return e.binary
+	}
+	return ""
+}
+
+// Available はこの形式に変換できるかを確認します。外部コマンドが必要な形式でPATH上に見つからない場合はエラーを返します
+func (f OutputFormat) Available() error {
+	if f.Encoder() != "" {
+		return nil
+	}
+	binaries := make([]string, len(f.encoders))
+	for i, e := range f.encoders {
+		binaries[i] = e.binary
+	}
+	return fmt.Errorf("output format %s requires %s on PATH", f.Name, strings.Join(binaries, " or "))
+}
+
+func (f OutputFormat) externalEncoder() (externalEncoder, bool) {
+	for _, e := range f.encoders {
+		if _, err := exec.LookPath(e.binary); err == nil {
+			return e, true
+		}
+	}
+	return externalEncoder{}, false
+}
+
+// Encode はWAVをこの形式に変換します
+func (f OutputFormat) Encode(wav []byte) ([]byte, error) {
+	return f.EncodeContext(context.Background(), wav)
+}
+
+// EncodeContext はWAVをこの形式に変換します。外部コマンドはコンテキストがキャンセルされると終了させます
+func (f OutputFormat) EncodeContext(ctx context.Context, wav []byte) ([]byte, error) {
+	if f.encode != nil {
+		return f.encode(wav)
+	}
+	e, ok := f.externalEncoder()
+	if !ok {
+		return nil, f.Available()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.binary, e.args...)
+	cmd.Stdin = bytes.NewReader(wav)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed to encode %s: %w: %s", e.binary, f.Name, err, msg)
+		}
+		return nil, fmt.Errorf("%s failed to encode %s: %w", e.binary, f.Name, err)
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("%s produced no %s output", e.binary, f.Name)
+	}
+	return stdout.Bytes(), nil
+}
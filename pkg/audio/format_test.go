@@ -0,0 +1,427 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// makeTestPCM は正弦波・雑音・無音を含む16bitのPCMを作成します
+func makeTestPCM(frames, channels int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	pcm := make([]byte, frames*channels*2)
+	for i := 0; i < frames; i++ {
+		for c := 0; c < channels; c++ {
+			var v float64
+			switch {
+			case i < frames/3:
+				v = 12000 * math.Sin(2*math.Pi*440*float64(i)/24000+float64(c))
+			case i < frames*2/3:
+				v = rng.NormFloat64() * 3000
+			}
+			binary.LittleEndian.PutUint16(pcm[(i*channels+c)*2:], uint16(int16(v)))
+		}
+	}
+	return pcm
+}
+
+func TestEncodeFLAC(t *testing.T) {
+	tests := []struct {
+		name     string
+		frames   int
+		channels int
+	}{
+		{"mono with a partial last block", 24000*2 + 123, 1},
+		{"stereo", 10000, 2},
+		{"shorter than a block", 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := makeTestPCM(tt.frames, tt.channels)
+			encoded, err := EncodeFLAC(makeWAV(24000, tt.channels, pcm))
+			if err != nil {
+				t.Fatalf("EncodeFLAC() error = %v", err)
+			}
+
+			info, decoded, err := decodeFLAC(encoded)
+			if err != nil {
+				t.Fatalf("decodeFLAC() error = %v", err)
+			}
+			if info.sampleRate != 24000 || info.channels != tt.channels || info.bps != 16 || info.frames != tt.frames {
+				t.Errorf("STREAMINFO = %+v", info)
+			}
+			if !bytes.Equal(decoded, pcm) {
+				t.Fatal("decoded FLAC does not match the original PCM")
+			}
+			if info.md5 != md5.Sum(pcm) {
+				t.Error("STREAMINFO MD5 does not match the original PCM")
+			}
+			if tt.frames > flacBlockSize && len(encoded) >= len(pcm) {
+				t.Errorf("FLAC size = %d, want smaller than PCM size %d", len(encoded), len(pcm))
+			}
+		})
+	}
+}
+
+func TestEncodeFLAC_Unsupported(t *testing.T) {
+	wav := EncodeWAV(WAVFormat{AudioFormat: 3, Channels: 1, SampleRate: 24000, BitsPerSample: 32}, make([]byte, 16))
+	if _, err := EncodeFLAC(wav); err == nil {
+		t.Error("EncodeFLAC(float) error = nil, want error")
+	}
+}
+
+func TestEncodeMulaw(t *testing.T) {
+	pcm := makeTestPCM(24000, 2)
+	encoded, err := EncodeMulaw(makeWAV(24000, 2, pcm))
+	if err != nil {
+		t.Fatalf("EncodeMulaw() error = %v", err)
+	}
+
+	format, err := ParseWAV(encoded)
+	if err != nil {
+		t.Fatalf("ParseWAV() error = %v", err)
+	}
+	if format.AudioFormat != 7 || format.SampleRate != 8000 || format.Channels != 1 || format.BitsPerSample != 8 {
+		t.Errorf("format = %+v", format)
+	}
+	if format.DataSize != 8000 {
+		t.Errorf("data size = %d, want 8000", format.DataSize)
+	}
+
+	// 正弦波の区間は元の音量（2チャンネルの平均）に近い値に戻る
+	var peak float64
+	for _, u := range encoded[format.DataOffset : format.DataOffset+8000/3] {
+		peak = math.Max(peak, math.Abs(float64(mulawToLinear(u))))
+	}
+	if peak < 6000 || peak > 12500 {
+		t.Errorf("decoded peak = %v, want about %v", peak, 12000*math.Cos(0.5))
+	}
+}
+
+func TestLinearToMulaw(t *testing.T) {
+	for _, v := range []int16{0, 1, -1, 100, -100, 1000, -5000, 32767, -32768} {
+		got := mulawToLinear(linearToMulaw(v))
+		// μ-law の量子化誤差は値の大きさのおよそ1/16以内
+		if diff := math.Abs(float64(got) - float64(v)); diff > math.Max(8, math.Abs(float64(v))/16) {
+			t.Errorf("mulaw round trip of %d = %d", v, got)
+		}
+	}
+	if linearToMulaw(0) != 0xFF {
+		t.Errorf("linearToMulaw(0) = %#x, want 0xff", linearToMulaw(0))
+	}
+}
+
+func TestSamples_Resample(t *testing.T) {
+	tone := func(freq float64) Samples {
+		s := Samples{SampleRate: 24000, Channels: 1, Data: make([]float64, 24000)}
+		for i := range s.Data {
+			s.Data[i] = 0.5 * math.Sin(2*math.Pi*freq*float64(i)/24000)
+		}
+		return s
+	}
+	peak := func(s Samples) float64 {
+		var p float64
+		// 端の影響を除く
+		for _, v := range s.Data[len(s.Data)/4 : len(s.Data)*3/4] {
+			p = math.Max(p, math.Abs(v))
+		}
+		return p
+	}
+
+	low := tone(440).Resample(8000)
+	if low.SampleRate != 8000 || len(low.Data) != 8000 {
+		t.Fatalf("Resample() = %d Hz, %d samples", low.SampleRate, len(low.Data))
+	}
+	if p := peak(low); math.Abs(p-0.5) > 0.02 {
+		t.Errorf("440 Hz peak after resampling = %v, want 0.5", p)
+	}
+	// 変換後のナイキスト周波数（4kHz）を超える成分は取り除かれる
+	if p := peak(tone(6000).Resample(8000)); p > 0.02 {
+		t.Errorf("6 kHz peak after resampling to 8 kHz = %v, want about 0", p)
+	}
+	up := tone(440).Resample(48000)
+	if p := peak(up); len(up.Data) != 48000 || math.Abs(p-0.5) > 0.02 {
+		t.Errorf("upsampled to %d samples with peak %v", len(up.Data), p)
+	}
+}
+
+func TestDecodeSamples(t *testing.T) {
+	pcm := []byte{0x00, 0x40, 0x00, 0xC0}
+	s, err := DecodeSamples(makeWAV(24000, 2, pcm))
+	if err != nil {
+		t.Fatalf("DecodeSamples() error = %v", err)
+	}
+	if !reflect.DeepEqual(s.Data, []float64{0.5, -0.5}) || s.Frames() != 1 {
+		t.Errorf("DecodeSamples() = %+v", s)
+	}
+	if got := s.EncodeWAV(); !bytes.Equal(got, makeWAV(24000, 2, pcm)) {
+		t.Errorf("EncodeWAV() = %v", got)
+	}
+	if mono := s.Mono(); !reflect.DeepEqual(mono.Data, []float64{0}) {
+		t.Errorf("Mono() = %v", mono.Data)
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		input     string
+		name      string
+		mime      string
+		extension string
+	}{
+		{"", FormatWAV, "audio/wav", ".wav"},
+		{"FLAC", FormatFLAC, "audio/flac", ".flac"},
+		{"ulaw", FormatMulaw, "audio/wav", ".wav"},
+		{"ogg", FormatOpus, "audio/ogg", ".ogg"},
+		{"mp3", FormatMP3, "audio/mpeg", ".mp3"},
+	}
+	for _, tt := range tests {
+		f, err := ParseOutputFormat(tt.input)
+		if err != nil {
+			t.Fatalf("ParseOutputFormat(%q) error = %v", tt.input, err)
+		}
+		if f.Name != tt.name || f.MIMEType != tt.mime || f.Extension != tt.extension {
+			t.Errorf("ParseOutputFormat(%q) = %s %s %s", tt.input, f.Name, f.MIMEType, f.Extension)
+		}
+	}
+	if _, err := ParseOutputFormat("aac"); err == nil {
+		t.Error("ParseOutputFormat(aac) error = nil, want error")
+	}
+}
+
+func TestOutputFormat_ExternalEncoder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not available")
+	}
+	dir := t.TempDir()
+	// 標準入力の先頭に印を付けて返すだけの opusenc
+	script := "#!/bin/sh\nPATH=/usr/bin:/bin\nprintf OggS\ncat\n"
+	if err := os.WriteFile(filepath.Join(dir, "opusenc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	opus, _ := ParseOutputFormat(FormatOpus)
+	if opus.Encoder() != "opusenc" || opus.Available() != nil {
+		t.Fatalf("Encoder() = %q, Available() = %v", opus.Encoder(), opus.Available())
+	}
+	got, err := opus.Encode([]byte("RIFF"))
+	if err != nil || string(got) != "OggSRIFF" {
+		t.Errorf("Encode() = %q, %v", got, err)
+	}
+
+	mp3, _ := ParseOutputFormat(FormatMP3)
+	if err := mp3.Available(); err == nil {
+		t.Error("mp3 Available() = nil without ffmpeg or lame")
+	}
+	if _, err := mp3.Encode([]byte("RIFF")); err == nil {
+		t.Error("mp3 Encode() error = nil without ffmpeg or lame")
+	}
+}
+
+func TestOutputFormat_EncodeContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not available")
+	}
+	dir := t.TempDir()
+	// 何も出力せずに止まったままの opusenc
+	script := "#!/bin/sh\nexec /bin/sleep 30\n"
+	if err := os.WriteFile(filepath.Join(dir, "opusenc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	opus, _ := ParseOutputFormat(FormatOpus)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := opus.EncodeContext(ctx, []byte("RIFF")); err == nil {
+		t.Error("EncodeContext() error = nil, want error after the context is done")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("EncodeContext() returned after %v, want it to stop the encoder", elapsed)
+	}
+}
+
+// mulawToLinear はμ-lawの1バイトを16bitのサンプルに戻します
+func mulawToLinear(u byte) int16 {
+	u = ^u
+	exponent := int(u>>4) & 0x07
+	s := ((int(u&0x0F) << 3) + 0x84) << exponent
+	s -= 0x84
+	if u&0x80 != 0 {
+		return int16(-s)
+	}
+	return int16(s)
+}
+
+type flacInfo struct {
+	sampleRate, channels, bps, frames int
+	md5                               [16]byte
+}
+
+// decodeFLAC はテスト用の最小限のFLACデコーダーです。
+// EncodeFLAC が出力する形式（固定予測・STREAMINFO のサンプリングレート）だけに対応し、CRCも確認します
+func decodeFLAC(data []byte) (flacInfo, []byte, error) {
+	var info flacInfo
+	if len(data) < 42 || string(data[:4]) != "fLaC" {
+		return info, nil, fmt.Errorf("missing fLaC marker")
+	}
+	r := &bitReader{data: data, pos: 32}
+	if last, typ, length := r.read(1), r.read(7), r.read(24); last != 1 || typ != 0 || length != 34 {
+		return info, nil, fmt.Errorf("unexpected metadata block %d/%d/%d", last, typ, length)
+	}
+	r.read(16)
+	r.read(16)
+	r.read(24)
+	r.read(24)
+	info.sampleRate = int(r.read(20))
+	info.channels = int(r.read(3)) + 1
+	info.bps = int(r.read(5)) + 1
+	info.frames = int(r.read(36))
+	for i := range info.md5 {
+		info.md5[i] = byte(r.read(8))
+	}
+
+	var pcm []byte
+	for decoded := 0; decoded < info.frames; {
+		start := r.pos / 8
+		if sync := r.read(14); sync != 0x3FFE {
+			return info, nil, fmt.Errorf("frame sync not found at byte %d", start)
+		}
+		r.read(2)
+		sizeCode := r.read(4)
+		r.read(4)
+		r.read(4)
+		r.read(3)
+		r.read(1)
+		first := r.read(8)
+		for n := 0; first&(0x80>>uint(n)) != 0 && n < 7; n++ {
+			if n > 0 {
+				r.read(8)
+			}
+		}
+		size := flacBlockSize
+		if sizeCode == 0x7 {
+			size = int(r.read(16)) + 1
+		}
+		if crc := byte(r.read(8)); crc != crc8(data[start:r.pos/8-1]) {
+			return info, nil, fmt.Errorf("frame header CRC mismatch")
+		}
+
+		channels := make([][]int64, info.channels)
+		for c := range channels {
+			samples, err := r.subframe(size, info.bps)
+			if err != nil {
+				return info, nil, err
+			}
+			channels[c] = samples
+		}
+		if r.pos%8 != 0 {
+			r.pos += 8 - r.pos%8
+		}
+		if crc := uint16(r.read(16)); crc != crc16(data[start:r.pos/8-2]) {
+			return info, nil, fmt.Errorf("frame CRC mismatch")
+		}
+
+		for i := 0; i < size; i++ {
+			for c := range channels {
+				pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(channels[c][i])))
+			}
+		}
+		decoded += size
+	}
+	return info, pcm, nil
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) uint64 {
+	var v uint64
+	for i := 0; i < n; i++ {
+		bit := r.data[r.pos/8] >> (7 - uint(r.pos%8)) & 1
+		v = v<<1 | uint64(bit)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) readSigned(n int) int64 {
+	v := r.read(n)
+	return int64(v<<(64-uint(n))) >> (64 - uint(n))
+}
+
+func (r *bitReader) subframe(size, bps int) ([]int64, error) {
+	header := r.read(8)
+	typ := header >> 1 & 0x3F
+	samples := make([]int64, size)
+	switch {
+	case typ == 0:
+		v := r.readSigned(bps)
+		for i := range samples {
+			samples[i] = v
+		}
+	case typ == 1:
+		for i := range samples {
+			samples[i] = r.readSigned(bps)
+		}
+	case typ >= 8 && typ <= 12:
+		order := int(typ - 8)
+		for i := 0; i < order; i++ {
+			samples[i] = r.readSigned(bps)
+		}
+		if method := r.read(2); method != 0 {
+			return nil, fmt.Errorf("unexpected residual coding method %d", method)
+		}
+		partitionOrder := int(r.read(4))
+		i := order
+		for p := 0; p < 1<<partitionOrder; p++ {
+			param := uint(r.read(4))
+			n := size >> partitionOrder
+			if p == 0 {
+				n -= order
+			}
+			for j := 0; j < n; j++ {
+				var q uint64
+				for r.read(1) == 0 {
+					q++
+				}
+				u := q<<param | r.read(int(param))
+				residual := int64(u>>1) ^ -int64(u&1)
+				samples[i] = residual + fixedPrediction(samples, i, order)
+				i++
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected subframe type %#x", typ)
+	}
+	return samples, nil
+}
+
+func fixedPrediction(s []int64, i, order int) int64 {
+	switch order {
+	case 1:
+		return s[i-1]
+	case 2:
+		return 2*s[i-1] - s[i-2]
+	case 3:
+		return 3*s[i-1] - 3*s[i-2] + s[i-3]
+	case 4:
+		return 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+	}
+	return 0
+}
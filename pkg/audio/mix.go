@@ -0,0 +1,295 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxMixTracks は1回のミックスで重ねられるトラックの最大数です
+	maxMixTracks = 8
+	// maxMixOffset はトラックの開始位置の上限です
+	maxMixOffset = 10 * time.Minute
+	// maxMixPadding は音声の前後に入れる時間の上限です
+	maxMixPadding = time.Minute
+	// duckThreshold は音声が鳴っているとみなす音量（dBFS）です
+	duckThreshold = -45.0
+	// duckWindow は音声が鳴っているかを判定する区間の長さです
+	duckWindow = 10 * time.Millisecond
+	// duckAttack と duckRelease は、話し始める前にBGMを下げ始める時間と、話し終わってから元の音量に戻す時間です
+	duckAttack  = 150 * time.Millisecond
+	duckRelease = 400 * time.Millisecond
+	// loopFadeOut はループするトラックを終わらせるときのフェードアウトの長さです
+	loopFadeOut = 500 * time.Millisecond
+)
+
+// MixTrack は音声に重ねるBGMや効果音のトラックです
+type MixTrack struct {
+	// File は素材ディレクトリ内のWAVファイルの名前です
+	File string `json:"file"`
+	// Gain はトラックの音量の調整（dB）です
+	Gain float64 `json:"gain"`
+	// Duck は音声が鳴っている間にトラックの音量を下げる量（dB、0以下）です。0 の場合は下げません
+	Duck float64 `json:"duck"`
+	// Offset はトラックを始める位置です
+	Offset time.Duration `json:"offset"`
+	// Loop が true の場合、トラックをミックスの終わりまで繰り返します
+	Loop bool `json:"loop"`
+}
+
+// MixOptions は音声とトラックを重ねる方法です
+type MixOptions struct {
+	// Tracks は重ねるトラックの一覧です
+	Tracks []MixTrack `json:"tracks"`
+	// VoiceOffset は音声を始める位置です。ジングルの後に話し始める場合などに使います
+	VoiceOffset time.Duration `json:"voice_offset"`
+	// Tail は音声の後にトラックを続ける長さです。ループするトラックはこの後にフェードアウトします
+	Tail time.Duration `json:"tail"`
+}
+
+// Validate はミックスの指定が正しいかを確認します
+func (o MixOptions) Validate() error {
+	if len(o.Tracks) == 0 {
+		return fmt.Errorf("mix requires at least one track")
+	}
+	if len(o.Tracks) > maxMixTracks {
+		return fmt.Errorf("mix supports at most %d tracks, got %d", maxMixTracks, len(o.Tracks))
+	}
+	if o.VoiceOffset < 0 || o.VoiceOffset > maxMixPadding {
+		return fmt.Errorf("mix voice_offset must be between 0 and %s", maxMixPadding)
+	}
+	if o.Tail < 0 || o.Tail > maxMixPadding {
+		return fmt.Errorf("mix tail must be between 0 and %s", maxMixPadding)
+	}
+	for i, t := range o.Tracks {
+		if t.File == "" {
+			return fmt.Errorf("mix track %d requires a file", i)
+		}
+		if t.Gain < -60 || t.Gain > 12 {
+			return fmt.Errorf("mix track %d gain must be between -60 and 12 dB, got %v", i, t.Gain)
+		}
+		if t.Duck < -60 || t.Duck > 0 {
+			return fmt.Errorf("mix track %d duck must be between -60 and 0 dB, got %v", i, t.Duck)
+		}
+		if t.Offset < 0 || t.Offset > maxMixOffset {
+			return fmt.Errorf("mix track %d offset must be between 0 and %s", i, maxMixOffset)
+		}
+	}
+	return nil
+}
+
+// AssetDir はBGMや効果音のWAVファイルを置くディレクトリです
+type AssetDir string
+
+// Path はディレクトリ内のファイルのパスを返します。ディレクトリの外を指す名前はエラーにします
+func (d AssetDir) Path(name string) (string, error) {
+	if d == "" {
+		return "", fmt.Errorf("asset directory is not configured")
+	}
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid asset name %q (expected a file in the asset directory)", name)
+	}
+	return filepath.Join(string(d), clean), nil
+}
+
+// List はディレクトリ内のWAVファイルの名前をサブディレクトリを含めて返します
+func (d AssetDir) List() ([]string, error) {
+	if d == "" {
+		return nil, fmt.Errorf("asset directory is not configured")
+	}
+	var names []string
+	err := filepath.WalkDir(string(d), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".wav") {
+			return nil
+		}
+		rel, err := filepath.Rel(string(d), path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assets: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load はディレクトリ内のWAVファイルを読み込みます。見つからない場合は使える名前をエラーに含めます
+func (d AssetDir) Load(name string) (Samples, error) {
+	path, err := d.Path(name)
+	if err != nil {
+		return Samples{}, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		names, _ := d.List()
+		return Samples{}, fmt.Errorf("unknown asset %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	if err != nil {
+		return Samples{}, fmt.Errorf("failed to read asset %s: %w", name, err)
+	}
+	samples, err := DecodeSamples(data)
+	if err != nil {
+		return Samples{}, fmt.Errorf("asset %s: %w", name, err)
+	}
+	return samples, nil
+}
+
+// Mix は音声のWAVに素材ディレクトリのトラックを重ね、16bitのWAVにします。
+// サンプリングレートは音声に合わせ、トラックのどれかがステレオの場合はステレオにします
+func Mix(voice []byte, assets AssetDir, opts MixOptions) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	speech, err := DecodeSamples(voice)
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]Samples, len(opts.Tracks))
+	for i, t := range opts.Tracks {
+		if tracks[i], err = assets.Load(t.File); err != nil {
+			return nil, err
+		}
+	}
+	return MixSamples(speech, tracks, opts).EncodeWAV(), nil
+}
+
+// MixSamples は読み込み済みの音声とトラックを重ねます。tracks は opts.Tracks と同じ順に並べます
+func MixSamples(speech Samples, tracks []Samples, opts MixOptions) Samples {
+	rate := speech.SampleRate
+	channels := speech.Channels
+	resampled := make([]Samples, len(tracks))
+	for i, t := range tracks {
+		resampled[i] = t.Resample(rate)
+		channels = max(channels, t.Channels)
+	}
+
+	frames := func(d time.Duration) int { return int(d.Seconds() * float64(rate)) }
+	voiceStart := frames(opts.VoiceOffset)
+	total := voiceStart + speech.Frames() + frames(opts.Tail)
+	for i, t := range opts.Tracks {
+		if !t.Loop {
+			total = max(total, frames(t.Offset)+resampled[i].Frames())
+		}
+	}
+
+	out := Samples{SampleRate: rate, Channels: channels, Data: make([]float64, total*channels)}
+	addAt(out, speech, voiceStart, 1)
+
+	var ducking []float64
+	for i, t := range opts.Tracks {
+		gain := dBToAmplitude(t.Gain)
+		start := frames(t.Offset)
+		track := resampled[i]
+		if track.Frames() == 0 || start >= total {
+			continue
+		}
+		if t.Loop {
+			track = loopTo(track, total-start)
+		}
+
+		envelope := make([]float64, track.Frames())
+		for f := range envelope {
+			envelope[f] = gain
+		}
+		if t.Duck < 0 {
+			if ducking == nil {
+				ducking = speechActivity(speech, voiceStart, total)
+			}
+			duck := dBToAmplitude(t.Duck)
+			for f := range envelope {
+				if pos := start + f; pos < total {
+					envelope[f] *= 1 - (1-duck)*ducking[pos]
+				}
+			}
+		}
+		addEnvelope(out, track, start, envelope)
+	}
+	return out
+}
+
+// addAt は src を dst の start フレーム目から gain 倍で足します
+func addAt(dst, src Samples, start int, gain float64) {
+	envelope := make([]float64, src.Frames())
+	for i := range envelope {
+		envelope[i] = gain
+	}
+	addEnvelope(dst, src, start, envelope)
+}
+
+// addEnvelope は src をフレームごとの倍率 envelope で dst に足します。チャンネル数が違う場合はモノラルを全チャンネルに広げ、
+// ステレオをモノラルにする場合は平均します
+func addEnvelope(dst, src Samples, start int, envelope []float64) {
+	for f := 0; f < src.Frames(); f++ {
+		pos := start + f
+		if pos >= dst.Frames() {
+			return
+		}
+		for c := 0; c < dst.Channels; c++ {
+			var v float64
+			switch {
+			case src.Channels == dst.Channels:
+				v = src.Data[f*src.Channels+c]
+			case src.Channels == 1:
+				v = src.Data[f]
+			default:
+				for sc := 0; sc < src.Channels; sc++ {
+					v += src.Data[f*src.Channels+sc]
+				}
+				v /= float64(src.Channels)
+			}
+			dst.Data[pos*dst.Channels+c] += v * envelope[f]
+		}
+	}
+}
+
+// loopTo はトラックを frames フレームになるまで繰り返し、最後をフェードアウトします
+func loopTo(track Samples, frames int) Samples {
+	out := Samples{SampleRate: track.SampleRate, Channels: track.Channels, Data: make([]float64, frames*track.Channels)}
+	for i := range out.Data {
+		out.Data[i] = track.Data[i%len(track.Data)]
+	}
+	return out.Fade(0, loopFadeOut)
+}
+
+// speechActivity は音声が鳴っている度合い（0〜1）をミックス全体のフレームごとに返します。
+// 話し始めの150ミリ秒前から上げ始め、話し終わってから400ミリ秒かけて0に戻します
+func speechActivity(speech Samples, start, total int) []float64 {
+	activity := make([]float64, total)
+	window := max(1, int(duckWindow.Seconds()*float64(speech.SampleRate)))
+	threshold := dBToAmplitude(duckThreshold)
+	for w := 0; w < speech.Frames(); w += window {
+		end := min(w+window, speech.Frames())
+		var sum float64
+		for _, v := range speech.Data[w*speech.Channels : end*speech.Channels] {
+			sum += v * v
+		}
+		if math.Sqrt(sum/float64((end-w)*speech.Channels)) <= threshold {
+			continue
+		}
+		for f := start + w; f < start+end && f < total; f++ {
+			activity[f] = 1
+		}
+	}
+
+	attack := 1 / math.Max(1, duckAttack.Seconds()*float64(speech.SampleRate))
+	for i := total - 2; i >= 0; i-- {
+		activity[i] = math.Max(activity[i], activity[i+1]-attack)
+	}
+	release := 1 / math.Max(1, duckRelease.Seconds()*float64(speech.SampleRate))
+	for i := 1; i < total; i++ {
+		activity[i] = math.Max(activity[i], activity[i-1]-release)
+	}
+	return activity
+}
@@ -0,0 +1,149 @@
+package audio
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// constant は全てのサンプルが value の音声を返します
+func constant(rate, channels int, value float64, d time.Duration) Samples {
+	frames := int(d.Seconds() * float64(rate))
+	s := Samples{SampleRate: rate, Channels: channels, Data: make([]float64, frames*channels)}
+	for i := range s.Data {
+		s.Data[i] = value
+	}
+	return s
+}
+
+func TestMixSamples(t *testing.T) {
+	speech := constant(1000, 1, 0.5, time.Second)
+	bed := constant(1000, 2, 0.1, 500*time.Millisecond)
+
+	mixed := MixSamples(speech, []Samples{bed}, MixOptions{
+		Tracks:      []MixTrack{{File: "bed.wav", Gain: -6.0206, Offset: 200 * time.Millisecond}},
+		VoiceOffset: 100 * time.Millisecond,
+		Tail:        300 * time.Millisecond,
+	})
+	if mixed.Channels != 2 || mixed.SampleRate != 1000 || mixed.Frames() != 1400 {
+		t.Fatalf("MixSamples() = %d Hz, %d ch, %d frames", mixed.SampleRate, mixed.Channels, mixed.Frames())
+	}
+	at := func(ms int) float64 { return mixed.Data[ms*2] }
+	// 50ms: 無音、150ms: 音声だけ、300ms: 音声とBGM（-6dB）、1200ms: 何もない
+	for _, tt := range []struct {
+		ms   int
+		want float64
+	}{{50, 0}, {150, 0.5}, {300, 0.55}, {1200, 0}} {
+		if got := at(tt.ms); math.Abs(got-tt.want) > 1e-3 {
+			t.Errorf("sample at %dms = %v, want %v", tt.ms, got, tt.want)
+		}
+	}
+}
+
+func TestMixSamples_LoopAndDuck(t *testing.T) {
+	speech := Samples{SampleRate: 1000, Channels: 1, Data: make([]float64, 3000)}
+	// 1秒目から2秒目まで話している
+	for i := 1000; i < 2000; i++ {
+		speech.Data[i] = 0.5
+	}
+	bed := constant(1000, 1, 0.2, 250*time.Millisecond)
+
+	mixed := MixSamples(speech, []Samples{bed}, MixOptions{
+		Tracks: []MixTrack{{File: "loop.wav", Duck: -20, Loop: true}},
+		Tail:   time.Second,
+	})
+	if mixed.Frames() != 4000 {
+		t.Fatalf("MixSamples() = %d frames, want 4000", mixed.Frames())
+	}
+	// 話していない間はそのまま、話している間は -20dB（0.1倍）
+	if got := mixed.Data[500]; math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("bed before speech = %v, want 0.2", got)
+	}
+	if got := mixed.Data[1500]; math.Abs(got-(0.5+0.02)) > 1e-9 {
+		t.Errorf("ducked bed during speech = %v, want 0.52", got)
+	}
+	// 話し始める前から下げ始め、話し終わった後にゆっくり戻す
+	if got := mixed.Data[950]; got >= 0.2 || got <= 0.02 {
+		t.Errorf("bed just before speech = %v, want between 0.02 and 0.2", got)
+	}
+	if got := mixed.Data[2200]; got >= 0.2 || got <= 0.02 {
+		t.Errorf("bed just after speech = %v, want between 0.02 and 0.2", got)
+	}
+	if got := mixed.Data[3000]; math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("bed after release = %v, want 0.2", got)
+	}
+	// ループしたトラックは最後にフェードアウトする
+	if got := mixed.Data[3999]; got != 0 {
+		t.Errorf("last sample = %v, want 0", got)
+	}
+}
+
+func TestMix(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "bgm"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	jingle := constant(48000, 2, 0.25, 100*time.Millisecond).EncodeWAV()
+	if err := os.WriteFile(filepath.Join(dir, "bgm", "jingle.wav"), jingle, 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not audio"), 0644)
+
+	assets := AssetDir(dir)
+	names, err := assets.List()
+	if err != nil || !reflect.DeepEqual(names, []string{"bgm/jingle.wav"}) {
+		t.Errorf("List() = %v, %v", names, err)
+	}
+
+	voice := constant(24000, 1, 0.5, 200*time.Millisecond).EncodeWAV()
+	out, err := Mix(voice, assets, MixOptions{Tracks: []MixTrack{{File: "bgm/jingle.wav"}}})
+	if err != nil {
+		t.Fatalf("Mix() error = %v", err)
+	}
+	s, err := DecodeSamples(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SampleRate != 24000 || s.Channels != 2 || s.Frames() != 4800 {
+		t.Errorf("Mix() = %d Hz, %d ch, %d frames", s.SampleRate, s.Channels, s.Frames())
+	}
+
+	_, err = Mix(voice, assets, MixOptions{Tracks: []MixTrack{{File: "missing.wav"}}})
+	if err == nil || !strings.Contains(err.Error(), "bgm/jingle.wav") {
+		t.Errorf("Mix(missing) error = %v, want the available assets", err)
+	}
+	for _, name := range []string{"../secret.wav", "/etc/passwd", ""} {
+		if _, err := assets.Path(name); err == nil {
+			t.Errorf("Path(%q) error = nil, want error", name)
+		}
+	}
+	if _, err := AssetDir("").Load("jingle.wav"); err == nil {
+		t.Error("Load() without an asset directory error = nil, want error")
+	}
+}
+
+func TestMixOptions_Validate(t *testing.T) {
+	track := MixTrack{File: "bgm.wav"}
+	valid := MixOptions{Tracks: []MixTrack{track}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	invalid := []MixOptions{
+		{},
+		{Tracks: make([]MixTrack, maxMixTracks+1)},
+		{Tracks: []MixTrack{{File: "bgm.wav", Gain: 20}}},
+		{Tracks: []MixTrack{{File: "bgm.wav", Duck: 6}}},
+		{Tracks: []MixTrack{{File: "bgm.wav", Offset: -time.Second}}},
+		{Tracks: []MixTrack{track}, Tail: 2 * time.Minute},
+		{Tracks: []MixTrack{{}}},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%+v) error = nil, want error", o)
+		}
+	}
+}
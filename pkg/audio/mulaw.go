@@ -0,0 +1,75 @@
+package audio
+
+import "encoding/binary"
+
+// MulawSampleRate は電話向けのμ-lawのサンプリングレートです
+const MulawSampleRate = 8000
+
+// wavFormatMulaw は fmt チャンクのμ-law（G.711）のフォーマットコードです
+const wavFormatMulaw = 7
+
+// EncodeMulaw はWAVを8kHz・モノラル・8bitのμ-law（G.711）のWAVにします
+func EncodeMulaw(wav []byte) ([]byte, error) {
+	samples, err := DecodeSamples(wav)
+	if err != nil {
+		return nil, err
+	}
+	samples = samples.Mono().Resample(MulawSampleRate)
+
+	encoded := make([]byte, len(samples.Data))
+	for i, v := range samples.Data {
+		encoded[i] = linearToMulaw(toInt16(v))
+	}
+	return encodeMulawWAV(encoded), nil
+}
+
+// encodeMulawWAV はμ-lawのデータにWAVヘッダーを付けます。
+// PCM以外の形式のため、fmt チャンクは18バイトで fact チャンクを含みます
+func encodeMulawWAV(data []byte) []byte {
+	const headerSize = 58
+	out := make([]byte, headerSize+len(data)+len(data)%2)
+	copy(out[0:], "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	copy(out[8:], "WAVE")
+	copy(out[12:], "fmt ")
+	binary.LittleEndian.PutUint32(out[16:], 18)
+	binary.LittleEndian.PutUint16(out[20:], wavFormatMulaw)
+	binary.LittleEndian.PutUint16(out[22:], 1)
+	binary.LittleEndian.PutUint32(out[24:], MulawSampleRate)
+	binary.LittleEndian.PutUint32(out[28:], MulawSampleRate)
+	binary.LittleEndian.PutUint16(out[32:], 1)
+	binary.LittleEndian.PutUint16(out[34:], 8)
+	binary.LittleEndian.PutUint16(out[36:], 0)
+	copy(out[38:], "fact")
+	binary.LittleEndian.PutUint32(out[42:], 4)
+	binary.LittleEndian.PutUint32(out[46:], uint32(len(data)))
+	copy(out[50:], "data")
+	binary.LittleEndian.PutUint32(out[54:], uint32(len(data)))
+	copy(out[headerSize:], data)
+	return out
+}
+
+// linearToMulaw は16bitのサンプルをμ-lawの1バイトにします
+func linearToMulaw(sample int16) byte {
+	const (
+		bias = 0x84
+		clip = 32635
+	)
+	s := int(sample)
+	sign := 0
+	if s < 0 {
+		s = -s
+		sign = 0x80
+	}
+	if s > clip {
+		s = clip
+	}
+	s += bias
+
+	exponent := 7
+	for mask := 0x4000; s&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (s >> (exponent + 3)) & 0x0F
+	return ^byte(sign | exponent<<4 | mantissa)
+}
@@ -1,44 +1,172 @@
 package audio
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
 )
 
-type Player struct {
-	enabled bool
+// AutoPlayer はOSごとに利用可能なバックエンドを自動検出する指定です
+const AutoPlayer = "auto"
+
+// PlayOptions は再生時のオプションです
+type PlayOptions struct {
+	// Device は出力先デバイス名です。空の場合は既定のデバイスを使います
+	Device string
+	// Volume は再生音量の倍率です。0 以下は 1.0（等倍）として扱います
+	Volume float64
+}
+
+// volume は実際に使う音量の倍率を返します
+func (o PlayOptions) volume() float64 {
+	if o.Volume <= 0 {
+		return 1.0
+	}
+	return o.Volume
+}
+
+// Player は音声再生バックエンドのインターフェースです
+type Player interface {
+	// Name はバックエンド名を返します
+	Name() string
+	// Play は音声ファイルを再生し、再生が終わるまでブロックします。
+	// ctx がキャンセルされたら速やかに再生を中断してください
+	Play(ctx context.Context, path string, opts PlayOptions) error
 }
 
-func NewPlayer(enabled bool) *Player {
-	return &Player{enabled: enabled}
+// Availability はバックエンドが現在の環境で使えるかを確認できるプレイヤーが実装します
+type Availability interface {
+	Available() error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]func() Player{
+		"afplay":     newAfplay,
+		"paplay":     newPaplay,
+		"aplay":      newAplay,
+		"mpv":        newMpv,
+		"pw-play":    newPwPlay,
+		"ffplay":     newFfplay,
+		"powershell": newPowerShell,
+		"null":       func() Player { return NullPlayer{} },
+	}
+
+	// detectOrder は自動検出時にバックエンドを試す順序です
+	detectOrder = map[string][]string{
+		"darwin":  {"afplay", "mpv", "ffplay"},
+		"linux":   {"paplay", "aplay", "mpv", "pw-play", "ffplay"},
+		"windows": {"powershell", "mpv", "ffplay"},
+	}
+)
+
+// Register はバックエンドを登録します。同名のバックエンドは置き換えられます
+func Register(name string, factory func() Player) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[name] = factory
+}
+
+// Backends は登録されているバックエンド名を昇順で返します
+func Backends() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewPlayer は設定からプレイヤーを作成します。
+// command が指定された場合はコマンドテンプレート、name が空か "auto" の場合は自動検出、
+// それ以外は登録済みのバックエンドを名前で選びます
+func NewPlayer(name, command string) (Player, error) {
+	if command != "" {
+		return NewCommandPlayer(command)
+	}
+
+	if name == "" || name == AutoPlayer {
+		player, err := Detect()
+		if err != nil {
+			// 再生しない用途でも起動できるよう、エラーは再生時に返す
+			return unavailablePlayer{err: err}, nil
+		}
+		return player, nil
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown audio player: %s (available: %s)", name, strings.Join(Backends(), ", "))
+	}
+
+	player := factory()
+	if checker, ok := player.(Availability); ok {
+		if err := checker.Available(); err != nil {
+			return nil, err
+		}
+	}
+	return player, nil
 }
 
-func (p *Player) Play(filepath string) error {
-	if !p.enabled {
-		return nil
+// Detect は現在のOSで利用可能なバックエンドを検出順に探します
+func Detect() (Player, error) {
+	order, ok := detectOrder[runtime.GOOS]
+	if !ok {
+		return nil, fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
 
-	var cmd *exec.Cmd
+	registryMu.RLock()
+	defer registryMu.RUnlock()
 
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("afplay", filepath)
-	case "linux":
-		if _, err := exec.LookPath("paplay"); err == nil {
-			cmd = exec.Command("paplay", filepath)
-		} else if _, err := exec.LookPath("aplay"); err == nil {
-			cmd = exec.Command("aplay", filepath)
-		} else if _, err := exec.LookPath("mpv"); err == nil {
-			cmd = exec.Command("mpv", "--no-video", filepath)
-		} else {
-			return fmt.Errorf("no audio player found on Linux")
+	for _, name := range order {
+		factory, ok := registry[name]
+		if !ok {
+			continue
+		}
+		player := factory()
+		if checker, ok := player.(Availability); ok && checker.Available() != nil {
+			continue
 		}
-	case "windows":
-		cmd = exec.Command("powershell", "-c", fmt.Sprintf("(New-Object Media.SoundPlayer '%s').PlaySync()", filepath))
-	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+		return player, nil
 	}
+	return nil, fmt.Errorf("no audio player found on %s (tried: %s)", runtime.GOOS, strings.Join(order, ", "))
+}
+
+// NullPlayer は何も再生しないバックエンドです。テストや再生デバイスのない環境で使います
+type NullPlayer struct{}
+
+// Name はバックエンド名を返します
+func (NullPlayer) Name() string {
+	return "null"
+}
+
+// Play は何もせずに成功を返します
+func (NullPlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	return ctx.Err()
+}
+
+// unavailablePlayer は自動検出に失敗したときのプレイヤーで、再生時に検出エラーを返します
+type unavailablePlayer struct {
+	err error
+}
+
+func (p unavailablePlayer) Name() string {
+	return "unavailable"
+}
+
+func (p unavailablePlayer) Available() error {
+	return p.err
+}
 
-	return cmd.Run()
+func (p unavailablePlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	return p.err
 }
@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{command: "mpv {file}", want: []string{"mpv", "{file}"}},
+		{command: "  ffplay   -nodisp\t{file} ", want: []string{"ffplay", "-nodisp", "{file}"}},
+		{command: `powershell -c "(New-Object Media.SoundPlayer '{file}').PlaySync()"`, want: []string{"powershell", "-c", "(New-Object Media.SoundPlayer '{file}').PlaySync()"}},
+		{command: `play '' {file}`, want: []string{"play", "", "{file}"}},
+		{command: `mpv "{file}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitCommand(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestNewCommandPlayer_Expand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		opts     PlayOptions
+		want     []string
+	}{
+		{
+			name:     "all placeholders",
+			template: "pw-play --target={device} --volume {volume} {file}",
+			opts:     PlayOptions{Device: "alsa_output.usb", Volume: 0.5},
+			want:     []string{"pw-play", "--target=alsa_output.usb", "--volume", "0.5", "/tmp/a b.wav"},
+		},
+		{
+			name:     "empty device argument is dropped",
+			template: "aplay -D {device} {file}",
+			opts:     PlayOptions{},
+			want:     []string{"aplay", "-D", "/tmp/a b.wav"},
+		},
+		{
+			name:     "argument with an empty device is dropped",
+			template: "pw-play --target={device} {file}",
+			opts:     PlayOptions{},
+			want:     []string{"pw-play", "/tmp/a b.wav"},
+		},
+		{
+			name:     "default volume",
+			template: "afplay -v {volume} {file}",
+			opts:     PlayOptions{},
+			want:     []string{"afplay", "-v", "1", "/tmp/a b.wav"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player, err := NewCommandPlayer(tt.template)
+			if err != nil {
+				t.Fatalf("NewCommandPlayer() error = %v", err)
+			}
+			got := player.(*templatePlayer).expand("/tmp/a b.wav", tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCommandPlayer_Invalid(t *testing.T) {
+	for _, template := range []string{"", "   ", `mpv "{file}`} {
+		if _, err := NewCommandPlayer(template); err == nil {
+			t.Errorf("NewCommandPlayer(%q) error = nil, want error", template)
+		}
+	}
+}
+
+func TestNewPlayer(t *testing.T) {
+	player, err := NewPlayer("null", "")
+	if err != nil {
+		t.Fatalf("NewPlayer(null) error = %v", err)
+	}
+	if player.Name() != "null" {
+		t.Errorf("Name() = %q, want null", player.Name())
+	}
+	if err := player.Play(context.Background(), "unused.wav", PlayOptions{}); err != nil {
+		t.Errorf("Play() error = %v", err)
+	}
+
+	if _, err := NewPlayer("no-such-player", ""); err == nil {
+		t.Error("NewPlayer(no-such-player) error = nil, want error")
+	}
+
+	// コマンドテンプレートはバックエンド名より優先される
+	player, err = NewPlayer("null", "mpv {file}")
+	if err != nil {
+		t.Fatalf("NewPlayer with command error = %v", err)
+	}
+	if player.Name() != "command" {
+		t.Errorf("Name() = %q, want command", player.Name())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-backend", func() Player { return NullPlayer{} })
+	defer func() {
+		registryMu.Lock()
+		delete(registry, "test-backend")
+		registryMu.Unlock()
+	}()
+
+	found := false
+	for _, name := range Backends() {
+		if name == "test-backend" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Backends() = %v, want test-backend included", Backends())
+	}
+	if _, err := NewPlayer("test-backend", ""); err != nil {
+		t.Errorf("NewPlayer(test-backend) error = %v", err)
+	}
+}
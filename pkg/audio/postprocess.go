@@ -0,0 +1,495 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// trimPadding はトリミングで前後に残す無音の長さです
+	trimPadding = 20 * time.Millisecond
+	// limiterAttack と limiterRelease はリミッターが音量を下げ始めてから上限に達するまで、
+	// および元の音量に戻るまでの時間です
+	limiterAttack  = 5 * time.Millisecond
+	limiterRelease = 50 * time.Millisecond
+	// maxFade はフェードイン・フェードアウトの最長の長さです
+	maxFade = 5 * time.Second
+)
+
+// PostProcessOptions は合成した音声に適用する後処理です。
+// 無音のトリミング、再サンプリング、ラウドネスの正規化、リミッター、フェードの順に適用します
+type PostProcessOptions struct {
+	// Loudness は目標のラウドネス（LUFS、EBU R128）です。0 の場合は正規化しません
+	Loudness float64 `json:"loudness"`
+	// Limiter が true の場合、ピークが PeakLimit を超えないように音量を抑えます
+	Limiter bool `json:"limiter"`
+	// PeakLimit はリミッターの上限（dBFS）です
+	PeakLimit float64 `json:"peak_limit"`
+	// FadeIn と FadeOut は先頭と末尾のフェードの長さです。0 の場合はフェードしません
+	FadeIn  time.Duration `json:"fade_in"`
+	FadeOut time.Duration `json:"fade_out"`
+	// Trim が true の場合、TrimThreshold（dBFS）以下の先頭と末尾の無音を取り除きます
+	Trim          bool    `json:"trim"`
+	TrimThreshold float64 `json:"trim_threshold"`
+	// SampleRate は変換後のサンプリングレート（Hz）です。0 の場合は変換しません
+	SampleRate int `json:"sample_rate"`
+}
+
+// DefaultPostProcessOptions は既定の後処理を返します。既定ではどの処理も行いません
+func DefaultPostProcessOptions() PostProcessOptions {
+	return PostProcessOptions{PeakLimit: -1, TrimThreshold: -50}
+}
+
+// Active はいずれかの処理が有効かを返します
+func (o PostProcessOptions) Active() bool {
+	return o.Loudness != 0 || o.Limiter || o.FadeIn > 0 || o.FadeOut > 0 || o.Trim || o.SampleRate != 0
+}
+
+// postProcessKeys は指定できるキーの一覧です
+var postProcessKeys = []string{"loudness", "limit", "fade_in", "fade_out", "trim", "trim_threshold", "sample_rate"}
+
+// Set はキーと値を指定して処理を1つ変更します。
+// loudness（LUFS）、limit（dBFS）、fade_in / fade_out（"50ms" または ミリ秒の数値）、trim（true / false）、
+// trim_threshold（dBFS）、sample_rate（Hz）を指定でき、loudness / limit / fade_in / fade_out / sample_rate は "off" で無効にします
+func (o *PostProcessOptions) Set(key, value string) error {
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	off := strings.EqualFold(value, "off")
+
+	switch key {
+	case "loudness":
+		if off {
+			o.Loudness = 0
+			return nil
+		}
+		v, err := parseRange(key, strings.TrimSuffix(value, "LUFS"), -70, -5)
+		if err != nil {
+			return err
+		}
+		o.Loudness = v
+	case "limit":
+		if off {
+			o.Limiter = false
+			return nil
+		}
+		v, err := parseRange(key, strings.TrimSuffix(value, "dB"), -20, 0)
+		if err != nil {
+			return err
+		}
+		o.Limiter, o.PeakLimit = true, v
+	case "fade_in", "fade_out":
+		d := time.Duration(0)
+		if !off {
+			var err error
+			if d, err = parseFade(key, value); err != nil {
+				return err
+			}
+		}
+		if key == "fade_in" {
+			o.FadeIn = d
+		} else {
+			o.FadeOut = d
+		}
+	case "trim":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid postprocess value for trim: %s (expected true or false)", value)
+		}
+		o.Trim = b
+	case "trim_threshold":
+		v, err := parseRange(key, strings.TrimSuffix(value, "dB"), -90, -10)
+		if err != nil {
+			return err
+		}
+		o.TrimThreshold = v
+	case "sample_rate":
+		if off || value == "0" {
+			o.SampleRate = 0
+			return nil
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 8000 || v > 192000 {
+			return fmt.Errorf("invalid postprocess value for sample_rate: %s (expected 8000 to 192000 Hz or off)", value)
+		}
+		o.SampleRate = v
+	default:
+		return fmt.Errorf("unknown postprocess option: %s (expected %s)", key, strings.Join(postProcessKeys, ", "))
+	}
+	return nil
+}
+
+// Apply は "loudness=-16,limit=-1,trim=true" 形式の指定を適用します。"off" は全ての処理を無効にします
+func (o *PostProcessOptions) Apply(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			if strings.EqualFold(part, "off") || strings.EqualFold(part, "none") {
+				*o = PostProcessOptions{PeakLimit: o.PeakLimit, TrimThreshold: o.TrimThreshold}
+				continue
+			}
+			return fmt.Errorf("invalid postprocess rule: %s (expected key=value)", part)
+		}
+		if err := o.Set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyArgs はツール引数の postprocess を適用します。
+// false で全ての処理を無効化し、{"loudness": -16, "fade_out": 50} のようなオブジェクトで個別の処理を上書きします
+func (o *PostProcessOptions) ApplyArgs(arg interface{}) error {
+	switch v := arg.(type) {
+	case nil:
+		return nil
+	case bool:
+		if !v {
+			return o.Apply("off")
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			var s string
+			switch value := v[key].(type) {
+			case string:
+				s = value
+			case bool:
+				s = strconv.FormatBool(value)
+			case float64:
+				s = strconv.FormatFloat(value, 'f', -1, 64)
+			case nil:
+				s = "off"
+			default:
+				return fmt.Errorf("invalid postprocess value for %s: %v", key, value)
+			}
+			if err := o.Set(key, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("postprocess must be a boolean or an object")
+	}
+}
+
+// Validate は処理の値が正しいかを確認します
+func (o PostProcessOptions) Validate() error {
+	if o.Loudness != 0 && (o.Loudness < -70 || o.Loudness > -5) {
+		return fmt.Errorf("postprocess loudness must be between -70 and -5 LUFS, got %v", o.Loudness)
+	}
+	if o.Limiter && (o.PeakLimit < -20 || o.PeakLimit > 0) {
+		return fmt.Errorf("postprocess limit must be between -20 and 0 dBFS, got %v", o.PeakLimit)
+	}
+	if o.FadeIn < 0 || o.FadeIn > maxFade || o.FadeOut < 0 || o.FadeOut > maxFade {
+		return fmt.Errorf("postprocess fades must be between 0 and %s", maxFade)
+	}
+	if o.Trim && (o.TrimThreshold < -90 || o.TrimThreshold > -10) {
+		return fmt.Errorf("postprocess trim_threshold must be between -90 and -10 dBFS, got %v", o.TrimThreshold)
+	}
+	if o.SampleRate != 0 && (o.SampleRate < 8000 || o.SampleRate > 192000) {
+		return fmt.Errorf("postprocess sample_rate must be between 8000 and 192000 Hz, got %d", o.SampleRate)
+	}
+	return nil
+}
+
+func parseRange(key, value string, lo, hi float64) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || v < lo || v > hi {
+		return 0, fmt.Errorf("invalid postprocess value for %s: %s (expected %v to %v or off)", key, value, lo, hi)
+	}
+	return v, nil
+}
+
+// parseFade はフェードの長さを "50ms"、"0.5s" またはミリ秒の数値で解釈します
+func parseFade(key, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		ms, numErr := strconv.ParseFloat(value, 64)
+		if numErr != nil {
+			return 0, fmt.Errorf("invalid postprocess value for %s: %s (expected a duration such as 50ms)", key, value)
+		}
+		d = time.Duration(ms * float64(time.Millisecond))
+	}
+	if d < 0 || d > maxFade {
+		return 0, fmt.Errorf("invalid postprocess value for %s: %s (expected 0 to %s)", key, value, maxFade)
+	}
+	return d, nil
+}
+
+// PostProcess はWAVに後処理を適用し、16bitのWAVにします。有効な処理がない場合は元のWAVをそのまま返します
+func PostProcess(wav []byte, opts PostProcessOptions) ([]byte, error) {
+	if !opts.Active() {
+		return wav, nil
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	samples, err := DecodeSamples(wav)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Trim {
+		samples = samples.TrimSilence(opts.TrimThreshold)
+	}
+	if opts.SampleRate != 0 {
+		samples = samples.Resample(opts.SampleRate)
+	}
+	if opts.Loudness != 0 {
+		samples = samples.Normalize(opts.Loudness)
+	}
+	if opts.Limiter {
+		samples = samples.Limit(opts.PeakLimit)
+	}
+	samples = samples.Fade(opts.FadeIn, opts.FadeOut)
+	return samples.EncodeWAV(), nil
+}
+
+// dBToAmplitude はデシベルを振幅の倍率にします
+func dBToAmplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// TrimSilence は振幅が threshold（dBFS）以下の先頭と末尾の区間を取り除きます。
+// 話し始めと話し終わりが途切れないよう前後に20ミリ秒を残し、全体が無音の場合はそのまま返します
+func (s Samples) TrimSilence(threshold float64) Samples {
+	start, end := s.trimBounds(threshold)
+	return Samples{SampleRate: s.SampleRate, Channels: s.Channels, Data: s.Data[start*s.Channels : end*s.Channels]}
+}
+
+// TrimOffset は TrimSilence が先頭から取り除く長さです。字幕のタイミングを合わせるのに使います
+func (s Samples) TrimOffset(threshold float64) time.Duration {
+	start, _ := s.trimBounds(threshold)
+	if s.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(start) / float64(s.SampleRate) * float64(time.Second))
+}
+
+// trimBounds は TrimSilence で残す区間の最初と最後（含まない）のフレームを返します
+func (s Samples) trimBounds(threshold float64) (int, int) {
+	limit := dBToAmplitude(threshold)
+	frames := s.Frames()
+	loud := func(i int) bool {
+		for c := 0; c < s.Channels; c++ {
+			if math.Abs(s.Data[i*s.Channels+c]) > limit {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := 0
+	for first < frames && !loud(first) {
+		first++
+	}
+	if first == frames {
+		return 0, frames
+	}
+	last := frames - 1
+	for last > first && !loud(last) {
+		last--
+	}
+
+	pad := int(trimPadding.Seconds() * float64(s.SampleRate))
+	return max(first-pad, 0), min(last+1+pad, frames)
+}
+
+// Fade は先頭を fadeIn、末尾を fadeOut の長さで直線的にフェードします
+func (s Samples) Fade(fadeIn, fadeOut time.Duration) Samples {
+	if fadeIn <= 0 && fadeOut <= 0 {
+		return s
+	}
+	out := s.copy()
+	frames := s.Frames()
+	in := min(int(fadeIn.Seconds()*float64(s.SampleRate)), frames)
+	for i := 0; i < in; i++ {
+		gain := float64(i) / float64(in)
+		for c := 0; c < s.Channels; c++ {
+			out.Data[i*s.Channels+c] *= gain
+		}
+	}
+	fo := min(int(fadeOut.Seconds()*float64(s.SampleRate)), frames)
+	for i := 0; i < fo; i++ {
+		gain := float64(i) / float64(fo)
+		frame := frames - 1 - i
+		for c := 0; c < s.Channels; c++ {
+			out.Data[frame*s.Channels+c] *= gain
+		}
+	}
+	return out
+}
+
+// Limit はピークが limit（dBFS）を超えないように音量を抑えます。
+// 超える箇所の5ミリ秒前から音量を下げ始め、50ミリ秒かけて元の音量に戻します
+func (s Samples) Limit(limit float64) Samples {
+	ceiling := dBToAmplitude(limit)
+	frames := s.Frames()
+	gain := make([]float64, frames)
+	for i := range gain {
+		gain[i] = 1
+		for c := 0; c < s.Channels; c++ {
+			if v := math.Abs(s.Data[i*s.Channels+c]); v > ceiling {
+				gain[i] = math.Min(gain[i], ceiling/v)
+			}
+		}
+	}
+
+	// 後ろから見て、音量を下げる箇所の手前に下げ始めの傾きを付ける
+	attack := 1 / math.Max(1, limiterAttack.Seconds()*float64(s.SampleRate))
+	for i := frames - 2; i >= 0; i-- {
+		gain[i] = math.Min(gain[i], gain[i+1]+attack)
+	}
+	// 前から見て、下げた音量をゆっくり戻す
+	release := 1 / math.Max(1, limiterRelease.Seconds()*float64(s.SampleRate))
+	for i := 1; i < frames; i++ {
+		gain[i] = math.Min(gain[i], gain[i-1]+release)
+	}
+
+	out := s.copy()
+	for i, g := range gain {
+		for c := 0; c < s.Channels; c++ {
+			out.Data[i*s.Channels+c] *= g
+		}
+	}
+	return out
+}
+
+// Normalize は統合ラウドネスが target（LUFS）になるように音量を変えます。無音の場合はそのまま返します
+func (s Samples) Normalize(target float64) Samples {
+	loudness := s.Loudness()
+	if math.IsInf(loudness, -1) {
+		return s
+	}
+	gain := dBToAmplitude(target - loudness)
+	out := s.copy()
+	for i := range out.Data {
+		out.Data[i] *= gain
+	}
+	return out
+}
+
+// Loudness はITU-R BS.1770（EBU R128）の統合ラウドネス（LUFS）を求めます。
+// 400ミリ秒のブロックを100ミリ秒ずつずらして測り、-70 LUFS の絶対ゲートと -10 LU の相対ゲートを適用します。
+// 無音の場合は -Inf を返します
+func (s Samples) Loudness() float64 {
+	frames := s.Frames()
+	if frames == 0 || s.SampleRate == 0 {
+		return math.Inf(-1)
+	}
+
+	// K特性のフィルターを通した二乗値
+	squared := make([]float64, frames)
+	for c := 0; c < s.Channels; c++ {
+		shelf, highpass := kWeighting(float64(s.SampleRate))
+		for i := 0; i < frames; i++ {
+			v := highpass.process(shelf.process(s.Data[i*s.Channels+c]))
+			squared[i] += v * v
+		}
+	}
+
+	block := int(0.4 * float64(s.SampleRate))
+	step := block / 4
+	var powers []float64
+	if frames < block {
+		powers = append(powers, mean(squared))
+	} else {
+		for start := 0; start+block <= frames; start += step {
+			powers = append(powers, mean(squared[start:start+block]))
+		}
+	}
+
+	gated := func(threshold float64) []float64 {
+		var kept []float64
+		for _, p := range powers {
+			if blockLoudness(p) > threshold {
+				kept = append(kept, p)
+			}
+		}
+		return kept
+	}
+	absolute := gated(-70)
+	if len(absolute) == 0 {
+		return math.Inf(-1)
+	}
+	relative := gated(blockLoudness(mean(absolute)) - 10)
+	if len(relative) == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(mean(relative))
+}
+
+// blockLoudness はブロックの平均二乗値をラウドネス（LUFS）にします
+func blockLoudness(power float64) float64 {
+	return -0.691 + 10*math.Log10(power)
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func (s Samples) copy() Samples {
+	data := make([]float64, len(s.Data))
+	copy(data, s.Data)
+	return Samples{SampleRate: s.SampleRate, Channels: s.Channels, Data: data}
+}
+
+// biquad は2次のIIRフィルターです
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting はBS.1770のK特性（高域のシェルビングと低域のハイパス）のフィルターを、
+// 任意のサンプリングレート向けに求めます
+func kWeighting(rate float64) (*biquad, *biquad) {
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / rate)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := &biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / rate)
+	a0 = 1 + k/q + k*k
+	highpass := &biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highpass
+}
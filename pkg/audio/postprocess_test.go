@@ -0,0 +1,185 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// sine は振幅 amplitude の正弦波を返します
+func sine(rate, channels int, freq, amplitude float64, d time.Duration) Samples {
+	frames := int(d.Seconds() * float64(rate))
+	s := Samples{SampleRate: rate, Channels: channels, Data: make([]float64, frames*channels)}
+	for i := 0; i < frames; i++ {
+		v := amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+		for c := 0; c < channels; c++ {
+			s.Data[i*channels+c] = v
+		}
+	}
+	return s
+}
+
+func peakOf(s Samples) float64 {
+	var p float64
+	for _, v := range s.Data {
+		p = math.Max(p, math.Abs(v))
+	}
+	return p
+}
+
+func TestSamples_Loudness(t *testing.T) {
+	// BS.1770 では 997Hz・0dBFS の正弦波（1チャンネル）は -3.01 LUFS
+	tests := []struct {
+		rate      int
+		channels  int
+		amplitude float64
+		want      float64
+	}{
+		{48000, 1, 1, -3.01},
+		{24000, 1, 0.5, -9.03},
+		{44100, 2, 0.1, -19.99},
+	}
+	for _, tt := range tests {
+		got := sine(tt.rate, tt.channels, 997, tt.amplitude, 3*time.Second).Loudness()
+		if math.Abs(got-tt.want) > 0.1 {
+			t.Errorf("Loudness(%d Hz, %d ch, %v) = %.2f, want %.2f", tt.rate, tt.channels, tt.amplitude, got, tt.want)
+		}
+	}
+	silent := Samples{SampleRate: 24000, Channels: 1, Data: make([]float64, 24000)}
+	if got := silent.Loudness(); !math.IsInf(got, -1) {
+		t.Errorf("Loudness(silence) = %v, want -Inf", got)
+	}
+}
+
+func TestSamples_Normalize(t *testing.T) {
+	for _, amplitude := range []float64{0.02, 0.3} {
+		got := sine(24000, 1, 440, amplitude, 2*time.Second).Normalize(-23).Loudness()
+		if math.Abs(got+23) > 0.1 {
+			t.Errorf("Normalize(-23) of amplitude %v measured %.2f LUFS", amplitude, got)
+		}
+	}
+}
+
+func TestSamples_Limit(t *testing.T) {
+	s := sine(24000, 2, 440, 0.2, time.Second)
+	// 途中の一部だけ大きくする
+	for i := 12000 * 2; i < 12240*2; i++ {
+		s.Data[i] *= 5
+	}
+	limited := s.Limit(-6)
+	if p := peakOf(limited); p > dBToAmplitude(-6)+1e-9 {
+		t.Errorf("peak after Limit(-6) = %v, want <= %v", p, dBToAmplitude(-6))
+	}
+	// 大きな箇所から離れた部分はそのまま
+	if limited.Data[100] != s.Data[100] {
+		t.Errorf("Limit() changed quiet sample: %v -> %v", s.Data[100], limited.Data[100])
+	}
+}
+
+func TestSamples_TrimSilence(t *testing.T) {
+	tone := sine(24000, 1, 440, 0.5, 500*time.Millisecond)
+	data := append(make([]float64, 12000), tone.Data...)
+	data = append(data, make([]float64, 24000)...)
+	s := Samples{SampleRate: 24000, Channels: 1, Data: data}
+
+	trimmed := s.TrimSilence(-50)
+	pad := int(trimPadding.Seconds() * 24000)
+	// 正弦波の先頭のサンプルは0のため、最初に閾値を超えるのは1サンプル後
+	if want := len(tone.Data) + 2*pad; math.Abs(float64(len(trimmed.Data)-want)) > 2 {
+		t.Errorf("TrimSilence() = %d samples, want about %d", len(trimmed.Data), want)
+	}
+	if got, want := s.TrimOffset(-50), 500*time.Millisecond-trimPadding; (got - want).Abs() > time.Millisecond {
+		t.Errorf("TrimOffset() = %v, want about %v", got, want)
+	}
+	silent := Samples{SampleRate: 24000, Channels: 1, Data: make([]float64, 100)}
+	if got := silent.TrimSilence(-50); len(got.Data) != 100 {
+		t.Errorf("TrimSilence(silence) = %d samples, want 100", len(got.Data))
+	}
+}
+
+func TestSamples_Fade(t *testing.T) {
+	s := Samples{SampleRate: 1000, Channels: 1, Data: make([]float64, 1000)}
+	for i := range s.Data {
+		s.Data[i] = 1
+	}
+	faded := s.Fade(100*time.Millisecond, 200*time.Millisecond)
+	if faded.Data[0] != 0 || faded.Data[50] != 0.5 || faded.Data[100] != 1 {
+		t.Errorf("fade in = %v, %v, %v", faded.Data[0], faded.Data[50], faded.Data[100])
+	}
+	if faded.Data[999] != 0 || faded.Data[899] != 0.5 || faded.Data[799] != 1 {
+		t.Errorf("fade out = %v, %v, %v", faded.Data[999], faded.Data[899], faded.Data[799])
+	}
+	if s.Data[0] != 1 {
+		t.Error("Fade() modified the input samples")
+	}
+}
+
+func TestPostProcess(t *testing.T) {
+	wav := sine(24000, 1, 440, 0.05, time.Second).EncodeWAV()
+	if got, err := PostProcess(wav, DefaultPostProcessOptions()); err != nil || &got[0] != &wav[0] {
+		t.Errorf("PostProcess(defaults) = new data, %v; want the input unchanged", err)
+	}
+
+	opts := DefaultPostProcessOptions()
+	if err := opts.Apply("loudness=-16,limit=-1,fade_in=10ms,fade_out=50,sample_rate=48000"); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	out, err := PostProcess(wav, opts)
+	if err != nil {
+		t.Fatalf("PostProcess() error = %v", err)
+	}
+	s, err := DecodeSamples(out)
+	if err != nil {
+		t.Fatalf("DecodeSamples() error = %v", err)
+	}
+	if s.SampleRate != 48000 || s.Frames() != 48000 {
+		t.Errorf("PostProcess() = %d Hz, %d frames", s.SampleRate, s.Frames())
+	}
+	if got := s.Loudness(); math.Abs(got+16) > 0.3 {
+		t.Errorf("loudness after PostProcess() = %.2f, want -16", got)
+	}
+	if p := peakOf(s); p > dBToAmplitude(-1)+1.0/32768 {
+		t.Errorf("peak after PostProcess() = %v", p)
+	}
+	if s.Data[0] != 0 || s.Data[len(s.Data)-1] != 0 {
+		t.Errorf("fades not applied: first %v, last %v", s.Data[0], s.Data[len(s.Data)-1])
+	}
+}
+
+func TestPostProcessOptions_Apply(t *testing.T) {
+	opts := DefaultPostProcessOptions()
+	if err := opts.Apply("loudness=-16LUFS, limit=-2dB, fade_out=0.1s, trim=true, trim_threshold=-40"); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := PostProcessOptions{Loudness: -16, Limiter: true, PeakLimit: -2, FadeOut: 100 * time.Millisecond, Trim: true, TrimThreshold: -40}
+	if opts != want {
+		t.Errorf("Apply() = %+v, want %+v", opts, want)
+	}
+	if err := opts.Apply("off"); err != nil || opts.Active() {
+		t.Errorf("Apply(off) = %+v, %v", opts, err)
+	}
+
+	for _, spec := range []string{"loudness=0", "limit=3", "fade_in=10s", "trim=maybe", "sample_rate=4000", "gain=3", "loudness"} {
+		opts := DefaultPostProcessOptions()
+		if err := opts.Apply(spec); err == nil {
+			t.Errorf("Apply(%q) error = nil, want error", spec)
+		}
+	}
+}
+
+func TestPostProcessOptions_ApplyArgs(t *testing.T) {
+	opts := DefaultPostProcessOptions()
+	opts.Loudness = -16
+	if err := opts.ApplyArgs(map[string]interface{}{"loudness": nil, "fade_in": float64(20), "trim": true}); err != nil {
+		t.Fatalf("ApplyArgs() error = %v", err)
+	}
+	if opts.Loudness != 0 || opts.FadeIn != 20*time.Millisecond || !opts.Trim {
+		t.Errorf("ApplyArgs() = %+v", opts)
+	}
+	if err := opts.ApplyArgs(false); err != nil || opts.Active() {
+		t.Errorf("ApplyArgs(false) = %+v, %v", opts, err)
+	}
+	if err := opts.ApplyArgs("loud"); err == nil {
+		t.Error("ApplyArgs(string) error = nil, want error")
+	}
+}
@@ -0,0 +1,367 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority は再生キューの優先度です。値が大きいほど先に再生されます
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+// String は優先度の名前を返します
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// ParsePriority は優先度の名前（low / normal / high / urgent）を解析します。空文字列は normal です
+func ParsePriority(name string) (Priority, error) {
+	if name == "" {
+		return PriorityNormal, nil
+	}
+	for p, n := range priorityNames {
+		if strings.EqualFold(n, name) {
+			return p, nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("unknown priority: %s (expected low, normal, high or urgent)", name)
+}
+
+var (
+	// ErrSkipped はskip/stop/割り込みによって再生が中断されたことを表します
+	ErrSkipped = errors.New("playback skipped")
+	// ErrCleared は再生前にキューから取り除かれたことを表します
+	ErrCleared = errors.New("removed from playback queue")
+)
+
+// EnqueueOptions はキューへの追加オプションです
+type EnqueueOptions struct {
+	// Text は状態表示用のテキストです
+	Text     string
+	Priority Priority
+	// Interrupt が true の場合、再生中の音声を中断してこの音声を先に再生します
+	Interrupt bool
+	// Play は再生バックエンドに渡すオプション（デバイス・音量）です
+	Play PlayOptions
+}
+
+// Ticket はキューに追加された音声の受付情報です
+type Ticket struct {
+	ID int64
+	// Position は追加時点での待ち順（0 は次に再生）です
+	Position int
+
+	done chan struct{}
+	err  error
+}
+
+// Wait は再生が終わるまで待ち、再生結果を返します
+func (t *Ticket) Wait() error {
+	<-t.done
+	return t.err
+}
+
+// Done は再生が終わったときに閉じられるチャネルを返します
+func (t *Ticket) Done() <-chan struct{} {
+	return t.done
+}
+
+// queueItem はキュー内の1件の音声です
+type queueItem struct {
+	ticket *Ticket
+	path   string
+	// data は EnqueueAudio で追加されたWAVデータです（path とどちらか一方）
+	data       []byte
+	opts       EnqueueOptions
+	enqueuedAt time.Time
+	startedAt  time.Time
+	skipped    bool
+}
+
+// ItemState はキュー内の音声の状態です
+type ItemState struct {
+	ID         int64      `json:"id"`
+	Text       string     `json:"text,omitempty"`
+	Priority   string     `json:"priority"`
+	EnqueuedAt time.Time  `json:"enqueued_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+}
+
+// QueueState は再生キュー全体の状態です
+type QueueState struct {
+	Current *ItemState  `json:"current,omitempty"`
+	Pending []ItemState `json:"pending"`
+	Played  int         `json:"played"`
+	Skipped int         `json:"skipped"`
+	Failed  int         `json:"failed"`
+}
+
+// Queue は音声を1件ずつ順番に再生する再生キューです。
+// 優先度の高いものから、同じ優先度では追加順に再生します
+type Queue struct {
+	player Player
+	// OnError は再生に失敗したときに呼ばれます（省略可）
+	OnError func(text string, err error)
+	// TempDir はストリーミング再生に対応しないバックエンドで EnqueueAudio の音声を
+	// 一時ファイルに書き出すディレクトリです。空の場合はOSの一時ディレクトリを使います
+	TempDir string
+	// Defaults は EnqueueOptions.Play で指定されなかったデバイス・音量の既定値です
+	Defaults PlayOptions
+
+	mu            sync.Mutex
+	cond          *sync.Cond
+	pending       []*queueItem
+	current       *queueItem
+	cancelCurrent context.CancelFunc
+	nextID        int64
+	closed        bool
+	played        int
+	skipped       int
+	failed        int
+	done          chan struct{}
+}
+
+// NewQueue は新しい再生キューを作成し、再生用のゴルーチンを開始します
+func NewQueue(player Player) *Queue {
+	q := &Queue{
+		player: player,
+		done:   make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+// Enqueue は音声ファイルをキューに追加し、再生を待たずに受付情報を返します
+func (q *Queue) Enqueue(path string, opts EnqueueOptions) *Ticket {
+	return q.enqueue(&queueItem{path: path, opts: opts})
+}
+
+// EnqueueAudio はWAVデータをキューに追加し、再生を待たずに受付情報を返します。
+// バックエンドが対応していれば、ファイルを経由せずに標準入力で再生します
+func (q *Queue) EnqueueAudio(data []byte, opts EnqueueOptions) *Ticket {
+	return q.enqueue(&queueItem{data: data, opts: opts})
+}
+
+func (q *Queue) enqueue(item *queueItem) *Ticket {
+	opts := item.opts
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.nextID++
+	ticket := &Ticket{ID: q.nextID, done: make(chan struct{})}
+	if q.closed {
+		ticket.err = ErrCleared
+		close(ticket.done)
+		return ticket
+	}
+
+	item.ticket = ticket
+	item.enqueuedAt = time.Now()
+
+	// 割り込みは同じ優先度の中でも先頭に入れる
+	position := sort.Search(len(q.pending), func(i int) bool {
+		if opts.Interrupt {
+			return q.pending[i].opts.Priority <= opts.Priority
+		}
+		return q.pending[i].opts.Priority < opts.Priority
+	})
+	q.pending = append(q.pending, nil)
+	copy(q.pending[position+1:], q.pending[position:])
+	q.pending[position] = item
+	ticket.Position = position
+
+	if opts.Interrupt {
+		q.skipCurrentLocked()
+	}
+
+	q.cond.Signal()
+	return ticket
+}
+
+// Skip は再生中の音声を中断し、次の音声に進みます。再生中の音声がなければ false を返します
+func (q *Queue) Skip() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.skipCurrentLocked()
+}
+
+// Clear は再生待ちの音声を全て取り除き、取り除いた件数を返します。再生中の音声はそのまま続けます
+func (q *Queue) Clear() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.clearLocked()
+}
+
+// Stop は再生待ちの音声を全て取り除き、再生中の音声も中断します
+func (q *Queue) Stop() (cleared int, stopped bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cleared = q.clearLocked()
+	stopped = q.skipCurrentLocked()
+	return cleared, stopped
+}
+
+// State は現在のキューの状態を返します
+func (q *Queue) State() QueueState {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := QueueState{
+		Pending: make([]ItemState, 0, len(q.pending)),
+		Played:  q.played,
+		Skipped: q.skipped,
+		Failed:  q.failed,
+	}
+	if q.current != nil {
+		current := q.current.state()
+		state.Current = &current
+	}
+	for _, item := range q.pending {
+		state.Pending = append(state.Pending, item.state())
+	}
+	return state
+}
+
+// Close は再生を停止し、再生用のゴルーチンを終了します
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	q.clearLocked()
+	q.skipCurrentLocked()
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+
+		item := q.pending[0]
+		q.pending = q.pending[1:]
+		ctx, cancel := context.WithCancel(context.Background())
+		item.startedAt = time.Now()
+		q.current = item
+		q.cancelCurrent = cancel
+		q.mu.Unlock()
+
+		err := q.play(ctx, item)
+		cancel()
+
+		q.mu.Lock()
+		switch {
+		case item.skipped:
+			q.skipped++
+			item.ticket.err = ErrSkipped
+		case err != nil:
+			q.failed++
+			item.ticket.err = err
+		default:
+			q.played++
+		}
+		q.current = nil
+		q.cancelCurrent = nil
+		q.mu.Unlock()
+
+		if err != nil && !item.skipped && q.OnError != nil {
+			q.OnError(item.opts.Text, err)
+		}
+		close(item.ticket.done)
+	}
+}
+
+// Player は再生に使うバックエンドを返します
+func (q *Queue) Player() Player {
+	return q.player
+}
+
+// play は1件の音声を再生します
+func (q *Queue) play(ctx context.Context, item *queueItem) error {
+	opts := item.opts.Play
+	if opts.Device == "" {
+		opts.Device = q.Defaults.Device
+	}
+	if opts.Volume <= 0 {
+		opts.Volume = q.Defaults.Volume
+	}
+
+	if item.data != nil {
+		return PlayAudio(ctx, q.player, item.data, opts, q.TempDir)
+	}
+	return q.player.Play(ctx, item.path, opts)
+}
+
+// skipCurrentLocked は再生中の音声を中断します。呼び出し側でロックを保持してください
+func (q *Queue) skipCurrentLocked() bool {
+	if q.current == nil || q.current.skipped {
+		return false
+	}
+	q.current.skipped = true
+	q.cancelCurrent()
+	return true
+}
+
+// clearLocked は再生待ちの音声を取り除きます。呼び出し側でロックを保持してください
+func (q *Queue) clearLocked() int {
+	cleared := len(q.pending)
+	for _, item := range q.pending {
+		item.ticket.err = ErrCleared
+		close(item.ticket.done)
+	}
+	q.pending = nil
+	return cleared
+}
+
+func (item *queueItem) state() ItemState {
+	state := ItemState{
+		ID:         item.ticket.ID,
+		Text:       item.opts.Text,
+		Priority:   item.opts.Priority.String(),
+		EnqueuedAt: item.enqueuedAt,
+	}
+	if !item.startedAt.IsZero() {
+		started := item.startedAt
+		state.StartedAt = &started
+	}
+	return state
+}
@@ -0,0 +1,234 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingPlayer は release されるかキャンセルされるまで再生を続けるテスト用のプレイヤーです
+type blockingPlayer struct {
+	mu      sync.Mutex
+	played  []string
+	started chan string
+	release chan struct{}
+}
+
+func newBlockingPlayer() *blockingPlayer {
+	return &blockingPlayer{
+		started: make(chan string, 16),
+		release: make(chan struct{}, 16),
+	}
+}
+
+func (p *blockingPlayer) Name() string { return "blocking" }
+
+func (p *blockingPlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	p.started <- path
+	select {
+	case <-p.release:
+		p.mu.Lock()
+		p.played = append(p.played, path)
+		p.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *blockingPlayer) waitStarted(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case got := <-p.started:
+		if got != want {
+			t.Fatalf("started %q, want %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("playback of %q did not start", want)
+	}
+}
+
+// failingPlayer は常に再生に失敗するテスト用のプレイヤーです
+type failingPlayer struct {
+	err error
+}
+
+func (p failingPlayer) Name() string { return "failing" }
+
+func (p failingPlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	return p.err
+}
+
+func TestQueue_PriorityOrder(t *testing.T) {
+	player := newBlockingPlayer()
+	q := NewQueue(player)
+	defer q.Close()
+
+	first := q.Enqueue("first", EnqueueOptions{})
+	player.waitStarted(t, "first")
+
+	q.Enqueue("normal-1", EnqueueOptions{Priority: PriorityNormal})
+	q.Enqueue("low", EnqueueOptions{Priority: PriorityLow})
+	q.Enqueue("normal-2", EnqueueOptions{Priority: PriorityNormal})
+	urgent := q.Enqueue("urgent", EnqueueOptions{Priority: PriorityUrgent})
+
+	if urgent.Position != 0 {
+		t.Errorf("urgent Position = %d, want 0", urgent.Position)
+	}
+
+	state := q.State()
+	if state.Current == nil || state.Current.ID != first.ID {
+		t.Fatalf("State().Current = %+v, want first", state.Current)
+	}
+	var pending []string
+	for _, item := range state.Pending {
+		pending = append(pending, item.Priority)
+	}
+	want := []string{"urgent", "normal", "normal", "low"}
+	for i := range want {
+		if pending[i] != want[i] {
+			t.Fatalf("pending priorities = %v, want %v", pending, want)
+		}
+	}
+
+	for _, next := range []string{"urgent", "normal-1", "normal-2", "low"} {
+		player.release <- struct{}{}
+		player.waitStarted(t, next)
+	}
+	player.release <- struct{}{}
+
+	if err := first.Wait(); err != nil {
+		t.Errorf("first.Wait() = %v", err)
+	}
+}
+
+func TestQueue_SkipAndInterrupt(t *testing.T) {
+	player := newBlockingPlayer()
+	q := NewQueue(player)
+	defer q.Close()
+
+	first := q.Enqueue("first", EnqueueOptions{})
+	player.waitStarted(t, "first")
+	second := q.Enqueue("second", EnqueueOptions{})
+
+	if !q.Skip() {
+		t.Fatal("Skip() = false, want true")
+	}
+	if err := first.Wait(); !errors.Is(err, ErrSkipped) {
+		t.Errorf("first.Wait() = %v, want ErrSkipped", err)
+	}
+	player.waitStarted(t, "second")
+
+	interrupting := q.Enqueue("announcement", EnqueueOptions{Interrupt: true})
+	if err := second.Wait(); !errors.Is(err, ErrSkipped) {
+		t.Errorf("second.Wait() = %v, want ErrSkipped", err)
+	}
+	player.waitStarted(t, "announcement")
+	player.release <- struct{}{}
+	if err := interrupting.Wait(); err != nil {
+		t.Errorf("interrupting.Wait() = %v", err)
+	}
+
+	state := q.State()
+	if state.Skipped != 2 || state.Played != 1 {
+		t.Errorf("State() = played %d skipped %d, want 1 and 2", state.Played, state.Skipped)
+	}
+}
+
+func TestQueue_ClearAndStop(t *testing.T) {
+	player := newBlockingPlayer()
+	q := NewQueue(player)
+	defer q.Close()
+
+	first := q.Enqueue("first", EnqueueOptions{})
+	player.waitStarted(t, "first")
+	second := q.Enqueue("second", EnqueueOptions{})
+	third := q.Enqueue("third", EnqueueOptions{})
+
+	if cleared := q.Clear(); cleared != 2 {
+		t.Errorf("Clear() = %d, want 2", cleared)
+	}
+	if err := second.Wait(); !errors.Is(err, ErrCleared) {
+		t.Errorf("second.Wait() = %v, want ErrCleared", err)
+	}
+	if err := third.Wait(); !errors.Is(err, ErrCleared) {
+		t.Errorf("third.Wait() = %v, want ErrCleared", err)
+	}
+
+	fourth := q.Enqueue("fourth", EnqueueOptions{})
+	cleared, stopped := q.Stop()
+	if cleared != 1 || !stopped {
+		t.Errorf("Stop() = %d, %v, want 1, true", cleared, stopped)
+	}
+	if err := first.Wait(); !errors.Is(err, ErrSkipped) {
+		t.Errorf("first.Wait() = %v, want ErrSkipped", err)
+	}
+	if err := fourth.Wait(); !errors.Is(err, ErrCleared) {
+		t.Errorf("fourth.Wait() = %v, want ErrCleared", err)
+	}
+}
+
+func TestQueue_PlaybackError(t *testing.T) {
+	playErr := errors.New("device busy")
+	var reported error
+	q := NewQueue(failingPlayer{err: playErr})
+	q.OnError = func(text string, err error) { reported = err }
+
+	ticket := q.Enqueue("a", EnqueueOptions{Text: "テスト"})
+	if err := ticket.Wait(); !errors.Is(err, playErr) {
+		t.Errorf("Wait() = %v, want %v", err, playErr)
+	}
+	q.Close()
+
+	if !errors.Is(reported, playErr) {
+		t.Errorf("OnError received %v, want %v", reported, playErr)
+	}
+	if state := q.State(); state.Failed != 1 {
+		t.Errorf("State().Failed = %d, want 1", state.Failed)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Priority
+		wantErr bool
+	}{
+		{"", PriorityNormal, false},
+		{"urgent", PriorityUrgent, false},
+		{"LOW", PriorityLow, false},
+		{"asap", PriorityNormal, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePriority(%q) = %v, %v, want %v, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestQueue_PlayDefaults(t *testing.T) {
+	recorder := &streamRecorder{}
+	q := NewQueue(recorder)
+	q.Defaults = PlayOptions{Device: "speakers", Volume: 0.8}
+	defer q.Close()
+
+	wav := makeWAV(24000, 1, []byte{0, 0})
+	if err := q.EnqueueAudio(wav, EnqueueOptions{}).Wait(); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if recorder.opts != (PlayOptions{Device: "speakers", Volume: 0.8}) {
+		t.Errorf("defaults not applied: %+v", recorder.opts)
+	}
+
+	// 呼び出しごとの指定は既定値より優先される
+	opts := EnqueueOptions{Play: PlayOptions{Device: "headphones", Volume: 1.5}}
+	if err := q.EnqueueAudio(wav, opts).Wait(); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if recorder.opts != opts.Play {
+		t.Errorf("per-call options not applied: %+v", recorder.opts)
+	}
+}
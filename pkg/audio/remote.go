@@ -0,0 +1,158 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// SinkPlayPath は sink サブコマンドが音声を受け付けるパスです
+	SinkPlayPath = "/play"
+	// SinkVolumeHeader は再生音量の倍率を伝えるヘッダーです
+	SinkVolumeHeader = "X-Playback-Volume"
+	// SinkDeviceHeader は出力先デバイス名を伝えるヘッダーです
+	SinkDeviceHeader = "X-Playback-Device"
+
+	// defaultPulsePort は PulseAudio / PipeWire（pipewire-pulse）のネイティブプロトコルの既定ポートです
+	defaultPulsePort = "4713"
+)
+
+// NewSinkPlayer はリモートの再生先（sink）に音声を送るバックエンドを作成します。
+// sinkURL のスキームで種類を選びます。
+//
+//   - http://, https:// : WAVをHTTP POSTで送信します。mcp-voicevox sink に送る場合はパス省略時に /play を使います。
+//     token は Authorization: Bearer ヘッダーで送られます
+//   - pulse://host[:port], tcp://host[:port] : PulseAudio / PipeWire のTCPシンクに paplay で再生します。
+//     認証には PulseAudio のクッキーを使い、?cookie=/path/to/cookie で指定できます（省略時は paplay の既定）
+func NewSinkPlayer(sinkURL, token string) (Player, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audio sink URL: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid audio sink URL (host is required): %s", sinkURL)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		if u.Path == "" || u.Path == "/" {
+			u.Path = SinkPlayPath
+		}
+		return &httpSinkPlayer{url: u.String(), token: token, client: &http.Client{}}, nil
+	case "pulse", "tcp":
+		host := u.Host
+		if u.Port() == "" {
+			host = u.Host + ":" + defaultPulsePort
+		}
+		return &pulseSinkPlayer{
+			streamCommandPlayer: newPaplay().(*streamCommandPlayer),
+			server:              "tcp:" + host,
+			cookie:              u.Query().Get("cookie"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported audio sink scheme: %s (expected http, https, pulse or tcp)", u.Scheme)
+	}
+}
+
+// httpSinkPlayer はWAVをHTTP POSTでリモートの再生先に送るバックエンドです。
+// 再生先は再生が終わってから応答を返すことを想定しています
+type httpSinkPlayer struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// Name はバックエンド名を返します
+func (p *httpSinkPlayer) Name() string {
+	return "http-sink"
+}
+
+// Play は音声ファイルを再生先に送ります
+func (p *httpSinkPlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	wav, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read audio file: %w", err)
+	}
+	return p.PlayStream(ctx, wav, opts)
+}
+
+// PlayStream はWAVデータを再生先に送り、再生が終わるまで待ちます。
+// ctx がキャンセルされると接続を切り、再生先も再生を中断します
+func (p *httpSinkPlayer) PlayStream(ctx context.Context, wav []byte, opts PlayOptions) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(wav))
+	if err != nil {
+		return fmt.Errorf("failed to create sink request: %w", err)
+	}
+	req.Header.Set("Content-Type", "audio/wav")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	if opts.Volume > 0 {
+		req.Header.Set(SinkVolumeHeader, formatFloat(opts.Volume))
+	}
+	if opts.Device != "" {
+		req.Header.Set(SinkDeviceHeader, opts.Device)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to send audio to sink %s: %w", p.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("audio sink %s returned %d: %s", p.url, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// pulseSinkPlayer はリモートの PulseAudio / PipeWire サーバーで paplay を使って再生するバックエンドです
+type pulseSinkPlayer struct {
+	*streamCommandPlayer
+	server string
+	cookie string
+}
+
+// Name はバックエンド名を返します
+func (p *pulseSinkPlayer) Name() string {
+	return "pulse-sink"
+}
+
+// Play は音声ファイルをリモートサーバーで再生します
+func (p *pulseSinkPlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	cmd := exec.CommandContext(ctx, p.binary, p.args(path, opts)...)
+	cmd.Env = p.env()
+	return runCommand(ctx, cmd)
+}
+
+// PlayStream はWAVデータを標準入力で paplay に渡し、リモートサーバーで再生します
+func (p *pulseSinkPlayer) PlayStream(ctx context.Context, wav []byte, opts PlayOptions) error {
+	args, input, err := p.streamArgs(wav, opts)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, p.binary, args...)
+	cmd.Env = p.env()
+	cmd.Stdin = bytes.NewReader(input)
+	return runCommand(ctx, cmd)
+}
+
+// env は接続先サーバーと認証クッキーを指定した環境変数を返します
+func (p *pulseSinkPlayer) env() []string {
+	env := append(os.Environ(), "PULSE_SERVER="+p.server)
+	if p.cookie != "" {
+		env = append(env, "PULSE_COOKIE="+p.cookie)
+	}
+	return env
+}
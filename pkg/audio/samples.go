@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Samples は -1.0〜1.0 に正規化したPCMです。Data はチャンネルごとにインターリーブされています
+type Samples struct {
+	SampleRate int
+	Channels   int
+	Data       []float64
+}
+
+// Frames はチャンネルあたりのサンプル数を返します
+func (s Samples) Frames() int {
+	if s.Channels == 0 {
+		return 0
+	}
+	return len(s.Data) / s.Channels
+}
+
+// Duration は再生時間（秒）を返します
+func (s Samples) Duration() float64 {
+	if s.SampleRate == 0 {
+		return 0
+	}
+	return float64(s.Frames()) / float64(s.SampleRate)
+}
+
+// DecodeSamples はWAVを正規化したPCMにします。
+// 8/16/24/32bitのリニアPCMと32bitの浮動小数点に対応しています
+func DecodeSamples(wav []byte) (Samples, error) {
+	format, err := ParseWAV(wav)
+	if err != nil {
+		return Samples{}, err
+	}
+	if format.Channels < 1 {
+		return Samples{}, fmt.Errorf("invalid WAV channel count %d", format.Channels)
+	}
+	data := wav[format.DataOffset : format.DataOffset+format.DataSize]
+
+	bytesPerSample := format.BitsPerSample / 8
+	var decode func(b []byte) float64
+	switch {
+	case format.AudioFormat == 1 && format.BitsPerSample == 8:
+		decode = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format.AudioFormat == 1 && format.BitsPerSample == 16:
+		decode = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format.AudioFormat == 1 && format.BitsPerSample == 24:
+		decode = func(b []byte) float64 {
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			return float64(v) / (1 << 23)
+		}
+	case format.AudioFormat == 1 && format.BitsPerSample == 32:
+		decode = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case format.AudioFormat == 3 && format.BitsPerSample == 32:
+		decode = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	default:
+		return Samples{}, fmt.Errorf("unsupported WAV format (format %d, %d bit)", format.AudioFormat, format.BitsPerSample)
+	}
+
+	frameSize := bytesPerSample * format.Channels
+	n := len(data) / frameSize * format.Channels
+	samples := Samples{SampleRate: format.SampleRate, Channels: format.Channels, Data: make([]float64, n)}
+	for i := range samples.Data {
+		samples.Data[i] = decode(data[i*bytesPerSample:])
+	}
+	return samples, nil
+}
+
+// EncodeWAV は16bitリニアPCMのWAVにします。範囲外のサンプルはクリップします
+func (s Samples) EncodeWAV() []byte {
+	pcm := make([]byte, len(s.Data)*2)
+	for i, v := range s.Data {
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(toInt16(v)))
+	}
+	return EncodeWAV(WAVFormat{AudioFormat: 1, Channels: s.Channels, SampleRate: s.SampleRate, BitsPerSample: 16}, pcm)
+}
+
+// toInt16 は正規化したサンプルを16bitの値にします
+func toInt16(v float64) int16 {
+	v = math.Round(v * 32768)
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(v)
+}
+
+// Mono はチャンネルを平均してモノラルにします
+func (s Samples) Mono() Samples {
+	if s.Channels == 1 {
+		return s
+	}
+	mono := Samples{SampleRate: s.SampleRate, Channels: 1, Data: make([]float64, s.Frames())}
+	for i := range mono.Data {
+		var sum float64
+		for c := 0; c < s.Channels; c++ {
+			sum += s.Data[i*s.Channels+c]
+		}
+		mono.Data[i] = sum / float64(s.Channels)
+	}
+	return mono
+}
+
+// resampleTaps は再サンプリングに使う窓付きsinc関数の片側の長さ（入力のサンプル数）です
+const resampleTaps = 16
+
+// Resample はサンプリングレートを変換します。ダウンサンプリングでは折り返しを防ぐため
+// 変換後のナイキスト周波数より高い成分を取り除きます
+func (s Samples) Resample(rate int) Samples {
+	if rate <= 0 || rate == s.SampleRate || s.SampleRate == 0 {
+		return s
+	}
+
+	ratio := float64(rate) / float64(s.SampleRate)
+	// cutoff は入力のサンプリングレートに対する遮断周波数の比です
+	cutoff := math.Min(1, ratio) * 0.95
+	width := float64(resampleTaps) / cutoff
+
+	frames := s.Frames()
+	outFrames := int(math.Round(float64(frames) * ratio))
+	out := Samples{SampleRate: rate, Channels: s.Channels, Data: make([]float64, outFrames*s.Channels)}
+	for i := 0; i < outFrames; i++ {
+		center := float64(i) / ratio
+		lo := int(math.Ceil(center - width))
+		hi := int(math.Floor(center + width))
+		for c := 0; c < s.Channels; c++ {
+			var sum float64
+			for j := max(lo, 0); j <= hi && j < frames; j++ {
+				sum += s.Data[j*s.Channels+c] * resampleKernel(float64(j)-center, cutoff, width)
+			}
+			out.Data[i*s.Channels+c] = sum
+		}
+	}
+	return out
+}
+
+// resampleKernel はBlackman窓を掛けたsinc関数です
+func resampleKernel(x, cutoff, width float64) float64 {
+	if math.Abs(x) >= width {
+		return 0
+	}
+	sinc := cutoff
+	if x != 0 {
+		sinc = math.Sin(math.Pi*cutoff*x) / (math.Pi * x)
+	}
+	w := 0.42 + 0.5*math.Cos(math.Pi*x/width) + 0.08*math.Cos(2*math.Pi*x/width)
+	return sinc * w
+}
@@ -0,0 +1,143 @@
+package audio
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxSinkBodySize は sink が受け付けるWAVデータの既定の最大サイズです
+const DefaultMaxSinkBodySize = 64 << 20
+
+// SinkServer はHTTPで受け取ったWAVを再生する sink サーバーです。
+// POST /play に音声を送ると、再生が終わってから 204 を返します。
+// 受け取った音声は到着順に1件ずつ再生します
+type SinkServer struct {
+	// Player は再生に使うバックエンドです
+	Player Player
+	// Token は Authorization: Bearer で要求する認証トークンです
+	Token string
+	// Device はリクエストでデバイスが指定されなかったときの出力先です
+	Device string
+	// TempDir はストリーミング再生に対応しないバックエンドで使う一時ディレクトリです
+	TempDir string
+	// MaxBodySize は受け付けるWAVデータの最大バイト数です。0 以下は DefaultMaxSinkBodySize です
+	MaxBodySize int64
+
+	// playing は再生を1件ずつに制限するセマフォです
+	playing chan struct{}
+}
+
+// NewSinkServer は新しい sink サーバーを作成します。token は空にできません
+func NewSinkServer(player Player, token string) (*SinkServer, error) {
+	if token == "" {
+		return nil, fmt.Errorf("sink token cannot be empty")
+	}
+	return &SinkServer{
+		Player:  player,
+		Token:   token,
+		playing: make(chan struct{}, 1),
+	}, nil
+}
+
+// ServeHTTP は http.Handler を実装します
+func (s *SinkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case SinkPlayPath:
+		s.handlePlay(w, r)
+	case "/health":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok", "player": s.Player.Name()})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handlePlay は受け取ったWAVを再生します
+func (s *SinkServer) handlePlay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-voicevox-sink"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	maxBodySize := s.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxSinkBodySize
+	}
+	wav, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "audio too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read audio", http.StatusBadRequest)
+		return
+	}
+	if _, err := ParseWAV(wav); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := s.playOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 前の音声の再生が終わるまで待つ。送信元が切断したら諦める
+	select {
+	case s.playing <- struct{}{}:
+		defer func() { <-s.playing }()
+	case <-r.Context().Done():
+		return
+	}
+
+	if err := PlayAudio(r.Context(), s.Player, wav, opts, s.TempDir); err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		log.Printf("Sink playback failed: %v", err)
+		http.Error(w, "playback failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorized はリクエストのトークンを定数時間で照合します
+func (s *SinkServer) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+// playOptions はリクエストヘッダーから再生オプションを作成します
+func (s *SinkServer) playOptions(r *http.Request) (PlayOptions, error) {
+	opts := PlayOptions{Device: s.Device}
+	if device := r.Header.Get(SinkDeviceHeader); device != "" {
+		opts.Device = device
+	}
+	if volume := r.Header.Get(SinkVolumeHeader); volume != "" {
+		// 再生音量は設定やツール引数と同じく 0.0 より大きく 2.0 以下に限る
+		v, err := strconv.ParseFloat(volume, 64)
+		if err != nil || v <= 0.0 || v > 2.0 {
+			return opts, fmt.Errorf("invalid %s header: must be greater than 0.0 and at most 2.0, got %s", SinkVolumeHeader, volume)
+		}
+		opts.Volume = v
+	}
+	return opts, nil
+}
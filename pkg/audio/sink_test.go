@@ -0,0 +1,171 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// streamRecorder は受け取った音声と再生オプションを記録するテスト用のプレイヤーです
+type streamRecorder struct {
+	mu   sync.Mutex
+	wav  []byte
+	opts PlayOptions
+}
+
+func (p *streamRecorder) Name() string { return "recorder" }
+
+func (p *streamRecorder) Play(ctx context.Context, path string, opts PlayOptions) error {
+	return nil
+}
+
+func (p *streamRecorder) PlayStream(ctx context.Context, wav []byte, opts PlayOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.wav = wav
+	p.opts = opts
+	return nil
+}
+
+func newTestSink(t *testing.T) (*streamRecorder, *httptest.Server) {
+	t.Helper()
+	recorder := &streamRecorder{}
+	sink, err := NewSinkServer(recorder, "secret")
+	if err != nil {
+		t.Fatalf("NewSinkServer() error = %v", err)
+	}
+	sink.Device = "default-device"
+	server := httptest.NewServer(sink)
+	t.Cleanup(server.Close)
+	return recorder, server
+}
+
+func TestSinkServer_RoundTrip(t *testing.T) {
+	recorder, server := newTestSink(t)
+	wav := makeWAV(24000, 1, []byte{1, 2, 3, 4})
+
+	player, err := NewSinkPlayer(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewSinkPlayer() error = %v", err)
+	}
+	if err := PlayAudio(context.Background(), player, wav, PlayOptions{Volume: 0.5}, ""); err != nil {
+		t.Fatalf("PlayAudio() error = %v", err)
+	}
+
+	if !bytes.Equal(recorder.wav, wav) {
+		t.Error("sink received different audio data")
+	}
+	// デバイス未指定のときは sink 側の既定のデバイスを使う
+	if recorder.opts.Volume != 0.5 || recorder.opts.Device != "default-device" {
+		t.Errorf("sink play options = %+v", recorder.opts)
+	}
+}
+
+func TestSinkServer_Unauthorized(t *testing.T) {
+	recorder, server := newTestSink(t)
+	wav := makeWAV(24000, 1, []byte{1, 2})
+
+	for _, token := range []string{"", "wrong"} {
+		player, _ := NewSinkPlayer(server.URL, token)
+		err := PlayAudio(context.Background(), player, wav, PlayOptions{}, "")
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("token %q: error = %v, want 401", token, err)
+		}
+	}
+	if recorder.wav != nil {
+		t.Error("unauthorized request was played")
+	}
+}
+
+func TestSinkServer_BadRequests(t *testing.T) {
+	_, server := newTestSink(t)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		header map[string]string
+		want   int
+	}{
+		{name: "not wav", method: http.MethodPost, body: "hello", want: http.StatusBadRequest},
+		{name: "invalid volume", method: http.MethodPost, body: string(makeWAV(24000, 1, []byte{0, 0})), header: map[string]string{SinkVolumeHeader: "loud"}, want: http.StatusBadRequest},
+		{name: "volume too loud", method: http.MethodPost, body: string(makeWAV(24000, 1, []byte{0, 0})), header: map[string]string{SinkVolumeHeader: "1000"}, want: http.StatusBadRequest},
+		{name: "zero volume", method: http.MethodPost, body: string(makeWAV(24000, 1, []byte{0, 0})), header: map[string]string{SinkVolumeHeader: "0"}, want: http.StatusBadRequest},
+		{name: "negative volume", method: http.MethodPost, body: string(makeWAV(24000, 1, []byte{0, 0})), header: map[string]string{SinkVolumeHeader: "-1"}, want: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodGet, want: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, server.URL+SinkPlayPath, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer secret")
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestNewSinkServer_RequiresToken(t *testing.T) {
+	if _, err := NewSinkServer(NullPlayer{}, ""); err == nil {
+		t.Error("NewSinkServer() with empty token error = nil, want error")
+	}
+}
+
+func TestNewSinkPlayer(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantName string
+		check    func(t *testing.T, p Player)
+		wantErr  bool
+	}{
+		{url: "http://laptop:50080", wantName: "http-sink", check: func(t *testing.T, p Player) {
+			if got := p.(*httpSinkPlayer).url; got != "http://laptop:50080/play" {
+				t.Errorf("url = %s, want default path /play", got)
+			}
+		}},
+		{url: "https://example.com/custom", wantName: "http-sink", check: func(t *testing.T, p Player) {
+			if got := p.(*httpSinkPlayer).url; got != "https://example.com/custom" {
+				t.Errorf("url = %s, want path kept", got)
+			}
+		}},
+		{url: "pulse://laptop?cookie=/run/pulse/cookie", wantName: "pulse-sink", check: func(t *testing.T, p Player) {
+			sink := p.(*pulseSinkPlayer)
+			if sink.server != "tcp:laptop:4713" || sink.cookie != "/run/pulse/cookie" {
+				t.Errorf("pulse sink = %s %s", sink.server, sink.cookie)
+			}
+		}},
+		{url: "tcp://10.0.0.2:14713", wantName: "pulse-sink", check: func(t *testing.T, p Player) {
+			if got := p.(*pulseSinkPlayer).server; got != "tcp:10.0.0.2:14713" {
+				t.Errorf("server = %s", got)
+			}
+		}},
+		{url: "ftp://laptop", wantErr: true},
+		{url: "laptop:50080", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		player, err := NewSinkPlayer(tt.url, "token")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewSinkPlayer(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if player.Name() != tt.wantName {
+			t.Errorf("NewSinkPlayer(%q).Name() = %s, want %s", tt.url, player.Name(), tt.wantName)
+		}
+		tt.check(t, player)
+	}
+}
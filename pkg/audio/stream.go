@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// StreamPlayer は音声データを標準入力などで直接受け取って再生できるバックエンドが実装します。
+// ファイルを経由しないため、合成から再生までの遅延を減らせます
+type StreamPlayer interface {
+	Player
+	// PlayStream はWAVデータを再生し、再生が終わるまでブロックします
+	PlayStream(ctx context.Context, wav []byte, opts PlayOptions) error
+}
+
+// PlayAudio はWAVデータを再生します。
+// バックエンドが StreamPlayer を実装していればそのまま渡し、
+// そうでなければ tempDir（空の場合はOSの一時ディレクトリ）に一時ファイルを書き出して再生し、再生後に削除します
+func PlayAudio(ctx context.Context, player Player, wav []byte, opts PlayOptions, tempDir string) error {
+	if streamer, ok := player.(StreamPlayer); ok {
+		return streamer.PlayStream(ctx, wav, opts)
+	}
+
+	file, err := os.CreateTemp(tempDir, "playback_*.wav")
+	if err != nil {
+		return fmt.Errorf("failed to create playback file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(wav); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write playback file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write playback file: %w", err)
+	}
+
+	return player.Play(ctx, file.Name(), opts)
+}
+
+// streamCommandPlayer は標準入力からの再生にも対応した組み込みバックエンドです
+type streamCommandPlayer struct {
+	*commandPlayer
+	// streamArgs は標準入力で再生するときの引数と、標準入力に渡すデータを返します
+	streamArgs func(wav []byte, opts PlayOptions) ([]string, []byte, error)
+}
+
+// PlayStream はWAVデータを再生コマンドの標準入力に渡して再生します
+func (p *streamCommandPlayer) PlayStream(ctx context.Context, wav []byte, opts PlayOptions) error {
+	args, input, err := p.streamArgs(wav, opts)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, p.binary, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	return runCommand(ctx, cmd)
+}
+
+// wholeWAV は先頭のヘッダーを含むWAVデータをそのまま標準入力に渡すための引数関数を作ります
+func wholeWAV(args func(opts PlayOptions) []string) func(wav []byte, opts PlayOptions) ([]string, []byte, error) {
+	return func(wav []byte, opts PlayOptions) ([]string, []byte, error) {
+		return args(opts), wav, nil
+	}
+}
+
+// pulseSampleFormat はWAVのフォーマットに対応する PulseAudio のサンプル形式を返します
+func pulseSampleFormat(format WAVFormat) (string, error) {
+	switch {
+	case format.AudioFormat == 3 && format.BitsPerSample == 32:
+		return "float32le", nil
+	case format.AudioFormat != 1:
+		return "", fmt.Errorf("unsupported WAV format for raw playback: %d", format.AudioFormat)
+	case format.BitsPerSample == 8:
+		return "u8", nil
+	case format.BitsPerSample == 16:
+		return "s16le", nil
+	case format.BitsPerSample == 24:
+		return "s24le", nil
+	case format.BitsPerSample == 32:
+		return "s32le", nil
+	default:
+		return "", fmt.Errorf("unsupported WAV bit depth for raw playback: %d", format.BitsPerSample)
+	}
+}
+
+// stdinTemplatePlayer は {file} を含まないコマンドテンプレートのバックエンドで、
+// 音声データを常に標準入力に渡します
+type stdinTemplatePlayer struct {
+	*templatePlayer
+}
+
+// Play は音声ファイルの内容をコマンドの標準入力に渡して再生します
+func (p *stdinTemplatePlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	wav, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read audio file: %w", err)
+	}
+	return p.PlayStream(ctx, wav, opts)
+}
+
+// PlayStream はWAVデータをコマンドの標準入力に渡して再生します
+func (p *stdinTemplatePlayer) PlayStream(ctx context.Context, wav []byte, opts PlayOptions) error {
+	args := p.expand("", opts)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(wav)
+	return runCommand(ctx, cmd)
+}
+
+// PlayStream は何もせずに成功を返します
+func (NullPlayer) PlayStream(ctx context.Context, wav []byte, opts PlayOptions) error {
+	return ctx.Err()
+}
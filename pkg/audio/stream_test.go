@@ -0,0 +1,106 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// recordingPlayer は再生されたファイルの内容を記録するテスト用のプレイヤーです
+type recordingPlayer struct {
+	path string
+	data []byte
+}
+
+func (p *recordingPlayer) Name() string { return "recording" }
+
+func (p *recordingPlayer) Play(ctx context.Context, path string, opts PlayOptions) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	p.path = path
+	p.data = data
+	return nil
+}
+
+func TestPlayAudio_FallbackToFile(t *testing.T) {
+	dir := t.TempDir()
+	wav := makeWAV(24000, 1, []byte{1, 2, 3, 4})
+	player := &recordingPlayer{}
+
+	if err := PlayAudio(context.Background(), player, wav, PlayOptions{}, dir); err != nil {
+		t.Fatalf("PlayAudio() error = %v", err)
+	}
+	if !bytes.Equal(player.data, wav) {
+		t.Error("player received different audio data")
+	}
+	if filepath.Dir(player.path) != dir {
+		t.Errorf("playback file %s was not created in %s", player.path, dir)
+	}
+	if _, err := os.Stat(player.path); !os.IsNotExist(err) {
+		t.Errorf("playback file %s was not removed", player.path)
+	}
+}
+
+func TestPlayAudio_StdinTemplate(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	out := filepath.Join(t.TempDir(), "out.wav")
+	player, err := NewCommandPlayer(fmt.Sprintf(`sh -c "cat > '%s'"`, out))
+	if err != nil {
+		t.Fatalf("NewCommandPlayer() error = %v", err)
+	}
+	if _, ok := player.(StreamPlayer); !ok {
+		t.Fatalf("template without {file} should stream via stdin, got %T", player)
+	}
+
+	wav := makeWAV(24000, 1, []byte{1, 2, 3, 4})
+	if err := PlayAudio(context.Background(), player, wav, PlayOptions{}, ""); err != nil {
+		t.Fatalf("PlayAudio() error = %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, wav) {
+		t.Error("command received different audio data on stdin")
+	}
+}
+
+func TestPaplay_StreamArgs(t *testing.T) {
+	pcm := []byte{1, 2, 3, 4}
+	wav := makeWAV(24000, 2, pcm)
+	player := newPaplay().(*streamCommandPlayer)
+
+	args, input, err := player.streamArgs(wav, PlayOptions{Device: "sink"})
+	if err != nil {
+		t.Fatalf("streamArgs() error = %v", err)
+	}
+	want := []string{"--volume=65536", "--device=sink", "--raw", "--format=s16le", "--rate=24000", "--channels=2"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %q, want %q", args, want)
+	}
+	if !bytes.Equal(input, pcm) {
+		t.Errorf("stdin = %v, want PCM data %v", input, pcm)
+	}
+}
+
+func TestBuiltinStreamSupport(t *testing.T) {
+	for name, streams := range map[string]bool{
+		"aplay": true, "paplay": true, "mpv": true, "ffplay": true, "null": true,
+		"afplay": false, "pw-play": false, "powershell": false,
+	} {
+		_, ok := registry[name]().(StreamPlayer)
+		if ok != streams {
+			t.Errorf("%s: streaming = %v, want %v", name, ok, streams)
+		}
+	}
+}
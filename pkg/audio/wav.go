@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// WAVFormat はWAVファイルのフォーマット情報です
+type WAVFormat struct {
+	// AudioFormat は fmt チャンクのフォーマットコード（1 はリニアPCM）です
+	AudioFormat   int
+	Channels      int
+	SampleRate    int
+	BitsPerSample int
+	// DataOffset は data チャンク本体の開始位置（バイト）です
+	DataOffset int
+	// DataSize は data チャンク本体のバイト数です
+	DataSize int
+}
+
+// Duration はPCMデータの再生時間（秒）を返します
+func (f WAVFormat) Duration() float64 {
+	bytesPerSecond := f.SampleRate * f.Channels * f.BitsPerSample / 8
+	if bytesPerSecond == 0 {
+		return 0
+	}
+	return float64(f.DataSize) / float64(bytesPerSecond)
+}
+
+// ParseWAV はWAV（RIFF）のヘッダーを解析します
+func ParseWAV(data []byte) (WAVFormat, error) {
+	var format WAVFormat
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return format, fmt.Errorf("not a WAV file")
+	}
+
+	foundFmt := false
+	offset := 12
+	for offset+8 <= len(data) {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := offset + 8
+
+		switch id {
+		case "fmt ":
+			if size < 16 || body+16 > len(data) {
+				return format, fmt.Errorf("invalid WAV fmt chunk")
+			}
+			format.AudioFormat = int(binary.LittleEndian.Uint16(data[body:]))
+			format.Channels = int(binary.LittleEndian.Uint16(data[body+2:]))
+			format.SampleRate = int(binary.LittleEndian.Uint32(data[body+4:]))
+			format.BitsPerSample = int(binary.LittleEndian.Uint16(data[body+14:]))
+			foundFmt = true
+		case "data":
+			if !foundFmt {
+				return format, fmt.Errorf("WAV data chunk appears before fmt chunk")
+			}
+			// ストリーミング出力などでサイズが未確定の場合はファイル末尾までをデータとみなす
+			if size > len(data)-body || size == 0 {
+				size = len(data) - body
+			}
+			format.DataOffset = body
+			format.DataSize = size
+			return format, nil
+		}
+
+		// チャンクは2バイト境界に揃えられる
+		offset = body + size + size%2
+	}
+	return format, fmt.Errorf("WAV data chunk not found")
+}
+
+// EncodeWAV はPCMデータに44バイトの標準的なWAVヘッダーを付けます
+func EncodeWAV(format WAVFormat, pcm []byte) []byte {
+	blockAlign := format.Channels * format.BitsPerSample / 8
+	data := make([]byte, 44+len(pcm))
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(36+len(pcm)))
+	copy(data[8:], "WAVE")
+	copy(data[12:], "fmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)
+	binary.LittleEndian.PutUint16(data[20:], uint16(format.AudioFormat))
+	binary.LittleEndian.PutUint16(data[22:], uint16(format.Channels))
+	binary.LittleEndian.PutUint32(data[24:], uint32(format.SampleRate))
+	binary.LittleEndian.PutUint32(data[28:], uint32(format.SampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(data[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(data[34:], uint16(format.BitsPerSample))
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], uint32(len(pcm)))
+	copy(data[44:], pcm)
+	return data
+}
+
+// ConcatWAV は同じフォーマットのWAVをつなげて1つのWAVにします
+func ConcatWAV(parts ...[]byte) ([]byte, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no WAV data to concatenate")
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+
+	var first WAVFormat
+	var pcm []byte
+	for i, part := range parts {
+		format, err := ParseWAV(part)
+		if err != nil {
+			return nil, fmt.Errorf("WAV part %d: %w", i, err)
+		}
+		if i == 0 {
+			first = format
+		} else if format.AudioFormat != first.AudioFormat || format.Channels != first.Channels ||
+			format.SampleRate != first.SampleRate || format.BitsPerSample != first.BitsPerSample {
+			return nil, fmt.Errorf("WAV part %d has a different format (%d Hz, %d ch, %d bit) from the first part (%d Hz, %d ch, %d bit)",
+				i, format.SampleRate, format.Channels, format.BitsPerSample, first.SampleRate, first.Channels, first.BitsPerSample)
+		}
+		pcm = append(pcm, part[format.DataOffset:format.DataOffset+format.DataSize]...)
+	}
+	return EncodeWAV(first, pcm), nil
+}
@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// makeWAV はテスト用の16bitリニアPCMのWAVデータを作成します
+func makeWAV(sampleRate, channels int, pcm []byte, extraChunks ...[]byte) []byte {
+	var chunks []byte
+	for _, chunk := range extraChunks {
+		chunks = append(chunks, chunk...)
+	}
+
+	fmtChunk := make([]byte, 24)
+	copy(fmtChunk, "fmt ")
+	binary.LittleEndian.PutUint32(fmtChunk[4:], 16)
+	binary.LittleEndian.PutUint16(fmtChunk[8:], 1)
+	binary.LittleEndian.PutUint16(fmtChunk[10:], uint16(channels))
+	binary.LittleEndian.PutUint32(fmtChunk[12:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(fmtChunk[16:], uint32(sampleRate*channels*2))
+	binary.LittleEndian.PutUint16(fmtChunk[20:], uint16(channels*2))
+	binary.LittleEndian.PutUint16(fmtChunk[22:], 16)
+
+	dataHeader := make([]byte, 8)
+	copy(dataHeader, "data")
+	binary.LittleEndian.PutUint32(dataHeader[4:], uint32(len(pcm)))
+
+	body := append(append(append(fmtChunk, chunks...), dataHeader...), pcm...)
+	header := make([]byte, 12)
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+len(body)))
+	copy(header[8:], "WAVE")
+	return append(header, body...)
+}
+
+func TestParseWAV(t *testing.T) {
+	pcm := make([]byte, 48000)
+	// 奇数長のチャンクはパディングを挟む
+	list := []byte{'L', 'I', 'S', 'T', 3, 0, 0, 0, 'a', 'b', 'c', 0}
+	wav := makeWAV(24000, 1, pcm, list)
+
+	format, err := ParseWAV(wav)
+	if err != nil {
+		t.Fatalf("ParseWAV() error = %v", err)
+	}
+	if format.SampleRate != 24000 || format.Channels != 1 || format.BitsPerSample != 16 || format.AudioFormat != 1 {
+		t.Errorf("ParseWAV() = %+v", format)
+	}
+	if format.DataOffset != len(wav)-len(pcm) || format.DataSize != len(pcm) {
+		t.Errorf("data chunk = offset %d size %d, want offset %d size %d", format.DataOffset, format.DataSize, len(wav)-len(pcm), len(pcm))
+	}
+	if got := format.Duration(); got != 1.0 {
+		t.Errorf("Duration() = %v, want 1", got)
+	}
+}
+
+func TestParseWAV_Invalid(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":    nil,
+		"not riff": []byte("RIFX\x00\x00\x00\x00WAVE"),
+		"no data":  makeWAV(24000, 1, nil)[:36],
+	}
+	for name, data := range tests {
+		if _, err := ParseWAV(data); err == nil {
+			t.Errorf("%s: ParseWAV() error = nil, want error", name)
+		}
+	}
+}
+
+func TestConcatWAV(t *testing.T) {
+	a := makeWAV(24000, 1, []byte{1, 2, 3, 4})
+	b := makeWAV(24000, 1, []byte{5, 6}, []byte("LIST\x02\x00\x00\x00ab"))
+
+	joined, err := ConcatWAV(a, b)
+	if err != nil {
+		t.Fatalf("ConcatWAV() error = %v", err)
+	}
+	format, err := ParseWAV(joined)
+	if err != nil {
+		t.Fatalf("ParseWAV() error = %v", err)
+	}
+	if format.SampleRate != 24000 || format.Channels != 1 || format.BitsPerSample != 16 || format.DataSize != 6 {
+		t.Errorf("ConcatWAV() format = %+v", format)
+	}
+	if got := joined[format.DataOffset:]; string(got) != "\x01\x02\x03\x04\x05\x06" {
+		t.Errorf("ConcatWAV() pcm = %v", got)
+	}
+
+	if _, err := ConcatWAV(a, makeWAV(48000, 1, []byte{1, 2})); err == nil {
+		t.Error("ConcatWAV() with different sample rates error = nil, want error")
+	}
+	if _, err := ConcatWAV(a, []byte("not wav")); err == nil {
+		t.Error("ConcatWAV() with invalid data error = nil, want error")
+	}
+}
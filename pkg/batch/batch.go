@@ -0,0 +1,316 @@
+// Package batch は CSV・JSON Lines・YAML に書いた複数の文をまとめて音声にします。
+// 同時に合成する数を制限し、出力ディレクトリの manifest.json に結果を記録して、やり直すときは完了した行を飛ばします
+package batch
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+	"gopkg.in/yaml.v3"
+)
+
+// 行のファイルの形式です
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatYAML  = "yaml"
+)
+
+// MaxRows は1回のバッチで扱える行の最大数です
+const MaxRows = 10000
+
+// Row は1つの音声にする行です。ID と Output を省略した場合は行番号から決めます
+type Row struct {
+	// ID は行を識別する名前です。マニフェストとやり直しの判定に使います
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Text は読み上げるテキストです
+	Text string `json:"text" yaml:"text"`
+	// Speaker は話者名（例: "ずんだもん ノーマル"）またはスタイルIDです。省略時は設定のデフォルト話者です
+	Speaker string `json:"speaker,omitempty" yaml:"speaker,omitempty"`
+	// SpeakerID はスタイルIDです。Speaker より優先します
+	SpeakerID *int `json:"speaker_id,omitempty" yaml:"speaker_id,omitempty"`
+	// SpeedScale などは省略時に設定のデフォルト値を使います
+	SpeedScale      *float64 `json:"speed_scale,omitempty" yaml:"speed_scale,omitempty"`
+	PitchScale      *float64 `json:"pitch_scale,omitempty" yaml:"pitch_scale,omitempty"`
+	IntonationScale *float64 `json:"intonation_scale,omitempty" yaml:"intonation_scale,omitempty"`
+	VolumeScale     *float64 `json:"volume_scale,omitempty" yaml:"volume_scale,omitempty"`
+	// Output は出力ディレクトリ内のファイル名です。拡張子を省略した場合は出力形式の拡張子を付けます
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// DetectFormat はファイルの拡張子から形式を決めます
+func DetectFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("cannot detect the batch format of %s (expected .csv, .jsonl or .yaml)", path)
+	}
+}
+
+// Load はファイルから行を読み込みます。format が空の場合は拡張子から決めます
+func Load(path, format string) ([]Row, error) {
+	if format == "" {
+		var err error
+		if format, err = DetectFormat(path); err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	return Parse(data, format)
+}
+
+// Parse は行を形式に従って読み取ります
+func Parse(data []byte, format string) ([]Row, error) {
+	var rows []Row
+	var err error
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		rows, err = parseCSV(data)
+	case FormatJSONL, "ndjson":
+		rows, err = parseJSONL(data)
+	case FormatYAML, "yml":
+		rows, err = parseYAML(data)
+	default:
+		return nil, fmt.Errorf("unsupported batch format %q (supported: csv, jsonl, yaml)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("batch has no rows")
+	}
+	if len(rows) > MaxRows {
+		return nil, fmt.Errorf("batch has %d rows (maximum %d)", len(rows), MaxRows)
+	}
+	return rows, nil
+}
+
+// parseCSV は1行目を列名とするCSVを読み取ります。空のセルは省略したものとして扱います
+func parseCSV(data []byte) ([]Row, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+		if !knownColumn(columns[i]) {
+			return nil, fmt.Errorf("unknown CSV column %q (expected %s)", name, strings.Join(rowColumns, ", "))
+		}
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		var row Row
+		for i, value := range record {
+			if i >= len(columns) {
+				return nil, fmt.Errorf("CSV line %d has more cells than columns", line)
+			}
+			if err := row.set(columns[i], strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("CSV line %d: %w", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// rowColumns はCSVの列名です
+var rowColumns = []string{"id", "text", "speaker", "speaker_id", "speed_scale", "pitch_scale", "intonation_scale", "volume_scale", "output"}
+
+// knownColumn はCSVの列名が使えるものかを返します
+func knownColumn(name string) bool {
+	for _, c := range rowColumns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// set はCSVのセルの値を行に設定します
+func (r *Row) set(column, value string) error {
+	if value == "" {
+		return nil
+	}
+	number := func() (*float64, error) {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %q", column, value)
+		}
+		return &v, nil
+	}
+	var err error
+	switch column {
+	case "id":
+		r.ID = value
+	case "text":
+		r.Text = value
+	case "speaker":
+		r.Speaker = value
+	case "speaker_id":
+		id, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return fmt.Errorf("speaker_id must be an integer, got %q", value)
+		}
+		r.SpeakerID = &id
+	case "speed_scale":
+		r.SpeedScale, err = number()
+	case "pitch_scale":
+		r.PitchScale, err = number()
+	case "intonation_scale":
+		r.IntonationScale, err = number()
+	case "volume_scale":
+		r.VolumeScale, err = number()
+	case "output":
+		r.Output = value
+	}
+	return err
+}
+
+// parseJSONL は1行に1つのJSONオブジェクトを読み取ります。空行と # で始まる行は飛ばします
+func parseJSONL(data []byte) ([]Row, error) {
+	var rows []Row
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.DisallowUnknownFields()
+		var row Row
+		if err := decoder.Decode(&row); err != nil {
+			return nil, fmt.Errorf("JSON Lines line %d: %w", i+1, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseYAML は行の配列、または rows に行の配列を持つYAMLを読み取ります
+func parseYAML(data []byte) ([]Row, error) {
+	var probe interface{}
+	if err := yaml.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	switch probe.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		var rows []Row
+		if err := decoder.Decode(&rows); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		return rows, nil
+	case map[string]interface{}:
+		var doc struct {
+			Rows []Row `yaml:"rows"`
+		}
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		return doc.Rows, nil
+	default:
+		return nil, fmt.Errorf("YAML must be a list of rows or have a rows list")
+	}
+}
+
+// Normalize は ID と Output を補い、行が正しいかを確認します。
+// extension は Output に拡張子がない場合に付ける出力形式の拡張子です
+func Normalize(rows []Row, extension string) error {
+	ids := make(map[string]int, len(rows))
+	outputs := make(map[string]int, len(rows))
+	for i := range rows {
+		r := &rows[i]
+		r.ID = strings.TrimSpace(r.ID)
+		if r.ID == "" {
+			r.ID = fmt.Sprintf("%04d", i+1)
+		}
+		if strings.TrimSpace(r.Text) == "" {
+			return fmt.Errorf("row %s: text is required", r.ID)
+		}
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("row %s: %w", r.ID, err)
+		}
+		if r.Output == "" {
+			r.Output = r.ID
+		}
+		if filepath.Ext(r.Output) == "" {
+			r.Output += extension
+		}
+		output, err := cleanOutput(r.Output)
+		if err != nil {
+			return fmt.Errorf("row %s: %w", r.ID, err)
+		}
+		r.Output = output
+
+		if prev, ok := ids[r.ID]; ok {
+			return fmt.Errorf("row %d and row %d have the same id %q", prev+1, i+1, r.ID)
+		}
+		ids[r.ID] = i
+		if prev, ok := outputs[r.Output]; ok {
+			return fmt.Errorf("rows %s and %s write the same output %q", rows[prev].ID, r.ID, r.Output)
+		}
+		outputs[r.Output] = i
+	}
+	return nil
+}
+
+// validate は話者と合成パラメータが範囲内かを確認します。合成パラメータの範囲は voicevox.AudioQueryOptions に従います
+func (r *Row) validate() error {
+	if r.SpeakerID != nil && *r.SpeakerID < 0 {
+		return fmt.Errorf("speaker_id must be a non-negative integer, got %d", *r.SpeakerID)
+	}
+	return voicevox.AudioQueryOptions{
+		SpeedScale:      r.SpeedScale,
+		PitchScale:      r.PitchScale,
+		IntonationScale: r.IntonationScale,
+		VolumeScale:     r.VolumeScale,
+	}.Validate()
+}
+
+// cleanOutput は出力ファイル名を出力ディレクトリ内の相対パス（/ 区切り）にします
+func cleanOutput(name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid output %q (expected a file name in the output directory)", name)
+	}
+	if clean == ManifestName {
+		return "", errors.New("output must not overwrite the manifest")
+	}
+	return filepath.ToSlash(clean), nil
+}
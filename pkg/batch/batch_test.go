@@ -0,0 +1,135 @@
+package batch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	csvData := "\ufeffid,text,speaker_id,speed_scale,output\n" +
+		"greet,こんにちは,3,1.2,hello\n" +
+		",\n" +
+		"bye,\"さようなら、また明日\",,,\n"
+	jsonl := `# コメント
+{"id":"greet","text":"こんにちは","speaker_id":3,"speed_scale":1.2,"output":"hello"}
+
+{"id":"bye","text":"さようなら、また明日"}
+`
+	yamlList := `- id: greet
+  text: こんにちは
+  speaker_id: 3
+  speed_scale: 1.2
+  output: hello
+- id: bye
+  text: さようなら、また明日
+`
+	yamlDoc := "rows:\n" + strings.ReplaceAll("\n"+yamlList, "\n", "\n  ")
+
+	tests := []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{"csv", csvData, FormatCSV},
+		{"jsonl", jsonl, FormatJSONL},
+		{"yaml list", yamlList, FormatYAML},
+		{"yaml rows", yamlDoc, FormatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Parse([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("Parse() = %d rows, want 2: %+v", len(rows), rows)
+			}
+			r := rows[0]
+			if r.ID != "greet" || r.Text != "こんにちは" || r.Output != "hello" ||
+				r.SpeakerID == nil || *r.SpeakerID != 3 || r.SpeedScale == nil || *r.SpeedScale != 1.2 || r.PitchScale != nil {
+				t.Errorf("row 1 = %+v", r)
+			}
+			if rows[1].ID != "bye" || rows[1].Text != "さようなら、また明日" || rows[1].SpeakerID != nil {
+				t.Errorf("row 2 = %+v", rows[1])
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   string
+	}{
+		{"unknown column", "text,voice\nこんにちは,a\n", FormatCSV, "unknown CSV column"},
+		{"bad number", "text,speed_scale\nこんにちは,fast\n", FormatCSV, "speed_scale must be a number"},
+		{"extra cells", "text\nこんにちは,余分\n", FormatCSV, "more cells than columns"},
+		{"unknown field", `{"text":"こんにちは","voice":"a"}`, FormatJSONL, "line 1"},
+		{"unknown yaml field", "- text: こんにちは\n  voice: a\n", FormatYAML, "invalid YAML"},
+		{"yaml scalar", "こんにちは", FormatYAML, "list of rows"},
+		{"empty", "text\n", FormatCSV, "no rows"},
+		{"format", "text\n", "xml", "unsupported batch format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for path, want := range map[string]string{"a.csv": FormatCSV, "a.JSONL": FormatJSONL, "a.ndjson": FormatJSONL, "a.yml": FormatYAML} {
+		if got, err := DetectFormat(path); err != nil || got != want {
+			t.Errorf("DetectFormat(%q) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+	if _, err := DetectFormat("a.txt"); err == nil {
+		t.Error("DetectFormat(a.txt) error = nil, want error")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	rows := []Row{
+		{Text: "一"},
+		{ID: " intro ", Text: "二", Output: "chapter1/intro"},
+		{ID: "three", Text: "三", Output: "three.mp3"},
+	}
+	if err := Normalize(rows, ".wav"); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	want := []struct{ id, output string }{{"0001", "0001.wav"}, {"intro", "chapter1/intro.wav"}, {"three", "three.mp3"}}
+	for i, w := range want {
+		if rows[i].ID != w.id || rows[i].Output != w.output {
+			t.Errorf("row %d = %q %q, want %q %q", i, rows[i].ID, rows[i].Output, w.id, w.output)
+		}
+	}
+
+	fast, negative := 3.0, -1
+	tests := []struct {
+		name string
+		rows []Row
+		want string
+	}{
+		{"no text", []Row{{Text: " "}}, "text is required"},
+		{"duplicate id", []Row{{ID: "a", Text: "一"}, {ID: "a", Text: "二", Output: "b"}}, "same id"},
+		{"duplicate output", []Row{{ID: "a", Text: "一"}, {ID: "b", Text: "二", Output: "a.wav"}}, "same output"},
+		{"parent", []Row{{Text: "一", Output: "../a.wav"}}, "invalid output"},
+		{"absolute", []Row{{Text: "一", Output: "/tmp/a.wav"}}, "invalid output"},
+		{"manifest", []Row{{Text: "一", Output: ManifestName}}, "manifest"},
+		{"speed", []Row{{Text: "一", SpeedScale: &fast}}, "speed_scale must be between 0.5 and 2"},
+		{"speaker id", []Row{{Text: "一", SpeakerID: &negative}}, "speaker_id must be a non-negative integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Normalize(tt.rows, ".wav")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Normalize() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// ManifestName は出力ディレクトリに書くマニフェストのファイル名です
+	ManifestName = "manifest.json"
+	// DefaultConcurrency は同時に合成する行の数の既定値です
+	DefaultConcurrency = 2
+	// MaxConcurrency は同時に合成する行の数の上限です
+	MaxConcurrency = 16
+)
+
+// 行の状態です
+const (
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Entry はマニフェストに記録する1行の結果です
+type Entry struct {
+	ID     string `json:"id"`
+	Text   string `json:"text"`
+	Output string `json:"output"`
+	// Status は done（合成した）、failed（失敗した）、skipped（前回の結果を使った）のいずれかです。
+	// マニフェストのファイルには done と failed だけを書きます
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Hash は行と設定から求めた値で、前回と同じ場合はやり直しのときに合成を省きます
+	Hash string `json:"hash"`
+	// Bytes と Duration は出力したファイルの大きさと音声の長さ（秒）です
+	Bytes    int     `json:"bytes,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// Manifest は出力ディレクトリの全ての行の結果です
+type Manifest struct {
+	Updated time.Time `json:"updated"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Result は1行を合成した結果です。Data は出力形式に変換済みの音声です
+type Result struct {
+	Data     []byte
+	Duration float64
+}
+
+// SynthesizeFunc は1行を合成します
+type SynthesizeFunc func(ctx context.Context, row Row) (Result, error)
+
+// Options はバッチの実行方法です
+type Options struct {
+	// Dir は音声とマニフェストを書くディレクトリです
+	Dir string
+	// Concurrency は同時に合成する行の数です。0 の場合は DefaultConcurrency です
+	Concurrency int
+	// Settings は行以外に音声を左右する設定（出力形式やデフォルトの話速など）です。変わった場合は全ての行を合成し直します
+	Settings interface{}
+	// Force が true の場合、前回の結果を使わずに全ての行を合成します
+	Force bool
+	// Progress は行が終わるたびに呼ばれます
+	Progress func(entry Entry, finished, total int)
+}
+
+// Summary はバッチの結果の集計です
+type Summary struct {
+	Dir      string  `json:"dir"`
+	Manifest string  `json:"manifest"`
+	Total    int     `json:"total"`
+	Done     int     `json:"done"`
+	Skipped  int     `json:"skipped"`
+	Failed   int     `json:"failed"`
+	Entries  []Entry `json:"entries"`
+}
+
+// Run は行を合成して出力ディレクトリに書き、マニフェストを更新します。
+// 前回のマニフェストで done になっていて、行と設定が同じで、ファイルが残っている行は合成せずに skipped にします。
+// 行ごとの失敗はマニフェストに記録して続け、ディレクトリやマニフェストを書けない場合だけエラーを返します
+func Run(ctx context.Context, rows []Row, opts Options, synthesize SynthesizeFunc) (*Summary, error) {
+	if opts.Concurrency == 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.Concurrency < 1 || opts.Concurrency > MaxConcurrency {
+		return nil, fmt.Errorf("concurrency must be between 1 and %d, got %d", MaxConcurrency, opts.Concurrency)
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	previous, err := LoadManifest(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]Entry, len(previous.Entries))
+	for _, e := range previous.Entries {
+		if e.Status == StatusDone {
+			done[e.ID] = e
+		}
+	}
+
+	entries := make([]Entry, len(rows))
+	var pending []int
+	for i, row := range rows {
+		hash, err := rowHash(row, opts.Settings)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = Entry{ID: row.ID, Text: row.Text, Output: row.Output, Hash: hash}
+		if prev, ok := done[row.ID]; ok && !opts.Force && prev.Hash == hash && prev.Output == row.Output && fileExists(filepath.Join(opts.Dir, filepath.FromSlash(row.Output))) {
+			prev.Status = StatusSkipped
+			entries[i] = prev
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	run := &runner{dir: opts.Dir, entries: entries, progress: opts.Progress, total: len(rows), finished: len(rows) - len(pending)}
+	if err := run.writeManifest(); err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Concurrency, len(pending)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run.finish(i, run.render(ctx, rows[i], synthesize))
+			}
+		}()
+	}
+dispatch:
+	for _, i := range pending {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := run.writeManifest(); err != nil {
+		return nil, err
+	}
+	if err := run.err(); err != nil {
+		return nil, err
+	}
+	return run.summary(), ctx.Err()
+}
+
+// runner はバッチの実行中の状態です
+type runner struct {
+	dir      string
+	progress func(entry Entry, finished, total int)
+
+	mu       sync.Mutex
+	entries  []Entry
+	total    int
+	finished int
+	writeErr error
+}
+
+// render は1行を合成してファイルに書き、結果を返します
+func (r *runner) render(ctx context.Context, row Row, synthesize SynthesizeFunc) Entry {
+	entry := Entry{ID: row.ID, Text: row.Text, Output: row.Output}
+	result, err := synthesize(ctx, row)
+	if err == nil {
+		err = writeFile(filepath.Join(r.dir, filepath.FromSlash(row.Output)), result.Data)
+	}
+	if err != nil {
+		entry.Status = StatusFailed
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Status = StatusDone
+	entry.Bytes = len(result.Data)
+	entry.Duration = result.Duration
+	return entry
+}
+
+// finish は行の結果を記録し、マニフェストを書き直します。途中で止まった場合も終わった行はやり直さずに済みます
+func (r *runner) finish(i int, entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.Hash = r.entries[i].Hash
+	r.entries[i] = entry
+	r.finished++
+	if err := r.writeManifestLocked(); err != nil && r.writeErr == nil {
+		r.writeErr = err
+	}
+	if r.progress != nil {
+		r.progress(entry, r.finished, r.total)
+	}
+}
+
+// writeManifest はマニフェストを書きます
+func (r *runner) writeManifest() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writeManifestLocked()
+}
+
+// writeManifestLocked はマニフェストを書きます。skipped の行は前回の done のまま、まだ合成していない行は書きません
+func (r *runner) writeManifestLocked() error {
+	manifest := Manifest{Updated: time.Now().UTC().Truncate(time.Second)}
+	for _, e := range r.entries {
+		switch e.Status {
+		case StatusSkipped:
+			e.Status = StatusDone
+		case "":
+			continue
+		}
+		manifest.Entries = append(manifest.Entries, e)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(r.dir, ManifestName), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// err は実行中にマニフェストを書けなかった場合のエラーを返します
+func (r *runner) err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writeErr
+}
+
+// summary は結果を集計します
+func (r *runner) summary() *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := &Summary{Dir: r.dir, Manifest: filepath.Join(r.dir, ManifestName), Total: len(r.entries), Entries: append([]Entry{}, r.entries...)}
+	for _, e := range r.entries {
+		switch e.Status {
+		case StatusDone:
+			s.Done++
+		case StatusSkipped:
+			s.Skipped++
+		case StatusFailed:
+			s.Failed++
+		}
+	}
+	return s
+}
+
+// LoadManifest は出力ディレクトリのマニフェストを読み込みます。ない場合は空のマニフェストを返します
+func LoadManifest(dir string) (Manifest, error) {
+	var manifest Manifest
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid manifest %s: %w", filepath.Join(dir, ManifestName), err)
+	}
+	return manifest, nil
+}
+
+// rowHash は行と設定から、前回と同じ音声になるかを判定する値を求めます
+func rowHash(row Row, settings interface{}) (string, error) {
+	row.ID = ""
+	data, err := json.Marshal(struct {
+		Row      Row         `json:"row"`
+		Settings interface{} `json:"settings"`
+	}{row, settings})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash row: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeFile はファイルを一時ファイルに書いてから置き換えます。途中で止まっても壊れたファイルを残しません
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".batch-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// fileExists はファイルがあるかを返します
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,198 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSynthesizer は行のテキストをそのまま音声のデータとして返します
+type fakeSynthesizer struct {
+	mu      sync.Mutex
+	calls   []string
+	fail    map[string]bool
+	active  int32
+	maxSeen int32
+}
+
+func (f *fakeSynthesizer) synthesize(ctx context.Context, row Row) (Result, error) {
+	n := atomic.AddInt32(&f.active, 1)
+	defer atomic.AddInt32(&f.active, -1)
+	for {
+		seen := atomic.LoadInt32(&f.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&f.maxSeen, seen, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, row.ID)
+	f.mu.Unlock()
+	if f.fail[row.ID] {
+		return Result{}, errors.New("engine error")
+	}
+	return Result{Data: []byte(row.Text), Duration: 1.5}, nil
+}
+
+func (f *fakeSynthesizer) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func testRows(t *testing.T) []Row {
+	t.Helper()
+	rows := []Row{
+		{ID: "a", Text: "一"},
+		{ID: "b", Text: "二"},
+		{ID: "c", Text: "三", Output: "sub/c"},
+		{ID: "d", Text: "四"},
+		{ID: "e", Text: "五"},
+	}
+	if err := Normalize(rows, ".wav"); err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeSynthesizer{}
+	var progress int32
+	opts := Options{
+		Dir:         dir,
+		Concurrency: 2,
+		Settings:    map[string]string{"format": "wav"},
+		Progress:    func(Entry, int, int) { atomic.AddInt32(&progress, 1) },
+	}
+
+	summary, err := Run(context.Background(), testRows(t), opts, fake.synthesize)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Total != 5 || summary.Done != 5 || summary.Skipped != 0 || summary.Failed != 0 {
+		t.Errorf("Run() summary = %+v", summary)
+	}
+	if fake.maxSeen > 2 {
+		t.Errorf("Run() synthesized %d rows at once, want at most 2", fake.maxSeen)
+	}
+	if progress != 5 {
+		t.Errorf("Progress called %d times, want 5", progress)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "sub", "c.wav")); err != nil || string(data) != "三" {
+		t.Errorf("sub/c.wav = %q, %v", data, err)
+	}
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Entries) != 5 || manifest.Entries[0].ID != "a" || manifest.Entries[0].Status != StatusDone ||
+		manifest.Entries[0].Bytes != len("一") || manifest.Entries[0].Duration != 1.5 || manifest.Entries[0].Hash == "" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+
+	// 2回目は全ての行を飛ばす
+	fake.reset()
+	summary, err = Run(context.Background(), testRows(t), opts, fake.synthesize)
+	if err != nil {
+		t.Fatalf("Run() again error = %v", err)
+	}
+	if summary.Skipped != 5 || len(fake.calls) != 0 {
+		t.Errorf("Run() again summary = %+v, calls = %v", summary, fake.calls)
+	}
+	if manifest, _ := LoadManifest(dir); len(manifest.Entries) != 5 || manifest.Entries[0].Status != StatusDone {
+		t.Errorf("manifest after skip = %+v", manifest)
+	}
+
+	// 変わった行と消えたファイルの行だけ合成し直す
+	fake.reset()
+	rows := testRows(t)
+	rows[1].Text = "二番"
+	os.Remove(filepath.Join(dir, "d.wav"))
+	summary, err = Run(context.Background(), rows, opts, fake.synthesize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Done != 2 || summary.Skipped != 3 || len(fake.calls) != 2 {
+		t.Errorf("Run() changed summary = %+v, calls = %v", summary, fake.calls)
+	}
+
+	// 設定が変わった場合と Force の場合は全て合成し直す
+	for _, o := range []Options{
+		{Dir: dir, Settings: map[string]string{"format": "mp3"}},
+		{Dir: dir, Settings: map[string]string{"format": "mp3"}, Force: true},
+	} {
+		fake.reset()
+		rows := testRows(t)
+		rows[1].Text = "二番"
+		if summary, err = Run(context.Background(), rows, o, fake.synthesize); err != nil {
+			t.Fatal(err)
+		}
+		if summary.Done != 5 || len(fake.calls) != 5 {
+			t.Errorf("Run(%+v) summary = %+v, calls = %v", o, summary, fake.calls)
+		}
+	}
+}
+
+func TestRun_Failure(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeSynthesizer{fail: map[string]bool{"b": true}}
+	opts := Options{Dir: dir, Concurrency: 1}
+
+	summary, err := Run(context.Background(), testRows(t), opts, fake.synthesize)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Done != 4 || summary.Failed != 1 || summary.Entries[1].Error != "engine error" {
+		t.Errorf("Run() summary = %+v", summary)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.wav")); !os.IsNotExist(err) {
+		t.Errorf("failed row wrote a file: %v", err)
+	}
+
+	// やり直すと失敗した行だけ合成する
+	fake.reset()
+	fake.fail = nil
+	summary, err = Run(context.Background(), testRows(t), opts, fake.synthesize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Done != 1 || summary.Skipped != 4 || len(fake.calls) != 1 || fake.calls[0] != "b" {
+		t.Errorf("Run() retry summary = %+v, calls = %v", summary, fake.calls)
+	}
+}
+
+func TestRun_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	synthesize := func(ctx context.Context, row Row) (Result, error) {
+		cancel()
+		return Result{Data: []byte(row.Text)}, nil
+	}
+
+	summary, err := Run(ctx, testRows(t), Options{Dir: dir, Concurrency: 1}, synthesize)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if summary == nil || summary.Done == 0 || summary.Done == summary.Total {
+		t.Fatalf("Run() summary = %+v, want a partial run", summary)
+	}
+	manifest, _ := LoadManifest(dir)
+	if len(manifest.Entries) != summary.Done {
+		t.Errorf("manifest has %d entries, want %d", len(manifest.Entries), summary.Done)
+	}
+}
+
+func TestRun_Concurrency(t *testing.T) {
+	for _, c := range []int{-1, MaxConcurrency + 1} {
+		if _, err := Run(context.Background(), testRows(t), Options{Dir: t.TempDir(), Concurrency: c}, nil); err == nil {
+			t.Errorf("Run(concurrency %d) error = nil, want error", c)
+		}
+	}
+}
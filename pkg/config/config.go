@@ -5,6 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
 )
 
 // Config はアプリケーションの設定を管理する構造体です
@@ -15,107 +20,91 @@ type Config struct {
 	// VOICEVOX settings
 	VoicevoxURL    string `json:"voicevox_url"`
 	DefaultSpeaker int    `json:"default_speaker"`
+	// DefaultSpeakerName は名前で指定されたデフォルト話者（例: "ずんだもん ノーマル"）です。
+	// 空でない場合は DefaultSpeaker より優先され、合成時に話者一覧から解決されます
+	DefaultSpeakerName string `json:"default_speaker_name,omitempty"`
+
+	// 起動時に事前初期化するスタイルIDの一覧
+	WarmupStyles []int `json:"warmup_styles"`
 
 	// Audio synthesis settings
 	DefaultSpeedScale      float64 `json:"default_speed_scale"`
 	DefaultPitchScale      float64 `json:"default_pitch_scale"`
 	DefaultIntonationScale float64 `json:"default_intonation_scale"`
 	DefaultVolumeScale     float64 `json:"default_volume_scale"`
+	// DefaultPrePhonemeLength と DefaultPostPhonemeLength は音声の前後の無音の秒数です
+	DefaultPrePhonemeLength  float64 `json:"default_pre_phoneme_length"`
+	DefaultPostPhonemeLength float64 `json:"default_post_phoneme_length"`
+	// DefaultOutputSamplingRate はVOICEVOXが出力する音声のサンプリングレート（Hz）です。0 の場合は話者の既定（通常は24000Hz）です
+	DefaultOutputSamplingRate int `json:"default_output_sampling_rate"`
+	// DefaultOutputStereo が true の場合、VOICEVOXはステレオの音声を出力します
+	DefaultOutputStereo bool `json:"default_output_stereo"`
+
+	// Preprocess は合成前にテキストへ適用する前処理（Markdownの除去など）のルールです
+	Preprocess preprocess.Options `json:"preprocess"`
+	// EnglishDictionary は英単語の読み方を上書きする辞書ファイルのパスです。空の場合は組み込みの辞書だけを使います
+	EnglishDictionary string `json:"english_dictionary"`
+	// EmojiDictionary は絵文字・顔文字の読み方と気分を上書きするファイルのパスです。空の場合は組み込みの表だけを使います
+	EmojiDictionary string `json:"emoji_dictionary"`
 
 	// File settings
 	TempDir string `json:"temp_dir"`
+	// SaveAudio が true の場合、合成した音声を TempDir にWAVファイルとして保存します。
+	// 再生の有無とは独立しており、false でも再生は標準入力経由で行われます
+	SaveAudio bool `json:"save_audio"`
+	// OutputFormat は保存・返却する音声の形式（wav / flac / mulaw / opus / mp3）です。再生には常にWAVを使います
+	OutputFormat string `json:"output_format"`
+	// AssetDir はBGMや効果音のWAVファイルを置くディレクトリです。mix 引数と mix_audio ツールのトラックはこの中から読み込みます
+	AssetDir string `json:"asset_dir,omitempty"`
+	// PostProcess は合成後の音声に適用する後処理（ラウドネスの正規化、リミッター、フェードなど）です
+	PostProcess audio.PostProcessOptions `json:"postprocess"`
 
 	// Audio settings
 	EnablePlayback bool `json:"enable_playback"`
+	// AudioPlayer は再生バックエンド名（auto / afplay / paplay / aplay / mpv / pw-play / ffplay / powershell / null）です
+	AudioPlayer string `json:"audio_player"`
+	// AudioPlayerCommand は再生に使うコマンドテンプレート（例: "mpv --volume={volume} {file}"）です。
+	// 指定した場合は AudioPlayer より優先されます
+	AudioPlayerCommand string `json:"audio_player_command,omitempty"`
+	// AudioSink はリモートの再生先のURL（http(s):// または pulse://host:port）です。
+	// 指定した場合はローカルのプレイヤーの代わりにこの再生先に音声を送ります
+	AudioSink string `json:"audio_sink,omitempty"`
+	// AudioSinkToken は再生先（mcp-voicevox sink など）への認証トークンです
+	AudioSinkToken string `json:"-"`
+	// AudioDevice は再生に使う出力デバイス名です。空の場合は既定のデバイスを使います
+	AudioDevice string `json:"audio_device,omitempty"`
+	// PlaybackVolume は再生時の音量の倍率です。合成パラメータの volume_scale とは独立して適用されます
+	PlaybackVolume float64 `json:"playback_volume"`
 }
 
 // DefaultConfig はデフォルト設定を返します
 func DefaultConfig() *Config {
 	return &Config{
-		Port:                   8080,
-		VoicevoxURL:            "http://localhost:50021",
-		DefaultSpeaker:         3,
-		DefaultSpeedScale:      1.0,
-		DefaultPitchScale:      0.0,
-		DefaultIntonationScale: 1.0,
-		DefaultVolumeScale:     1.0,
-		TempDir:                os.TempDir(),
-		EnablePlayback:         false,
+		Port:                     8080,
+		VoicevoxURL:              "http://localhost:50021",
+		DefaultSpeaker:           3,
+		DefaultSpeedScale:        1.0,
+		DefaultPitchScale:        0.0,
+		DefaultIntonationScale:   1.0,
+		DefaultVolumeScale:       1.0,
+		DefaultPrePhonemeLength:  0.1,
+		DefaultPostPhonemeLength: 0.1,
+		Preprocess:               preprocess.DefaultOptions(),
+		TempDir:                  os.TempDir(),
+		SaveAudio:                true,
+		OutputFormat:             audio.FormatWAV,
+		PostProcess:              audio.DefaultPostProcessOptions(),
+		EnablePlayback:           false,
+		AudioPlayer:              "auto",
+		PlaybackVolume:           1.0,
 	}
 }
 
-// LoadFromEnv は環境変数から設定を読み込みます
+// LoadFromEnv は環境変数（EnvVars）から設定を読み込みます。
+// 合成パラメータと再生音量の範囲外の値はエラーにせず無視します
 func (c *Config) LoadFromEnv() error {
-	if envPort := os.Getenv("MCP_VOICEVOX_PORT"); envPort != "" {
-		if p, err := strconv.Atoi(envPort); err == nil {
-			c.Port = p
-		} else {
-			return fmt.Errorf("invalid port value: %s", envPort)
-		}
-	}
-
-	if envURL := os.Getenv("MCP_VOICEVOX_URL"); envURL != "" {
-		c.VoicevoxURL = envURL
-	}
-
-	if envTempDir := os.Getenv("MCP_VOICEVOX_TEMP_DIR"); envTempDir != "" {
-		c.TempDir = envTempDir
-	}
-
-	if envSpeaker := os.Getenv("MCP_VOICEVOX_DEFAULT_SPEAKER"); envSpeaker != "" {
-		if s, err := strconv.Atoi(envSpeaker); err == nil {
-			c.DefaultSpeaker = s
-		} else {
-			return fmt.Errorf("invalid speaker ID value: %s", envSpeaker)
-		}
-	}
-
-	if envPlayback := os.Getenv("MCP_VOICEVOX_ENABLE_PLAYBACK"); envPlayback != "" {
-		c.EnablePlayback = envPlayback == "true"
-	}
-
-	// 音声合成パラメータの環境変数読み込み
-	if envSpeedScale := os.Getenv("MCP_VOICEVOX_DEFAULT_SPEED_SCALE"); envSpeedScale != "" {
-		if s, err := strconv.ParseFloat(envSpeedScale, 64); err == nil {
-			if s >= 0.5 && s <= 2.0 {
-				c.DefaultSpeedScale = s
-			}
-		} else {
-			return fmt.Errorf("invalid speed scale value: %s", envSpeedScale)
-		}
-	}
-
-	if envPitchScale := os.Getenv("MCP_VOICEVOX_DEFAULT_PITCH_SCALE"); envPitchScale != "" {
-		if p, err := strconv.ParseFloat(envPitchScale, 64); err == nil {
-			if p >= -0.15 && p <= 0.15 {
-				c.DefaultPitchScale = p
-			}
-		} else {
-			return fmt.Errorf("invalid pitch scale value: %s", envPitchScale)
-		}
-	}
-
-	if envIntonationScale := os.Getenv("MCP_VOICEVOX_DEFAULT_INTONATION_SCALE"); envIntonationScale != "" {
-		if i, err := strconv.ParseFloat(envIntonationScale, 64); err == nil {
-			if i >= 0.0 && i <= 2.0 {
-				c.DefaultIntonationScale = i
-			}
-		} else {
-			return fmt.Errorf("invalid intonation scale value: %s", envIntonationScale)
-		}
-	}
-
-	if envVolumeScale := os.Getenv("MCP_VOICEVOX_DEFAULT_VOLUME_SCALE"); envVolumeScale != "" {
-		if v, err := strconv.ParseFloat(envVolumeScale, 64); err == nil {
-			if v >= 0.0 && v <= 2.0 {
-				c.DefaultVolumeScale = v
-			}
-		} else {
-			return fmt.Errorf("invalid volume scale value: %s", envVolumeScale)
-		}
-	}
-
-	return nil
+	_, err := c.LoadEnv()
+	return err
 }
 
 // Validate は設定値の妥当性をチェックします
@@ -132,6 +121,12 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("default speaker ID must be non-negative, got %d", c.DefaultSpeaker)
 	}
 
+	for _, id := range c.WarmupStyles {
+		if id < 0 {
+			return fmt.Errorf("warmup style ID must be non-negative, got %d", id)
+		}
+	}
+
 	if c.TempDir == "" {
 		return fmt.Errorf("temp directory cannot be empty")
 	}
@@ -153,9 +148,50 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("default volume scale must be between 0.0 and 2.0, got %f", c.DefaultVolumeScale)
 	}
 
+	if err := c.AudioQueryOptions().Validate(); err != nil {
+		return fmt.Errorf("invalid default: %w", err)
+	}
+
+	if c.PlaybackVolume <= 0.0 || c.PlaybackVolume > 2.0 {
+		return fmt.Errorf("playback volume must be greater than 0.0 and at most 2.0, got %f", c.PlaybackVolume)
+	}
+
+	if err := c.Preprocess.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := audio.ParseOutputFormat(c.OutputFormat); err != nil {
+		return err
+	}
+
+	if err := c.PostProcess.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// AudioQueryOptions は設定の既定値から音声合成のオプションを作ります。
+// DefaultOutputSamplingRate が 0 の場合、サンプリングレートはVOICEVOXの既定のままにします
+func (c *Config) AudioQueryOptions() *voicevox.AudioQueryOptions {
+	speed, pitch, intonation, volume := c.DefaultSpeedScale, c.DefaultPitchScale, c.DefaultIntonationScale, c.DefaultVolumeScale
+	pre, post, stereo := c.DefaultPrePhonemeLength, c.DefaultPostPhonemeLength, c.DefaultOutputStereo
+	options := &voicevox.AudioQueryOptions{
+		SpeedScale:        &speed,
+		PitchScale:        &pitch,
+		IntonationScale:   &intonation,
+		VolumeScale:       &volume,
+		PrePhonemeLength:  &pre,
+		PostPhonemeLength: &post,
+		OutputStereo:      &stereo,
+	}
+	if c.DefaultOutputSamplingRate != 0 {
+		rate := c.DefaultOutputSamplingRate
+		options.OutputSamplingRate = &rate
+	}
+	return options
+}
+
 // SetupTempDir は一時ディレクトリを設定・作成します
 func (c *Config) SetupTempDir() error {
 	if c.TempDir == os.TempDir() {
@@ -172,6 +208,35 @@ func (c *Config) SetupTempDir() error {
 	return nil
 }
 
+// SetDefaultSpeaker はデフォルト話者を設定します。
+// 数値の場合はスタイルID、それ以外は話者名（例: "四国めたん あまあま"）として扱います
+func (c *Config) SetDefaultSpeaker(value string) {
+	value = strings.TrimSpace(value)
+	if id, err := strconv.Atoi(value); err == nil {
+		c.DefaultSpeaker = id
+		c.DefaultSpeakerName = ""
+		return
+	}
+	c.DefaultSpeakerName = value
+}
+
+// ParseStyleList はカンマ区切りのスタイルID一覧（例: "3,1,8"）を解析します
+func ParseStyleList(value string) ([]int, error) {
+	var styles []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid style ID: %s", part)
+		}
+		styles = append(styles, id)
+	}
+	return styles, nil
+}
+
 // New は新しい設定インスタンスを作成し、環境変数から読み込みます
 func New() (*Config, error) {
 	config := DefaultConfig()
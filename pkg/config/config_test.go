@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -23,6 +24,10 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.EnablePlayback != false {
 		t.Errorf("Expected default playback false, got %t", cfg.EnablePlayback)
 	}
+
+	if cfg.SaveAudio != true {
+		t.Errorf("Expected default save audio true, got %t", cfg.SaveAudio)
+	}
 }
 
 func TestLoadFromEnv(t *testing.T) {
@@ -123,6 +128,15 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "zero playback volume",
+			config: func() *Config {
+				c := DefaultConfig()
+				c.PlaybackVolume = 0
+				return c
+			}(),
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,3 +164,272 @@ func TestNew(t *testing.T) {
 		t.Errorf("Expected default port 8080, got %d", cfg.Port)
 	}
 }
+
+func TestParseStyleList(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []int
+		wantErr bool
+	}{
+		{"single", "3", []int{3}, false},
+		{"multiple with spaces", "3, 1 ,8", []int{3, 1, 8}, false},
+		{"trailing comma", "3,", []int{3}, false},
+		{"not a number", "3,zundamon", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStyleList(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStyleList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseStyleList() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseStyleList() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFromEnv_SaveAudio(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_SAVE_AUDIO", "false")
+	os.Setenv("MCP_VOICEVOX_ENABLE_PLAYBACK", "true")
+	defer func() {
+		os.Unsetenv("MCP_VOICEVOX_SAVE_AUDIO")
+		os.Unsetenv("MCP_VOICEVOX_ENABLE_PLAYBACK")
+	}()
+
+	cfg := DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+
+	// 保存と再生は独立して設定できる
+	if cfg.SaveAudio || !cfg.EnablePlayback {
+		t.Errorf("Expected save audio false and playback true, got %t and %t", cfg.SaveAudio, cfg.EnablePlayback)
+	}
+}
+
+func TestLoadFromEnv_PlaybackDevice(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_AUDIO_DEVICE", "alsa_output.usb")
+	os.Setenv("MCP_VOICEVOX_PLAYBACK_VOLUME", "0.5")
+	defer func() {
+		os.Unsetenv("MCP_VOICEVOX_AUDIO_DEVICE")
+		os.Unsetenv("MCP_VOICEVOX_PLAYBACK_VOLUME")
+	}()
+
+	cfg := DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+
+	if cfg.AudioDevice != "alsa_output.usb" {
+		t.Errorf("Expected audio device alsa_output.usb, got %s", cfg.AudioDevice)
+	}
+	// 再生音量は合成の音量（volume_scale）とは独立
+	if cfg.PlaybackVolume != 0.5 || cfg.DefaultVolumeScale != 1.0 {
+		t.Errorf("Expected playback volume 0.5 and volume scale 1.0, got %f and %f", cfg.PlaybackVolume, cfg.DefaultVolumeScale)
+	}
+}
+
+func TestLoadFromEnv_Preprocess(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_PREPROCESS", "code_blocks=skip,english=false,emoji=style")
+	os.Setenv("MCP_VOICEVOX_ENGLISH_DICTIONARY", "/etc/voicevox/team.tsv")
+	os.Setenv("MCP_VOICEVOX_EMOJI_DICTIONARY", "/etc/voicevox/emoji.tsv")
+	defer func() {
+		os.Unsetenv("MCP_VOICEVOX_PREPROCESS")
+		os.Unsetenv("MCP_VOICEVOX_ENGLISH_DICTIONARY")
+		os.Unsetenv("MCP_VOICEVOX_EMOJI_DICTIONARY")
+	}()
+
+	cfg := DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+
+	if cfg.Preprocess.CodeBlocks != "skip" || cfg.Preprocess.English || !cfg.Preprocess.Normalize || cfg.Preprocess.Emoji != "style" {
+		t.Errorf("Unexpected preprocess options: %+v", cfg.Preprocess)
+	}
+	if cfg.EnglishDictionary != "/etc/voicevox/team.tsv" {
+		t.Errorf("Expected english dictionary /etc/voicevox/team.tsv, got %s", cfg.EnglishDictionary)
+	}
+	if cfg.EmojiDictionary != "/etc/voicevox/emoji.tsv" {
+		t.Errorf("Expected emoji dictionary /etc/voicevox/emoji.tsv, got %s", cfg.EmojiDictionary)
+	}
+
+	os.Setenv("MCP_VOICEVOX_PREPROCESS", "code_blocks=maybe")
+	if err := DefaultConfig().LoadFromEnv(); err == nil {
+		t.Error("Expected error for invalid preprocess rule")
+	}
+}
+
+func TestLoadFromEnv_OutputFormat(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_OUTPUT_FORMAT", "flac")
+	defer os.Unsetenv("MCP_VOICEVOX_OUTPUT_FORMAT")
+
+	cfg := DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+	if cfg.OutputFormat != "flac" {
+		t.Errorf("Expected output format flac, got %s", cfg.OutputFormat)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	cfg.OutputFormat = "aac"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown output format")
+	}
+}
+
+func TestLoadFromEnv_PostProcess(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_POSTPROCESS", "loudness=-16,limit=-1,trim=true")
+	defer os.Unsetenv("MCP_VOICEVOX_POSTPROCESS")
+
+	cfg := DefaultConfig()
+	if cfg.PostProcess.Active() {
+		t.Errorf("Expected no post-processing by default, got %+v", cfg.PostProcess)
+	}
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+	if cfg.PostProcess.Loudness != -16 || !cfg.PostProcess.Limiter || cfg.PostProcess.PeakLimit != -1 || !cfg.PostProcess.Trim {
+		t.Errorf("Unexpected post-processing %+v", cfg.PostProcess)
+	}
+
+	os.Setenv("MCP_VOICEVOX_POSTPROCESS", "loudness=3")
+	if err := DefaultConfig().LoadFromEnv(); err == nil {
+		t.Error("Expected error for out-of-range loudness")
+	}
+}
+
+func TestLoadFromEnv_AudioOutput(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_DEFAULT_OUTPUT_SAMPLING_RATE", "16000")
+	os.Setenv("MCP_VOICEVOX_DEFAULT_OUTPUT_STEREO", "true")
+	os.Setenv("MCP_VOICEVOX_DEFAULT_PRE_PHONEME_LENGTH", "0")
+	os.Setenv("MCP_VOICEVOX_DEFAULT_POST_PHONEME_LENGTH", "0.5")
+	defer func() {
+		os.Unsetenv("MCP_VOICEVOX_DEFAULT_OUTPUT_SAMPLING_RATE")
+		os.Unsetenv("MCP_VOICEVOX_DEFAULT_OUTPUT_STEREO")
+		os.Unsetenv("MCP_VOICEVOX_DEFAULT_PRE_PHONEME_LENGTH")
+		os.Unsetenv("MCP_VOICEVOX_DEFAULT_POST_PHONEME_LENGTH")
+	}()
+
+	cfg := DefaultConfig()
+	if options := cfg.AudioQueryOptions(); options.OutputSamplingRate != nil || *options.OutputStereo || *options.PrePhonemeLength != 0.1 {
+		t.Errorf("Unexpected default options %+v", options)
+	}
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	options := cfg.AudioQueryOptions()
+	if *options.OutputSamplingRate != 16000 || !*options.OutputStereo || *options.PrePhonemeLength != 0 || *options.PostPhonemeLength != 0.5 {
+		t.Errorf("Unexpected options %+v", options)
+	}
+
+	cfg.DefaultOutputSamplingRate = 1000
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for out-of-range output sampling rate")
+	}
+	cfg.DefaultOutputSamplingRate = 48000
+	cfg.DefaultPostPhonemeLength = 2
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for out-of-range post phoneme length")
+	}
+
+	os.Setenv("MCP_VOICEVOX_DEFAULT_OUTPUT_SAMPLING_RATE", "fast")
+	if err := DefaultConfig().LoadFromEnv(); err == nil {
+		t.Error("Expected error for non-numeric output sampling rate")
+	}
+}
+
+func TestLoadFromEnv_WarmupStyles(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_WARMUP_STYLES", "3,1")
+	defer os.Unsetenv("MCP_VOICEVOX_WARMUP_STYLES")
+
+	cfg := DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+
+	if len(cfg.WarmupStyles) != 2 || cfg.WarmupStyles[0] != 3 || cfg.WarmupStyles[1] != 1 {
+		t.Errorf("Expected warmup styles [3 1], got %v", cfg.WarmupStyles)
+	}
+}
+
+func TestSetDefaultSpeaker(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		wantID   int
+		wantName string
+	}{
+		{"numeric ID", "8", 8, ""},
+		{"numeric ID with spaces", " 2 ", 2, ""},
+		{"character and style name", "ずんだもん ノーマル", 3, "ずんだもん ノーマル"},
+		{"character name only", "四国めたん", 3, "四国めたん"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.SetDefaultSpeaker(tt.value)
+			if cfg.DefaultSpeaker != tt.wantID {
+				t.Errorf("DefaultSpeaker = %d, want %d", cfg.DefaultSpeaker, tt.wantID)
+			}
+			if cfg.DefaultSpeakerName != tt.wantName {
+				t.Errorf("DefaultSpeakerName = %q, want %q", cfg.DefaultSpeakerName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	os.Setenv("MCP_VOICEVOX_URL", "http://test:50021")
+	os.Setenv("MCP_VOICEVOX_DEFAULT_SPEED_SCALE", "3")
+	os.Setenv("MCP_VOICEVOX_PLAYBACK_VOLUME", "0")
+	defer func() {
+		os.Unsetenv("MCP_VOICEVOX_URL")
+		os.Unsetenv("MCP_VOICEVOX_DEFAULT_SPEED_SCALE")
+		os.Unsetenv("MCP_VOICEVOX_PLAYBACK_VOLUME")
+	}()
+
+	cfg := DefaultConfig()
+	result, err := cfg.LoadEnv()
+	if err != nil {
+		t.Fatalf("LoadEnv failed: %v", err)
+	}
+	if !result.Applied["MCP_VOICEVOX_URL"] || len(result.Applied) != 1 {
+		t.Errorf("Applied = %v, want only MCP_VOICEVOX_URL", result.Applied)
+	}
+	// 範囲外の値は無視して既定値のまま
+	if result.Ignored["MCP_VOICEVOX_DEFAULT_SPEED_SCALE"] == nil || result.Ignored["MCP_VOICEVOX_PLAYBACK_VOLUME"] == nil {
+		t.Errorf("Ignored = %v, want speed scale and playback volume", result.Ignored)
+	}
+	if cfg.DefaultSpeedScale != 1.0 || cfg.PlaybackVolume != 1.0 {
+		t.Errorf("Expected default speed scale and playback volume, got %f and %f", cfg.DefaultSpeedScale, cfg.PlaybackVolume)
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	names := map[string]bool{}
+	for _, v := range EnvVars {
+		if names[v.Name] || !strings.HasPrefix(v.Name, "MCP_VOICEVOX_") || v.Setting == "" {
+			t.Errorf("invalid or duplicate env var %+v", v)
+		}
+		names[v.Name] = true
+	}
+	if v, ok := LookupEnvVar("default_speaker"); !ok || v.Name != "MCP_VOICEVOX_DEFAULT_SPEAKER" {
+		t.Errorf("LookupEnvVar(default_speaker) = %+v, %t", v, ok)
+	}
+}
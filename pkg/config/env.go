@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// EnvVar は設定を上書きする環境変数です
+type EnvVar struct {
+	// Name は環境変数の名前です
+	Name string
+	// Setting は上書きする設定の名前（Config のJSONの名前）です
+	Setting string
+	// apply は値を設定に反映します。範囲外のため使わない値には *ignoredValue を返します
+	apply func(c *Config, value string) error
+}
+
+// EnvResult は環境変数を読み込んだ結果です
+type EnvResult struct {
+	// Applied は設定に反映した環境変数の名前です
+	Applied map[string]bool
+	// Ignored は値が範囲外のため反映しなかった環境変数の名前と、その理由です
+	Ignored map[string]error
+}
+
+// ignoredValue はエラーにせず無視する環境変数の値です
+type ignoredValue struct {
+	err error
+}
+
+func (e *ignoredValue) Error() string {
+	return e.err.Error()
+}
+
+// EnvVars は LoadFromEnv が読む環境変数の一覧です。この順に読み込みます
+var EnvVars = []EnvVar{
+	{"MCP_VOICEVOX_PORT", "port", func(c *Config, v string) error {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid port value: %s", v)
+		}
+		c.Port = p
+		return nil
+	}},
+	{"MCP_VOICEVOX_URL", "voicevox_url", func(c *Config, v string) error {
+		c.VoicevoxURL = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_TEMP_DIR", "temp_dir", func(c *Config, v string) error {
+		c.TempDir = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_SAVE_AUDIO", "save_audio", func(c *Config, v string) error {
+		c.SaveAudio = v == "true"
+		return nil
+	}},
+	{"MCP_VOICEVOX_OUTPUT_FORMAT", "output_format", func(c *Config, v string) error {
+		c.OutputFormat = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_DEFAULT_SPEAKER", "default_speaker", func(c *Config, v string) error {
+		c.SetDefaultSpeaker(v)
+		return nil
+	}},
+	{"MCP_VOICEVOX_WARMUP_STYLES", "warmup_styles", func(c *Config, v string) error {
+		styles, err := ParseStyleList(v)
+		if err != nil {
+			return fmt.Errorf("invalid warmup styles value: %s", v)
+		}
+		c.WarmupStyles = styles
+		return nil
+	}},
+	{"MCP_VOICEVOX_ENABLE_PLAYBACK", "enable_playback", func(c *Config, v string) error {
+		c.EnablePlayback = v == "true"
+		return nil
+	}},
+	{"MCP_VOICEVOX_AUDIO_PLAYER", "audio_player", func(c *Config, v string) error {
+		c.AudioPlayer = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_AUDIO_PLAYER_COMMAND", "audio_player_command", func(c *Config, v string) error {
+		c.AudioPlayerCommand = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_AUDIO_SINK", "audio_sink", func(c *Config, v string) error {
+		c.AudioSink = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_AUDIO_SINK_TOKEN", "audio_sink_token", func(c *Config, v string) error {
+		c.AudioSinkToken = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_AUDIO_DEVICE", "audio_device", func(c *Config, v string) error {
+		c.AudioDevice = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_PLAYBACK_VOLUME", "playback_volume", func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid playback volume value: %s", v)
+		}
+		if f <= 0.0 || f > 2.0 {
+			return &ignoredValue{fmt.Errorf("playback volume must be greater than 0.0 and at most 2.0, got %v", f)}
+		}
+		c.PlaybackVolume = f
+		return nil
+	}},
+	// 音声合成パラメータの範囲外の値は無視して既定値を使う
+	{"MCP_VOICEVOX_DEFAULT_SPEED_SCALE", "default_speed_scale", scaleEnv("speed scale", 0.5, 2.0, func(c *Config) *float64 { return &c.DefaultSpeedScale })},
+	{"MCP_VOICEVOX_DEFAULT_PITCH_SCALE", "default_pitch_scale", scaleEnv("pitch scale", -0.15, 0.15, func(c *Config) *float64 { return &c.DefaultPitchScale })},
+	{"MCP_VOICEVOX_DEFAULT_INTONATION_SCALE", "default_intonation_scale", scaleEnv("intonation scale", 0.0, 2.0, func(c *Config) *float64 { return &c.DefaultIntonationScale })},
+	{"MCP_VOICEVOX_DEFAULT_VOLUME_SCALE", "default_volume_scale", scaleEnv("volume scale", 0.0, 2.0, func(c *Config) *float64 { return &c.DefaultVolumeScale })},
+	{"MCP_VOICEVOX_DEFAULT_PRE_PHONEME_LENGTH", "default_pre_phoneme_length", func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid pre phoneme length value: %s", v)
+		}
+		c.DefaultPrePhonemeLength = f
+		return nil
+	}},
+	{"MCP_VOICEVOX_DEFAULT_POST_PHONEME_LENGTH", "default_post_phoneme_length", func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid post phoneme length value: %s", v)
+		}
+		c.DefaultPostPhonemeLength = f
+		return nil
+	}},
+	{"MCP_VOICEVOX_DEFAULT_OUTPUT_SAMPLING_RATE", "default_output_sampling_rate", func(c *Config, v string) error {
+		rate, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid output sampling rate value: %s", v)
+		}
+		c.DefaultOutputSamplingRate = rate
+		return nil
+	}},
+	{"MCP_VOICEVOX_DEFAULT_OUTPUT_STEREO", "default_output_stereo", func(c *Config, v string) error {
+		c.DefaultOutputStereo = v == "true"
+		return nil
+	}},
+	{"MCP_VOICEVOX_ENGLISH_DICTIONARY", "english_dictionary", func(c *Config, v string) error {
+		c.EnglishDictionary = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_EMOJI_DICTIONARY", "emoji_dictionary", func(c *Config, v string) error {
+		c.EmojiDictionary = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_PREPROCESS", "preprocess", func(c *Config, v string) error {
+		if err := c.Preprocess.Apply(v); err != nil {
+			return fmt.Errorf("invalid preprocess value: %w", err)
+		}
+		return nil
+	}},
+	{"MCP_VOICEVOX_ASSET_DIR", "asset_dir", func(c *Config, v string) error {
+		c.AssetDir = v
+		return nil
+	}},
+	{"MCP_VOICEVOX_POSTPROCESS", "postprocess", func(c *Config, v string) error {
+		if err := c.PostProcess.Apply(v); err != nil {
+			return fmt.Errorf("invalid postprocess value: %w", err)
+		}
+		return nil
+	}},
+}
+
+// scaleEnv は min 以上 max 以下の合成パラメータを読み込みます。範囲外の値は無視します
+func scaleEnv(name string, min, max float64, field func(c *Config) *float64) func(c *Config, value string) error {
+	return func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s value: %s", name, v)
+		}
+		if f < min || f > max {
+			return &ignoredValue{fmt.Errorf("%s must be between %v and %v, got %v", name, min, max, f)}
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+// LookupEnvVar は設定の名前（Config のJSONの名前）を上書きする環境変数を返します
+func LookupEnvVar(setting string) (EnvVar, bool) {
+	for _, v := range EnvVars {
+		if v.Setting == setting {
+			return v, true
+		}
+	}
+	return EnvVar{}, false
+}
+
+// LoadEnv は環境変数から設定を読み込み、反映した環境変数と範囲外のため無視した環境変数を返します。
+// 空の環境変数は読みません。読めない値があった場合は、それまでに読んだ結果とエラーを返します
+func (c *Config) LoadEnv() (EnvResult, error) {
+	result := EnvResult{Applied: map[string]bool{}, Ignored: map[string]error{}}
+	for _, v := range EnvVars {
+		value := os.Getenv(v.Name)
+		if value == "" {
+			continue
+		}
+		if err := v.apply(c, value); err != nil {
+			var ignored *ignoredValue
+			if errors.As(err, &ignored) {
+				result.Ignored[v.Name] = ignored.err
+				continue
+			}
+			return result, err
+		}
+		result.Applied[v.Name] = true
+	}
+	return result, nil
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+)
 
 // ErrorCode はアプリケーション固有のエラーコードを定義します
 type ErrorCode int
@@ -19,6 +22,12 @@ const (
 	AudioPlaybackError      ErrorCode = -40003 // 音声再生エラー
 	FileOperationError      ErrorCode = -40004 // ファイル操作エラー
 	ConfigurationError      ErrorCode = -40005 // 設定エラー
+
+	// VOICEVOXエンジンの応答に応じたエラー
+	VoicevoxTimeoutError     ErrorCode = -40006 // VOICEVOXタイムアウト
+	VoicevoxValidationError  ErrorCode = -40007 // VOICEVOXがリクエストを拒否（400/422）
+	VoicevoxEngineError      ErrorCode = -40008 // VOICEVOXエンジン内部エラー（5xx）
+	VoicevoxUnsupportedError ErrorCode = -40009 // VOICEVOXが未対応のエンドポイント（404/405）
 )
 
 // AppError はアプリケーション固有のエラー型です
@@ -26,6 +35,8 @@ type AppError struct {
 	Code    ErrorCode
 	Message string
 	Cause   error
+	// Data はMCPエラーの data フィールドとしてクライアントに返す構造化情報です
+	Data map[string]interface{}
 }
 
 // Error はerrorインターフェースを実装します
@@ -52,8 +63,9 @@ func NewAppError(code ErrorCode, message string, cause error) *AppError {
 
 // MCPError はMCPプロトコル用のエラー構造体です
 type MCPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
 }
 
 // ToMCPError はAppErrorをMCPErrorに変換します
@@ -61,6 +73,7 @@ func (e *AppError) ToMCPError() *MCPError {
 	return &MCPError{
 		Code:    int(e.Code),
 		Message: e.Message,
+		Data:    e.Data,
 	}
 }
 
@@ -95,3 +108,50 @@ func NewConfigurationError(message string, cause error) *AppError {
 func NewMCPError(code ErrorCode, message string) *AppError {
 	return NewAppError(code, message, nil)
 }
+
+// ClassifiedError は種類と再試行の可否を持つエラーです。VOICEVOXクライアントのエラー型が実装します
+type ClassifiedError interface {
+	error
+	// Kind はエラーの種類（unreachable、timeout など）です
+	Kind() string
+	// Retryable は再試行で成功する可能性があるかです
+	Retryable() bool
+	// ErrorData はMCPエラーの data に含める情報（endpoint、status など）です
+	ErrorData() map[string]interface{}
+}
+
+// voicevoxKindCodes はVOICEVOXクライアントのエラーの種類ごとのエラーコードです
+var voicevoxKindCodes = map[string]ErrorCode{
+	"unreachable":          VoicevoxConnectionError,
+	"timeout":              VoicevoxTimeoutError,
+	"validation":           VoicevoxValidationError,
+	"engine":               VoicevoxEngineError,
+	"unsupported_endpoint": VoicevoxUnsupportedError,
+	"unexpected_status":    VoicevoxEngineError,
+}
+
+// NewVoicevoxAPIError はVOICEVOXクライアントのエラーを種類ごとのエラーコードに変換します。
+// data にはエラーの種類（kind）、再試行の可否（retryable）、エンドポイントなどを格納します
+func NewVoicevoxAPIError(message string, cause error) *AppError {
+	var classified ClassifiedError
+	if !stderrors.As(cause, &classified) {
+		return NewVoicevoxError(message, cause)
+	}
+	code, ok := voicevoxKindCodes[classified.Kind()]
+	if !ok {
+		return NewVoicevoxError(message, cause)
+	}
+
+	data := map[string]interface{}{
+		"error": cause.Error(),
+	}
+	for key, value := range classified.ErrorData() {
+		data[key] = value
+	}
+	data["kind"] = classified.Kind()
+	data["retryable"] = classified.Retryable()
+
+	appErr := NewAppError(code, fmt.Sprintf("%s: %v", message, cause), cause)
+	appErr.Data = data
+	return appErr
+}
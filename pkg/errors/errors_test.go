@@ -2,6 +2,7 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -145,3 +146,96 @@ func TestNewMCPError(t *testing.T) {
 		t.Errorf("NewMCPError().Cause = %v, want nil", appErr.Cause)
 	}
 }
+
+// classifiedError は ClassifiedError を実装するテスト用のエラーです
+type classifiedError struct {
+	kind      string
+	retryable bool
+}
+
+func (e *classifiedError) Error() string {
+	return e.kind + " error"
+}
+
+func (e *classifiedError) Kind() string {
+	return e.kind
+}
+
+func (e *classifiedError) Retryable() bool {
+	return e.retryable
+}
+
+func (e *classifiedError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{"endpoint": "/audio_query"}
+}
+
+func TestNewVoicevoxAPIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		retryable bool
+		wantCode  ErrorCode
+	}{
+		{"unreachable", "unreachable", true, VoicevoxConnectionError},
+		{"timeout", "timeout", true, VoicevoxTimeoutError},
+		{"validation", "validation", false, VoicevoxValidationError},
+		{"engine fault", "engine", true, VoicevoxEngineError},
+		{"unsupported endpoint", "unsupported_endpoint", false, VoicevoxUnsupportedError},
+		{"unexpected status", "unexpected_status", false, VoicevoxEngineError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cause := &classifiedError{kind: tt.kind, retryable: tt.retryable}
+			appErr := NewVoicevoxAPIError("request failed", cause)
+			if appErr.Code != tt.wantCode {
+				t.Errorf("Code = %v, want %v", appErr.Code, tt.wantCode)
+			}
+			if appErr.Data["kind"] != tt.kind {
+				t.Errorf("Data[kind] = %v, want %v", appErr.Data["kind"], tt.kind)
+			}
+			if appErr.Data["retryable"] != tt.retryable {
+				t.Errorf("Data[retryable] = %v, want %v", appErr.Data["retryable"], tt.retryable)
+			}
+			if appErr.Data["endpoint"] != "/audio_query" {
+				t.Errorf("Data[endpoint] = %v, want /audio_query", appErr.Data["endpoint"])
+			}
+			if !errors.Is(appErr, cause) {
+				t.Error("cause is not wrapped")
+			}
+
+			mcpErr := appErr.ToMCPError()
+			if mcpErr.Code != int(tt.wantCode) || mcpErr.Data["kind"] != tt.kind {
+				t.Errorf("ToMCPError() = %+v", mcpErr)
+			}
+		})
+	}
+}
+
+func TestNewVoicevoxAPIError_Wrapped(t *testing.T) {
+	cause := fmt.Errorf("synthesis failed: %w", &classifiedError{kind: "timeout", retryable: true})
+	appErr := NewVoicevoxAPIError("request failed", cause)
+	if appErr.Code != VoicevoxTimeoutError {
+		t.Errorf("Code = %v, want %v", appErr.Code, VoicevoxTimeoutError)
+	}
+}
+
+func TestNewVoicevoxAPIError_Unclassified(t *testing.T) {
+	appErr := NewVoicevoxAPIError("request failed", errors.New("unexpected EOF"))
+	if appErr.Code != VoicevoxConnectionError {
+		t.Errorf("Code = %v, want %v", appErr.Code, VoicevoxConnectionError)
+	}
+	if appErr.Data != nil {
+		t.Errorf("Data = %v, want nil", appErr.Data)
+	}
+}
+
+func TestNewVoicevoxAPIError_UnknownKind(t *testing.T) {
+	appErr := NewVoicevoxAPIError("request failed", &classifiedError{kind: "unknown"})
+	if appErr.Code != VoicevoxConnectionError {
+		t.Errorf("Code = %v, want %v", appErr.Code, VoicevoxConnectionError)
+	}
+	if appErr.Data != nil {
+		t.Errorf("Data = %v, want nil", appErr.Data)
+	}
+}
@@ -0,0 +1,460 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/batch"
+	"github.com/metapox/mcp-voicevox-go/pkg/errors"
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+// BatchOptions はバッチ合成の実行方法です
+type BatchOptions struct {
+	// Dir は音声とマニフェストを書くディレクトリです
+	Dir string
+	// Concurrency は同時に合成する行の数です。0 の場合は batch.DefaultConcurrency です
+	Concurrency int
+	// Force が true の場合、前回の結果を使わずに全ての行を合成します
+	Force bool
+	// OutputFormat は保存する音声の形式です。Name が空の場合は設定の出力形式を使います
+	OutputFormat audio.OutputFormat
+	// Progress は行が終わるたびに呼ばれます
+	Progress func(entry batch.Entry, finished, total int)
+}
+
+// batchSettings は行以外に音声を左右する設定です。変わった場合は前回の結果を使わずに合成し直します。
+// エンジンのユーザー辞書の変更は含まないため、辞書を変えた場合は Force で合成し直します
+type batchSettings struct {
+	Format             string                      `json:"format"`
+	DefaultSpeaker     int                         `json:"default_speaker"`
+	DefaultSpeakerName string                      `json:"default_speaker_name"`
+	Options            *voicevox.AudioQueryOptions `json:"options"`
+	Preprocess         preprocess.Options          `json:"preprocess"`
+	PostProcess        audio.PostProcessOptions    `json:"postprocess"`
+}
+
+// RunBatch は行の ID と出力ファイル名を補い、同時に合成する数を制限して opts.Dir に音声とマニフェストを書きます。
+// 行は設定の話者・合成パラメータ・前処理・後処理で合成し、行ごとの失敗はマニフェストに記録します。
+// 行が正しくない場合は MCPInvalidParams、ディレクトリやマニフェストを書けない場合は FileOperationError の *errors.AppError を返します
+func (h *Handler) RunBatch(ctx context.Context, rows []batch.Row, opts BatchOptions) (*batch.Summary, error) {
+	if opts.OutputFormat.Name == "" {
+		opts.OutputFormat = h.outputFormat
+	}
+	return runBatch(ctx, rows, opts, batchSettings{
+		DefaultSpeaker:     h.config.DefaultSpeaker,
+		DefaultSpeakerName: h.config.DefaultSpeakerName,
+		Options:            h.config.AudioQueryOptions(),
+		Preprocess:         h.preprocess,
+		PostProcess:        h.config.PostProcess,
+	}, h.Synthesize)
+}
+
+// synthesizeFunc はバッチの1行を合成し、後処理をしたWAVを返す関数です
+type synthesizeFunc func(ctx context.Context, req SpeechRequest) ([]byte, error)
+
+// runBatch は RunBatch の本体です。settings の Format には opts.OutputFormat を使い、行は synthesize で合成します
+func runBatch(ctx context.Context, rows []batch.Row, opts BatchOptions, settings batchSettings, synthesize synthesizeFunc) (*batch.Summary, error) {
+	format := opts.OutputFormat
+	if err := batch.Normalize(rows, format.Extension); err != nil {
+		return nil, errors.NewMCPError(errors.MCPInvalidParams, err.Error())
+	}
+	if opts.Concurrency < 0 || opts.Concurrency > batch.MaxConcurrency {
+		return nil, errors.NewMCPError(errors.MCPInvalidParams, fmt.Sprintf("concurrency must be between 1 and %d, got %d", batch.MaxConcurrency, opts.Concurrency))
+	}
+
+	settings.Format = format.Name
+	summary, err := batch.Run(ctx, rows, batch.Options{
+		Dir:         opts.Dir,
+		Concurrency: opts.Concurrency,
+		Settings:    settings,
+		Force:       opts.Force,
+		Progress:    opts.Progress,
+	}, func(ctx context.Context, row batch.Row) (batch.Result, error) {
+		return synthesizeRow(ctx, row, format, synthesize)
+	})
+	if err != nil && summary == nil {
+		return nil, errors.NewFileOperationError("Failed to run batch", err)
+	}
+	return summary, err
+}
+
+// synthesizeRow は1行を合成し、出力形式に変換します
+func synthesizeRow(ctx context.Context, row batch.Row, format audio.OutputFormat, synthesize synthesizeFunc) (batch.Result, error) {
+	wav, err := synthesize(ctx, SpeechRequest{
+		Text:            row.Text,
+		Speaker:         row.Speaker,
+		SpeakerID:       row.SpeakerID,
+		SpeedScale:      row.SpeedScale,
+		PitchScale:      row.PitchScale,
+		IntonationScale: row.IntonationScale,
+		VolumeScale:     row.VolumeScale,
+	})
+	if err != nil {
+		return batch.Result{}, err
+	}
+	duration := 0.0
+	if wavFormat, err := audio.ParseWAV(wav); err == nil {
+		duration = wavFormat.Duration()
+	}
+	encoded, err := format.EncodeContext(ctx, wav)
+	if err != nil {
+		return batch.Result{}, fmt.Errorf("failed to encode audio as %s: %w", format.Name, err)
+	}
+	return batch.Result{Data: encoded, Duration: duration}, nil
+}
+
+// handleBatchSynthesize は複数の行をまとめて合成し、一時ディレクトリ内の出力ディレクトリに音声とマニフェストを保存します。
+// 同じ出力ディレクトリでやり直すと、前回合成した行は飛ばします
+func (h *Handler) handleBatchSynthesize(id interface{}, args map[string]interface{}) MCPResponse {
+	invalid := func(err error) MCPResponse {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+
+	req, err := parseBatchRequest(args, h.config.TempDir, h.outputFormat)
+	if err != nil {
+		return invalid(err)
+	}
+	job, err := h.batches.startRequest(req, h.RunBatch)
+	if err != nil {
+		return invalid(err)
+	}
+	status := job.snapshot()
+
+	text := fmt.Sprintf("バッチ合成をバックグラウンドで開始しました（ジョブID: %d）。\n入力: %s\n出力先: %s\n行数: %d\nget_batch_status に job_id を指定すると、進み具合と結果を確認できます",
+		status.JobID, status.Source, status.Dir, status.Total)
+	return h.batchStatusResponse(id, text, status)
+}
+
+// handleGetBatchStatus は batch_synthesize のジョブの進み具合と結果を返します。job_id を省略した場合は全てのジョブを返します
+func (h *Handler) handleGetBatchStatus(id interface{}, args map[string]interface{}) MCPResponse {
+	value, ok := args["job_id"]
+	if !ok || value == nil {
+		statuses := h.batches.list()
+		running := 0
+		for _, s := range statuses {
+			if s.State == batchJobRunning {
+				running++
+			}
+		}
+		text := fmt.Sprintf("バッチ合成のジョブ: %d件（実行中: %d件）", len(statuses), running)
+		return h.batchStatusResponse(id, text, statuses)
+	}
+
+	job, err := h.batches.lookup(value)
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+	status := job.snapshot()
+	return h.batchStatusResponse(id, batchStatusText(status), status)
+}
+
+// batchStatusText はジョブの状態の説明です
+func batchStatusText(status batchJobStatus) string {
+	var text string
+	switch status.State {
+	case batchJobRunning:
+		return fmt.Sprintf("バッチ合成を実行中です（ジョブID: %d, %d/%d行）。\n入力: %s\n出力先: %s",
+			status.JobID, status.Finished, status.Total, status.Source, status.Dir)
+	case batchJobFailed:
+		return fmt.Sprintf("バッチ合成に失敗しました（ジョブID: %d）: %s\n入力: %s\n出力先: %s",
+			status.JobID, status.Error, status.Source, status.Dir)
+	case batchJobCancelled:
+		text = fmt.Sprintf("バッチ合成を中断しました（ジョブID: %d, %d/%d行）。\n入力: %s\n出力先: %s",
+			status.JobID, status.Finished, status.Total, status.Source, status.Dir)
+	default:
+		text = fmt.Sprintf("バッチ合成が完了しました（ジョブID: %d）。\n入力: %s\n出力先: %s",
+			status.JobID, status.Source, status.Dir)
+	}
+
+	summary := status.Summary
+	if summary == nil {
+		return text
+	}
+	text += fmt.Sprintf("\nマニフェスト: %s\n行数: %d（合成: %d, 前回の結果を使用: %d, 失敗: %d）",
+		summary.Manifest, summary.Total, summary.Done, summary.Skipped, summary.Failed)
+	if status.OutputFormat != audio.FormatWAV {
+		text += fmt.Sprintf("\n形式: %s", status.OutputFormat)
+	}
+	for _, e := range summary.Entries {
+		if e.Status == batch.StatusFailed {
+			text += fmt.Sprintf("\n失敗（%s）: %s", e.ID, e.Error)
+		}
+	}
+	switch {
+	case status.State == batchJobCancelled:
+		text += fmt.Sprintf("\noutput_dir に %s を指定して再実行すると、残りの行から合成します", summary.Dir)
+	case summary.Failed > 0:
+		text += fmt.Sprintf("\noutput_dir に %s を指定して再実行すると、失敗した行だけを合成し直します", summary.Dir)
+	}
+	return text
+}
+
+// batchStatusResponse は説明とジョブの状態のJSONを結果として返します
+func (h *Handler) batchStatusResponse(id interface{}, text string, status interface{}) MCPResponse {
+	statusJSON, _ := json.MarshalIndent(status, "", "  ")
+
+	result := ToolCallResult{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: text,
+			},
+			{
+				Type: "text",
+				Text: string(statusJSON),
+			},
+		},
+	}
+
+	return MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// batchRequest は batch_synthesize の引数です
+type batchRequest struct {
+	rows   []batch.Row
+	source string
+	dir    string
+	opts   BatchOptions
+}
+
+// parseBatchRequest は batch_synthesize の引数を読み取ります。行の誤りはジョブを始める前に返します
+func parseBatchRequest(args map[string]interface{}, tempDir string, defaultFormat audio.OutputFormat) (*batchRequest, error) {
+	rows, source, err := parseBatchRows(args, tempDir)
+	if err != nil {
+		return nil, err
+	}
+	outputDir, _ := args["output_dir"].(string)
+	dir, err := resolveBatchDir(outputDir, tempDir)
+	if err != nil {
+		return nil, err
+	}
+	concurrency := 0
+	if value, ok := args["concurrency"]; ok && value != nil {
+		c, ok := value.(float64)
+		if !ok || c != float64(int(c)) || c < 1 || c > batch.MaxConcurrency {
+			return nil, fmt.Errorf("concurrency must be an integer between 1 and %d", batch.MaxConcurrency)
+		}
+		concurrency = int(c)
+	}
+	force, _ := args["force"].(bool)
+	outputFormat, err := parseOutputFormat(args, defaultFormat)
+	if err != nil {
+		return nil, err
+	}
+	if err := batch.Normalize(rows, outputFormat.Extension); err != nil {
+		return nil, err
+	}
+	// 出力ディレクトリ内のシンボリックリンクで一時ディレクトリの外に書き込まないようにする
+	for _, row := range rows {
+		if !insideDir(tempDir, filepath.Join(dir, filepath.FromSlash(row.Output))) {
+			return nil, fmt.Errorf("output %q of row %s must be in the temp directory (%s)", row.Output, row.ID, tempDir)
+		}
+	}
+
+	return &batchRequest{
+		rows:   rows,
+		source: source,
+		dir:    dir,
+		opts: BatchOptions{
+			Dir:          dir,
+			Concurrency:  concurrency,
+			Force:        force,
+			OutputFormat: outputFormat,
+		},
+	}, nil
+}
+
+// parseBatchRows はツール引数の rows（行の配列）または file（一時ディレクトリ内の CSV・JSON Lines・YAML のファイル）から行を読み込み、
+// 入力の説明と一緒に返します
+func parseBatchRows(args map[string]interface{}, tempDir string) ([]batch.Row, string, error) {
+	file, _ := args["file"].(string)
+	format, _ := args["format"].(string)
+	value, hasRows := args["rows"]
+	switch {
+	case file != "" && hasRows:
+		return nil, "", fmt.Errorf("specify either file or rows, not both")
+	case file != "":
+		path, err := resolveBatchFile(file, tempDir)
+		if err != nil {
+			return nil, "", err
+		}
+		rows, err := batch.Load(path, format)
+		return rows, path, err
+	case !hasRows:
+		return nil, "", fmt.Errorf("file or rows parameter is required")
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("rows must be an array of objects")
+	}
+	if len(items) == 0 || len(items) > batch.MaxRows {
+		return nil, "", fmt.Errorf("rows must have between 1 and %d rows", batch.MaxRows)
+	}
+	// 行はファイルと同じく、知らない項目をエラーにする
+	rows := make([]batch.Row, len(items))
+	for i, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return nil, "", fmt.Errorf("rows[%d] must be an object", i)
+		}
+		data, _ := json.Marshal(item)
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&rows[i]); err != nil {
+			return nil, "", fmt.Errorf("rows[%d]: %w", i, err)
+		}
+	}
+	return rows, fmt.Sprintf("rows（%d行）", len(rows)), nil
+}
+
+// resolveBatchDir はツール引数の output_dir を一時ディレクトリ内のパスにします。
+// 省略時は一時ディレクトリに新しいディレクトリを作り、相対パスは一時ディレクトリからのパスとして扱います
+func resolveBatchDir(dir, tempDir string) (string, error) {
+	if dir == "" {
+		return filepath.Join(tempDir, fmt.Sprintf("batch_%d", time.Now().UnixNano())), nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(tempDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	if !insideDir(tempDir, dir) {
+		return "", fmt.Errorf("output_dir must be in the temp directory (%s)", tempDir)
+	}
+	return dir, nil
+}
+
+// resolveBatchFile はツール引数の file を一時ディレクトリ内のパスにします。
+// 相対パスは一時ディレクトリからのパスとして扱い、一時ディレクトリの外を指すパスはエラーにします
+func resolveBatchFile(file, tempDir string) (string, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(tempDir, file)
+	}
+	file = filepath.Clean(file)
+	if file == filepath.Clean(tempDir) || !insideDir(tempDir, file) {
+		return "", fmt.Errorf("file must be in the temp directory (%s)", tempDir)
+	}
+	return file, nil
+}
+
+// insideDir は path が dir 自身またはその中を指すかを返します。
+// シンボリックリンクは存在する部分まで解決してから比べるので、dir 内のリンクで外を指すパスは含まれません
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(resolveSymlinks(dir), resolveSymlinks(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveSymlinks は path のうち存在する親ディレクトリまでのシンボリックリンクを解決し、まだ存在しない残りをそのまま付け加えます
+func resolveSymlinks(path string) string {
+	path = filepath.Clean(path)
+	var rest []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(append([]string{path}, rest...)...)
+		}
+		rest = append([]string{filepath.Base(path)}, rest...)
+		path = parent
+	}
+}
+
+// batchSynthesizeSchema は batch_synthesize の引数のJSON Schemaです
+func batchSynthesizeSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"file": map[string]interface{}{
+				"type":        "string",
+				"description": "行を書いたファイルのパス（.csv: 1行目に列名, .jsonl: 1行に1つのオブジェクト, .yaml: 行の配列）。一時ディレクトリ内に限り、相対パスは一時ディレクトリから。列は rows の項目と同じ",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "file の形式（省略時は拡張子から判定）",
+				"enum":        []string{"csv", "jsonl", "yaml"},
+			},
+			"rows": map[string]interface{}{
+				"type":        "array",
+				"description": "合成する行（file の代わりに指定）",
+				"items":       batchRowSchema(),
+			},
+			"output_dir": map[string]interface{}{
+				"type":        "string",
+				"description": "音声とマニフェストを保存するディレクトリ。一時ディレクトリ内に限り、相対パスは一時ディレクトリから（省略時は新しいディレクトリを作成）",
+			},
+			"concurrency": map[string]interface{}{
+				"type":        "integer",
+				"description": "同時に合成する行の数（デフォルト: 2）",
+				"minimum":     1,
+				"maximum":     16,
+			},
+			"force": map[string]interface{}{
+				"type":        "boolean",
+				"description": "前回の結果を使わずに全ての行を合成し直す",
+			},
+			"output_format": outputFormatSchema(),
+		},
+	}
+}
+
+// batchStatusSchema は get_batch_status の引数のJSON Schemaです
+func batchStatusSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"job_id": map[string]interface{}{
+				"type":        "integer",
+				"description": "batch_synthesize が返したジョブID（省略時は全てのジョブ）",
+				"minimum":     1,
+			},
+		},
+	}
+}
+
+// batchRowSchema は rows の1行のJSON Schemaです
+func batchRowSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "行の名前。やり直しの判定とマニフェストに使う（省略時は行番号の 0001 など）",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "読み上げるテキスト",
+			},
+			"speaker": map[string]interface{}{
+				"type":        "string",
+				"description": "話者名（例: \"ずんだもん ノーマル\"）またはスタイルID（省略時は設定のデフォルト話者）",
+			},
+			"speaker_id": map[string]interface{}{
+				"type":        "integer",
+				"description": "スタイルID。speaker より優先",
+				"minimum":     0,
+			},
+			"speed_scale":      map[string]interface{}{"type": "number", "minimum": 0.5, "maximum": 2.0},
+			"pitch_scale":      map[string]interface{}{"type": "number", "minimum": -0.15, "maximum": 0.15},
+			"intonation_scale": map[string]interface{}{"type": "number", "minimum": 0.0, "maximum": 2.0},
+			"volume_scale":     map[string]interface{}{"type": "number", "minimum": 0.0, "maximum": 2.0},
+			"output": map[string]interface{}{
+				"type":        "string",
+				"description": "出力ディレクトリ内のファイル名（例: \"chapter1/001\"）。拡張子を省略した場合は出力形式の拡張子を付ける（省略時は id）",
+			},
+		},
+		"required": []string{"text"},
+	}
+}
@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/batch"
+)
+
+// バッチ合成のジョブの状態
+const (
+	batchJobRunning   = "running"
+	batchJobDone      = "done"
+	batchJobFailed    = "failed"
+	batchJobCancelled = "cancelled"
+)
+
+// batchJobStatus はバックグラウンドで実行するバッチ合成のジョブの状態です
+type batchJobStatus struct {
+	JobID  int    `json:"job_id"`
+	State  string `json:"state"`
+	Source string `json:"source"`
+	Dir    string `json:"dir"`
+	// OutputFormat は保存する音声の形式です
+	OutputFormat string `json:"output_format"`
+	// Finished は終わった行（前回の結果を使った行を含む）の数です
+	Finished int    `json:"finished"`
+	Total    int    `json:"total"`
+	Error    string `json:"error,omitempty"`
+	// Summary は終わったジョブの結果です。中断した場合も終わった行までの結果を含みます
+	Summary *batch.Summary `json:"summary,omitempty"`
+}
+
+// batchRunFunc はジョブとしてバッチを実行する関数です。progress は行が終わるたびに呼びます
+type batchRunFunc func(ctx context.Context, progress func(entry batch.Entry, finished, total int)) (*batch.Summary, error)
+
+// batchJob はバックグラウンドで実行するバッチ合成です
+type batchJob struct {
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	status batchJobStatus
+}
+
+// snapshot はジョブの現在の状態を返します
+func (j *batchJob) snapshot() batchJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// batchJobs は batch_synthesize が開始したジョブの一覧です。ジョブIDは1から順に振ります
+type batchJobs struct {
+	mu   sync.Mutex
+	jobs []*batchJob
+}
+
+// start はバッチをバックグラウンドで開始します。実行中のジョブと同じ出力ディレクトリには書けません
+func (b *batchJobs) start(source, dir, format string, total int, run batchRunFunc) (*batchJob, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, job := range b.jobs {
+		if s := job.snapshot(); s.State == batchJobRunning && filepath.Clean(s.Dir) == filepath.Clean(dir) {
+			return nil, fmt.Errorf("output_dir %s is in use by running batch job %d", dir, s.JobID)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &batchJob{
+		cancel: cancel,
+		status: batchJobStatus{
+			JobID:  len(b.jobs) + 1,
+			State:  batchJobRunning,
+			Source: source,
+			Dir:    dir,
+			Total:  total,
+
+			OutputFormat: format,
+		},
+	}
+	b.jobs = append(b.jobs, job)
+
+	go func() {
+		defer cancel()
+		summary, err := run(ctx, func(entry batch.Entry, finished, total int) {
+			job.mu.Lock()
+			job.status.Finished, job.status.Total = finished, total
+			job.mu.Unlock()
+		})
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.status.Summary = summary
+		switch {
+		case err == nil:
+			job.status.State = batchJobDone
+		case ctx.Err() != nil:
+			job.status.State = batchJobCancelled
+			job.status.Error = err.Error()
+		default:
+			job.status.State = batchJobFailed
+			job.status.Error = err.Error()
+		}
+		if summary != nil {
+			job.status.Finished = summary.Done + summary.Skipped + summary.Failed
+		}
+	}()
+	return job, nil
+}
+
+// startRequest は batch_synthesize の引数のバッチを start で開始します。行は run で合成します
+func (b *batchJobs) startRequest(req *batchRequest, run func(ctx context.Context, rows []batch.Row, opts BatchOptions) (*batch.Summary, error)) (*batchJob, error) {
+	return b.start(req.source, req.dir, req.opts.OutputFormat.Name, len(req.rows), func(ctx context.Context, progress func(batch.Entry, int, int)) (*batch.Summary, error) {
+		opts := req.opts
+		opts.Progress = progress
+		return run(ctx, req.rows, opts)
+	})
+}
+
+// get は ID のジョブを返します
+func (b *batchJobs) get(id int) (*batchJob, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if id < 1 || id > len(b.jobs) {
+		return nil, false
+	}
+	return b.jobs[id-1], true
+}
+
+// lookup はツール引数の job_id のジョブを返します
+func (b *batchJobs) lookup(value interface{}) (*batchJob, error) {
+	id, ok := value.(float64)
+	if !ok || id != float64(int(id)) {
+		return nil, fmt.Errorf("job_id must be an integer")
+	}
+	job, ok := b.get(int(id))
+	if !ok {
+		return nil, fmt.Errorf("unknown batch job: %d", int(id))
+	}
+	return job, nil
+}
+
+// list は全てのジョブの状態を開始した順に返します
+func (b *batchJobs) list() []batchJobStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	statuses := make([]batchJobStatus, 0, len(b.jobs))
+	for _, job := range b.jobs {
+		statuses = append(statuses, job.snapshot())
+	}
+	return statuses
+}
+
+// cancelAll は実行中の全てのジョブを止めます。終わった行はマニフェストに残ります
+func (b *batchJobs) cancelAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, job := range b.jobs {
+		job.cancel()
+	}
+}
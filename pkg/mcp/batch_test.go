@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/batch"
+)
+
+func TestResolveBatchFile(t *testing.T) {
+	tempDir := t.TempDir()
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"relative", "lines.csv", filepath.Join(tempDir, "lines.csv")},
+		{"nested", "scripts/../lines.csv", filepath.Join(tempDir, "lines.csv")},
+		{"absolute inside", filepath.Join(tempDir, "batch", "rows.yaml"), filepath.Join(tempDir, "batch", "rows.yaml")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBatchFile(tt.file, tempDir)
+			if err != nil || got != tt.want {
+				t.Errorf("resolveBatchFile(%q) = %q, %v, want %q", tt.file, got, err, tt.want)
+			}
+		})
+	}
+
+	outside := filepath.Join(filepath.Dir(tempDir), "secret.csv")
+	for _, file := range []string{"../secret.csv", "a/../../secret.csv", outside, "/etc/passwd", tempDir, tempDir + "-other/lines.csv", "."} {
+		t.Run("reject "+file, func(t *testing.T) {
+			if got, err := resolveBatchFile(file, tempDir); err == nil || !strings.Contains(err.Error(), "temp directory") {
+				t.Errorf("resolveBatchFile(%q) = %q, %v, want error", file, got, err)
+			}
+		})
+	}
+}
+
+func TestResolveBatchDir(t *testing.T) {
+	tempDir := t.TempDir()
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{"relative", "voices", filepath.Join(tempDir, "voices")},
+		{"nested", "chapter1/../chapter2/voices", filepath.Join(tempDir, "chapter2", "voices")},
+		{"absolute inside", filepath.Join(tempDir, "out"), filepath.Join(tempDir, "out")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBatchDir(tt.dir, tempDir)
+			if err != nil || got != tt.want {
+				t.Errorf("resolveBatchDir(%q) = %q, %v, want %q", tt.dir, got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("omitted", func(t *testing.T) {
+		got, err := resolveBatchDir("", tempDir)
+		if err != nil || filepath.Dir(got) != tempDir || !strings.HasPrefix(filepath.Base(got), "batch_") {
+			t.Errorf("resolveBatchDir(\"\") = %q, %v, want a batch_ directory in %s", got, err, tempDir)
+		}
+	})
+
+	outside := filepath.Join(filepath.Dir(tempDir), "voices")
+	for _, dir := range []string{"../voices", "a/../../voices", outside, "/etc", tempDir + "-other/voices"} {
+		t.Run("reject "+dir, func(t *testing.T) {
+			if got, err := resolveBatchDir(dir, tempDir); err == nil || !strings.Contains(err.Error(), "temp directory") {
+				t.Errorf("resolveBatchDir(%q) = %q, %v, want error", dir, got, err)
+			}
+		})
+	}
+}
+
+func TestParseBatchRows_FileOutsideTempDir(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.csv")
+	if err := os.WriteFile(outside, []byte("password\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := parseBatchRows(map[string]interface{}{"file": outside, "format": "csv"}, tempDir)
+	if err == nil || strings.Contains(err.Error(), "password") {
+		t.Fatalf("parseBatchRows() error = %v, want rejection without file content", err)
+	}
+
+	inside := filepath.Join(tempDir, "lines.csv")
+	if err := os.WriteFile(inside, []byte("text\nこんにちは\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rows, source, err := parseBatchRows(map[string]interface{}{"file": "lines.csv"}, tempDir)
+	if err != nil || len(rows) != 1 || source != inside {
+		t.Errorf("parseBatchRows() = %+v, %q, %v", rows, source, err)
+	}
+}
+
+// waitBatchJob はジョブが終わるまで待って状態を返します
+func waitBatchJob(t *testing.T, job *batchJob) batchJobStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := job.snapshot(); status.State != batchJobRunning {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("batch job did not finish")
+	return batchJobStatus{}
+}
+
+func TestBatchJobs(t *testing.T) {
+	var jobs batchJobs
+	release := make(chan struct{})
+	progressed := make(chan struct{})
+	job, err := jobs.start("rows", "/tmp/out", "wav", 2, func(ctx context.Context, progress func(batch.Entry, int, int)) (*batch.Summary, error) {
+		progress(batch.Entry{ID: "0001", Status: batch.StatusDone}, 1, 2)
+		close(progressed)
+		<-release
+		return &batch.Summary{Dir: "/tmp/out", Total: 2, Done: 2}, nil
+	})
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	<-progressed
+
+	status := job.snapshot()
+	if status.JobID != 1 || status.State != batchJobRunning || status.Finished != 1 || status.Total != 2 {
+		t.Errorf("running status = %+v", status)
+	}
+	if _, err := jobs.start("rows", "/tmp/out/", "wav", 1, nil); err == nil {
+		t.Error("start() with the output_dir of a running job error = nil, want error")
+	}
+
+	close(release)
+	status = waitBatchJob(t, job)
+	if status.State != batchJobDone || status.Finished != 2 || status.Summary == nil {
+		t.Errorf("done status = %+v", status)
+	}
+	if got, ok := jobs.get(1); !ok || got != job {
+		t.Errorf("get(1) = %v, %v", got, ok)
+	}
+	if _, ok := jobs.get(2); ok {
+		t.Error("get(2) ok = true, want false")
+	}
+}
+
+func TestBatchJobs_Cancel(t *testing.T) {
+	var jobs batchJobs
+	job, err := jobs.start("rows", "/tmp/out", "wav", 3, func(ctx context.Context, progress func(batch.Entry, int, int)) (*batch.Summary, error) {
+		<-ctx.Done()
+		return &batch.Summary{Dir: "/tmp/out", Total: 3, Done: 1}, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+
+	jobs.cancelAll()
+	status := waitBatchJob(t, job)
+	if status.State != batchJobCancelled || status.Finished != 1 || status.Error == "" {
+		t.Errorf("cancelled status = %+v", status)
+	}
+	if text := batchStatusText(status); !strings.Contains(text, "中断") || !strings.Contains(text, "再実行") {
+		t.Errorf("batchStatusText() = %q", text)
+	}
+
+	// 終わったジョブと同じ出力ディレクトリには新しいジョブを始められる
+	if _, err := jobs.start("rows", "/tmp/out", "wav", 1, func(ctx context.Context, progress func(batch.Entry, int, int)) (*batch.Summary, error) {
+		return &batch.Summary{}, nil
+	}); err != nil {
+		t.Errorf("start() after the job finished error = %v", err)
+	}
+	if statuses := jobs.list(); len(statuses) != 2 || statuses[1].JobID != 2 {
+		t.Errorf("list() = %+v", statuses)
+	}
+}
+
+func TestInsideDir_Symlinks(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.csv"), []byte("password\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(tempDir, "link")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.csv"), filepath.Join(tempDir, "lines.csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := resolveBatchFile("lines.csv", tempDir); err == nil {
+		t.Errorf("resolveBatchFile(symlink to outside) = %q, want error", got)
+	}
+	if got, err := resolveBatchFile("link/secret.csv", tempDir); err == nil {
+		t.Errorf("resolveBatchFile(file in a linked directory) = %q, want error", got)
+	}
+	if got, err := resolveBatchDir("link/voices", tempDir); err == nil {
+		t.Errorf("resolveBatchDir(under a linked directory) = %q, want error", got)
+	}
+
+	// 出力ディレクトリ内のリンクから外に書く行も受け付けない
+	if err := os.Mkdir(filepath.Join(tempDir, "voices"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(tempDir, "voices", "sub")); err != nil {
+		t.Fatal(err)
+	}
+	args := map[string]interface{}{
+		"rows":       []interface{}{map[string]interface{}{"text": "a", "output": "sub/a.wav"}},
+		"output_dir": "voices",
+	}
+	wav, _ := audio.ParseOutputFormat("wav")
+	if _, err := parseBatchRequest(args, tempDir, wav); err == nil || !strings.Contains(err.Error(), "temp directory") {
+		t.Errorf("parseBatchRequest(output through a symlink) error = %v, want error", err)
+	}
+
+	// 一時ディレクトリ自体がリンクでも、その中のパスは受け付ける
+	linkedTemp := filepath.Join(outside, "temp")
+	if err := os.Symlink(tempDir, linkedTemp); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := resolveBatchDir(filepath.Join(tempDir, "out"), linkedTemp); err != nil {
+		t.Errorf("resolveBatchDir(real path of a linked temp dir) = %q, %v, want no error", got, err)
+	}
+}
@@ -1,15 +1,20 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/metapox/mcp-voicevox-go/pkg/audio"
 	"github.com/metapox/mcp-voicevox-go/pkg/config"
 	"github.com/metapox/mcp-voicevox-go/pkg/errors"
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/timing"
 	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
 )
 
@@ -17,16 +22,73 @@ import (
 type Handler struct {
 	config         *config.Config
 	voicevoxClient *voicevox.Client
-	audioPlayer    *audio.Player
+	playback       *audio.Queue
+	warmup         *voicevox.Warmup
+	speakers       *voicevox.SpeakerCache
+	userDict       *voicevox.UserDictCache
+	// preprocess は設定の前処理ルールに英単語の辞書を加えたものです
+	preprocess preprocess.Options
+	// outputFormat は output_format を省略したときに保存する音声の形式です
+	outputFormat audio.OutputFormat
+	// batches は batch_synthesize がバックグラウンドで実行するジョブです
+	batches batchJobs
 }
 
-// NewHandler は新しいMCPハンドラーを作成します
-func NewHandler(cfg *config.Config) *Handler {
-	return &Handler{
+// NewHandler は新しいMCPハンドラーを作成します。
+// 再生が有効な場合は設定された再生バックエンドを作成し、作成できなければエラーを返します。
+// 英単語の辞書ファイルや絵文字の表が読み込めない場合や、出力形式の変換に必要なコマンドがない場合もエラーを返します
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	outputFormat, err := audio.ParseOutputFormat(cfg.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	if err := outputFormat.Available(); err != nil {
+		return nil, err
+	}
+	dict, err := preprocess.LoadDictionary(cfg.EnglishDictionary)
+	if err != nil {
+		return nil, err
+	}
+	emojiTable, err := preprocess.LoadEmojiTable(cfg.EmojiDictionary)
+	if err != nil {
+		return nil, err
+	}
+
+	client := voicevox.NewClient(cfg.VoicevoxURL)
+	h := &Handler{
 		config:         cfg,
-		voicevoxClient: voicevox.NewClient(cfg.VoicevoxURL),
-		audioPlayer:    audio.NewPlayer(cfg.EnablePlayback),
+		voicevoxClient: client,
+		warmup:         voicevox.NewWarmup(client, cfg.WarmupStyles),
+		speakers:       voicevox.NewSpeakerCache(client, voicevox.DefaultSpeakerCacheTTL),
+		userDict:       voicevox.NewUserDictCache(client, voicevox.DefaultUserDictCacheTTL),
+		preprocess:     cfg.Preprocess,
+		outputFormat:   outputFormat,
 	}
+	h.preprocess.Dictionary = dict
+	h.preprocess.EmojiTable = emojiTable
+
+	if cfg.EnablePlayback {
+		playback, err := NewPlaybackQueue(cfg)
+		if err != nil {
+			return nil, err
+		}
+		h.playback = playback
+	}
+
+	return h, nil
+}
+
+// Close は実行中のバッチ合成のジョブを中断し、再生キューを停止します
+func (h *Handler) Close() {
+	h.batches.cancelAll()
+	if h.playback != nil {
+		h.playback.Close()
+	}
+}
+
+// StartWarmup は設定されたスタイルの事前初期化をバックグラウンドで開始します
+func (h *Handler) StartWarmup() {
+	h.warmup.Start()
 }
 
 // HandleRequest はMCPリクエストを処理します
@@ -79,9 +141,13 @@ func (h *Handler) handleToolsList(id interface{}) MCPResponse {
 					},
 					"speaker_id": map[string]interface{}{
 						"type":        "integer",
-						"description": "話者ID（省略時はデフォルト話者を使用）",
+						"description": "話者のスタイルID（省略時はデフォルト話者を使用）",
 						"minimum":     0,
 					},
+					"speaker": map[string]interface{}{
+						"type":        "string",
+						"description": "話者名とスタイル名（例: \"ずんだもん ノーマル\", \"四国めたん あまあま\"）。speaker_id が指定された場合はそちらを優先",
+					},
 					"speed_scale": map[string]interface{}{
 						"type":        "number",
 						"description": "話速（0.5-2.0、デフォルト: 1.0）",
@@ -106,13 +172,149 @@ func (h *Handler) handleToolsList(id interface{}) MCPResponse {
 						"minimum":     0.0,
 						"maximum":     2.0,
 					},
+					"pre_phoneme_length": map[string]interface{}{
+						"type":        "number",
+						"description": "音声の前の無音の秒数（0.0-1.5、デフォルト: 0.1）",
+						"minimum":     0.0,
+						"maximum":     1.5,
+					},
+					"post_phoneme_length": map[string]interface{}{
+						"type":        "number",
+						"description": "音声の後の無音の秒数（0.0-1.5、デフォルト: 0.1）",
+						"minimum":     0.0,
+						"maximum":     1.5,
+					},
+					"output_sampling_rate": map[string]interface{}{
+						"type":        "integer",
+						"description": "VOICEVOXが出力する音声のサンプリングレート（8000-96000Hz、例: 音声認識向けに16000、動画向けに48000。省略時は設定の値、未設定なら話者の既定）",
+						"minimum":     8000,
+						"maximum":     96000,
+					},
+					"output_stereo": map[string]interface{}{
+						"type":        "boolean",
+						"description": "ステレオで出力する（省略時は設定の値、デフォルト: false）",
+					},
+					"priority": map[string]interface{}{
+						"type":        "string",
+						"description": "再生キューでの優先度（urgent は待ち中の音声より先に再生、デフォルト: normal）",
+						"enum":        []string{"low", "normal", "high", "urgent"},
+					},
+					"interrupt": map[string]interface{}{
+						"type":        "boolean",
+						"description": "再生中の音声を中断してすぐに再生する",
+					},
+					"wait": map[string]interface{}{
+						"type":        "boolean",
+						"description": "再生が終わるまで待ってから結果を返す（デフォルト: false）",
+					},
+					"device": map[string]interface{}{
+						"type":        "string",
+						"description": "出力先デバイス（list_audio_devices の name、省略時は設定のデバイス）",
+					},
+					"playback_volume": map[string]interface{}{
+						"type":             "number",
+						"description":      "再生音量の倍率（volume_scale とは独立、省略時は設定の再生音量）",
+						"exclusiveMinimum": 0.0,
+						"maximum":          2.0,
+					},
+					"output_format": outputFormatSchema(),
+					"postprocess":   postProcessSchema(),
+					"mix":           mixSchema(),
+					"subtitles":     subtitlesSchema(),
+					"visemes":       visemesSchema(),
+					"ssml": map[string]interface{}{
+						"type":        "boolean",
+						"description": "text をSSML（speak, break, prosody, say-as, sub, voice, emphasis, p, s）として解析する。省略時は <speak> で始まる場合にSSMLとして扱う",
+					},
+					"preprocess": map[string]interface{}{
+						"type":        []string{"boolean", "object"},
+						"description": "読み上げ前のテキストの前処理。false で無効化、オブジェクトで設定のルールを上書き（省略時は設定に従う）",
+						"properties": map[string]interface{}{
+							"enabled":     map[string]interface{}{"type": "boolean"},
+							"markdown":    map[string]interface{}{"type": "boolean", "description": "見出し・強調・リスト・リンクなどの記法を取り除く"},
+							"code_blocks": map[string]interface{}{"type": "string", "enum": []string{preprocess.CodeSkip, preprocess.CodeSummary, preprocess.CodeRead}},
+							"urls":        map[string]interface{}{"type": "string", "enum": []string{preprocess.URLDomain, preprocess.URLSkip, preprocess.URLRead}},
+							"tables":      map[string]interface{}{"type": "string", "enum": []string{preprocess.TableRead, preprocess.TableSkip, preprocess.TableSummary}},
+							"normalize":   map[string]interface{}{"type": "boolean", "description": "日付・時刻・バージョン・単位・記号を日本語の読み方に書き換える"},
+							"english":     map[string]interface{}{"type": "boolean", "description": "英単語・略語・識別子をカタカナの読み方に書き換える"},
+							"emoji": map[string]interface{}{
+								"type":        "string",
+								"enum":        []string{preprocess.EmojiStrip, preprocess.EmojiRead, preprocess.EmojiStyle, preprocess.EmojiKeep},
+								"description": "絵文字・顔文字を読まない / 名前で読む / 気分に合わせてスタイルを切り替える / そのまま渡す",
+							},
+						},
+					},
 				},
 				"required": []string{"text"},
 			},
 		},
 		{
 			Name:        ToolGetSpeakers,
-			Description: "利用可能な話者一覧を取得します",
+			Description: "利用可能な話者一覧とスタイルIDを取得します",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "話者名で絞り込み（部分一致）",
+					},
+					"style_type": map[string]interface{}{
+						"type":        "string",
+						"description": "スタイルの種類で絞り込み（talk: 読み上げ, singing: 歌唱）",
+						"enum":        []string{voicevox.StyleCategoryTalk, voicevox.StyleCategorySinging},
+					},
+				},
+			},
+		},
+		{
+			Name:        ToolControlPlayback,
+			Description: "音声の再生キューを操作します（stop: 全て停止, skip: 再生中の音声を飛ばす, clear: 再生待ちを破棄）",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "操作の種類",
+						"enum":        []string{"stop", "skip", "clear"},
+					},
+				},
+				"required": []string{"action"},
+			},
+		},
+		{
+			Name:        ToolGetPlaybackStatus,
+			Description: "再生中の音声と再生待ちのキューの状態を取得します",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        ToolListAudioDevices,
+			Description: "再生に使える出力デバイスの一覧を取得します（text_to_speech の device に指定できます）",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        ToolMixAudio,
+			Description: "保存済みの音声に素材ディレクトリのBGM・効果音を重ねます（ジングル、BGMのループ、話している間のダッキング）",
+			InputSchema: mixAudioSchema(),
+		},
+		{
+			Name:        ToolBatchSynthesize,
+			Description: "複数のテキストをまとめて音声ファイルにします（ナレーション、ゲームのセリフなど）。CSV・JSON Lines・YAML のファイルまたは rows の行ごとに合成し、出力ディレクトリに音声と manifest.json を保存します。合成はバックグラウンドで行い、すぐにジョブIDを返します（結果は get_batch_status で確認）。同じ output_dir で再実行すると、変わっていない行は合成し直しません。音声は再生しません",
+			InputSchema: batchSynthesizeSchema(),
+		},
+		{
+			Name:        ToolGetBatchStatus,
+			Description: "batch_synthesize のジョブの進み具合と結果を取得します",
+			InputSchema: batchStatusSchema(),
+		},
+		{
+			Name:        ToolGetWarmupStatus,
+			Description: "起動時に事前初期化しているスタイルの準備状況を取得します",
 			InputSchema: map[string]interface{}{
 				"type":       "object",
 				"properties": map[string]interface{}{},
@@ -140,7 +342,21 @@ func (h *Handler) handleToolsCall(id interface{}, params interface{}) MCPRespons
 	case ToolTextToSpeech:
 		return h.handleTextToSpeech(id, callParams.Arguments)
 	case ToolGetSpeakers:
-		return h.handleGetSpeakers(id)
+		return h.handleGetSpeakers(id, callParams.Arguments)
+	case ToolGetWarmupStatus:
+		return h.handleGetWarmupStatus(id)
+	case ToolControlPlayback:
+		return h.handleControlPlayback(id, callParams.Arguments)
+	case ToolGetPlaybackStatus:
+		return h.handleGetPlaybackStatus(id)
+	case ToolListAudioDevices:
+		return h.handleListAudioDevices(id)
+	case ToolMixAudio:
+		return h.handleMixAudio(id, callParams.Arguments)
+	case ToolBatchSynthesize:
+		return h.handleBatchSynthesize(id, callParams.Arguments)
+	case ToolGetBatchStatus:
+		return h.handleGetBatchStatus(id, callParams.Arguments)
 	default:
 		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, "Unknown tool: "+callParams.Name))
 	}
@@ -153,23 +369,61 @@ func (h *Handler) handleTextToSpeech(id interface{}, args map[string]interface{}
 		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, "text parameter is required"))
 	}
 
-	speakerID := h.config.DefaultSpeaker
-	if sid, ok := args["speaker_id"].(float64); ok {
-		speakerID = int(sid)
+	speakerID, appErr := h.resolveSpeaker(args)
+	if appErr != nil {
+		return h.createErrorResponse(id, appErr)
 	}
 
+	queueOptions, err := parseQueueOptions(args)
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+	wait, _ := args["wait"].(bool)
+	outputFormat, err := parseOutputFormat(args, h.outputFormat)
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+	postProcess, err := parsePostProcess(args, h.config.PostProcess)
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+	mix, hasMix, err := parseMix(args["mix"])
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+	subtitles, err := parseTimingFormats(args, "subtitles", subtitleFormats)
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+	visemes, err := parseTimingFormats(args, "visemes", visemeFormats)
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+
+	// 読み上げ用にテキストを前処理（Markdownの記法・コードブロック・URL・英単語など）
+	parts, err := planSpeech(speechRequest{
+		text:         text,
+		styleID:      speakerID,
+		ssml:         args["ssml"],
+		preprocess:   args["preprocess"],
+		resolveVoice: h.resolveVoice,
+	}, h.preprocess, h.userDict)
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			return h.createErrorResponse(id, appErr)
+		}
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+	spoken := spokenText(parts)
+
 	// 音声合成オプションを準備
 	var options *voicevox.AudioQueryOptions
 	hasOptions := args["speed_scale"] != nil || args["pitch_scale"] != nil || 
 		args["intonation_scale"] != nil || args["volume_scale"] != nil
 	
 	// デフォルト設定またはパラメータ指定の値を使用
-	options = &voicevox.AudioQueryOptions{
-		SpeedScale:      &h.config.DefaultSpeedScale,
-		PitchScale:      &h.config.DefaultPitchScale,
-		IntonationScale: &h.config.DefaultIntonationScale,
-		VolumeScale:     &h.config.DefaultVolumeScale,
-	}
+	options = h.config.AudioQueryOptions()
 	
 	// パラメータで上書き
 	if hasOptions {
@@ -197,37 +451,126 @@ func (h *Handler) handleTextToSpeech(id interface{}, args map[string]interface{}
 			}
 		}
 	}
+	// サンプリングレート・ステレオ・前後の無音は範囲外ならエラーにする
+	if _, err := parseAudioOutputOptions(args, options); err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
 
-	// 音声クエリ作成
-	query, err := h.voicevoxClient.CreateAudioQueryWithOptions(text, speakerID, options)
-	if err != nil {
-		appErr := errors.NewVoicevoxError("Failed to create audio query", err)
-		return h.createErrorResponse(id, appErr)
+	// 字幕や口の形を作る場合はモーラごとのタイミングを求める。字幕は1文ずつ合成して文ごとに区切る
+	var track *timing.Track
+	if len(subtitles) > 0 || len(visemes) > 0 {
+		track = &timing.Track{}
+	}
+	if len(subtitles) > 0 {
+		parts = splitSentences(parts)
 	}
 
-	// 音声合成
-	audioData, err := h.voicevoxClient.SynthesizeVoice(query, speakerID)
+	// 音声合成（SSMLや絵文字の気分で区間が分かれる場合は区間ごとに合成してつなげる）
+	ctx := context.Background()
+	audioData, err := synthesizeSpeech(ctx, h.voicevoxClient, h.speakers, parts, options, track)
+	if err != nil {
+		return h.createErrorResponse(id, synthesisError(err))
+	}
+	// BGM・効果音を重ねてから後処理する
+	if hasMix {
+		audioData, err = mixAudio(audioData, h.config.AssetDir, mix)
+		if err != nil {
+			return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+		}
+	}
+	// 後処理（ラウドネスの正規化・リミッター・フェードなど）は保存と再生の両方に適用する
+	voiced := audioData
+	audioData, err = audio.PostProcess(audioData, postProcess)
 	if err != nil {
-		appErr := errors.NewAudioSynthesisError("Text to speech failed", err)
+		appErr := errors.NewAudioSynthesisError("Failed to post-process audio", err)
 		return h.createErrorResponse(id, appErr)
 	}
+	var subtitleFiles, visemeFiles []timingFile
+	if track != nil {
+		alignTimings(track, voiced, audioData, mix.VoiceOffset, postProcess)
+		if subtitleFiles, err = renderTimings(*track, subtitles); err != nil {
+			appErr := errors.NewAudioSynthesisError("Failed to create subtitles", err)
+			return h.createErrorResponse(id, appErr)
+		}
+		if visemeFiles, err = renderTimings(*track, visemes); err != nil {
+			appErr := errors.NewAudioSynthesisError("Failed to create visemes", err)
+			return h.createErrorResponse(id, appErr)
+		}
+	}
 
-	// ファイル保存
-	filename := fmt.Sprintf("speech_%d_%d.wav", speakerID, time.Now().Unix())
-	filepath := fmt.Sprintf("%s/%s", h.config.TempDir, filename)
+	// ファイル保存（再生とは独立した設定）。保存する音声は出力形式に変換し、再生にはWAVのまま使う
+	filepath := ""
+	saveStatus := "ファイルは保存していません"
+	if h.config.SaveAudio {
+		encoded, err := outputFormat.EncodeContext(ctx, audioData)
+		if err != nil {
+			appErr := errors.NewAudioSynthesisError("Failed to encode audio as "+outputFormat.Name, err)
+			return h.createErrorResponse(id, appErr)
+		}
+		filename := fmt.Sprintf("speech_%d_%d%s", speakerID, time.Now().UnixNano(), outputFormat.Extension)
+		filepath = fmt.Sprintf("%s/%s", h.config.TempDir, filename)
 
-	if err := os.WriteFile(filepath, audioData, 0644); err != nil {
-		appErr := errors.NewFileOperationError("Failed to save audio file", err)
-		return h.createErrorResponse(id, appErr)
+		if err := os.WriteFile(filepath, encoded, 0644); err != nil {
+			appErr := errors.NewFileOperationError("Failed to save audio file", err)
+			return h.createErrorResponse(id, appErr)
+		}
+		if err := saveTimings(subtitleFiles, filepath); err != nil {
+			appErr := errors.NewFileOperationError("Failed to save subtitle file", err)
+			return h.createErrorResponse(id, appErr)
+		}
+		if err := saveTimings(visemeFiles, filepath); err != nil {
+			appErr := errors.NewFileOperationError("Failed to save viseme file", err)
+			return h.createErrorResponse(id, appErr)
+		}
+		saveStatus = "ファイルに保存されました"
+	}
+
+	// 音声再生（再生キューに追加）。対応するプレイヤーではファイルを経由せず標準入力で再生する
+	playbackStatus := saveStatus
+	if h.playback != nil {
+		queueOptions.Text = spoken
+		ticket := h.playback.EnqueueAudio(audioData, queueOptions)
+		if wait {
+			if err := ticket.Wait(); err != nil {
+				playbackStatus = fmt.Sprintf("%s。音声は再生されませんでした: %v", saveStatus, err)
+			} else {
+				playbackStatus = fmt.Sprintf("%s。音声を再生しました", saveStatus)
+			}
+		} else {
+			playbackStatus = fmt.Sprintf("%s。再生キューに追加しました（ID: %d, 待ち順: %d）", saveStatus, ticket.ID, ticket.Position)
+		}
 	}
 
-	// 音声再生
-	playbackStatus := "ファイルに保存されました"
-	if err := h.audioPlayer.Play(filepath); err != nil {
-		log.Printf("Audio playback failed: %v", err)
-		playbackStatus = fmt.Sprintf("ファイルに保存されましたが、再生に失敗しました: %v", err)
-	} else if h.audioPlayer != nil && h.config.EnablePlayback {
-		playbackStatus = "ファイルに保存され、音声を再生しました"
+	spokenInfo := ""
+	if spoken != text {
+		spokenInfo = "\n読み上げ: " + spoken
+	}
+
+	fileInfo := ""
+	if filepath != "" {
+		fileInfo = "\nファイル: " + filepath
+		if outputFormat.Name != audio.FormatWAV {
+			fileInfo += fmt.Sprintf("\n形式: %s (%s)", outputFormat.Name, outputFormat.MIMEType)
+		}
+	}
+	if hasMix {
+		fileInfo += "\nミックス: " + mixTrackNames(mix)
+	}
+	for _, f := range subtitleFiles {
+		if f.path != "" {
+			fileInfo += fmt.Sprintf("\n字幕（%s）: %s", f.format.name, f.path)
+		}
+	}
+	if len(subtitles) > 0 {
+		fileInfo += fmt.Sprintf("\n字幕: %d件（%s）", len(track.Cues), timingFormatNames(subtitles))
+	}
+	for _, f := range visemeFiles {
+		if f.path != "" {
+			fileInfo += fmt.Sprintf("\n口の形（%s）: %s", f.format.name, f.path)
+		}
+	}
+	if len(visemes) > 0 {
+		fileInfo += fmt.Sprintf("\n口の形: %d区間（%s）", len(track.Visemes()), timingFormatNames(visemes))
 	}
 
 	// オプション情報を含む結果メッセージ
@@ -237,17 +580,24 @@ func (h *Handler) handleTextToSpeech(id interface{}, args map[string]interface{}
 		optionsInfo += fmt.Sprintf("\n音高: %.2f", *options.PitchScale)
 		optionsInfo += fmt.Sprintf("\n抑揚: %.2f", *options.IntonationScale)
 		optionsInfo += fmt.Sprintf("\n音量: %.2f", *options.VolumeScale)
+		if options.OutputSamplingRate != nil || *options.OutputStereo {
+			optionsInfo += "\n出力: " + describeAudioOutput(options)
+		}
 	}
 
 	result := ToolCallResult{
 		Content: []ContentItem{
 			{
 				Type: "text",
-				Text: fmt.Sprintf("音声合成が完了しました。\nテキスト: %s\n話者ID: %d%s\nファイル: %s\n状態: %s",
-					text, speakerID, optionsInfo, filepath, playbackStatus),
+				Text: fmt.Sprintf("音声合成が完了しました。\nテキスト: %s%s\n話者ID: %d%s%s\n状態: %s",
+					text, spokenInfo, speakerID, optionsInfo, fileInfo, playbackStatus),
 			},
 		},
 	}
+	// 字幕・タイミング・口の形は形式ごとに別の要素で返す
+	for _, f := range append(subtitleFiles, visemeFiles...) {
+		result.Content = append(result.Content, ContentItem{Type: "text", Text: string(f.content)})
+	}
 
 	return MCPResponse{
 		JSONRPC: "2.0",
@@ -256,21 +606,284 @@ func (h *Handler) handleTextToSpeech(id interface{}, args map[string]interface{}
 	}
 }
 
+// resolveSpeaker は引数と設定から合成に使うスタイルIDを決定します。
+// 優先順位は speaker_id、speaker（名前）、設定のデフォルト話者名、デフォルト話者IDの順です
+func (h *Handler) resolveSpeaker(args map[string]interface{}) (int, *errors.AppError) {
+	if sid, ok := args["speaker_id"].(float64); ok {
+		styleID, err := parseSpeakerID(sid)
+		if err != nil {
+			return 0, errors.NewMCPError(errors.MCPInvalidParams, err.Error())
+		}
+		return h.validateStyleID(styleID)
+	}
+
+	name, _ := args["speaker"].(string)
+	if name == "" {
+		name = h.config.DefaultSpeakerName
+	}
+	if name == "" {
+		return h.validateStyleID(h.config.DefaultSpeaker)
+	}
+	return h.resolveSpeakerName(name)
+}
+
+// resolveSpeakerName は「キャラクター名 + スタイル名」形式の話者名をスタイルIDにします
+func (h *Handler) resolveSpeakerName(name string) (int, *errors.AppError) {
+	style, err := h.speakers.Resolve(name)
+	if err != nil {
+		var lookupErr *voicevox.SpeakerLookupError
+		if stderrors.As(err, &lookupErr) {
+			return 0, errors.NewMCPError(errors.MCPInvalidParams, lookupErr.Error())
+		}
+		return 0, errors.NewVoicevoxAPIError("Failed to get speakers", err)
+	}
+	return style.StyleID, nil
+}
+
+// resolveVoice はSSMLの <voice name> の話者をスタイルIDにします
+func (h *Handler) resolveVoice(name string) (int, error) {
+	styleID, appErr := h.resolveSpeakerName(name)
+	if appErr != nil {
+		return 0, appErr
+	}
+	return styleID, nil
+}
+
+// validateStyleID はスタイルIDがエンジンに存在するかを確認します。
+// 話者一覧を取得できない場合は検証を省略し、合成時のエラーに委ねます
+func (h *Handler) validateStyleID(styleID int) (int, *errors.AppError) {
+	err := h.speakers.ValidateStyleID(styleID)
+
+	var unknownErr *voicevox.UnknownStyleError
+	if stderrors.As(err, &unknownErr) {
+		return 0, errors.NewMCPError(errors.MCPInvalidParams, unknownErr.Error())
+	}
+	if err != nil {
+		log.Printf("Speaker validation skipped: %v", err)
+	}
+	return styleID, nil
+}
+
 // handleGetSpeakers は話者一覧取得を処理します
-func (h *Handler) handleGetSpeakers(id interface{}) MCPResponse {
-	speakers, err := h.voicevoxClient.GetSpeakers()
+func (h *Handler) handleGetSpeakers(id interface{}, args map[string]interface{}) MCPResponse {
+	name, _ := args["name"].(string)
+	styleType, _ := args["style_type"].(string)
+
+	speakers, err := h.speakers.Speakers()
+	if err != nil {
+		appErr := errors.NewVoicevoxAPIError("Failed to get speakers", err)
+		return h.createErrorResponse(id, appErr)
+	}
+
+	speakers = voicevox.FilterSpeakers(speakers, name, styleType)
+
+	result := ToolCallResult{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: formatSpeakers(speakers),
+			},
+		},
+	}
+
+	return MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// formatSpeakers は話者一覧をキャラクターごとにスタイルIDを並べたテキストに整形します
+func formatSpeakers(speakers []voicevox.Speaker) string {
+	if len(speakers) == 0 {
+		return "条件に一致する話者は見つかりませんでした"
+	}
+
+	var b strings.Builder
+	b.WriteString("利用可能な話者一覧（text_to_speech の speaker_id にはスタイルIDを指定します）:\n")
+	for _, speaker := range speakers {
+		fmt.Fprintf(&b, "\n%s", speaker.Name)
+		if speaker.Version != "" {
+			fmt.Fprintf(&b, " (v%s)", speaker.Version)
+		}
+		b.WriteString("\n")
+		for _, style := range speaker.Styles {
+			fmt.Fprintf(&b, "  - %s: %d", style.Name, style.ID)
+			if style.Category() != voicevox.StyleCategoryTalk {
+				fmt.Fprintf(&b, " [%s]", style.Type)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// handleControlPlayback は再生キューの操作を処理します
+func (h *Handler) handleControlPlayback(id interface{}, args map[string]interface{}) MCPResponse {
+	if h.playback == nil {
+		return h.createErrorResponse(id, errors.NewAudioPlaybackError("Audio playback is disabled", nil))
+	}
+
+	action, _ := args["action"].(string)
+	control, err := controlPlayback(h.playback, action)
+	if err != nil {
+		return h.createErrorResponse(id, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+
+	var message string
+	switch action {
+	case "stop":
+		message = fmt.Sprintf("再生を停止しました（中断: %v, 破棄した再生待ち: %d件）", control.Stopped, control.Cleared)
+	case "skip":
+		if control.Stopped {
+			message = "再生中の音声をスキップしました"
+		} else {
+			message = "再生中の音声はありません"
+		}
+	case "clear":
+		message = fmt.Sprintf("再生待ちの音声を%d件破棄しました", control.Cleared)
+	}
+
+	result := ToolCallResult{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: message,
+			},
+		},
+	}
+
+	return MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// handleGetPlaybackStatus は再生キューの状態取得を処理します
+func (h *Handler) handleGetPlaybackStatus(id interface{}) MCPResponse {
+	if h.playback == nil {
+		return h.createErrorResponse(id, errors.NewAudioPlaybackError("Audio playback is disabled", nil))
+	}
+
+	state := h.playback.State()
+	stateJSON, _ := json.MarshalIndent(state, "", "  ")
+
+	summary := "再生中の音声はありません"
+	if state.Current != nil {
+		summary = fmt.Sprintf("再生中（ID: %d）、再生待ち: %d件", state.Current.ID, len(state.Pending))
+	}
+
+	result := ToolCallResult{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: fmt.Sprintf("%s\n%s", summary, string(stateJSON)),
+			},
+		},
+	}
+
+	return MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// handleListAudioDevices は出力デバイスの一覧取得を処理します。
+// 再生が無効な場合も、設定されたバックエンドでデバイスを列挙します
+func (h *Handler) handleListAudioDevices(id interface{}) MCPResponse {
+	player, devices, err := listAudioDevices(h.playback, h.config)
 	if err != nil {
-		appErr := errors.NewVoicevoxError("Failed to get speakers", err)
+		return h.createErrorResponse(id, errors.NewAudioPlaybackError("Failed to list audio devices", err))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "出力デバイス一覧（バックエンド: %s、text_to_speech の device に name を指定します）:\n", player.Name())
+	if len(devices) == 0 {
+		b.WriteString("\nデバイスが見つかりませんでした\n")
+	}
+	for _, device := range devices {
+		fmt.Fprintf(&b, "\n- %s", device.Name)
+		if device.Default {
+			b.WriteString(" [default]")
+		}
+		if device.Description != "" {
+			fmt.Fprintf(&b, "\n  %s", device.Description)
+		}
+	}
+	if h.config.AudioDevice != "" {
+		fmt.Fprintf(&b, "\n\n設定されたデバイス: %s", h.config.AudioDevice)
+	}
+
+	result := ToolCallResult{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: b.String(),
+			},
+		},
+	}
+
+	return MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// handleMixAudio は保存済みの音声または素材にBGM・効果音を重ね、一時ディレクトリに保存します。
+// 再生が有効な場合はミックスした音声を再生キューに追加します
+func (h *Handler) handleMixAudio(id interface{}, args map[string]interface{}) MCPResponse {
+	mixed, appErr := mixFile(args, h.config.TempDir, h.config.AssetDir, h.outputFormat, h.config.PostProcess)
+	if appErr != nil {
 		return h.createErrorResponse(id, appErr)
 	}
 
-	speakersJSON, _ := json.MarshalIndent(speakers, "", "  ")
+	status := "ファイルに保存されました"
+	if h.playback != nil {
+		ticket := h.playback.EnqueueAudio(mixed.wav, audio.EnqueueOptions{Text: "mix: " + mixed.file})
+		status = fmt.Sprintf("%s。再生キューに追加しました（ID: %d, 待ち順: %d）", status, ticket.ID, ticket.Position)
+	}
+
+	text := fmt.Sprintf("ミックスが完了しました。\n元の音声: %s\nミックス: %s\n長さ: %.2f秒\nファイル: %s", mixed.file, mixTrackNames(mixed.mix), mixed.duration, mixed.path)
+	if mixed.format.Name != audio.FormatWAV {
+		text += fmt.Sprintf("\n形式: %s (%s)", mixed.format.Name, mixed.format.MIMEType)
+	}
+
+	result := ToolCallResult{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: text + "\n状態: " + status,
+			},
+		},
+	}
+
+	return MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// handleGetWarmupStatus はスタイル事前初期化の状態取得を処理します
+func (h *Handler) handleGetWarmupStatus(id interface{}) MCPResponse {
+	report := h.warmup.Report()
+	reportJSON, _ := json.MarshalIndent(report, "", "  ")
+
+	summary := "全てのスタイルの準備が完了しています"
+	if len(report.Styles) == 0 {
+		summary = "事前初期化するスタイルは設定されていません"
+	} else if !report.Ready {
+		summary = "準備中のスタイルがあります"
+	}
 
 	result := ToolCallResult{
 		Content: []ContentItem{
 			{
 				Type: "text",
-				Text: fmt.Sprintf("利用可能な話者一覧:\n%s", string(speakersJSON)),
+				Text: fmt.Sprintf("%s\n%s", summary, string(reportJSON)),
 			},
 		},
 	}
@@ -291,6 +904,7 @@ func (h *Handler) createErrorResponse(id interface{}, appErr *errors.AppError) M
 		Error: &MCPError{
 			Code:    mcpErr.Code,
 			Message: mcpErr.Message,
+			Data:    mcpErr.Data,
 		},
 	}
 }
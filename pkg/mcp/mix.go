@@ -0,0 +1,337 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/errors"
+)
+
+// parseMix はツール引数の mix を読み取ります。省略された場合は false を返します。
+// {"tracks": [...], "voice_offset": 500, "tail": 1000} のオブジェクトのほか、トラックの配列やファイル名だけも受け付けます
+func parseMix(arg interface{}) (audio.MixOptions, bool, error) {
+	var opts audio.MixOptions
+	switch v := arg.(type) {
+	case nil:
+		return opts, false, nil
+	case string, []interface{}:
+		tracks, err := parseMixTracks(v)
+		if err != nil {
+			return opts, false, err
+		}
+		opts.Tracks = tracks
+	case map[string]interface{}:
+		if err := parseMixObject(v, &opts); err != nil {
+			return opts, false, err
+		}
+	default:
+		return opts, false, fmt.Errorf("mix must be an object, an array of tracks or a file name")
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, false, err
+	}
+	return opts, true, nil
+}
+
+// parseMixObject は tracks・voice_offset・tail を持つオブジェクトを読み取ります
+func parseMixObject(args map[string]interface{}, opts *audio.MixOptions) error {
+	tracks, err := parseMixTracks(args["tracks"])
+	if err != nil {
+		return err
+	}
+	opts.Tracks = tracks
+	if opts.VoiceOffset, err = parseMilliseconds(args, "voice_offset"); err != nil {
+		return err
+	}
+	if opts.Tail, err = parseMilliseconds(args, "tail"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseMixTracks はトラックの一覧を読み取ります。ファイル名だけの要素は音量などを変えないトラックとして扱います
+func parseMixTracks(arg interface{}) ([]audio.MixTrack, error) {
+	var items []interface{}
+	switch v := arg.(type) {
+	case nil:
+		return nil, fmt.Errorf("mix requires tracks")
+	case string:
+		items = []interface{}{v}
+	case []interface{}:
+		items = v
+	default:
+		return nil, fmt.Errorf("mix tracks must be an array")
+	}
+
+	tracks := make([]audio.MixTrack, 0, len(items))
+	for i, item := range items {
+		switch t := item.(type) {
+		case string:
+			tracks = append(tracks, audio.MixTrack{File: t})
+		case map[string]interface{}:
+			track, err := parseMixTrack(t)
+			if err != nil {
+				return nil, fmt.Errorf("mix track %d: %w", i, err)
+			}
+			tracks = append(tracks, track)
+		default:
+			return nil, fmt.Errorf("mix track %d must be an object or a file name", i)
+		}
+	}
+	return tracks, nil
+}
+
+// parseMixTrack は file・gain・duck・offset・loop を持つトラックを読み取ります
+func parseMixTrack(args map[string]interface{}) (audio.MixTrack, error) {
+	var track audio.MixTrack
+	file, ok := args["file"].(string)
+	if !ok || file == "" {
+		return track, fmt.Errorf("file must be a non-empty string")
+	}
+	track.File = file
+	for _, p := range []struct {
+		name   string
+		target *float64
+	}{{"gain", &track.Gain}, {"duck", &track.Duck}} {
+		value, ok := args[p.name]
+		if !ok || value == nil {
+			continue
+		}
+		v, ok := value.(float64)
+		if !ok {
+			return track, fmt.Errorf("%s must be a number", p.name)
+		}
+		*p.target = v
+	}
+	offset, err := parseMilliseconds(args, "offset")
+	if err != nil {
+		return track, err
+	}
+	track.Offset = offset
+	if value, ok := args["loop"]; ok && value != nil {
+		loop, ok := value.(bool)
+		if !ok {
+			return track, fmt.Errorf("loop must be a boolean")
+		}
+		track.Loop = loop
+	}
+	return track, nil
+}
+
+// parseMilliseconds はミリ秒の数値の引数を読み取ります
+func parseMilliseconds(args map[string]interface{}, name string) (time.Duration, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	ms, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a number of milliseconds", name)
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+// mixAudio は音声にトラックを重ねます。素材が見つからないなど引数の誤りによる失敗も含めてエラーを返します
+func mixAudio(wav []byte, assetDir string, opts audio.MixOptions) ([]byte, error) {
+	mixed, err := audio.Mix(wav, audio.AssetDir(assetDir), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mix audio: %w", err)
+	}
+	return mixed, nil
+}
+
+// resolveMixSource は mix_audio の file を読み込むパスにします。
+// 絶対パスは一時ディレクトリ内（text_to_speech が保存したファイル）に限り、それ以外は素材ディレクトリ内の名前として扱います
+func resolveMixSource(file, tempDir, assetDir string) (string, error) {
+	if !filepath.IsAbs(file) {
+		return audio.AssetDir(assetDir).Path(file)
+	}
+	if !insideDir(tempDir, filepath.Clean(file)) {
+		return "", fmt.Errorf("file must be in the temp directory (%s) or the asset directory", tempDir)
+	}
+	return filepath.Clean(file), nil
+}
+
+// mixedFile は mix_audio がトラックを重ねて保存した音声です
+type mixedFile struct {
+	// file は引数の元の音声、path は保存したファイルのパスです
+	file string
+	path string
+	// wav は再生に使う後処理をしたWAVです
+	wav      []byte
+	format   audio.OutputFormat
+	mix      audio.MixOptions
+	duration float64
+}
+
+// mixFile は mix_audio の引数の音声にトラックを重ねて後処理し、出力形式で一時ディレクトリに mix_<タイムスタンプ> として保存します。
+// 引数の誤りは MCPInvalidParams、読み書きの失敗は FileOperationError、後処理や変換の失敗は AudioSynthesisError の *errors.AppError を返します
+func mixFile(args map[string]interface{}, tempDir, assetDir string, defaultFormat audio.OutputFormat, defaultPost audio.PostProcessOptions) (*mixedFile, *errors.AppError) {
+	invalid := func(err error) *errors.AppError {
+		return errors.NewMCPError(errors.MCPInvalidParams, err.Error())
+	}
+
+	file, _ := args["file"].(string)
+	if file == "" {
+		return nil, invalid(fmt.Errorf("file parameter is required"))
+	}
+	source, err := resolveMixSource(file, tempDir, assetDir)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	var mix audio.MixOptions
+	if err := parseMixObject(args, &mix); err != nil {
+		return nil, invalid(err)
+	}
+	if err := mix.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	outputFormat, err := parseOutputFormat(args, defaultFormat)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	postProcess, err := parsePostProcess(args, defaultPost)
+	if err != nil {
+		return nil, invalid(err)
+	}
+
+	wav, err := os.ReadFile(source)
+	if os.IsNotExist(err) {
+		return nil, invalid(fmt.Errorf("file not found: %s", file))
+	}
+	if err != nil {
+		return nil, errors.NewFileOperationError("Failed to read audio file", err)
+	}
+	mixed, err := mixAudio(wav, assetDir, mix)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	mixed, err = audio.PostProcess(mixed, postProcess)
+	if err != nil {
+		return nil, errors.NewAudioSynthesisError("Failed to post-process audio", err)
+	}
+	encoded, err := outputFormat.Encode(mixed)
+	if err != nil {
+		return nil, errors.NewAudioSynthesisError("Failed to encode audio as "+outputFormat.Name, err)
+	}
+	path := filepath.Join(tempDir, fmt.Sprintf("mix_%d%s", time.Now().UnixNano(), outputFormat.Extension))
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		return nil, errors.NewFileOperationError("Failed to save audio file", err)
+	}
+
+	duration := 0.0
+	if samples, err := audio.DecodeSamples(mixed); err == nil {
+		duration = samples.Duration()
+	}
+	return &mixedFile{
+		file:     file,
+		path:     path,
+		wav:      mixed,
+		format:   outputFormat,
+		mix:      mix,
+		duration: duration,
+	}, nil
+}
+
+// mixAudioSchema は mix_audio の引数のJSON Schemaです
+func mixAudioSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"file": map[string]interface{}{
+				"type":        "string",
+				"description": "元の音声のWAVファイル（text_to_speech が保存したファイルのパス、または素材ディレクトリ内の名前）",
+			},
+			"tracks": map[string]interface{}{
+				"type":        "array",
+				"description": "重ねるトラック（最大8個）",
+				"items":       mixTrackSchema(),
+			},
+			"voice_offset": map[string]interface{}{
+				"type":        "number",
+				"description": "元の音声を始める位置（ミリ秒）",
+				"minimum":     0,
+			},
+			"tail": map[string]interface{}{
+				"type":        "number",
+				"description": "元の音声の後にBGMを続ける長さ（ミリ秒）",
+				"minimum":     0,
+			},
+			"output_format": outputFormatSchema(),
+			"postprocess":   postProcessSchema(),
+		},
+		"required": []string{"file", "tracks"},
+	}
+}
+
+// mixTrackSchema はトラックのJSON Schemaです
+func mixTrackSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": []string{"object", "string"},
+		"properties": map[string]interface{}{
+			"file": map[string]interface{}{
+				"type":        "string",
+				"description": "素材ディレクトリ内のWAVファイルの名前（例: \"bgm/loop.wav\"）",
+			},
+			"gain": map[string]interface{}{
+				"type":        "number",
+				"description": "音量の調整（dB、-60〜12、デフォルト: 0）",
+				"minimum":     -60,
+				"maximum":     12,
+			},
+			"duck": map[string]interface{}{
+				"type":        "number",
+				"description": "話している間に下げる量（dB、-60〜0、例: -12。デフォルト: 0 で下げない）",
+				"minimum":     -60,
+				"maximum":     0,
+			},
+			"offset": map[string]interface{}{
+				"type":        "number",
+				"description": "トラックを始める位置（ミリ秒）",
+				"minimum":     0,
+			},
+			"loop": map[string]interface{}{
+				"type":        "boolean",
+				"description": "ミックスの終わりまで繰り返す（BGM向け）",
+			},
+		},
+		"required": []string{"file"},
+	}
+}
+
+// mixSchema は text_to_speech の mix 引数のJSON Schemaです
+func mixSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        []string{"object", "array", "string"},
+		"description": "合成した音声に素材ディレクトリのBGM・効果音を重ねる。{\"tracks\": [{\"file\": \"bgm.wav\", \"gain\": -12, \"duck\": -12, \"loop\": true}], \"voice_offset\": 1000, \"tail\": 1500} のように指定し、トラックの配列やファイル名だけも使える",
+		"properties": map[string]interface{}{
+			"tracks": map[string]interface{}{
+				"type":  "array",
+				"items": mixTrackSchema(),
+			},
+			"voice_offset": map[string]interface{}{
+				"type":        "number",
+				"description": "音声を始める位置（ミリ秒、ジングルの後に話し始める場合など）",
+				"minimum":     0,
+			},
+			"tail": map[string]interface{}{
+				"type":        "number",
+				"description": "音声の後にBGMを続ける長さ（ミリ秒）",
+				"minimum":     0,
+			},
+		},
+	}
+}
+
+// mixTrackNames は結果に表示するトラックのファイル名の一覧です
+func mixTrackNames(opts audio.MixOptions) string {
+	names := make([]string, len(opts.Tracks))
+	for i, t := range opts.Tracks {
+		names[i] = t.File
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+)
+
+func TestResolveMixSource(t *testing.T) {
+	tempDir := t.TempDir()
+	assetDir := t.TempDir()
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"asset name", "jingle.wav", filepath.Join(assetDir, "jingle.wav")},
+		{"nested asset name", "bgm/../se/chime.wav", filepath.Join(assetDir, "se", "chime.wav")},
+		{"file in temp dir", filepath.Join(tempDir, "speech_3_1.wav"), filepath.Join(tempDir, "speech_3_1.wav")},
+		{"unclean file in temp dir", filepath.Join(tempDir, "a", "..", "speech.wav"), filepath.Join(tempDir, "speech.wav")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveMixSource(tt.file, tempDir, assetDir)
+			if err != nil {
+				t.Fatalf("resolveMixSource() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveMixSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveMixSource_Rejected(t *testing.T) {
+	tempDir := t.TempDir()
+	assetDir := t.TempDir()
+	tests := []struct {
+		name     string
+		file     string
+		assetDir string
+	}{
+		{"parent of asset dir", "../secret.wav", assetDir},
+		{"traversal in asset name", "bgm/../../secret.wav", assetDir},
+		{"absolute path outside", "/etc/passwd", assetDir},
+		{"absolute traversal out of temp dir", filepath.Join(tempDir, "..", "secret.wav"), assetDir},
+		{"sibling of temp dir", tempDir + "-other/speech.wav", assetDir},
+		{"asset dir given as absolute path", filepath.Join(assetDir, "jingle.wav"), assetDir},
+		{"no asset dir", "jingle.wav", ""},
+		{"empty name", "", assetDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := resolveMixSource(tt.file, tempDir, tt.assetDir); err == nil {
+				t.Errorf("resolveMixSource(%q) = %q, want error", tt.file, got)
+			}
+		})
+	}
+}
+
+func TestResolveMixSource_Symlink(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(tempDir, "link")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.wav"), filepath.Join(tempDir, "speech.wav")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.wav"), timingWAV(0, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{filepath.Join(tempDir, "link", "secret.wav"), filepath.Join(tempDir, "speech.wav")} {
+		if got, err := resolveMixSource(file, tempDir, t.TempDir()); err == nil {
+			t.Errorf("resolveMixSource(%q) = %q, want error for a link out of the temp directory", file, got)
+		}
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  interface{}
+		want audio.MixOptions
+	}{
+		{
+			name: "file name",
+			arg:  "bgm.wav",
+			want: audio.MixOptions{Tracks: []audio.MixTrack{{File: "bgm.wav"}}},
+		},
+		{
+			name: "array of file names and tracks",
+			arg: []interface{}{
+				"jingle.wav",
+				map[string]interface{}{"file": "bgm.wav", "gain": -12.0, "duck": -6.0, "offset": 250.0, "loop": true},
+			},
+			want: audio.MixOptions{Tracks: []audio.MixTrack{
+				{File: "jingle.wav"},
+				{File: "bgm.wav", Gain: -12, Duck: -6, Offset: 250 * time.Millisecond, Loop: true},
+			}},
+		},
+		{
+			name: "object",
+			arg: map[string]interface{}{
+				"tracks":       []interface{}{"jingle.wav"},
+				"voice_offset": 1500.0,
+				"tail":         500.5,
+			},
+			want: audio.MixOptions{
+				Tracks:      []audio.MixTrack{{File: "jingle.wav"}},
+				VoiceOffset: 1500 * time.Millisecond,
+				Tail:        500*time.Millisecond + 500*time.Microsecond,
+			},
+		},
+		{
+			name: "null fields are omitted",
+			arg: map[string]interface{}{
+				"tracks":       []interface{}{map[string]interface{}{"file": "bgm.wav", "gain": nil, "loop": nil}},
+				"voice_offset": nil,
+			},
+			want: audio.MixOptions{Tracks: []audio.MixTrack{{File: "bgm.wav"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseMix(tt.arg)
+			if err != nil {
+				t.Fatalf("parseMix() error = %v", err)
+			}
+			if !ok {
+				t.Fatal("parseMix() ok = false, want true")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMix() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok, err := parseMix(nil); ok || err != nil {
+		t.Errorf("parseMix(nil) = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestParseMix_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     interface{}
+		wantErr string
+	}{
+		{"wrong type", 1.0, "mix must be an object"},
+		{"object without tracks", map[string]interface{}{"voice_offset": 100.0}, "mix requires tracks"},
+		{"empty tracks", []interface{}{}, "at least one track"},
+		{"tracks not an array", map[string]interface{}{"tracks": 1.0}, "mix tracks must be an array"},
+		{"track of wrong type", []interface{}{1.0}, "mix track 0 must be an object or a file name"},
+		{"track without file", []interface{}{map[string]interface{}{"gain": -3.0}}, "mix track 0: file must be a non-empty string"},
+		{"gain not a number", []interface{}{map[string]interface{}{"file": "bgm.wav", "gain": "loud"}}, "gain must be a number"},
+		{"loop not a boolean", []interface{}{map[string]interface{}{"file": "bgm.wav", "loop": "yes"}}, "loop must be a boolean"},
+		{"offset not a number", []interface{}{map[string]interface{}{"file": "bgm.wav", "offset": "1s"}}, "offset must be a number of milliseconds"},
+		{"voice_offset not a number", map[string]interface{}{"tracks": "bgm.wav", "voice_offset": "1s"}, "voice_offset must be a number of milliseconds"},
+		{"negative voice_offset", map[string]interface{}{"tracks": "bgm.wav", "voice_offset": -1.0}, "voice_offset must be between"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok, err := parseMix(tt.arg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseMix() error = %v, want %q", err, tt.wantErr)
+			}
+			if ok {
+				t.Error("parseMix() ok = true, want false")
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+// parseOutputFormat はツール引数の output_format から保存・返却する音声の形式を決めます。
+// 省略された場合は def を返します。外部コマンドが必要な形式でコマンドが見つからない場合はエラーを返します
+func parseOutputFormat(args map[string]interface{}, def audio.OutputFormat) (audio.OutputFormat, error) {
+	value, ok := args["output_format"]
+	if !ok || value == nil {
+		return def, nil
+	}
+	name, ok := value.(string)
+	if !ok {
+		return def, fmt.Errorf("output_format must be a string")
+	}
+	format, err := audio.ParseOutputFormat(name)
+	if err != nil {
+		return def, err
+	}
+	if err := format.Available(); err != nil {
+		return def, err
+	}
+	return format, nil
+}
+
+// outputFormatSchema は output_format 引数のJSON Schemaです
+func outputFormatSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "保存・返却する音声の形式（wav, flac, mulaw: 8kHzのμ-law, opus: Ogg/Opus, mp3）。opus と mp3 は ffmpeg などの外部コマンドが必要。再生には常にWAVを使う",
+		"enum":        audio.OutputFormatNames(),
+	}
+}
+
+// parsePostProcess はツール引数の postprocess で設定の後処理を上書きします
+func parsePostProcess(args map[string]interface{}, def audio.PostProcessOptions) (audio.PostProcessOptions, error) {
+	opts := def
+	if err := opts.ApplyArgs(args["postprocess"]); err != nil {
+		return def, err
+	}
+	if err := opts.Validate(); err != nil {
+		return def, err
+	}
+	return opts, nil
+}
+
+// postProcessSchema は postprocess 引数のJSON Schemaです
+func postProcessSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        []string{"boolean", "object"},
+		"description": "合成後の音声の後処理。false で無効化、オブジェクトで設定の処理を上書き（省略時は設定に従う）。loudness: 目標ラウドネス（LUFS）, limit: ピークの上限（dBFS）, fade_in / fade_out: フェードの長さ（ミリ秒）, trim: 前後の無音を除去, trim_threshold: 無音とみなす音量（dBFS）, sample_rate: 変換後のサンプリングレート（Hz）。数値の代わりに \"off\" または null で個別に無効化",
+		"properties": map[string]interface{}{
+			"loudness":       map[string]interface{}{"type": []string{"number", "string", "null"}, "minimum": -70, "maximum": -5},
+			"limit":          map[string]interface{}{"type": []string{"number", "string", "null"}, "minimum": -20, "maximum": 0},
+			"fade_in":        map[string]interface{}{"type": []string{"number", "string", "null"}, "minimum": 0, "maximum": 5000},
+			"fade_out":       map[string]interface{}{"type": []string{"number", "string", "null"}, "minimum": 0, "maximum": 5000},
+			"trim":           map[string]interface{}{"type": "boolean"},
+			"trim_threshold": map[string]interface{}{"type": "number", "minimum": -90, "maximum": -10},
+			"sample_rate":    map[string]interface{}{"type": []string{"integer", "string", "null"}, "minimum": 8000, "maximum": 192000},
+		},
+	}
+}
+
+// parseAudioOutputOptions はツール引数の output_sampling_rate、output_stereo、pre_phoneme_length、post_phoneme_length を
+// options に設定します。いずれかが指定された場合は true を返し、型や範囲の誤りはエラーにします
+func parseAudioOutputOptions(args map[string]interface{}, options *voicevox.AudioQueryOptions) (bool, error) {
+	found := false
+	for _, name := range []string{"pre_phoneme_length", "post_phoneme_length"} {
+		value, ok := args[name]
+		if !ok || value == nil {
+			continue
+		}
+		v, ok := value.(float64)
+		if !ok {
+			return false, fmt.Errorf("%s must be a number", name)
+		}
+		if name == "pre_phoneme_length" {
+			options.PrePhonemeLength = &v
+		} else {
+			options.PostPhonemeLength = &v
+		}
+		found = true
+	}
+	if value, ok := args["output_sampling_rate"]; ok && value != nil {
+		v, ok := value.(float64)
+		if !ok || v != math.Trunc(v) {
+			return false, fmt.Errorf("output_sampling_rate must be an integer")
+		}
+		rate := int(v)
+		options.OutputSamplingRate = &rate
+		found = true
+	}
+	if value, ok := args["output_stereo"]; ok && value != nil {
+		v, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("output_stereo must be a boolean")
+		}
+		options.OutputStereo = &v
+		found = true
+	}
+	if err := options.Validate(); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// describeAudioOutput は出力のサンプリングレートとチャンネル数を "48000Hz ステレオ" のように表します
+func describeAudioOutput(options *voicevox.AudioQueryOptions) string {
+	rate := "話者の既定のサンプリングレート"
+	if options.OutputSamplingRate != nil {
+		rate = fmt.Sprintf("%dHz", *options.OutputSamplingRate)
+	}
+	if options.OutputStereo != nil && *options.OutputStereo {
+		return rate + " ステレオ"
+	}
+	return rate + " モノラル"
+}
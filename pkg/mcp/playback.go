@@ -0,0 +1,132 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/config"
+)
+
+// deviceListTimeout は出力デバイスの列挙に使うコマンドのタイムアウトです
+const deviceListTimeout = 10 * time.Second
+
+// NewPlaybackQueue は設定に従って再生バックエンドを選び、再生キューを作成します
+func NewPlaybackQueue(cfg *config.Config) (*audio.Queue, error) {
+	player, err := NewPlayer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := audio.NewQueue(player)
+	queue.TempDir = cfg.TempDir
+	queue.Defaults = audio.PlayOptions{Device: cfg.AudioDevice, Volume: cfg.PlaybackVolume}
+	queue.OnError = func(text string, err error) {
+		log.Printf("Audio playback failed (%s): %v", player.Name(), err)
+	}
+	return queue, nil
+}
+
+// NewPlayer は設定から再生バックエンドを作成します。
+// リモートの再生先（AudioSink）、コマンドテンプレート、バックエンド名の順に優先します
+func NewPlayer(cfg *config.Config) (audio.Player, error) {
+	var (
+		player audio.Player
+		err    error
+	)
+	if cfg.AudioSink != "" {
+		player, err = audio.NewSinkPlayer(cfg.AudioSink, cfg.AudioSinkToken)
+	} else {
+		player, err = audio.NewPlayer(cfg.AudioPlayer, cfg.AudioPlayerCommand)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio player: %w", err)
+	}
+	return player, nil
+}
+
+// playbackControl は control_playback の操作の結果です
+type playbackControl struct {
+	Action string `json:"action"`
+	// Stopped は再生中の音声を止めたかどうかです（stop と skip）
+	Stopped bool `json:"stopped"`
+	// Cleared は破棄した再生待ちの件数です（stop と clear）
+	Cleared int `json:"cleared"`
+}
+
+// controlPlayback は control_playback の action を再生キューに適用します
+func controlPlayback(queue *audio.Queue, action string) (playbackControl, error) {
+	result := playbackControl{Action: action}
+	switch action {
+	case "stop":
+		result.Cleared, result.Stopped = queue.Stop()
+	case "skip":
+		result.Stopped = queue.Skip()
+	case "clear":
+		result.Cleared = queue.Clear()
+	default:
+		return result, fmt.Errorf("action must be one of stop, skip, clear")
+	}
+	return result, nil
+}
+
+// listAudioDevices は出力デバイスを列挙します。
+// 再生が無効な場合（queue が nil）も、設定から作成したバックエンドでデバイスを列挙します
+func listAudioDevices(queue *audio.Queue, cfg *config.Config) (audio.Player, []audio.Device, error) {
+	var player audio.Player
+	if queue != nil {
+		player = queue.Player()
+	} else {
+		p, err := NewPlayer(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		player = p
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deviceListTimeout)
+	defer cancel()
+
+	devices, err := audio.ListDevices(ctx, player)
+	return player, devices, err
+}
+
+// parseQueueOptions はツール引数の priority、interrupt、device、playback_volume から
+// 再生キューへの追加オプションを作成します
+func parseQueueOptions(args map[string]interface{}) (audio.EnqueueOptions, error) {
+	var opts audio.EnqueueOptions
+	name, _ := args["priority"].(string)
+	priority, err := audio.ParsePriority(name)
+	if err != nil {
+		return opts, err
+	}
+	opts.Priority = priority
+	opts.Interrupt, _ = args["interrupt"].(bool)
+	if opts.Play, err = parsePlayOptions(args); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// parsePlayOptions はツール引数の device と playback_volume から再生オプションを作成します。
+// 省略された値は再生キューの既定値（設定の AudioDevice と PlaybackVolume）になります
+func parsePlayOptions(args map[string]interface{}) (audio.PlayOptions, error) {
+	var opts audio.PlayOptions
+	if device, ok := args["device"]; ok {
+		name, ok := device.(string)
+		if !ok {
+			return opts, fmt.Errorf("device must be a string")
+		}
+		opts.Device = name
+	}
+	if volume, ok := args["playback_volume"]; ok {
+		v, ok := volume.(float64)
+		if !ok || v <= 0.0 || v > 2.0 {
+			return opts, fmt.Errorf("playback_volume must be a number greater than 0.0 and at most 2.0, got %v", volume)
+		}
+		opts.Volume = v
+	}
+	return opts, nil
+}
@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/ssml"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+// preprocessOptions は設定のルールにツール引数の preprocess を重ねます。
+// 英単語をカタカナにする場合、VOICEVOXのユーザー辞書に登録済みの単語は変換せずにエンジンに任せます
+func preprocessOptions(base preprocess.Options, arg interface{}, userDict *voicevox.UserDictCache) (preprocess.Options, error) {
+	opts := base
+	if err := opts.ApplyArgs(arg); err != nil {
+		return opts, err
+	}
+
+	if opts.Enabled && opts.English && userDict != nil {
+		dict := opts.Dictionary
+		if dict == nil {
+			dict = preprocess.DefaultDictionary()
+		}
+		// ユーザー辞書を取得できない場合は前回取得した単語だけを除外する
+		surfaces, _ := userDict.Surfaces()
+		opts.Dictionary = dict.WithKeep(surfaces)
+	}
+	return opts, nil
+}
+
+// speechRequest は読み上げるテキストと、合成する区間の作り方です
+type speechRequest struct {
+	text    string
+	styleID int
+	// ssml はツール引数の ssml です。省略時はテキストが <speak> で始まる場合にSSMLとして扱います
+	ssml interface{}
+	// preprocess はツール引数の preprocess です
+	preprocess interface{}
+	// resolveVoice はSSMLの <voice name> の話者をスタイルIDにします
+	resolveVoice func(name string) (int, error)
+}
+
+// planSpeech はテキストを前処理し、合成する区間に分けます。
+// SSMLの場合は <voice> の話者、<prosody> と <emphasis> の調整、<break> の無音を区間ごとに設定します
+func planSpeech(req speechRequest, base preprocess.Options, userDict *voicevox.UserDictCache) ([]speechPart, error) {
+	opts, err := preprocessOptions(base, req.preprocess, userDict)
+	if err != nil {
+		return nil, err
+	}
+
+	useSSML := ssml.IsSSML(req.text)
+	switch v := req.ssml.(type) {
+	case nil:
+	case bool:
+		useSSML = v
+	default:
+		return nil, fmt.Errorf("ssml must be a boolean")
+	}
+
+	var parts []speechPart
+	if !useSSML {
+		for _, segment := range preprocess.Segments(req.text, opts) {
+			parts = append(parts, speechPart{text: segment.Text, styleID: req.styleID, style: segment.Style, prosody: ssml.NeutralProsody()})
+		}
+	} else {
+		parts, err = planSSML(req, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(spokenText(parts)) == "" {
+		return nil, fmt.Errorf("text has nothing to read after preprocessing")
+	}
+	return parts, nil
+}
+
+// planSSML はSSMLの区間を前処理し、合成する区間に分けます。<sub> と <say-as> の読み方は前処理しません
+func planSSML(req speechRequest, opts preprocess.Options) ([]speechPart, error) {
+	segments, err := ssml.Parse(req.text)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []speechPart
+	var pause time.Duration
+	for _, segment := range segments {
+		if segment.Text == "" {
+			// 無音は直前の区間の後ろに入れる。先頭の無音は次の区間の前に入れる
+			if n := len(parts); n > 0 {
+				parts[n-1].pauseAfter += segment.Break
+			} else {
+				pause += segment.Break
+			}
+			continue
+		}
+
+		styleID := req.styleID
+		if segment.Voice != "" {
+			styleID, err = req.resolveVoice(segment.Voice)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		texts := []preprocess.Segment{{Text: segment.Text}}
+		if !segment.Verbatim {
+			texts = preprocess.Segments(segment.Text, opts)
+		}
+		for _, text := range texts {
+			parts = append(parts, speechPart{text: text.Text, styleID: styleID, style: text.Style, prosody: segment.Prosody, pauseBefore: pause})
+			pause = 0
+		}
+	}
+	return parts, nil
+}
+
+// spokenText は区間のテキストを改行でつないだ読み上げ用のテキストを返します
+func spokenText(parts []speechPart) string {
+	texts := make([]string, len(parts))
+	for i, part := range parts {
+		texts[i] = part.text
+	}
+	return strings.Join(texts, "\n")
+}
@@ -1,15 +1,24 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/batch"
+	"github.com/metapox/mcp-voicevox-go/pkg/config"
+	"github.com/metapox/mcp-voicevox-go/pkg/errors"
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/timing"
 	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
 	"github.com/rs/cors"
 )
@@ -20,20 +29,67 @@ type MCPServer struct {
 	VoicevoxURL    string
 	VoicevoxClient *voicevox.Client
 	TempDir        string
+	// SaveAudio が true の場合、合成した音声を TempDir に保存します。再生とは独立した設定です
+	SaveAudio      bool
 	DefaultSpeaker int
+	// DefaultSpeakerName は名前で指定されたデフォルト話者です。空でなければ DefaultSpeaker より優先します
+	DefaultSpeakerName string
+	Warmup             *voicevox.Warmup
+	Speakers           *voicevox.SpeakerCache
+	// Playback は音声の再生キューです。nil の場合は再生しません
+	Playback *audio.Queue
+	// PlayerConfig は再生バックエンドの設定です。list_audio_devices で使います
+	PlayerConfig *config.Config
+	// Preprocess は読み上げ前のテキストの前処理ルールです
+	Preprocess preprocess.Options
+	// UserDict はVOICEVOXのユーザー辞書のキャッシュです。登録済みの単語は英単語の変換から除外します
+	UserDict *voicevox.UserDictCache
+	// OutputFormat は output_format を省略したときの音声の形式です
+	OutputFormat audio.OutputFormat
+	// PostProcess は合成後の音声に適用する後処理です
+	PostProcess audio.PostProcessOptions
+	// AssetDir は mix 引数のトラックを読み込むディレクトリです
+	AssetDir string
+	// OutputOptions は省略時の出力のサンプリングレート・ステレオ・前後の無音の長さです。話速などの項目は使いません
+	OutputOptions voicevox.AudioQueryOptions
+
+	// batches は batch_synthesize がバックグラウンドで実行するジョブです
+	batches batchJobs
 }
 
 // NewMCPServer は新しいMCPサーバーを作成します
 func NewMCPServer(port int, voicevoxURL string, tempDir string, defaultSpeaker int) *MCPServer {
+	client := voicevox.NewClient(voicevoxURL)
+	wav, _ := audio.ParseOutputFormat(audio.FormatWAV)
 	return &MCPServer{
 		Port:           port,
 		VoicevoxURL:    voicevoxURL,
-		VoicevoxClient: voicevox.NewClient(voicevoxURL),
+		VoicevoxClient: client,
 		TempDir:        tempDir,
+		SaveAudio:      true,
 		DefaultSpeaker: defaultSpeaker,
+		Speakers:       voicevox.NewSpeakerCache(client, voicevox.DefaultSpeakerCacheTTL),
+		Preprocess:     preprocess.DefaultOptions(),
+		UserDict:       voicevox.NewUserDictCache(client, voicevox.DefaultUserDictCacheTTL),
+		OutputFormat:   wav,
+		PostProcess:    audio.DefaultPostProcessOptions(),
 	}
 }
 
+// StartWarmup は指定したスタイルの事前初期化をバックグラウンドで開始します
+func (s *MCPServer) StartWarmup(styles []int) {
+	s.Warmup = voicevox.NewWarmup(s.VoicevoxClient, styles)
+	s.Warmup.Start()
+}
+
+// warmupReport は事前初期化の状態を返します。未設定の場合は空のレポートを返します
+func (s *MCPServer) warmupReport() voicevox.WarmupReport {
+	if s.Warmup == nil {
+		return voicevox.WarmupReport{Ready: true, Styles: []voicevox.WarmupStatus{}}
+	}
+	return s.Warmup.Report()
+}
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true
@@ -55,6 +111,7 @@ func (s *MCPServer) Start() error {
 	mux.HandleFunc("/ws", s.handleWebSocket)
 	mux.HandleFunc("/manifest", s.handleManifest)
 	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/synthesize", s.handleSynthesize)
 
 	// サーバーの起動
 	handler := c.Handler(mux)
@@ -141,7 +198,24 @@ func (s *MCPServer) handleInvoke(requestID string, request map[string]interface{
 	case "text_to_speech":
 		return s.handleTextToSpeech(requestID, toolParams)
 	case "get_speakers":
-		return s.handleGetSpeakers(requestID)
+		return s.handleGetSpeakers(requestID, toolParams)
+	case "get_warmup_status":
+		return map[string]interface{}{
+			"id":     requestID,
+			"result": s.warmupReport(),
+		}, nil
+	case "control_playback":
+		return s.handleControlPlayback(requestID, toolParams)
+	case "get_playback_status":
+		return s.handleGetPlaybackStatus(requestID)
+	case "list_audio_devices":
+		return s.handleListAudioDevices(requestID)
+	case "mix_audio":
+		return s.handleMixAudio(requestID, toolParams)
+	case "batch_synthesize":
+		return s.handleBatchSynthesize(requestID, toolParams)
+	case "get_batch_status":
+		return s.handleGetBatchStatus(requestID, toolParams)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", toolName)
 	}
@@ -150,64 +224,397 @@ func (s *MCPServer) handleInvoke(requestID string, request map[string]interface{
 // handleTextToSpeech はテキスト音声合成リクエストを処理します
 func (s *MCPServer) handleTextToSpeech(requestID string, params map[string]interface{}) (map[string]interface{}, error) {
 	text, _ := params["text"].(string)
-	speakerIDFloat, ok := params["speaker_id"].(float64)
-	var speakerID int
-	if ok {
-		speakerID = int(speakerIDFloat)
-	} else {
-		speakerID = s.DefaultSpeaker // デフォルト話者IDを使用
-	}
-
 	if text == "" {
 		return nil, fmt.Errorf("text parameter is required")
 	}
 
-	// 音声合成クエリの作成
-	query, err := s.VoicevoxClient.CreateAudioQuery(text, speakerID)
+	speakerID, err := s.resolveSpeaker(params)
+	if err != nil {
+		return nil, err
+	}
+	format, err := parseOutputFormat(params, s.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	postProcess, err := parsePostProcess(params, s.PostProcess)
+	if err != nil {
+		return nil, err
+	}
+	mix, hasMix, err := parseMix(params["mix"])
+	if err != nil {
+		return nil, err
+	}
+	subtitles, err := parseTimingFormats(params, "subtitles", subtitleFormats)
+	if err != nil {
+		return nil, err
+	}
+	visemes, err := parseTimingFormats(params, "visemes", visemeFormats)
+	if err != nil {
+		return nil, err
+	}
+	queueOptions, err := parseQueueOptions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := planSpeech(speechRequest{
+		text:         text,
+		styleID:      speakerID,
+		ssml:         params["ssml"],
+		preprocess:   params["preprocess"],
+		resolveVoice: s.resolveVoice,
+	}, s.Preprocess, s.UserDict)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create audio query: %v", err)
+		return nil, err
 	}
+	spoken := spokenText(parts)
 
-	// 音声合成の実行
-	audioData, err := s.VoicevoxClient.SynthesizeVoice(query, speakerID)
+	// 音声合成の実行。SSMLや絵文字の気分で区間が分かれる場合は区間ごとに合成してつなげる
+	options, err := parseQueryOptions(params, s.OutputOptions)
+	if err != nil {
+		return nil, err
+	}
+	var track *timing.Track
+	if len(subtitles) > 0 || len(visemes) > 0 {
+		track = &timing.Track{}
+	}
+	if len(subtitles) > 0 {
+		parts = splitSentences(parts)
+	}
+	ctx := context.Background()
+	audioData, err := synthesizeSpeech(ctx, s.VoicevoxClient, s.Speakers, parts, options, track)
+	if err != nil {
+		return nil, err
+	}
+	if hasMix {
+		if audioData, err = mixAudio(audioData, s.AssetDir, mix); err != nil {
+			return nil, err
+		}
+	}
+	voiced := audioData
+	audioData, err = audio.PostProcess(audioData, postProcess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to synthesize voice: %v", err)
+		return nil, fmt.Errorf("failed to post-process audio: %v", err)
+	}
+
+	// ファイル保存（再生とは独立した設定）。保存する音声は出力形式に変換し、再生にはWAVのまま使う
+	var subtitleFiles, visemeFiles []timingFile
+	if track != nil {
+		alignTimings(track, voiced, audioData, mix.VoiceOffset, postProcess)
+		if subtitleFiles, err = renderTimings(*track, subtitles); err != nil {
+			return nil, err
+		}
+		if visemeFiles, err = renderTimings(*track, visemes); err != nil {
+			return nil, err
+		}
 	}
+	audioPath := ""
+	if s.SaveAudio {
+		encoded, err := format.EncodeContext(ctx, audioData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audio as %s: %v", format.Name, err)
+		}
+		filename := fmt.Sprintf("voicevox_%d%s", time.Now().UnixNano(), format.Extension)
+		audioPath = filepath.Join(s.TempDir, filename)
 
-	// 一時ファイルの作成
-	timestamp := time.Now().UnixNano()
-	filename := fmt.Sprintf("voicevox_%d.wav", timestamp)
-	filepath := filepath.Join(s.TempDir, filename)
+		if err := os.WriteFile(audioPath, encoded, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write audio file: %v", err)
+		}
+		// 字幕と口の形は音声ファイルの隣に保存する
+		if err := saveTimings(subtitleFiles, audioPath); err != nil {
+			return nil, err
+		}
+		if err := saveTimings(visemeFiles, audioPath); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := os.WriteFile(filepath, audioData, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write audio file: %v", err)
+	result := map[string]interface{}{
+		"text":          text,
+		"speaker_id":    speakerID,
+		"output_format": format.Name,
+		"mime_type":     format.MIMEType,
+	}
+	if audioPath != "" {
+		result["audio_path"] = audioPath
+	}
+	if spoken != text {
+		result["spoken_text"] = spoken
+	}
+	if len(subtitleFiles) > 0 {
+		contents, paths := timingContents(subtitleFiles)
+		result["subtitles"] = contents
+		if audioPath != "" {
+			result["subtitle_paths"] = paths
+		}
+	}
+	if len(visemeFiles) > 0 {
+		contents, paths := timingContents(visemeFiles)
+		result["visemes"] = contents
+		if audioPath != "" {
+			result["viseme_paths"] = paths
+		}
+	}
+	if s.Playback != nil {
+		queueOptions.Text = spoken
+		ticket := s.Playback.EnqueueAudio(audioData, queueOptions)
+		result["playback_id"] = ticket.ID
 	}
 
 	return map[string]interface{}{
-		"id": requestID,
-		"result": map[string]interface{}{
-			"audio_path": filepath,
-			"text":       text,
-			"speaker_id": speakerID,
-		},
+		"id":     requestID,
+		"result": result,
 	}, nil
 }
 
+// resolveSpeaker はパラメータからスタイルIDを決定します。
+// speaker_id、speaker（名前）、デフォルト話者名、デフォルト話者IDの順に参照します
+func (s *MCPServer) resolveSpeaker(params map[string]interface{}) (int, error) {
+	if speakerID, ok := params["speaker_id"].(float64); ok {
+		styleID, err := parseSpeakerID(speakerID)
+		if err != nil {
+			return 0, err
+		}
+		return s.validateStyleID(styleID)
+	}
+
+	name, _ := params["speaker"].(string)
+	if name == "" {
+		name = s.DefaultSpeakerName
+	}
+	if name == "" {
+		return s.validateStyleID(s.DefaultSpeaker)
+	}
+
+	return s.resolveVoice(name)
+}
+
+// resolveVoice は話者名（SSMLの <voice name> を含む）をスタイルIDにします
+func (s *MCPServer) resolveVoice(name string) (int, error) {
+	style, err := s.Speakers.Resolve(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve speaker: %v", err)
+	}
+	return style.StyleID, nil
+}
+
+// validateStyleID はスタイルIDがエンジンに存在するかを確認します。
+// 話者一覧を取得できない場合は検証を省略します
+func (s *MCPServer) validateStyleID(styleID int) (int, error) {
+	err := s.Speakers.ValidateStyleID(styleID)
+
+	var unknownErr *voicevox.UnknownStyleError
+	if stderrors.As(err, &unknownErr) {
+		return 0, unknownErr
+	}
+	if err != nil {
+		log.Printf("Speaker validation skipped: %v", err)
+	}
+	return styleID, nil
+}
+
 // handleGetSpeakers は利用可能な話者一覧を取得します
-func (s *MCPServer) handleGetSpeakers(requestID string) (map[string]interface{}, error) {
-	speakers, err := s.VoicevoxClient.GetSpeakers()
+func (s *MCPServer) handleGetSpeakers(requestID string, params map[string]interface{}) (map[string]interface{}, error) {
+	name, _ := params["name"].(string)
+	styleType, _ := params["style_type"].(string)
+
+	speakers, err := s.Speakers.Speakers()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get speakers: %v", err)
 	}
+	speakers = voicevox.FilterSpeakers(speakers, name, styleType)
 
 	return map[string]interface{}{
 		"id": requestID,
 		"result": map[string]interface{}{
 			"speakers": speakers,
+			"styles":   voicevox.FlattenSpeakers(speakers),
 		},
 	}, nil
 }
 
+// handleControlPlayback は再生キューを操作します
+func (s *MCPServer) handleControlPlayback(requestID string, params map[string]interface{}) (map[string]interface{}, error) {
+	if s.Playback == nil {
+		return nil, fmt.Errorf("audio playback is disabled")
+	}
+
+	action, _ := params["action"].(string)
+	control, err := controlPlayback(s.Playback, action)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":     requestID,
+		"result": control,
+	}, nil
+}
+
+// handleGetPlaybackStatus は再生キューの状態を返します
+func (s *MCPServer) handleGetPlaybackStatus(requestID string) (map[string]interface{}, error) {
+	if s.Playback == nil {
+		return nil, fmt.Errorf("audio playback is disabled")
+	}
+
+	return map[string]interface{}{
+		"id":     requestID,
+		"result": s.Playback.State(),
+	}, nil
+}
+
+// handleListAudioDevices は出力デバイスの一覧を返します。
+// 再生が無効な場合も、設定された再生バックエンドでデバイスを列挙します
+func (s *MCPServer) handleListAudioDevices(requestID string) (map[string]interface{}, error) {
+	if s.Playback == nil && s.PlayerConfig == nil {
+		return nil, fmt.Errorf("audio player is not configured")
+	}
+
+	player, devices, err := listAudioDevices(s.Playback, s.PlayerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audio devices: %v", err)
+	}
+	if devices == nil {
+		devices = []audio.Device{}
+	}
+
+	result := map[string]interface{}{
+		"backend": player.Name(),
+		"devices": devices,
+	}
+	if s.PlayerConfig != nil && s.PlayerConfig.AudioDevice != "" {
+		result["configured_device"] = s.PlayerConfig.AudioDevice
+	}
+
+	return map[string]interface{}{
+		"id":     requestID,
+		"result": result,
+	}, nil
+}
+
+// handleMixAudio は保存済みの音声または素材にBGM・効果音を重ね、一時ディレクトリに保存します。
+// 再生が有効な場合はミックスした音声を再生キューに追加します
+func (s *MCPServer) handleMixAudio(requestID string, params map[string]interface{}) (map[string]interface{}, error) {
+	mixed, appErr := mixFile(params, s.TempDir, s.AssetDir, s.OutputFormat, s.PostProcess)
+	if appErr != nil {
+		return nil, plainError(appErr)
+	}
+
+	tracks := make([]string, len(mixed.mix.Tracks))
+	for i, t := range mixed.mix.Tracks {
+		tracks[i] = t.File
+	}
+	result := map[string]interface{}{
+		"file":          mixed.file,
+		"tracks":        tracks,
+		"duration":      mixed.duration,
+		"audio_path":    mixed.path,
+		"output_format": mixed.format.Name,
+		"mime_type":     mixed.format.MIMEType,
+	}
+	if s.Playback != nil {
+		ticket := s.Playback.EnqueueAudio(mixed.wav, audio.EnqueueOptions{Text: "mix: " + mixed.file})
+		result["playback_id"] = ticket.ID
+	}
+
+	return map[string]interface{}{
+		"id":     requestID,
+		"result": result,
+	}, nil
+}
+
+// handleBatchSynthesize は複数の行をまとめて合成するジョブを開始し、ジョブの状態を返します
+func (s *MCPServer) handleBatchSynthesize(requestID string, params map[string]interface{}) (map[string]interface{}, error) {
+	req, err := parseBatchRequest(params, s.TempDir, s.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.batches.startRequest(req, s.runBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":     requestID,
+		"result": job.snapshot(),
+	}, nil
+}
+
+// handleGetBatchStatus は batch_synthesize のジョブの状態を返します。job_id を省略した場合は全てのジョブを jobs に返します
+func (s *MCPServer) handleGetBatchStatus(requestID string, params map[string]interface{}) (map[string]interface{}, error) {
+	value, ok := params["job_id"]
+	if !ok || value == nil {
+		return map[string]interface{}{
+			"id": requestID,
+			"result": map[string]interface{}{
+				"jobs": s.batches.list(),
+			},
+		}, nil
+	}
+
+	job, err := s.batches.lookup(value)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":     requestID,
+		"result": job.snapshot(),
+	}, nil
+}
+
+// runBatch はサーバーの話者・出力の既定値・前処理・後処理で行を合成し、opts.Dir に音声とマニフェストを書きます
+func (s *MCPServer) runBatch(ctx context.Context, rows []batch.Row, opts BatchOptions) (*batch.Summary, error) {
+	options := s.OutputOptions
+	return runBatch(ctx, rows, opts, batchSettings{
+		DefaultSpeaker:     s.DefaultSpeaker,
+		DefaultSpeakerName: s.DefaultSpeakerName,
+		Options:            &options,
+		Preprocess:         s.Preprocess,
+		PostProcess:        s.PostProcess,
+	}, s.synthesize)
+}
+
+// synthesize はテキストを前処理して合成し、後処理をしたWAVを返します。バッチ合成の行に使います
+func (s *MCPServer) synthesize(ctx context.Context, req SpeechRequest) ([]byte, error) {
+	params := req.speakerArgs()
+	for name, value := range map[string]*float64{
+		"speed_scale":      req.SpeedScale,
+		"pitch_scale":      req.PitchScale,
+		"intonation_scale": req.IntonationScale,
+		"volume_scale":     req.VolumeScale,
+	} {
+		if value != nil {
+			params[name] = *value
+		}
+	}
+	options, err := parseQueryOptions(params, s.OutputOptions)
+	if err != nil {
+		return nil, err
+	}
+	styleID, err := s.resolveSpeaker(params)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := planSpeech(speechRequest{
+		text:         req.Text,
+		styleID:      styleID,
+		resolveVoice: s.resolveVoice,
+	}, s.Preprocess, s.UserDict)
+	if err != nil {
+		return nil, err
+	}
+
+	wav, err := synthesizeSpeech(ctx, s.VoicevoxClient, s.Speakers, parts, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	wav, err = audio.PostProcess(wav, s.PostProcess)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post-process audio: %w", err)
+	}
+	return wav, nil
+}
+
 // handleDiscover はツール一覧を返します
 func (s *MCPServer) handleDiscover(requestID string) (map[string]interface{}, error) {
 	return map[string]interface{}{
@@ -229,15 +636,122 @@ func (s *MCPServer) handleDiscover(requestID string) (map[string]interface{}, er
 							},
 							"speaker_id": map[string]interface{}{
 								"type":        "integer",
-								"description": "話者ID（省略時はデフォルト話者を使用）",
+								"description": "話者のスタイルID（省略時はデフォルト話者を使用）",
+							},
+							"speaker": map[string]interface{}{
+								"type":        "string",
+								"description": "話者名とスタイル名（例: \"ずんだもん ノーマル\"）",
+							},
+							"preprocess": map[string]interface{}{
+								"type":        []string{"boolean", "object"},
+								"description": "読み上げ前のテキストの前処理（false で無効化、オブジェクトでルールを上書き）",
+							},
+							"ssml": map[string]interface{}{
+								"type":        "boolean",
+								"description": "text をSSMLとして解析する（省略時は <speak> で始まる場合にSSMLとして扱う）",
+							},
+							"output_sampling_rate": map[string]interface{}{
+								"type":        "integer",
+								"description": "出力のサンプリングレート（8000-96000Hz）",
+							},
+							"output_stereo": map[string]interface{}{
+								"type":        "boolean",
+								"description": "ステレオで出力する",
+							},
+							"priority": map[string]interface{}{
+								"type":        "string",
+								"description": "再生キューでの優先度（urgent は待ち中の音声より先に再生、デフォルト: normal）",
+								"enum":        []string{"low", "normal", "high", "urgent"},
 							},
+							"interrupt": map[string]interface{}{
+								"type":        "boolean",
+								"description": "再生中の音声を中断してすぐに再生する",
+							},
+							"device": map[string]interface{}{
+								"type":        "string",
+								"description": "出力先デバイス（list_audio_devices の name、省略時は設定のデバイス）",
+							},
+							"playback_volume": map[string]interface{}{
+								"type":             "number",
+								"description":      "再生音量の倍率（volume_scale とは独立、省略時は設定の再生音量）",
+								"exclusiveMinimum": 0.0,
+								"maximum":          2.0,
+							},
+							"output_format": outputFormatSchema(),
+							"postprocess":   postProcessSchema(),
+							"mix":           mixSchema(),
+							"subtitles":     subtitlesSchema(),
+							"visemes":       visemesSchema(),
 						},
 						"required": []string{"text"},
 					},
 				},
 				{
 					"name":        "get_speakers",
-					"description": "利用可能な話者一覧を取得します",
+					"description": "利用可能な話者一覧とスタイルIDを取得します",
+					"parameters": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"name": map[string]interface{}{
+								"type":        "string",
+								"description": "話者名で絞り込み（部分一致）",
+							},
+							"style_type": map[string]interface{}{
+								"type":        "string",
+								"description": "スタイルの種類で絞り込み（talk / singing）",
+							},
+						},
+					},
+				},
+				{
+					"name":        "control_playback",
+					"description": "音声の再生キューを操作します（stop: 全て停止, skip: 再生中の音声を飛ばす, clear: 再生待ちを破棄）",
+					"parameters": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"action": map[string]interface{}{
+								"type":        "string",
+								"description": "操作の種類",
+								"enum":        []string{"stop", "skip", "clear"},
+							},
+						},
+						"required": []string{"action"},
+					},
+				},
+				{
+					"name":        "get_playback_status",
+					"description": "再生中の音声と再生待ちのキューの状態を取得します",
+					"parameters": map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{},
+					},
+				},
+				{
+					"name":        "list_audio_devices",
+					"description": "再生に使える出力デバイスの一覧を取得します（text_to_speech の device に指定できます）",
+					"parameters": map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{},
+					},
+				},
+				{
+					"name":        "mix_audio",
+					"description": "保存済みの音声に素材ディレクトリのBGM・効果音を重ねます（ジングル、BGMのループ、話している間のダッキング）",
+					"parameters":  mixAudioSchema(),
+				},
+				{
+					"name":        "batch_synthesize",
+					"description": "複数のテキストをまとめて音声ファイルにします。CSV・JSON Lines・YAML のファイルまたは rows の行ごとに合成し、出力ディレクトリに音声と manifest.json を保存します。合成はバックグラウンドで行い、すぐにジョブの状態を返します（結果は get_batch_status で確認）。音声は再生しません",
+					"parameters":  batchSynthesizeSchema(),
+				},
+				{
+					"name":        "get_batch_status",
+					"description": "batch_synthesize のジョブの進み具合と結果を取得します",
+					"parameters":  batchStatusSchema(),
+				},
+				{
+					"name":        "get_warmup_status",
+					"description": "起動時に事前初期化しているスタイルの準備状況を取得します",
 					"parameters": map[string]interface{}{
 						"type":       "object",
 						"properties": map[string]interface{}{},
@@ -260,8 +774,9 @@ func (s *MCPServer) handleManifest(w http.ResponseWriter, r *http.Request) {
 			"type": "none",
 		},
 		"endpoints": map[string]interface{}{
-			"ws":     fmt.Sprintf("ws://localhost:%d/ws", s.Port),
-			"health": fmt.Sprintf("http://localhost:%d/health", s.Port),
+			"ws":         fmt.Sprintf("ws://localhost:%d/ws", s.Port),
+			"health":     fmt.Sprintf("http://localhost:%d/health", s.Port),
+			"synthesize": fmt.Sprintf("http://localhost:%d/synthesize", s.Port),
 		},
 	}
 
@@ -273,8 +788,158 @@ func (s *MCPServer) handleManifest(w http.ResponseWriter, r *http.Request) {
 func (s *MCPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	health := map[string]interface{}{
 		"status": "ok",
+		"warmup": s.warmupReport(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(health)
 }
+
+// maxSynthesizeRequestSize は /synthesize のリクエストボディの上限です
+const maxSynthesizeRequestSize = 1 << 20
+
+// handleSynthesize は POST /synthesize でテキストを音声に変換し、output_format の形式の音声をそのまま返します。
+// 引数の誤りは400、音声合成や変換の失敗は500で、本文はJSONのエラーです
+func (s *MCPServer) handleSynthesize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeHTTPError(w, http.StatusMethodNotAllowed, errors.NewMCPError(errors.MCPInvalidRequest, "method not allowed"))
+		return
+	}
+
+	var params map[string]interface{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSynthesizeRequestSize)).Decode(&params); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, errors.NewMCPError(errors.MCPParseError, fmt.Sprintf("invalid JSON request: %v", err)))
+		return
+	}
+	invalid := func(err error) {
+		writeHTTPError(w, http.StatusBadRequest, errors.NewMCPError(errors.MCPInvalidParams, err.Error()))
+	}
+
+	text, _ := params["text"].(string)
+	if text == "" {
+		invalid(fmt.Errorf("text parameter is required"))
+		return
+	}
+	format, err := parseOutputFormat(params, s.OutputFormat)
+	if err != nil {
+		invalid(err)
+		return
+	}
+	postProcess, err := parsePostProcess(params, s.PostProcess)
+	if err != nil {
+		invalid(err)
+		return
+	}
+	mix, hasMix, err := parseMix(params["mix"])
+	if err != nil {
+		invalid(err)
+		return
+	}
+	for _, name := range []string{"subtitles", "visemes"} {
+		if params[name] != nil && params[name] != false {
+			invalid(fmt.Errorf("%s are not available from /synthesize; use the text_to_speech tool", name))
+			return
+		}
+	}
+	options, err := parseQueryOptions(params, s.OutputOptions)
+	if err != nil {
+		invalid(err)
+		return
+	}
+	speakerID, err := s.resolveSpeaker(params)
+	if err != nil {
+		invalid(err)
+		return
+	}
+	parts, err := planSpeech(speechRequest{
+		text:         text,
+		styleID:      speakerID,
+		ssml:         params["ssml"],
+		preprocess:   params["preprocess"],
+		resolveVoice: s.resolveVoice,
+	}, s.Preprocess, s.UserDict)
+	if err != nil {
+		invalid(err)
+		return
+	}
+
+	audioData, err := synthesizeSpeech(r.Context(), s.VoicevoxClient, s.Speakers, parts, options, nil)
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, synthesisError(err))
+		return
+	}
+	if hasMix {
+		if audioData, err = mixAudio(audioData, s.AssetDir, mix); err != nil {
+			invalid(err)
+			return
+		}
+	}
+	audioData, err = audio.PostProcess(audioData, postProcess)
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, errors.NewAudioSynthesisError("Failed to post-process audio", err))
+		return
+	}
+	encoded, err := format.EncodeContext(r.Context(), audioData)
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, errors.NewAudioSynthesisError("Failed to encode audio as "+format.Name, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", format.MIMEType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"speech_%d%s\"", speakerID, format.Extension))
+	w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+	w.Write(encoded)
+}
+
+// writeHTTPError はエラーをJSONで返します
+func writeHTTPError(w http.ResponseWriter, status int, appErr *errors.AppError) {
+	mcpErr := appErr.ToMCPError()
+	if appErr.Cause != nil {
+		mcpErr.Message = appErr.Message + ": " + appErr.Cause.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": mcpErr})
+}
+
+// parseQueryOptions は speed_scale などの合成パラメータを読み取り、出力の既定値 defaults に重ねます。
+// どれも指定されておらず既定値もない場合は nil を返します
+func parseQueryOptions(params map[string]interface{}, defaults voicevox.AudioQueryOptions) (*voicevox.AudioQueryOptions, error) {
+	options := voicevox.AudioQueryOptions{
+		PrePhonemeLength:   defaults.PrePhonemeLength,
+		PostPhonemeLength:  defaults.PostPhonemeLength,
+		OutputSamplingRate: defaults.OutputSamplingRate,
+		OutputStereo:       defaults.OutputStereo,
+	}
+	found := options != voicevox.AudioQueryOptions{}
+	for _, p := range []struct {
+		name     string
+		min, max float64
+		target   **float64
+	}{
+		{"speed_scale", 0.5, 2.0, &options.SpeedScale},
+		{"pitch_scale", -0.15, 0.15, &options.PitchScale},
+		{"intonation_scale", 0.0, 2.0, &options.IntonationScale},
+		{"volume_scale", 0.0, 2.0, &options.VolumeScale},
+	} {
+		value, ok := params[p.name]
+		if !ok || value == nil {
+			continue
+		}
+		v, ok := value.(float64)
+		if !ok || v < p.min || v > p.max {
+			return nil, fmt.Errorf("%s must be a number between %v and %v, got %v", p.name, p.min, p.max, value)
+		}
+		*p.target = &v
+		found = true
+	}
+	output, err := parseAudioOutputOptions(params, &options)
+	if err != nil {
+		return nil, err
+	}
+	if !found && !output {
+		return nil, nil
+	}
+	return &options, nil
+}
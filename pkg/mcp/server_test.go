@@ -0,0 +1,200 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/batch"
+)
+
+// newFakeEngine は話者一覧・音声クエリ・合成に答えるテスト用のVOICEVOXエンジンを起動します。合成した音声は 100ms の無音です
+func newFakeEngine(t *testing.T) *httptest.Server {
+	t.Helper()
+	silence := audio.EncodeWAV(audio.WAVFormat{AudioFormat: 1, Channels: 1, SampleRate: 24000, BitsPerSample: 16}, make([]byte, 2*2400))
+	engine := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/version":
+			w.Write([]byte(`"0.15.0"`))
+		case "/speakers":
+			w.Write([]byte(`[{"name": "ずんだもん", "speaker_uuid": "388f246b", "styles": [{"name": "ノーマル", "id": 3}]}]`))
+		case "/audio_query":
+			w.Write([]byte(`{"accent_phrases": [], "speedScale": 1, "pitchScale": 0, "intonationScale": 1, "volumeScale": 1, "outputSamplingRate": 24000}`))
+		case "/synthesis":
+			w.Write(silence)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(engine.Close)
+	return engine
+}
+
+// invoke はサーバーのツールを呼び出します
+func invoke(t *testing.T, s *MCPServer, tool string, params map[string]interface{}) (map[string]interface{}, error) {
+	t.Helper()
+	return s.handleRequest(map[string]interface{}{
+		"id":     "1",
+		"method": "invoke",
+		"params": map[string]interface{}{"name": tool, "parameters": params},
+	})
+}
+
+func TestMCPServer_BatchSynthesize(t *testing.T) {
+	engine := newFakeEngine(t)
+	tempDir := t.TempDir()
+	s := NewMCPServer(0, engine.URL, tempDir, 3)
+
+	response, err := invoke(t, s, "batch_synthesize", map[string]interface{}{
+		"rows": []interface{}{
+			map[string]interface{}{"id": "greet", "text": "こんにちは"},
+			map[string]interface{}{"text": "さようなら", "speaker": "ずんだもん ノーマル", "speed_scale": 1.2},
+		},
+		"output_dir": "voices",
+	})
+	if err != nil {
+		t.Fatalf("batch_synthesize error = %v", err)
+	}
+	started, ok := response["result"].(batchJobStatus)
+	if !ok || started.JobID != 1 || started.Total != 2 || started.Dir != filepath.Join(tempDir, "voices") {
+		t.Fatalf("batch_synthesize result = %+v", response["result"])
+	}
+
+	var status batchJobStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		response, err := invoke(t, s, "get_batch_status", map[string]interface{}{"job_id": 1.0})
+		if err != nil {
+			t.Fatalf("get_batch_status error = %v", err)
+		}
+		status = response["result"].(batchJobStatus)
+		if status.State != batchJobRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status.State != batchJobDone || status.Summary == nil || status.Summary.Done != 2 {
+		t.Fatalf("get_batch_status result = %+v", status)
+	}
+	for _, e := range status.Summary.Entries {
+		if e.Status != batch.StatusDone {
+			t.Errorf("entry %s = %+v", e.ID, e)
+		}
+	}
+	for _, name := range []string{"greet.wav", "0002.wav", "manifest.json"} {
+		if _, err := os.Stat(filepath.Join(tempDir, "voices", name)); err != nil {
+			t.Errorf("output %s: %v", name, err)
+		}
+	}
+
+	response, err = invoke(t, s, "get_batch_status", nil)
+	if err != nil {
+		t.Fatalf("get_batch_status without job_id error = %v", err)
+	}
+	if jobs := response["result"].(map[string]interface{})["jobs"].([]batchJobStatus); len(jobs) != 1 {
+		t.Errorf("jobs = %+v", jobs)
+	}
+}
+
+func TestMCPServer_BatchSynthesize_Errors(t *testing.T) {
+	engine := newFakeEngine(t)
+	s := NewMCPServer(0, engine.URL, t.TempDir(), 3)
+
+	tests := []struct {
+		name    string
+		tool    string
+		params  map[string]interface{}
+		wantErr string
+	}{
+		{"output_dir outside", "batch_synthesize", map[string]interface{}{"rows": []interface{}{map[string]interface{}{"text": "a"}}, "output_dir": "../out"}, "temp directory"},
+		{"no rows", "batch_synthesize", map[string]interface{}{}, "file or rows parameter is required"},
+		{"unknown job", "get_batch_status", map[string]interface{}{"job_id": 9.0}, "unknown batch job: 9"},
+		{"job_id not an integer", "get_batch_status", map[string]interface{}{"job_id": "1"}, "job_id must be an integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := invoke(t, s, tt.tool, tt.params); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s error = %v, want %q", tt.tool, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMCPServer_Discover(t *testing.T) {
+	s := NewMCPServer(0, "http://localhost:0", t.TempDir(), 3)
+	response, err := s.handleRequest(map[string]interface{}{"id": "1", "method": "discover"})
+	if err != nil {
+		t.Fatalf("discover error = %v", err)
+	}
+
+	tools := response["result"].(map[string]interface{})["tools"].([]map[string]interface{})
+	names := map[string]bool{}
+	for _, tool := range tools {
+		names[tool["name"].(string)] = true
+	}
+	for _, name := range []string{"text_to_speech", "mix_audio", "batch_synthesize", "get_batch_status"} {
+		if !names[name] {
+			t.Errorf("discover does not list %s", name)
+		}
+	}
+}
+
+func TestMCPServer_MixAudio(t *testing.T) {
+	tempDir := t.TempDir()
+	s := NewMCPServer(0, "http://localhost:0", tempDir, 3)
+	s.AssetDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(s.AssetDir, "jingle.wav"), timingWAV(0, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	speech := filepath.Join(tempDir, "speech.wav")
+	if err := os.WriteFile(speech, timingWAV(0, 300), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := invoke(t, s, "mix_audio", map[string]interface{}{
+		"file":         speech,
+		"tracks":       []interface{}{"jingle.wav"},
+		"voice_offset": 100.0,
+	})
+	if err != nil {
+		t.Fatalf("mix_audio error = %v", err)
+	}
+	result := response["result"].(map[string]interface{})
+	path, _ := result["audio_path"].(string)
+	if filepath.Dir(path) != tempDir || !strings.HasPrefix(filepath.Base(path), "mix_") {
+		t.Errorf("audio_path = %q, want mix_ file in %s", path, tempDir)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("mixed file: %v", err)
+	}
+	if duration := result["duration"].(float64); duration < 0.4 {
+		t.Errorf("duration = %v, want the voice offset plus the speech", duration)
+	}
+
+	for _, file := range []string{"../speech.wav", "/etc/passwd", filepath.Join(tempDir, "..", "speech.wav"), "missing.wav"} {
+		t.Run("reject "+file, func(t *testing.T) {
+			if _, err := invoke(t, s, "mix_audio", map[string]interface{}{"file": file, "tracks": []interface{}{"jingle.wav"}}); err == nil {
+				t.Errorf("mix_audio(%q) error = nil, want error", file)
+			}
+		})
+	}
+}
+
+func TestMCPServer_ResolveSpeaker(t *testing.T) {
+	engine := newFakeEngine(t)
+	s := NewMCPServer(0, engine.URL, t.TempDir(), 1)
+
+	if got, err := s.resolveSpeaker(map[string]interface{}{"speaker_id": 3.0}); err != nil || got != 3 {
+		t.Errorf("resolveSpeaker(3) = %d, %v, want 3", got, err)
+	}
+	for _, sid := range []float64{1.7, -1} {
+		if got, err := s.resolveSpeaker(map[string]interface{}{"speaker_id": sid}); err == nil || !strings.Contains(err.Error(), "non-negative integer") {
+			t.Errorf("resolveSpeaker(%v) = %d, %v, want error", sid, got, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package mcp
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/errors"
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/ssml"
+	"github.com/metapox/mcp-voicevox-go/pkg/timing"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+// speechPart は1回の音声合成で読み上げる区間です
+type speechPart struct {
+	text    string
+	styleID int
+	// style は絵文字の気分またはスタイル名です。styleID と同じキャラクターのスタイルに切り替えます
+	style string
+	// prosody は音声クエリに重ねる話速・音高・音量・抑揚の調整です
+	prosody ssml.Prosody
+	// pauseBefore と pauseAfter は区間の前後に入れる無音です
+	pauseBefore time.Duration
+	pauseAfter  time.Duration
+}
+
+// engineError は VOICEVOXエンジンへのリクエストの失敗です
+type engineError struct {
+	err error
+}
+
+func (e *engineError) Error() string {
+	return e.err.Error()
+}
+
+func (e *engineError) Unwrap() error {
+	return e.err
+}
+
+// synthesisError は synthesizeSpeech のエラーを AppError にします。
+// エンジンへのリクエストの失敗は種類ごとのVOICEVOXのエラーに、WAVの結合やタイミングの計算などの失敗は AudioSynthesisError にします
+func synthesisError(err error) *errors.AppError {
+	var engineErr *engineError
+	if stderrors.As(err, &engineErr) {
+		return errors.NewVoicevoxAPIError("Text to speech failed", engineErr.err)
+	}
+	return errors.NewAudioSynthesisError("Text to speech failed", err)
+}
+
+// synthesizeSpeech は区間ごとに音声を合成し、1つのWAVにつなげます。
+// 区間が1つだけの場合はつなげずにそのまま返します。track が nil でない場合は、区間ごとのタイミングを後ろに追加します。
+// エンジンへのリクエストの失敗は engineError で返します。ctx がキャンセルされると、合成中のリクエストを中断して ctx.Err() を返します
+func synthesizeSpeech(ctx context.Context, client *voicevox.Client, speakers *voicevox.SpeakerCache, parts []speechPart, options *voicevox.AudioQueryOptions, track *timing.Track) ([]byte, error) {
+	wavs := make([][]byte, 0, len(parts))
+	for _, part := range parts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		id := segmentStyleID(speakers, part.styleID, part.style)
+
+		query, err := client.CreateAudioQueryContext(ctx, part.text, id, options)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, &engineError{fmt.Errorf("failed to create audio query: %w", err)}
+		}
+		part.prosody.Apply(query)
+		// <break> の無音は前後の無音の長さに加える
+		query.PrePhonemeLength += part.pauseBefore.Seconds()
+		query.PostPhonemeLength += part.pauseAfter.Seconds()
+
+		wav, err := client.SynthesizeVoiceContext(ctx, query, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, &engineError{fmt.Errorf("failed to synthesize voice: %w", err)}
+		}
+		if track != nil {
+			if err := appendTiming(track, query, part.text, wav); err != nil {
+				return nil, err
+			}
+		}
+		wavs = append(wavs, wav)
+	}
+
+	if len(wavs) == 1 {
+		return wavs[0], nil
+	}
+	return audio.ConcatWAV(wavs...)
+}
+
+// SpeechRequest はツールを経由しない1回の音声合成の指定です。省略した値は設定のデフォルトを使います
+type SpeechRequest struct {
+	// Text は読み上げるテキストです。<speak> で始まる場合はSSMLとして扱います
+	Text string
+	// Speaker は話者名またはスタイルIDです。SpeakerID が nil でない場合はそちらを優先します
+	Speaker   string
+	SpeakerID *int
+	// SpeedScale などは範囲を確認済みの合成パラメータです
+	SpeedScale      *float64
+	PitchScale      *float64
+	IntonationScale *float64
+	VolumeScale     *float64
+}
+
+// parseSpeakerID はツール引数の speaker_id をスタイルIDにします。整数でない値や負の値はエラーです
+func parseSpeakerID(sid float64) (int, error) {
+	if sid != math.Trunc(sid) || sid < 0 {
+		return 0, fmt.Errorf("speaker_id must be a non-negative integer, got %v", sid)
+	}
+	return int(sid), nil
+}
+
+// speakerArgs は話者の指定をツール引数にします。
+// 話者は speaker_id、speaker（数値ならスタイルID、それ以外は名前）、設定のデフォルト話者の順に決めます
+func (req SpeechRequest) speakerArgs() map[string]interface{} {
+	args := map[string]interface{}{}
+	if req.SpeakerID != nil {
+		args["speaker_id"] = float64(*req.SpeakerID)
+	} else if id, err := strconv.Atoi(strings.TrimSpace(req.Speaker)); err == nil {
+		args["speaker_id"] = float64(id)
+	} else if req.Speaker != "" {
+		args["speaker"] = req.Speaker
+	}
+	return args
+}
+
+// Synthesize はテキストを前処理して合成し、設定の後処理をしたWAVを返します。
+// バッチ合成やコマンドラインからの読み上げに使い、エラーはエラーコードを含まないメッセージにします
+func (h *Handler) Synthesize(ctx context.Context, req SpeechRequest) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	styleID, appErr := h.resolveSpeaker(req.speakerArgs())
+	if appErr != nil {
+		return nil, plainError(appErr)
+	}
+
+	parts, err := planSpeech(speechRequest{
+		text:         req.Text,
+		styleID:      styleID,
+		resolveVoice: h.resolveVoice,
+	}, h.preprocess, h.userDict)
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			return nil, plainError(appErr)
+		}
+		return nil, err
+	}
+
+	options := h.config.AudioQueryOptions()
+	if req.SpeedScale != nil {
+		options.SpeedScale = req.SpeedScale
+	}
+	if req.PitchScale != nil {
+		options.PitchScale = req.PitchScale
+	}
+	if req.IntonationScale != nil {
+		options.IntonationScale = req.IntonationScale
+	}
+	if req.VolumeScale != nil {
+		options.VolumeScale = req.VolumeScale
+	}
+
+	wav, err := synthesizeSpeech(ctx, h.voicevoxClient, h.speakers, parts, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	wav, err = audio.PostProcess(wav, h.config.PostProcess)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post-process audio: %w", err)
+	}
+	return wav, nil
+}
+
+// plainError は AppError をエラーコードを含まないエラーにします
+func plainError(appErr *errors.AppError) error {
+	if appErr.Cause != nil {
+		return fmt.Errorf("%s: %w", appErr.Message, appErr.Cause)
+	}
+	return stderrors.New(appErr.Message)
+}
+
+// appendTiming は合成した区間のタイミングを音声クエリとWAVの長さから求め、track の後ろに追加します
+func appendTiming(track *timing.Track, query *voicevox.AudioQuery, text string, wav []byte) error {
+	format, err := audio.ParseWAV(wav)
+	if err != nil {
+		return fmt.Errorf("failed to compute timings: %w", err)
+	}
+	part, err := timing.FromQuery(query, text, format.Duration())
+	if err != nil {
+		return fmt.Errorf("failed to compute timings: %w", err)
+	}
+	track.Append(part)
+	return nil
+}
+
+// segmentStyleID は区間の気分またはスタイル名に合う、同じキャラクターのスタイルIDを返します。
+// 合うスタイルがない場合や話者一覧を取得できない場合は styleID のまま読み上げます
+func segmentStyleID(speakers *voicevox.SpeakerCache, styleID int, style string) int {
+	names := preprocess.StyleNames(style)
+	if len(names) == 0 || speakers == nil {
+		return styleID
+	}
+	sibling, ok, err := speakers.SiblingStyle(styleID, names)
+	if err != nil || !ok {
+		return styleID
+	}
+	return sibling.StyleID
+}
@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/errors"
+	"github.com/metapox/mcp-voicevox-go/pkg/timing"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+func TestSynthesisError(t *testing.T) {
+	// 音声クエリには答えるが、合成の結果がWAVではないエンジン
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio_query":
+			w.Write([]byte(`{"accent_phrases": []}`))
+		case "/synthesis":
+			w.Write([]byte("not a wav"))
+		}
+	}))
+	defer broken.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	one := []speechPart{{text: "こんにちは", styleID: 3}}
+	two := []speechPart{{text: "こんにちは", styleID: 3}, {text: "さようなら", styleID: 3}}
+	tests := []struct {
+		name  string
+		url   string
+		parts []speechPart
+		track *timing.Track
+		want  errors.ErrorCode
+	}{
+		{"concatenating invalid audio", broken.URL, two, nil, errors.AudioSynthesisError},
+		{"timings of invalid audio", broken.URL, one, &timing.Track{}, errors.AudioSynthesisError},
+		{"engine error", failing.URL, one, nil, errors.VoicevoxEngineError},
+		{"engine unreachable", unreachable.URL, one, nil, errors.VoicevoxConnectionError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := synthesizeSpeech(context.Background(), voicevox.NewClient(tt.url), nil, tt.parts, nil, tt.track)
+			if err == nil {
+				t.Fatal("synthesizeSpeech() error = nil, want error")
+			}
+			if got := synthesisError(err).Code; got != tt.want {
+				t.Errorf("synthesisError(%v).Code = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/timing"
+)
+
+const (
+	// sentenceEnds は字幕を区切る文末の記号です
+	sentenceEnds = "。！？!?"
+	// sentenceClosers は文末の記号の後に続けて同じ字幕に含める閉じ括弧です
+	sentenceClosers = "」』）)】"
+)
+
+// timingFormat は text_to_speech が返せる字幕・タイミング・口の形の形式です
+type timingFormat struct {
+	name      string
+	extension string
+	render    func(timing.Track) ([]byte, error)
+}
+
+// subtitleFormats は subtitles で指定できる形式です。json は単語（アクセント句）とモーラのタイミングを含みます
+var subtitleFormats = []timingFormat{
+	{name: "srt", extension: ".srt", render: textFormat(timing.Track.SRT)},
+	{name: "vtt", extension: ".vtt", render: textFormat(timing.Track.VTT)},
+	{name: "json", extension: ".timings.json", render: timing.Track.JSON},
+}
+
+// visemeFormats は visemes で指定できるリップシンク用の口の形の形式です
+var visemeFormats = []timingFormat{
+	{name: "json", extension: ".visemes.json", render: timing.Track.VisemeJSON},
+	{name: "rhubarb", extension: ".rhubarb.tsv", render: textFormat(timing.Track.RhubarbTSV)},
+	{name: "vrm", extension: ".vrm.json", render: timing.Track.VRMJSON},
+}
+
+// textFormat はテキストを返す形式を render の関数にします
+func textFormat(f func(timing.Track) string) func(timing.Track) ([]byte, error) {
+	return func(t timing.Track) ([]byte, error) { return []byte(f(t)), nil }
+}
+
+// timingFile は作成した字幕などのファイルです。path は保存した場合のファイルのパスです
+type timingFile struct {
+	format  timingFormat
+	content []byte
+	path    string
+}
+
+// parseTimingFormats はツール引数の subtitles・visemes を読み取ります。true は全ての形式、文字列や配列は指定した形式です。
+// 省略または false の場合は nil を返します
+func parseTimingFormats(args map[string]interface{}, name string, supported []timingFormat) ([]timingFormat, error) {
+	var names []string
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !v {
+			return nil, nil
+		}
+		return supported, nil
+	case string:
+		names = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a boolean, a format name or an array of format names", name)
+			}
+			names = append(names, s)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be a boolean, a format name or an array of format names", name)
+	}
+
+	var formats []timingFormat
+	for _, n := range names {
+		format, ok := findTimingFormat(supported, n)
+		if !ok {
+			return nil, fmt.Errorf("unsupported %s format %q (supported: %s)", name, n, timingFormatNames(supported))
+		}
+		if _, dup := findTimingFormat(formats, n); !dup {
+			formats = append(formats, format)
+		}
+	}
+	return formats, nil
+}
+
+// findTimingFormat は formats から name の形式を探します
+func findTimingFormat(formats []timingFormat, name string) (timingFormat, bool) {
+	for _, f := range formats {
+		if strings.EqualFold(strings.TrimSpace(name), f.name) {
+			return f, true
+		}
+	}
+	return timingFormat{}, false
+}
+
+// timingFormatNames は形式の名前をカンマ区切りで返します
+func timingFormatNames(formats []timingFormat) string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitSentences は1文が1つの字幕になるよう、区間を文末の記号と改行で分けます。
+// <break> の無音は最初の文の前と最後の文の後に残します
+func splitSentences(parts []speechPart) []speechPart {
+	var out []speechPart
+	for _, part := range parts {
+		sentences := sentences(part.text)
+		if len(sentences) <= 1 {
+			out = append(out, part)
+			continue
+		}
+		for i, sentence := range sentences {
+			p := part
+			p.text = sentence
+			if i > 0 {
+				p.pauseBefore = 0
+			}
+			if i < len(sentences)-1 {
+				p.pauseAfter = 0
+			}
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// sentences はテキストを文に分けます。文字を含まない記号だけの部分は前の文につなげます
+func sentences(text string) []string {
+	var out []string
+	var b strings.Builder
+	ended := false
+	flush := func() {
+		ended = false
+		s := strings.TrimSpace(b.String())
+		if s != "" && !strings.ContainsFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) {
+			if len(out) == 0 {
+				// 先頭の記号は次の文の前につなげる
+				return
+			}
+			out[len(out)-1] += s
+		} else if s != "" {
+			out = append(out, s)
+		}
+		b.Reset()
+	}
+	for _, r := range text {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		if ended && !strings.ContainsRune(sentenceEnds+sentenceClosers, r) {
+			flush()
+		}
+		b.WriteRune(r)
+		ended = ended || strings.ContainsRune(sentenceEnds, r)
+	}
+	flush()
+	if s := strings.TrimSpace(b.String()); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// alignTimings は合成した音声のタイミングを、ミックスと後処理を終えた音声に合わせます。
+// voiced はミックス後・後処理前の音声、processed は後処理後の音声です
+func alignTimings(track *timing.Track, voiced, processed []byte, voiceOffset time.Duration, post audio.PostProcessOptions) {
+	track.Shift(voiceOffset.Seconds())
+	if post.Active() && post.Trim {
+		if samples, err := audio.DecodeSamples(voiced); err == nil {
+			track.Shift(-samples.TrimOffset(post.TrimThreshold).Seconds())
+		}
+	}
+	if format, err := audio.ParseWAV(processed); err == nil {
+		track.Duration = format.Duration()
+	}
+}
+
+// renderTimings は指定された形式のファイルの内容を作ります
+func renderTimings(track timing.Track, formats []timingFormat) ([]timingFile, error) {
+	files := make([]timingFile, 0, len(formats))
+	for _, f := range formats {
+		content, err := f.render(track)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", f.name, err)
+		}
+		files = append(files, timingFile{format: f, content: content})
+	}
+	return files, nil
+}
+
+// saveTimings はファイルを音声ファイルと同じ名前（拡張子だけ変えたもの）で保存します
+func saveTimings(files []timingFile, audioPath string) error {
+	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+	for i := range files {
+		path := base + files[i].format.extension
+		if err := os.WriteFile(path, files[i].content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s file: %w", files[i].format.name, err)
+		}
+		files[i].path = path
+	}
+	return nil
+}
+
+// timingContents は serverモードの結果に含める、形式ごとの内容とパスです。JSONの形式はオブジェクトのまま含めます
+func timingContents(files []timingFile) (map[string]interface{}, map[string]string) {
+	contents := make(map[string]interface{}, len(files))
+	paths := make(map[string]string, len(files))
+	for _, f := range files {
+		if strings.HasSuffix(f.format.extension, ".json") {
+			contents[f.format.name] = json.RawMessage(f.content)
+		} else {
+			contents[f.format.name] = string(f.content)
+		}
+		paths[f.format.name] = f.path
+	}
+	return contents, paths
+}
+
+// subtitlesSchema は text_to_speech の subtitles 引数のJSON Schemaです
+func subtitlesSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        []string{"boolean", "string", "array"},
+		"description": "字幕とタイミングを返す。true で全ての形式、\"srt\"・\"vtt\"・\"json\"（単語とモーラのタイミング）またはその配列で形式を指定する。字幕は1文ごとに区切る",
+		"items": map[string]interface{}{
+			"type": "string",
+			"enum": []string{"srt", "vtt", "json"},
+		},
+	}
+}
+
+// visemesSchema は text_to_speech の visemes 引数のJSON Schemaです
+func visemesSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        []string{"boolean", "string", "array"},
+		"description": "リップシンク用の口の形（a・i・u・e・o・N・sil）の区間を返す。true で全ての形式、\"json\"・\"rhubarb\"（Rhubarb Lip Sync のTSV）・\"vrm\"（VRMの表情 aa・ih・ou・ee・oh の重み）またはその配列で形式を指定する",
+		"items": map[string]interface{}{
+			"type": "string",
+			"enum": []string{"json", "rhubarb", "vrm"},
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/audio"
+	"github.com/metapox/mcp-voicevox-go/pkg/timing"
+)
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"single sentence", "こんにちは", []string{"こんにちは"}},
+		{"sentence ends", "おはよう。元気？はい！", []string{"おはよう。", "元気？", "はい！"}},
+		{"ascii sentence ends", "Done! Really? Yes", []string{"Done!", "Really?", "Yes"}},
+		{"closing brackets stay with the sentence", "「行くよ。」そう言った。", []string{"「行くよ。」", "そう言った。"}},
+		{"repeated sentence ends", "本当に！？すごい", []string{"本当に！？", "すごい"}},
+		{"newlines", "一行目\n\n二行目\n", []string{"一行目", "二行目"}},
+		{"punctuation only", "……", []string{"……"}},
+		{"punctuation only line joins the previous sentence", "終わり。\n……", []string{"終わり。……"}},
+		{"leading symbols join the next sentence", "「こんにちは」", []string{"「こんにちは」"}},
+		{"leading sentence end joins the next sentence", "！はい。", []string{"！はい。"}},
+		{"leading symbol line joins the next line", "＊\n見出し", []string{"＊見出し"}},
+		{"empty", "", nil},
+		{"spaces only", "  \n ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	parts := []speechPart{
+		{text: "おはよう。元気？", styleID: 3, pauseBefore: time.Second, pauseAfter: 2 * time.Second},
+		{text: "……", styleID: 1, pauseAfter: time.Second},
+	}
+	want := []speechPart{
+		{text: "おはよう。", styleID: 3, pauseBefore: time.Second},
+		{text: "元気？", styleID: 3, pauseAfter: 2 * time.Second},
+		{text: "……", styleID: 1, pauseAfter: time.Second},
+	}
+
+	if got := splitSentences(parts); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitSentences() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseTimingFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  interface{}
+		want []string
+	}{
+		{"omitted", nil, nil},
+		{"false", false, nil},
+		{"true", true, []string{"srt", "vtt", "json"}},
+		{"name", "vtt", []string{"vtt"}},
+		{"name is case insensitive", " SRT ", []string{"srt"}},
+		{"array keeps order and drops duplicates", []interface{}{"json", "srt", "JSON"}, []string{"json", "srt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{}
+			if tt.arg != nil {
+				args["subtitles"] = tt.arg
+			}
+			formats, err := parseTimingFormats(args, "subtitles", subtitleFormats)
+			if err != nil {
+				t.Fatalf("parseTimingFormats() error = %v", err)
+			}
+			var got []string
+			for _, f := range formats {
+				got = append(got, f.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTimingFormats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimingFormats_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     interface{}
+		wantErr string
+	}{
+		{"unknown format", "ass", `unsupported visemes format "ass" (supported: json, rhubarb, vrm)`},
+		{"subtitle format for visemes", []interface{}{"srt"}, `unsupported visemes format "srt"`},
+		{"number", 1.0, "visemes must be a boolean, a format name or an array of format names"},
+		{"array of numbers", []interface{}{1.0}, "visemes must be a boolean, a format name or an array of format names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTimingFormats(map[string]interface{}{"visemes": tt.arg}, "visemes", visemeFormats)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseTimingFormats() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// timingWAV は1kHz・モノラルの16bitのWAVを作ります。silence フレームの無音の後に loud フレームの音を続けます
+func timingWAV(silence, loud int) []byte {
+	pcm := make([]byte, 2*(silence+loud))
+	for i := silence; i < silence+loud; i++ {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(10000))
+	}
+	return audio.EncodeWAV(audio.WAVFormat{AudioFormat: 1, Channels: 1, SampleRate: 1000, BitsPerSample: 16}, pcm)
+}
+
+func TestAlignTimings(t *testing.T) {
+	// ミックス後の音声は 200ms の無音の後に 300ms 話す。トリミングは前に 20ms の無音を残すので、字幕は 180ms 早まる
+	voiced := timingWAV(200, 300)
+	processed := timingWAV(0, 300)
+	trim := audio.DefaultPostProcessOptions()
+	trim.Trim = true
+
+	tests := []struct {
+		name        string
+		voiceOffset time.Duration
+		post        audio.PostProcessOptions
+		wantCue     timing.Cue
+	}{
+		{"voice offset only", 500 * time.Millisecond, audio.DefaultPostProcessOptions(), timing.Cue{Start: 0.6, End: 0.8}},
+		{"trim only", 0, trim, timing.Cue{Start: 0, End: 0.12}},
+		{"trim and voice offset", 500 * time.Millisecond, trim, timing.Cue{Start: 0.42, End: 0.62}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := &timing.Track{
+				Duration: 0.3,
+				Cues:     []timing.Cue{{Start: 0.1, End: 0.3, Text: "こんにちは"}},
+			}
+			alignTimings(track, voiced, processed, tt.voiceOffset, tt.post)
+
+			cue := track.Cues[0]
+			if math.Abs(cue.Start-tt.wantCue.Start) > 1e-9 || math.Abs(cue.End-tt.wantCue.End) > 1e-9 {
+				t.Errorf("cue = %.3f-%.3f, want %.3f-%.3f", cue.Start, cue.End, tt.wantCue.Start, tt.wantCue.End)
+			}
+			if math.Abs(track.Duration-0.3) > 1e-9 {
+				t.Errorf("Duration = %v, want the processed length 0.3", track.Duration)
+			}
+		})
+	}
+}
@@ -18,8 +18,9 @@ type MCPResponse struct {
 
 // MCPError はMCPプロトコルのエラー構造体です
 type MCPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
 }
 
 // Tool はMCPツールの定義構造体です
@@ -80,6 +81,13 @@ const (
 
 // ツール名の定数
 const (
-	ToolTextToSpeech = "text_to_speech"
-	ToolGetSpeakers  = "get_speakers"
+	ToolTextToSpeech      = "text_to_speech"
+	ToolGetSpeakers       = "get_speakers"
+	ToolGetWarmupStatus   = "get_warmup_status"
+	ToolControlPlayback   = "control_playback"
+	ToolGetPlaybackStatus = "get_playback_status"
+	ToolListAudioDevices  = "list_audio_devices"
+	ToolMixAudio          = "mix_audio"
+	ToolBatchSynthesize   = "batch_synthesize"
+	ToolGetBatchStatus    = "get_batch_status"
 )
@@ -0,0 +1,172 @@
+package preprocess
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//go:embed data/english.tsv
+var embeddedDictionary string
+
+// KeepReading は辞書の読み方に指定すると、その単語を変換せずに残します
+const KeepReading = "-"
+
+// Dictionary は英単語・略語のカタカナの読み方の辞書です。単語の大文字と小文字は区別しません
+type Dictionary struct {
+	words map[string]string
+	// keep は変換せずに残す単語です。VOICEVOXのユーザー辞書に登録済みの単語など、エンジンに読み方を任せるものを指定します
+	keep map[string]bool
+}
+
+var defaultDictionary = mustParseDictionary(embeddedDictionary)
+
+// DefaultDictionary は組み込みの辞書を返します
+func DefaultDictionary() *Dictionary {
+	return defaultDictionary
+}
+
+// LoadDictionary は組み込みの辞書にファイルの読み方を重ねた辞書を返します。path が空の場合は組み込みの辞書を返します
+func LoadDictionary(path string) (*Dictionary, error) {
+	if path == "" {
+		return defaultDictionary, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dictionary: %w", err)
+	}
+	defer f.Close()
+
+	overrides, err := ParseDictionary(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dictionary %s: %w", path, err)
+	}
+	return defaultDictionary.Merge(overrides), nil
+}
+
+// ParseDictionary は1行に「単語<TAB>読み方」を書いた辞書を読み込みます。
+// 区切りにはタブのほか「,」「=」も使え、# で始まる行と空行は無視します。
+// 読み方はカタカナで書き、「-」を指定した単語は変換しません
+func ParseDictionary(r io.Reader) (*Dictionary, error) {
+	d := &Dictionary{words: map[string]string{}, keep: map[string]bool{}}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		i := strings.IndexAny(text, "\t,=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected word and reading separated by a tab, comma or equals sign", line)
+		}
+		word := foldWord(text[:i])
+		reading := strings.TrimSpace(text[i+1:])
+		if word == "" || reading == "" {
+			return nil, fmt.Errorf("line %d: word and reading cannot be empty", line)
+		}
+
+		if reading == KeepReading {
+			d.keep[word] = true
+			delete(d.words, word)
+			continue
+		}
+		if !isKatakana(reading) {
+			return nil, fmt.Errorf("line %d: reading for %s must be katakana: %s", line, word, reading)
+		}
+		d.words[word] = reading
+		delete(d.keep, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func mustParseDictionary(s string) *Dictionary {
+	d, err := ParseDictionary(strings.NewReader(s))
+	if err != nil {
+		panic(fmt.Sprintf("preprocess: invalid embedded dictionary: %v", err))
+	}
+	return d
+}
+
+// Merge は other の読み方で上書きした新しい辞書を返します
+func (d *Dictionary) Merge(other *Dictionary) *Dictionary {
+	merged := &Dictionary{
+		words: make(map[string]string, len(d.words)+len(other.words)),
+		keep:  make(map[string]bool, len(d.keep)+len(other.keep)),
+	}
+	for word, reading := range d.words {
+		merged.words[word] = reading
+	}
+	for word := range d.keep {
+		merged.keep[word] = true
+	}
+	for word, reading := range other.words {
+		merged.words[word] = reading
+		delete(merged.keep, word)
+	}
+	for word := range other.keep {
+		merged.keep[word] = true
+		delete(merged.words, word)
+	}
+	return merged
+}
+
+// WithKeep は words を変換せずに残す新しい辞書を返します。
+// VOICEVOXのユーザー辞書の表記（全角の英字）をそのまま渡せます
+func (d *Dictionary) WithKeep(words []string) *Dictionary {
+	if len(words) == 0 {
+		return d
+	}
+	keep := make(map[string]bool, len(d.keep)+len(words))
+	for word := range d.keep {
+		keep[word] = true
+	}
+	for _, word := range words {
+		keep[foldWord(word)] = true
+	}
+	return &Dictionary{words: d.words, keep: keep}
+}
+
+// Reading は単語の読み方を返します
+func (d *Dictionary) Reading(word string) (string, bool) {
+	reading, ok := d.words[foldWord(word)]
+	return reading, ok
+}
+
+// Len は辞書に登録された読み方の数を返します
+func (d *Dictionary) Len() int {
+	return len(d.words)
+}
+
+func (d *Dictionary) kept(word string) bool {
+	return d.keep[foldWord(word)]
+}
+
+// foldWord は全角の英数字を半角にし、小文字にそろえます
+func foldWord(s string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if r >= '！' && r <= '～' {
+			return r - '！' + '!'
+		}
+		return r
+	}, strings.TrimSpace(s)))
+}
+
+// isKatakana は文字列がカタカナ（長音符・中点を含む）だけでできているかを判定します
+func isKatakana(s string) bool {
+	for _, r := range s {
+		if !unicode.In(r, unicode.Katakana) && r != 'ー' && r != '・' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,411 @@
+package preprocess
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed data/emoji.tsv
+var embeddedEmoji string
+
+// 絵文字・顔文字の扱い
+const (
+	// EmojiStrip は絵文字・顔文字を読み上げません
+	EmojiStrip = "strip"
+	// EmojiRead は絵文字・顔文字を「にっこり笑う」のような名前で読み上げます
+	EmojiRead = "read"
+	// EmojiStyle は絵文字・顔文字を読み上げずに、気分に合わせて次の文のスタイルを切り替えます
+	EmojiStyle = "style"
+	// EmojiKeep は絵文字・顔文字をそのままエンジンに渡します
+	EmojiKeep = "keep"
+)
+
+// 絵文字・顔文字に付ける気分
+const (
+	MoodHappy     = "happy"
+	MoodSad       = "sad"
+	MoodAngry     = "angry"
+	MoodWhisper   = "whisper"
+	MoodTired     = "tired"
+	MoodCalm      = "calm"
+	MoodSurprised = "surprised"
+)
+
+// moodStyleNames は気分ごとに切り替え先として探すスタイル名です。先頭から順にキャラクターのスタイルと照合します
+var moodStyleNames = map[string][]string{
+	MoodHappy:     {"あまあま", "喜び", "わーい", "たのしい", "楽々", "うきうき", "元気", "熱血"},
+	MoodSad:       {"なみだめ", "悲しみ", "かなしみ", "かなしい", "びえーん", "泣き"},
+	MoodAngry:     {"ツンツン", "怒り", "おこ", "不機嫌", "ツンギレ"},
+	MoodWhisper:   {"ささやき", "ヒソヒソ", "囁き", "内緒話"},
+	MoodTired:     {"ヘロヘロ", "へろへろ", "のんびり"},
+	MoodCalm:      {"しっとり", "おちつき"},
+	MoodSurprised: {"おどろき", "びくびく", "恐怖"},
+}
+
+// StyleNames は Segment の Style から切り替え先のスタイル名の候補を返します。
+// 気分の場合は対応するスタイル名の一覧、それ以外はスタイル名そのものを返します
+func StyleNames(style string) []string {
+	if style == "" {
+		return nil
+	}
+	if names, ok := moodStyleNames[style]; ok {
+		return names
+	}
+	return []string{style}
+}
+
+// EmojiEntry は絵文字・顔文字の読み方と気分です
+type EmojiEntry struct {
+	// Name は読み上げる名前です。空の場合は読み上げません
+	Name string
+	// Style は気分（happy など）またはスタイル名です。空の場合はスタイルを切り替えません
+	Style string
+}
+
+// EmojiTable は絵文字・顔文字の表です
+type EmojiTable struct {
+	entries map[string]EmojiEntry
+	// pattern は表にある顔文字と、一般的な顔文字・絵文字の並びに一致します
+	pattern *regexp.Regexp
+}
+
+var (
+	// emojiSequence は絵文字1つ分の並び（異体字セレクタ、肌の色、ZWJでつないだ絵文字、国旗、キーキャップ）です
+	emojiSequence = func() string {
+		base := `[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2300}-\x{23FF}\x{2B00}-\x{2BFF}\x{3030}\x{303D}\x{3297}\x{3299}\x{203C}\x{2049}\x{25B6}\x{25C0}]`
+		modifiers := `(?:[\x{FE0E}\x{FE0F}]|[\x{1F3FB}-\x{1F3FF}])*`
+		return `[\x{1F1E6}-\x{1F1FF}]{2}|[0-9#*]\x{FE0F}?\x{20E3}|` + base + modifiers + `(?:\x{200D}` + base + modifiers + `)*`
+	}()
+	emojiSequencePattern = regexp.MustCompile(`^(?:` + emojiSequence + `)$`)
+
+	// kaomojiPattern は表にない括弧で囲まれた顔文字です。腕（ヽ ノ など）が付いていても一致します
+	kaomojiPattern = `[ヽ＼٩ｍ]?[(（][^()（）\p{Han}\p{Hiragana}\x{30A1}-\x{30FA}A-Za-z0-9\n]{1,12}[)）][ノﾉ／۶ｍ]?`
+
+	// kaomojiEyes は顔文字に使われる目や口の文字です。括弧の中にこれを含まないものは顔文字として扱いません
+	kaomojiEyes = "・･ω▽∀´｀^＾°≧≦◕‿ﾟ∇Д□д"
+
+	// privateUse は絵文字の目印に使う私用領域の文字です
+	privateUse = regexp.MustCompile(`[\x{E000}-\x{F8FF}]`)
+)
+
+var defaultEmojiTable = mustParseEmojiTable(embeddedEmoji)
+
+// DefaultEmojiTable は組み込みの絵文字・顔文字の表を返します
+func DefaultEmojiTable() *EmojiTable {
+	return defaultEmojiTable
+}
+
+// LoadEmojiTable は組み込みの表にファイルの内容を重ねた表を返します。path が空の場合は組み込みの表を返します
+func LoadEmojiTable(path string) (*EmojiTable, error) {
+	if path == "" {
+		return defaultEmojiTable, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open emoji dictionary: %w", err)
+	}
+	defer f.Close()
+
+	overrides, err := ParseEmojiTable(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load emoji dictionary %s: %w", path, err)
+	}
+	return defaultEmojiTable.Merge(overrides), nil
+}
+
+// ParseEmojiTable は1行に「記号<TAB>読み方<TAB>気分」を書いた表を読み込みます。
+// 顔文字には「,」や「=」が含まれるため、区切りはタブだけです。気分は省略でき、
+// happy / sad / angry / whisper / tired / calm / surprised のほか、スタイル名（あまあま など）も指定できます。
+// 読み方に「-」を指定した記号は読み上げません。# で始まる行と空行は無視します
+func ParseEmojiTable(r io.Reader) (*EmojiTable, error) {
+	entries := map[string]EmojiEntry{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: expected symbol, reading and optional mood separated by tabs", line)
+		}
+		symbol := emojiKey(strings.TrimSpace(fields[0]))
+		name := strings.TrimSpace(fields[1])
+		if symbol == "" || name == "" {
+			return nil, fmt.Errorf("line %d: symbol and reading cannot be empty", line)
+		}
+		if name == KeepReading {
+			name = ""
+		}
+
+		entry := EmojiEntry{Name: name}
+		if len(fields) == 3 {
+			entry.Style = strings.TrimSpace(fields[2])
+		}
+		entries[symbol] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newEmojiTable(entries), nil
+}
+
+func mustParseEmojiTable(s string) *EmojiTable {
+	t, err := ParseEmojiTable(strings.NewReader(s))
+	if err != nil {
+		panic(fmt.Sprintf("preprocess: invalid embedded emoji table: %v", err))
+	}
+	return t
+}
+
+// newEmojiTable は表にある顔文字を長いものから照合する正規表現を作成します
+func newEmojiTable(entries map[string]EmojiEntry) *EmojiTable {
+	var literals []string
+	for symbol := range entries {
+		if !emojiSequencePattern.MatchString(symbol) {
+			literals = append(literals, symbol)
+		}
+	}
+	sort.Slice(literals, func(i, j int) bool {
+		if len(literals[i]) != len(literals[j]) {
+			return len(literals[i]) > len(literals[j])
+		}
+		return literals[i] < literals[j]
+	})
+
+	alternatives := make([]string, 0, 3)
+	if len(literals) > 0 {
+		quoted := make([]string, len(literals))
+		for i, literal := range literals {
+			quoted[i] = regexp.QuoteMeta(literal)
+		}
+		alternatives = append(alternatives, `(`+strings.Join(quoted, "|")+`)`)
+	} else {
+		// 表に顔文字がない場合も、グループの番号をそろえるため一致しないグループを置く
+		alternatives = append(alternatives, `(\b\B)`)
+	}
+	alternatives = append(alternatives, `(`+kaomojiPattern+`)`, `(`+emojiSequence+`)`)
+
+	return &EmojiTable{entries: entries, pattern: regexp.MustCompile(strings.Join(alternatives, "|"))}
+}
+
+// Merge は other の内容で上書きした新しい表を返します
+func (t *EmojiTable) Merge(other *EmojiTable) *EmojiTable {
+	entries := make(map[string]EmojiEntry, len(t.entries)+len(other.entries))
+	for symbol, entry := range t.entries {
+		entries[symbol] = entry
+	}
+	for symbol, entry := range other.entries {
+		entries[symbol] = entry
+	}
+	return newEmojiTable(entries)
+}
+
+// Lookup は絵文字・顔文字の読み方と気分を返します。
+// 肌の色や異体字セレクタの違いは無視し、ZWJでつないだ絵文字が表にない場合は先頭の絵文字で探します
+func (t *EmojiTable) Lookup(symbol string) (EmojiEntry, bool) {
+	key := emojiKey(symbol)
+	if entry, ok := t.entries[key]; ok {
+		return entry, true
+	}
+	if first, size := utf8.DecodeRuneInString(key); size > 0 && size < len(key) && emojiSequencePattern.MatchString(key) {
+		entry, ok := t.entries[string(first)]
+		return entry, ok
+	}
+	return EmojiEntry{}, false
+}
+
+// Len は表に登録された記号の数を返します
+func (t *EmojiTable) Len() int {
+	return len(t.entries)
+}
+
+// find はテキスト中の絵文字・顔文字の位置を返します
+func (t *EmojiTable) find(text string) [][]int {
+	return t.findIn(text, 0, len(text))
+}
+
+// findIn は text[from:to] の中の絵文字・顔文字の位置を返します。
+// 顔文字として扱わなかった一致は、その内側（「(🎉)」の 🎉 など）を探し直します
+func (t *EmojiTable) findIn(text string, from, to int) [][]int {
+	var found [][]int
+	for _, m := range t.pattern.FindAllStringSubmatchIndex(text[from:to], -1) {
+		start, end := from+m[0], from+m[1]
+		symbol := text[start:end]
+		rejected := false
+		switch {
+		case m[2] >= 0:
+			// orz のような英字の顔文字は単語の一部でない場合だけ扱う
+			rejected = isASCIIWord(rune(symbol[0])) && (!boundaryBefore(text, start) || !boundaryAfter(text, end))
+		case m[4] >= 0:
+			rejected = !strings.ContainsAny(symbol, kaomojiEyes)
+		}
+		if rejected {
+			_, size := utf8.DecodeRuneInString(symbol)
+			found = append(found, t.findIn(text, start+size, end)...)
+			continue
+		}
+		found = append(found, []int{start, end})
+	}
+	return found
+}
+
+// emojiKey は肌の色と異体字セレクタを取り除いた照合用のキーを返します
+func emojiKey(symbol string) string {
+	return strings.Map(func(r rune) rune {
+		if r == 0xFE0E || r == 0xFE0F || (r >= 0x1F3FB && r <= 0x1F3FF) {
+			return -1
+		}
+		return r
+	}, symbol)
+}
+
+// emojiMarks はテキスト中の絵文字・顔文字を私用領域の1文字の目印に置き換えたものです。
+// 目印にしておくことで、Markdownの記法（*^▽^* の強調など）や英単語の変換に巻き込まれないようにします
+type emojiMarks struct {
+	symbols []string
+}
+
+// mark は絵文字・顔文字を目印に置き換えます。元のテキストにある私用領域の文字は取り除きます
+func (m *emojiMarks) mark(text string, table *EmojiTable) string {
+	text = privateUse.ReplaceAllString(text, "")
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range table.find(text) {
+		if len(m.symbols) > 0xF8FF-0xE000 {
+			break
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteRune(rune(0xE000 + len(m.symbols)))
+		m.symbols = append(m.symbols, text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// symbol は目印に対応する絵文字・顔文字を返します
+func (m *emojiMarks) symbol(r rune) (string, bool) {
+	i := int(r - 0xE000)
+	if r < 0xE000 || i >= len(m.symbols) {
+		return "", false
+	}
+	return m.symbols[i], true
+}
+
+// resolve は目印を扱いに応じて記号そのもの、読み方、または空文字列に戻します
+func (m *emojiMarks) resolve(text string, table *EmojiTable, mode string) string {
+	if len(m.symbols) == 0 {
+		return text
+	}
+	var b strings.Builder
+	for _, r := range text {
+		symbol, ok := m.symbol(r)
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		switch mode {
+		case EmojiKeep:
+			b.WriteString(symbol)
+		case EmojiRead:
+			if entry, ok := table.Lookup(symbol); ok {
+				b.WriteString(entry.Name)
+			}
+		}
+	}
+	if mode == EmojiKeep {
+		return b.String()
+	}
+	return tidySpaces(b.String())
+}
+
+// styleSegments は文ごとに分割し、絵文字・顔文字の気分をその次の文のスタイルにします。
+// 文の途中の絵文字（「疲れた😊 明日は休みです。」など）では文を分け、絵文字の後ろを次の文として扱います。
+// 後ろに読み上げる文がない絵文字の気分は使いません
+func (m *emojiMarks) styleSegments(text string, table *EmojiTable) []Segment {
+	var segments []Segment
+	mood := ""
+	for _, sentence := range splitSentences(text, m, table) {
+		spoken := m.resolve(sentence, table, EmojiStrip)
+		if strings.TrimSpace(spoken) != "" {
+			segments = append(segments, Segment{Text: spoken, Style: mood})
+			mood = ""
+		}
+		for _, r := range sentence {
+			if style := m.style(r, table); style != "" {
+				mood = style
+			}
+		}
+	}
+	return segments
+}
+
+// style は目印の絵文字・顔文字の気分またはスタイル名を返します。目印でない場合や気分がない場合は空文字列です
+func (m *emojiMarks) style(r rune, table *EmojiTable) string {
+	symbol, ok := m.symbol(r)
+	if !ok {
+		return ""
+	}
+	entry, ok := table.Lookup(symbol)
+	if !ok {
+		return ""
+	}
+	return entry.Style
+}
+
+// splitSentences は「。」「！」「？」と改行、気分のある絵文字・顔文字の後ろでテキストを分割します
+func splitSentences(text string, m *emojiMarks, table *EmojiTable) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\n' {
+			sentences = append(sentences, string(runes[start:i+1]))
+			start = i + 1
+			continue
+		}
+		closers := "。！？!?」』)）"
+		switch {
+		case strings.ContainsRune("。！？!?", r):
+		case m.style(r, table) != "":
+			// 絵文字の後ろの閉じ括弧は絵文字と同じ文に含める
+			closers = "」』)）"
+		default:
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(closers, runes[end]) {
+			end++
+		}
+		sentences = append(sentences, string(runes[start:end]))
+		start = end
+		i = end - 1
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+	return sentences
+}
+
+// tidySpaces は絵文字を取り除いた後に残る連続した空白と空行を整えます
+func tidySpaces(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = spacesPattern.ReplaceAllString(line, " ")
+	}
+	return joinLines(lines)
+}
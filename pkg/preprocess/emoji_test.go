@@ -0,0 +1,176 @@
+package preprocess
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcess_Emoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  string
+		input string
+		want  string
+	}{
+		// strip
+		{"strip emoji", EmojiStrip, "ビルドが通りました✅", "ビルドが通りました"},
+		{"strip emoji between words", EmojiStrip, "完了 🎉 です", "完了 です"},
+		{"strip variation selector", EmojiStrip, "注意⚠️してください", "注意してください"},
+		{"strip skin tone", EmojiStrip, "了解👍🏽", "了解"},
+		{"strip zwj sequence", EmojiStrip, "開発者👩‍💻です", "開発者です"},
+		{"strip flag", EmojiStrip, "日本🇯🇵から", "日本から"},
+		{"strip keycap", EmojiStrip, "手順1️⃣です", "手順です"},
+		{"strip kaomoji", EmojiStrip, "できました(^^)", "できました"},
+		{"strip kaomoji with emphasis syntax", EmojiStrip, "やった(*^▽^*)", "やった"},
+		{"strip unknown kaomoji", EmojiStrip, "ねむい(´-ω-`)", "ねむい"},
+		{"strip kaomoji with arms", EmojiStrip, "ばんざいヽ(´▽`)ﾉ", "ばんざい"},
+		{"strip ascii kaomoji", EmojiStrip, "失敗しました orz", "失敗しました"},
+		{"ascii kaomoji inside a word", EmojiStrip, "Horz", "Horz"},
+		{"parentheses are not kaomoji", EmojiStrip, "設定(任意)と (1/2) と (;;)", "設定(任意)と (1/2) と (;;)"},
+		{"emoji inside parentheses", EmojiStrip, "お祝い(🎉)", "お祝い()"},
+		{"empty line after stripping", EmojiStrip, "🚀\n公開しました", "公開しました"},
+
+		// read
+		{"read emoji", EmojiRead, "ビルドが通りました✅", "ビルドが通りましたチェックマークボタン"},
+		{"read emoji with variation selector", EmojiRead, "⚠️注意", "警告注意"},
+		{"read emoji with skin tone", EmojiRead, "👍🏻", "サムズアップ"},
+		{"read kaomoji", EmojiRead, "失敗しました(T_T)", "失敗しました泣き顔"},
+		{"read unknown emoji", EmojiRead, "🦩です", "です"},
+
+		// keep
+		{"keep emoji", EmojiKeep, "完了✅ (^^)", "完了✅ (^^)"},
+		{"keep kaomoji with emphasis syntax", EmojiKeep, "やった(*^▽^*)", "やった(*^▽^*)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Emoji = tt.mode
+			if got := Process(tt.input, opts); got != tt.want {
+				t.Errorf("Process(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegments_Style(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Emoji = EmojiStyle
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Segment
+	}{
+		{
+			"no emoji",
+			"テストが通りました。",
+			[]Segment{{Text: "テストが通りました。"}},
+		},
+		{
+			"emoji after sentence end styles the next sentence",
+			"テストが通りました！🎉 ただし警告が1件あります。",
+			[]Segment{{Text: "テストが通りました！"}, {Text: "ただし警告が1件あります。", Style: MoodHappy}},
+		},
+		{
+			"emoji in the middle of a sentence splits it",
+			"今日は疲れた😊 明日は休みです。",
+			[]Segment{{Text: "今日は疲れた"}, {Text: "明日は休みです。", Style: MoodHappy}},
+		},
+		{
+			"emoji at sentence start",
+			"確認しました。\n😢 ビルドが失敗しています。",
+			[]Segment{{Text: "確認しました。"}, {Text: "ビルドが失敗しています。", Style: MoodSad}},
+		},
+		{
+			"kaomoji",
+			"失敗しました(T_T)\n直します",
+			[]Segment{{Text: "失敗しました"}, {Text: "直します", Style: MoodSad}},
+		},
+		{
+			"mood lasts one sentence",
+			"😢 失敗しました。直します。",
+			[]Segment{{Text: "失敗しました。", Style: MoodSad}, {Text: "直します。"}},
+		},
+		{
+			"adjacent sentences with the same mood are merged",
+			"やった！😄 できた！🎉 次へ。",
+			[]Segment{{Text: "やった！"}, {Text: "できた！\n次へ。", Style: MoodHappy}},
+		},
+		{
+			"closing bracket after emoji",
+			"「やった😄」と言った。",
+			[]Segment{{Text: "「やった」"}, {Text: "と言った。", Style: MoodHappy}},
+		},
+		{
+			"emoji at the end has no next sentence",
+			"テストが通りました！🎉",
+			[]Segment{{Text: "テストが通りました！"}},
+		},
+		{
+			"emoji without mood",
+			"メモ📝です。",
+			[]Segment{{Text: "メモです。"}},
+		},
+		{
+			"emoji only line styles the next line",
+			"🎉\n公開しました。",
+			[]Segment{{Text: "公開しました。", Style: MoodHappy}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Segments(tt.input, opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Segments(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmojiTable_Overrides(t *testing.T) {
+	overrides, err := ParseEmojiTable(strings.NewReader("# チームの絵文字\n🚀\tリリース\thappy\n✅\t-\n(=^・^=)\tにゃーん\tあまあま\n"))
+	if err != nil {
+		t.Fatalf("ParseEmojiTable() error = %v", err)
+	}
+	table := DefaultEmojiTable().Merge(overrides)
+
+	opts := DefaultOptions()
+	opts.Emoji = EmojiRead
+	opts.EmojiTable = table
+	if got, want := Process("🚀しました✅ 猫(=^・^=) 😀", opts), "リリースしました 猫にゃーん にっこり笑う"; got != want {
+		t.Errorf("Process() = %q, want %q", got, want)
+	}
+
+	opts.Emoji = EmojiStyle
+	want := []Segment{{Text: "猫です。", Style: "あまあま"}}
+	if got := Segments("(=^・^=)猫です。", opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("Segments() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseEmojiTable_Errors(t *testing.T) {
+	for _, input := range []string{
+		"🚀",
+		"🚀\t",
+		"🚀 ロケット",
+		"🚀\tロケット\thappy\textra",
+	} {
+		if _, err := ParseEmojiTable(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseEmojiTable(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func TestStyleNames(t *testing.T) {
+	if got := StyleNames(MoodHappy); len(got) == 0 || got[0] != "あまあま" {
+		t.Errorf("StyleNames(happy) = %v", got)
+	}
+	if got := StyleNames("セクシー"); !reflect.DeepEqual(got, []string{"セクシー"}) {
+		t.Errorf("StyleNames(セクシー) = %v", got)
+	}
+	if got := StyleNames(""); got != nil {
+		t.Errorf("StyleNames(\"\") = %v, want nil", got)
+	}
+}
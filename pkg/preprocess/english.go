@@ -0,0 +1,207 @@
+package preprocess
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// englishTokenPattern は英字で始まる単語・識別子・ドメイン名です
+var englishTokenPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*(?:[_'.\-][A-Za-z0-9]+)*`)
+
+// letterReadings はアルファベットを1文字ずつ読む場合の読み方です
+var letterReadings = map[rune]string{
+	'a': "エー", 'b': "ビー", 'c': "シー", 'd': "ディー", 'e': "イー", 'f': "エフ", 'g': "ジー",
+	'h': "エイチ", 'i': "アイ", 'j': "ジェー", 'k': "ケー", 'l': "エル", 'm': "エム", 'n': "エヌ",
+	'o': "オー", 'p': "ピー", 'q': "キュー", 'r': "アール", 's': "エス", 't': "ティー", 'u': "ユー",
+	'v': "ブイ", 'w': "ダブリュー", 'x': "エックス", 'y': "ワイ", 'z': "ゼット",
+}
+
+// digitReadings は数字を1文字ずつ読む場合の読み方です
+var digitReadings = map[rune]string{
+	'0': "ゼロ", '1': "イチ", '2': "ニー", '3': "サン", '4': "ヨン",
+	'5': "ゴー", '6': "ロク", '7': "ナナ", '8': "ハチ", '9': "キュー",
+}
+
+// Katakana はテキスト中の英単語・略語・識別子をカタカナの読み方に書き換えます。
+// 辞書にある単語は辞書の読み方、大文字だけの略語や母音のない単語は1文字ずつ、
+// それ以外は綴りの規則から読み方を作ります。URLの中は書き換えません
+func Katakana(text string, dict *Dictionary) string {
+	if dict == nil {
+		dict = defaultDictionary
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(katakanaSegment(text[last:loc[0]], dict))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(katakanaSegment(text[last:], dict))
+	return b.String()
+}
+
+func katakanaSegment(text string, dict *Dictionary) string {
+	return englishTokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		return dict.token(token)
+	})
+}
+
+// token は単語・識別子・ドメイン名の読み方を返します
+func (d *Dictionary) token(token string) string {
+	if d.kept(token) {
+		return token
+	}
+	if reading, ok := d.Reading(token); ok {
+		return reading
+	}
+	// GitHub's のような所有格は元の語として読む
+	if base, ok := strings.CutSuffix(token, "'s"); ok {
+		return d.token(base)
+	}
+
+	// github.com のようなドメイン名は「ドット」でつなぐ。e.g. のような省略形はそのまま
+	if strings.Contains(token, ".") {
+		parts := strings.Split(token, ".")
+		for _, part := range parts {
+			if len(part) < 2 {
+				return token
+			}
+		}
+		readings := make([]string, len(parts))
+		for i, part := range parts {
+			readings[i] = d.token(part)
+		}
+		return strings.Join(readings, "ドット")
+	}
+
+	words := splitIdentifier(token)
+	if len(words) == 1 {
+		return d.word(words[0])
+	}
+	var b strings.Builder
+	for _, word := range words {
+		if word[0] >= '0' && word[0] <= '9' {
+			b.WriteString(word)
+			continue
+		}
+		b.WriteString(d.word(word))
+	}
+	return b.String()
+}
+
+// word は識別子を分割した1語の読み方を返します
+func (d *Dictionary) word(word string) string {
+	if d.kept(word) {
+		return word
+	}
+	if reading, ok := d.Reading(word); ok {
+		return reading
+	}
+	if reading, ok := d.plural(word); ok {
+		return reading
+	}
+	if isAcronym(word) {
+		return spellLetters(word)
+	}
+	return transliterate(word)
+}
+
+// plural は辞書にある単語の複数形（commits、issues など）の読み方を返します
+func (d *Dictionary) plural(word string) (string, bool) {
+	lower := strings.ToLower(word)
+	for _, suffix := range []string{"es", "s"} {
+		stem, ok := strings.CutSuffix(lower, suffix)
+		if !ok || len(stem) < 2 {
+			continue
+		}
+		reading, ok := d.Reading(stem)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(reading, "ト"):
+			return strings.TrimSuffix(reading, "ト") + "ツ", true
+		case strings.HasSuffix(reading, "ド"):
+			return strings.TrimSuffix(reading, "ド") + "ズ", true
+		case strings.HasSuffix(reading, "ク"), strings.HasSuffix(reading, "プ"), strings.HasSuffix(reading, "フ"):
+			return reading + "ス", true
+		default:
+			return reading + "ズ", true
+		}
+	}
+	return "", false
+}
+
+// splitIdentifier は camelCase、PascalCase、snake_case、kebab-case の識別子と英字・数字の境目で単語に分割します。
+// HTTPServer のように大文字が続く場合は HTTP と Server に分けます
+func splitIdentifier(token string) []string {
+	var words []string
+	for _, part := range strings.FieldsFunc(token, func(r rune) bool { return r == '_' || r == '-' || r == '\'' }) {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			boundary := (unicode.IsLower(prev) && unicode.IsUpper(cur)) ||
+				(unicode.IsDigit(prev) != unicode.IsDigit(cur)) ||
+				(unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+			if boundary {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// isAcronym は1文字ずつ読む略語かを判定します。大文字だけの語と、母音を含まない語が該当します
+func isAcronym(word string) bool {
+	if len(word) == 1 {
+		return true
+	}
+	upper := true
+	vowel := false
+	for _, r := range word {
+		if !unicode.IsUpper(r) {
+			upper = false
+		}
+		if strings.ContainsRune("aeiouyAEIOUY", r) {
+			vowel = true
+		}
+	}
+	return (upper && len(word) <= 6) || !vowel
+}
+
+// spellLetters はアルファベットを1文字ずつ読みます
+func spellLetters(word string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if reading, ok := letterReadings[r]; ok {
+			b.WriteString(reading)
+		}
+	}
+	return b.String()
+}
+
+// Spell は英字と数字を1文字ずつ読みます（"AB12" → エービーイチニー）。
+// 全角の英数字も同じように読み、それ以外の文字はそのまま残します
+func Spell(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		folded := []rune(foldWord(string(r)))
+		if len(folded) == 1 {
+			if reading, ok := letterReadings[folded[0]]; ok {
+				b.WriteString(reading)
+				continue
+			}
+			if reading, ok := digitReadings[folded[0]]; ok {
+				b.WriteString(reading)
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package preprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKatakana(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		// 辞書
+		{"dictionary word", "Kubernetes にデプロイ", "クバネティス にデプロイ"},
+		{"case insensitive", "GITHUB と github", "ギットハブ と ギットハブ"},
+		{"multiple words", "GitHub Actions で実行", "ギットハブ アクションズ で実行"},
+		{"dotted name in dictionary", "Node.js を使う", "ノードジェイエス を使う"},
+		{"plural of dictionary word", "commits と issues", "コミッツ と イシューズ"},
+		{"possessive", "GitHub's API", "ギットハブ エーピーアイ"},
+
+		// 略語
+		{"acronym in dictionary", "PR を出す", "ピーアール を出す"},
+		{"unknown acronym is spelled", "ABC 分析", "エービーシー 分析"},
+		{"word without vowels is spelled", "npm と k8s", "エヌピーエム と ケーエイツ"},
+
+		// 識別子
+		{"camel case", "CamelCaseNames", "キャメルケースネームズ"},
+		{"lower camel case", "getUserName", "ゲットユーザーネーム"},
+		{"acronym followed by word", "HTTPServer", "エイチティーティーピーサーバー"},
+		{"snake case", "speaker_id", "スピーカーアイディー"},
+		{"kebab case", "pull-request", "プルリクエスト"},
+		{"digits in identifier", "S3 と Route53", "エス3 と ルート53"},
+		{"domain", "github.com", "ギットハブドットコム"},
+		{"abbreviation with dots is kept", "e.g. の例", "e.g. の例"},
+
+		// 綴りの規則
+		{"magic e", "make", "メイク"},
+		{"r-colored vowels", "order", "オーダー"},
+		{"igh", "night", "ナイト"},
+		{"ng", "single", "シングル"},
+		{"final consonant after short vowel", "fix", "フィックス"},
+		{"ck", "click", "クリック"},
+		{"final re", "fire", "ファイア"},
+		{"final y", "happy", "ハッピー"},
+
+		// その他
+		{"url is kept", "https://github.com/org/repo を参照", "https://github.com/org/repo を参照"},
+		{"japanese only", "こんにちは", "こんにちは"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Katakana(tt.input, nil); got != tt.want {
+				t.Errorf("Katakana(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKatakana_Overrides(t *testing.T) {
+	overrides, err := ParseDictionary(strings.NewReader("# チームの読み方\nkubernetes\tケーツ\nmetapox=メタポックス\nzundamon,-\n"))
+	if err != nil {
+		t.Fatalf("ParseDictionary() error = %v", err)
+	}
+	dict := DefaultDictionary().Merge(overrides)
+
+	if got, want := Katakana("Kubernetes と metapox と Zundamon と GitHub", dict), "ケーツ と メタポックス と Zundamon と ギットハブ"; got != want {
+		t.Errorf("Katakana() = %q, want %q", got, want)
+	}
+
+	// VOICEVOXのユーザー辞書に登録済みの単語（全角の表記）はエンジンに任せる
+	dict = dict.WithKeep([]string{"ＧｉｔＨｕｂ"})
+	if got, want := Katakana("GitHub と PR", dict), "GitHub と ピーアール"; got != want {
+		t.Errorf("Katakana() with keep = %q, want %q", got, want)
+	}
+}
+
+func TestParseDictionary_Errors(t *testing.T) {
+	for _, input := range []string{
+		"kubernetes",
+		"kubernetes\t",
+		"kubernetes\tくばねてぃす",
+		"kubernetes\tKubernetes",
+	} {
+		if _, err := ParseDictionary(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseDictionary(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func TestLoadDictionary(t *testing.T) {
+	dict, err := LoadDictionary("")
+	if err != nil || dict != DefaultDictionary() {
+		t.Fatalf("LoadDictionary(\"\") = %v, %v", dict, err)
+	}
+	if DefaultDictionary().Len() == 0 {
+		t.Error("embedded dictionary is empty")
+	}
+
+	if _, err := LoadDictionary(t.TempDir() + "/missing.tsv"); err == nil {
+		t.Error("LoadDictionary() with missing file error = nil, want error")
+	}
+}
+
+func TestProcess_English(t *testing.T) {
+	// 英単語の変換は既定で無効
+	opts := DefaultOptions()
+	if got, want := Process("**PR** を GitHub に出しました", opts), "PR を GitHub に出しました"; got != want {
+		t.Errorf("Process() with default options = %q, want %q", got, want)
+	}
+
+	opts.English = true
+	if got, want := Process("**PR** を GitHub に出しました", opts), "ピーアール を ギットハブ に出しました"; got != want {
+		t.Errorf("Process() with english = %q, want %q", got, want)
+	}
+}
+
+func TestSpell(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"AB12", "エービーイチニー"},
+		{"ｗｅｂ", "ダブリューイービー"},
+		{"090-1234", "ゼロキューゼロ-イチニーサンヨン"},
+		{"ずんだ", "ずんだ"},
+	}
+	for _, tt := range tests {
+		if got := Spell(tt.input); got != tt.want {
+			t.Errorf("Spell(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
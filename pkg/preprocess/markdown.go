@@ -0,0 +1,313 @@
+package preprocess
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	headingPattern    = regexp.MustCompile(`^#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	ruleLinePattern   = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,}|={3,})$`)
+	quotePattern      = regexp.MustCompile(`^(?:>\s?)+`)
+	listPattern       = regexp.MustCompile(`^\s*(?:[-*+]|\d{1,9}[.)])\s+(?:\[[ xX]\]\s+)?`)
+	tableSepPattern   = regexp.MustCompile(`^\|?\s*:?-{1,}:?\s*(?:\|\s*:?-{1,}:?\s*)*\|?$`)
+	codeSpanPattern   = regexp.MustCompile("(`+)(.+?)(`+)")
+	imagePattern      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	footnotePattern   = regexp.MustCompile(`\[\^[^\]]+\]`)
+	autolinkPattern   = regexp.MustCompile(`<((?:https?|ftp)://[^>\s]+)>`)
+	urlPattern        = regexp.MustCompile(`(?:https?|ftp)://[^\s<>()\[\]「」『』、。，！？]+`)
+	strongPattern     = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	strikePattern     = regexp.MustCompile(`~~(.+?)~~`)
+	emPattern         = regexp.MustCompile(`\*([^\s*](?:[^*]*[^\s*])?)\*`)
+	underscorePattern = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^\s_](?:[^_]*[^\s_])?)_($|[^\p{L}\p{N}_])`)
+	htmlTagPattern    = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>`)
+	escapePattern     = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!|~<>])")
+	spacesPattern     = regexp.MustCompile(`[ \t]{2,}`)
+)
+
+// Segment は読み上げ用のテキストを、同じスタイルで読み上げる区間に分けたものです
+type Segment struct {
+	Text string `json:"text"`
+	// Style は区間の気分（happy など）またはスタイル名です。空の場合は指定されたスタイルのまま読み上げます
+	Style string `json:"style,omitempty"`
+}
+
+// Process は前処理のルールに従って読み上げ用のテキストを作成します
+func Process(text string, opts Options) string {
+	if !opts.Enabled {
+		return text
+	}
+
+	segments := Segments(text, opts)
+	texts := make([]string, len(segments))
+	for i, segment := range segments {
+		texts[i] = segment.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Segments は前処理のルールに従って読み上げ用のテキストを作成し、スタイルごとの区間に分けます。
+// 絵文字の扱いが style の場合だけ複数の区間に分かれます。読み上げる内容がない区間は含みません
+func Segments(text string, opts Options) []Segment {
+	if !opts.Enabled {
+		return []Segment{{Text: text}}
+	}
+
+	table := opts.EmojiTable
+	if table == nil {
+		table = defaultEmojiTable
+	}
+	var marks emojiMarks
+	spoken := markdown(marks.mark(text, table), opts)
+
+	var segments []Segment
+	if opts.Emoji == EmojiStyle {
+		segments = marks.styleSegments(spoken, table)
+	} else {
+		segments = []Segment{{Text: marks.resolve(spoken, table, opts.Emoji)}}
+	}
+
+	merged := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		if opts.Normalize {
+			segment.Text = Normalize(segment.Text)
+		}
+		if opts.English {
+			segment.Text = Katakana(segment.Text, opts.Dictionary)
+		}
+		segment.Text = strings.TrimSpace(segment.Text)
+		if segment.Text == "" {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].Style == segment.Style {
+			merged[n-1].Text += "\n" + segment.Text
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
+
+// markdown はMarkdownの記法・コードブロック・表・URLを変換し、空行を取り除きます
+func markdown(text string, opts Options) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		if fence := codeFence(trimmed); fence != "" {
+			lang := strings.Trim(strings.TrimSpace(trimmed[len(fence):]), "{}.")
+			var body []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				body = append(body, lines[i])
+			}
+			out = append(out, codeBlock(lang, body, opts.CodeBlocks)...)
+			continue
+		}
+
+		if isTableRow(trimmed) && i+1 < len(lines) && tableSepPattern.MatchString(strings.TrimSpace(lines[i+1])) {
+			header := tableCells(trimmed)
+			var rows [][]string
+			for i += 2; i < len(lines) && isTableRow(strings.TrimSpace(lines[i])); i++ {
+				rows = append(rows, tableCells(strings.TrimSpace(lines[i])))
+			}
+			i--
+			out = append(out, table(header, rows, opts)...)
+			continue
+		}
+
+		line := lines[i]
+		if opts.Markdown {
+			line = blockSyntax(line)
+		}
+		out = append(out, inline(line, opts))
+	}
+
+	return joinLines(out)
+}
+
+// codeFence は行がコードフェンス（``` または ~~~ が3つ以上）で始まる場合にフェンス文字列を返します
+func codeFence(line string) string {
+	for _, ch := range []string{"`", "~"} {
+		n := 0
+		for strings.HasPrefix(line[n:], ch) {
+			n++
+		}
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// codeBlock はコードブロックを扱いに応じて変換します
+func codeBlock(lang string, body []string, mode string) []string {
+	switch mode {
+	case CodeRead:
+		return body
+	case CodeSummary:
+		if lang != "" {
+			return []string{fmt.Sprintf("%sのコード%d行は省略します。", lang, len(body))}
+		}
+		return []string{fmt.Sprintf("コード%d行は省略します。", len(body))}
+	default:
+		return nil
+	}
+}
+
+// isTableRow は行が表の行（| で始まるか、| を含む）かを判定します
+func isTableRow(line string) bool {
+	return strings.HasPrefix(line, "|") || (strings.Count(line, "|") >= 2 && !strings.HasPrefix(line, "`"))
+}
+
+// tableCells は表の行をセルに分割します
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// table は表を扱いに応じて変換します。読み上げる場合は「見出しは値、見出しは値。」の形で1行ずつ読みます
+func table(header []string, rows [][]string, opts Options) []string {
+	switch opts.Tables {
+	case TableSummary:
+		return []string{fmt.Sprintf("%d行の表は省略します。", len(rows))}
+	case TableRead:
+		lines := make([]string, 0, len(rows))
+		for _, row := range rows {
+			parts := make([]string, 0, len(row))
+			for j, cell := range row {
+				cell = inline(cell, opts)
+				if cell == "" {
+					continue
+				}
+				if j < len(header) && header[j] != "" {
+					parts = append(parts, fmt.Sprintf("%sは%s", inline(header[j], opts), cell))
+				} else {
+					parts = append(parts, cell)
+				}
+			}
+			if len(parts) > 0 {
+				lines = append(lines, endSentence(strings.Join(parts, "、")))
+			}
+		}
+		return lines
+	default:
+		return nil
+	}
+}
+
+// blockSyntax は見出し・区切り線・引用・リストの記法を取り除きます
+func blockSyntax(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if ruleLinePattern.MatchString(trimmed) {
+		return ""
+	}
+	if m := headingPattern.FindStringSubmatch(trimmed); m != nil {
+		return endSentence(m[1])
+	}
+
+	line = quotePattern.ReplaceAllString(trimmed, "")
+	if loc := listPattern.FindStringIndex(line); loc != nil {
+		return endSentence(line[loc[1]:])
+	}
+	return line
+}
+
+// inline は行内の記法（コード・リンク・強調・HTMLタグ）とURLを変換します
+func inline(line string, opts Options) string {
+	// インラインコードの中身とエスケープされた文字は、他の記法として扱わないよう退避する
+	var spans []string
+	if opts.Markdown {
+		line = codeSpanPattern.ReplaceAllStringFunc(line, func(s string) string {
+			m := codeSpanPattern.FindStringSubmatch(s)
+			if len(m[1]) != len(m[3]) {
+				return s
+			}
+			spans = append(spans, strings.TrimSpace(m[2]))
+			return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+		})
+
+		line = escapePattern.ReplaceAllStringFunc(line, func(s string) string {
+			spans = append(spans, s[1:])
+			return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+		})
+
+		line = imagePattern.ReplaceAllString(line, "$1")
+		line = linkPattern.ReplaceAllString(line, "$1")
+		line = footnotePattern.ReplaceAllString(line, "")
+	}
+
+	line = autolinkPattern.ReplaceAllString(line, "$1")
+	line = urlPattern.ReplaceAllStringFunc(line, func(raw string) string {
+		trimmed := strings.TrimRight(raw, ".,;:!?'\"")
+		return shortenURL(trimmed, opts.URLs) + raw[len(trimmed):]
+	})
+
+	if opts.Markdown {
+		line = strongPattern.ReplaceAllString(line, "$1$2")
+		line = strikePattern.ReplaceAllString(line, "$1")
+		line = emPattern.ReplaceAllString(line, "$1")
+		line = underscorePattern.ReplaceAllString(line, "$1$2$3")
+		line = htmlTagPattern.ReplaceAllString(line, "")
+
+		for i, span := range spans {
+			line = strings.Replace(line, fmt.Sprintf("\x00%d\x00", i), span, 1)
+		}
+	}
+
+	return strings.TrimSpace(spacesPattern.ReplaceAllString(line, " "))
+}
+
+// shortenURL はURLを扱いに応じて変換します
+func shortenURL(raw, mode string) string {
+	switch mode {
+	case URLSkip:
+		return ""
+	case URLDomain:
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			return raw
+		}
+		return strings.TrimPrefix(u.Hostname(), "www.")
+	default:
+		return raw
+	}
+}
+
+// endSentence は文末に句読点がなければ「。」を付けます
+func endSentence(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	last, _ := utf8.DecodeLastRuneInString(s)
+	if strings.ContainsRune("。．.！!？?、，,:：;；」』)）", last) {
+		return s
+	}
+	return s + "。"
+}
+
+// joinLines は空行を取り除いて行を連結します
+func joinLines(lines []string) string {
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,108 @@
+package preprocess
+
+import "testing"
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  func(*Options)
+		want  string
+	}{
+		{
+			name:  "heading and emphasis",
+			input: "## 変更点\n**重要**な修正と*軽微*な修正、~~削除~~があります",
+			want:  "変更点。\n重要な修正と軽微な修正、削除があります",
+		},
+		{
+			name:  "lists and task lists",
+			input: "- 一つ目\n* 二つ目です。\n1. 三つ目\n- [x] 完了",
+			want:  "一つ目。\n二つ目です。\n三つ目。\n完了。",
+		},
+		{
+			name:  "inline code and links",
+			input: "`go test` を実行し、[ドキュメント](https://example.com/docs)を参照",
+			want:  "go test を実行し、ドキュメントを参照",
+		},
+		{
+			name:  "bare url shortened to domain",
+			input: "詳細は https://www.github.com/metapox/mcp-voicevox-go/pull/12. を見てください",
+			want:  "詳細は github.com. を見てください",
+		},
+		{
+			name:  "url skip",
+			input: "参照: <https://example.com/a?b=c>",
+			opts:  func(o *Options) { o.URLs = URLSkip },
+			want:  "参照:",
+		},
+		{
+			name:  "code block summary",
+			input: "修正しました。\n```go\nfunc main() {\n}\n```\n以上です。",
+			want:  "修正しました。\ngoのコード2行は省略します。\n以上です。",
+		},
+		{
+			name:  "code block skip",
+			input: "前\n~~~\nrm -rf /\n~~~\n後",
+			opts:  func(o *Options) { o.CodeBlocks = CodeSkip },
+			want:  "前\n後",
+		},
+		{
+			name:  "code block read",
+			input: "```\necho hello\n```",
+			opts:  func(o *Options) { o.CodeBlocks = CodeRead },
+			want:  "echo hello",
+		},
+		{
+			name:  "unclosed code block",
+			input: "説明\n```python\nprint(1)",
+			want:  "説明\npythonのコード1行は省略します。",
+		},
+		{
+			name:  "table read",
+			input: "| 名前 | 役割 |\n|---|:---:|\n| ずんだもん | **妖精** |\n| めたん | 高校生 |\n\n終わり",
+			want:  "名前はずんだもん、役割は妖精。\n名前はめたん、役割は高校生。\n終わり",
+		},
+		{
+			name:  "table summary",
+			input: "| a | b |\n| - | - |\n| 1 | 2 |",
+			opts:  func(o *Options) { o.Tables = TableSummary },
+			want:  "1行の表は省略します。",
+		},
+		{
+			name:  "quotes, rules and html",
+			input: "> 引用です<br>\n---\n***\n本文",
+			want:  "引用です\n本文",
+		},
+		{
+			name:  "snake_case is kept",
+			input: "speaker_id と _強調_ と \\*記号\\*",
+			want:  "speaker_id と 強調 と *記号*",
+		},
+		{
+			name:  "markdown disabled keeps syntax but shortens urls",
+			input: "# 見出し https://example.com/x",
+			opts:  func(o *Options) { o.Markdown = false },
+			want:  "# 見出し example.com",
+		},
+		{
+			name:  "disabled",
+			input: "# そのまま\n\n`code`",
+			opts:  func(o *Options) { o.Enabled = false },
+			want:  "# そのまま\n\n`code`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 記法の変換だけを確認するため、英単語の読み替えは行わない
+			opts := DefaultOptions()
+			opts.English = false
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+			if got := Process(tt.input, opts); got != tt.want {
+				t.Errorf("Process() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// normalizeRule は読み方を書き換える規則です
+type normalizeRule struct {
+	pattern *regexp.Regexp
+	// replace は一致した部分の読み方を返します。false を返した場合は書き換えません
+	replace func(m []string) (string, bool)
+	// open が true の場合、一致した直後に英数字が続いても書き換えます（50%OFF など）
+	open bool
+}
+
+// units は数値の後ろに付く単位の読み方です。長い表記から順に照合します
+var units = []struct {
+	symbol  string
+	reading string
+}{
+	{"KiB", "キビバイト"}, {"MiB", "メビバイト"}, {"GiB", "ギビバイト"}, {"TiB", "テビバイト"},
+	{"Kbps", "キロビーピーエス"}, {"kbps", "キロビーピーエス"}, {"Mbps", "メガビーピーエス"}, {"Gbps", "ギガビーピーエス"},
+	{"bps", "ビーピーエス"},
+	{"kHz", "キロヘルツ"}, {"MHz", "メガヘルツ"}, {"GHz", "ギガヘルツ"}, {"Hz", "ヘルツ"},
+	{"KB", "キロバイト"}, {"kB", "キロバイト"}, {"MB", "メガバイト"}, {"GB", "ギガバイト"}, {"TB", "テラバイト"}, {"PB", "ペタバイト"},
+	{"ns", "ナノ秒"}, {"μs", "マイクロ秒"}, {"us", "マイクロ秒"}, {"ms", "ミリ秒"}, {"sec", "秒"}, {"min", "分"},
+	{"mm", "ミリメートル"}, {"cm", "センチメートル"}, {"km", "キロメートル"},
+	{"mg", "ミリグラム"}, {"kg", "キログラム"},
+	{"fps", "エフピーエス"}, {"px", "ピクセル"}, {"dB", "デシベル"},
+	{"°C", "度"}, {"℃", "度"},
+	{"B", "バイト"}, {"s", "秒"}, {"h", "時間"}, {"x", "倍"},
+}
+
+var (
+	// isoDateTimePattern は 2024-10-17T10:30:00Z の形式の日時です。区切りの T と秒未満、UTCを表す Z は読みません
+	isoDateTimePattern = regexp.MustCompile(`(\d{4}-\d{1,2}-\d{1,2})T(\d{1,2}:\d{2}(?::\d{2})?)(?:\.\d+)?Z?`)
+
+	fullwidthDigits = strings.NewReplacer("０", "0", "１", "1", "２", "2", "３", "3", "４", "4",
+		"５", "5", "６", "6", "７", "7", "８", "8", "９", "9", "％", "%", "＃", "#")
+
+	normalizeRules = []normalizeRule{
+		// 2024/10/17、2024-10-17、2024.10.17
+		{pattern: regexp.MustCompile(`(\d{4})([-/.])(\d{1,2})([-/.])(\d{1,2})`), replace: readDate},
+		// 2024/10
+		{pattern: regexp.MustCompile(`(\d{4})/(\d{1,2})`), replace: readYearMonth},
+		// 10:30、10:30:15
+		{pattern: regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?`), replace: readTime},
+		// v1.2.3。V8 のような名前と区別するため、点を含むものだけを扱う
+		{pattern: regexp.MustCompile(`[vV](\d+(?:\.\d+)+)`), replace: readVersion},
+		// 1.2.3、192.168.0.1
+		{pattern: regexp.MustCompile(`\d+(?:\.\d+){2,3}`), replace: readDotted},
+		// 1,000,000
+		{pattern: regexp.MustCompile(`\d{1,3}(?:,\d{3})+`), replace: readThousands},
+		// 50%、12.5 %
+		{pattern: regexp.MustCompile(`(\d+(?:\.\d+)?) ?%`), replace: readPercent, open: true},
+		// 3.5GB、100 ms
+		{pattern: unitPattern(), replace: readUnit},
+		// #123
+		{pattern: regexp.MustCompile(`#(\d+)`), replace: readNumberSign},
+	}
+)
+
+// unitPattern は単位付きの数値に一致する正規表現を作成します
+func unitPattern() *regexp.Regexp {
+	symbols := make([]string, len(units))
+	for i, unit := range units {
+		symbols[i] = regexp.QuoteMeta(unit.symbol)
+	}
+	return regexp.MustCompile(`(\d+(?:\.\d+)?)( ?)(` + strings.Join(symbols, "|") + `)`)
+}
+
+// Normalize は技術的な文章に含まれる日付・時刻・バージョン・単位・記号を自然な日本語の読み方に書き換えます。
+// URLの中は書き換えません
+func Normalize(text string) string {
+	text = fullwidthDigits.Replace(text)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(normalizeSegment(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(normalizeSegment(text[last:]))
+	return b.String()
+}
+
+// normalizeSegment はURLを含まないテキストに規則を順に適用します
+func normalizeSegment(text string) string {
+	text = isoDateTimePattern.ReplaceAllString(text, "$1 $2")
+	for _, rule := range normalizeRules {
+		text = rule.apply(text)
+	}
+	return text
+}
+
+// apply は単語の境界にある一致だけを書き換えます。
+// 英数字の途中（abc10:30 や 1.2.3.4.5 の一部など）に一致した場合は書き換えません
+func (r normalizeRule) apply(text string) string {
+	matches := r.pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range matches {
+		start, end := loc[0], loc[1]
+		if !boundaryBefore(text, start) || (!r.open && !boundaryAfter(text, end)) {
+			continue
+		}
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = text[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		reading, ok := r.replace(m)
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(reading)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// boundaryBefore は位置の直前が英数字や数値の一部でないかを判定します
+func boundaryBefore(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	if isASCIIWord(r) {
+		return false
+	}
+	// 1.2.3.4.5 や 1,000 のように数値の区切りの後ろは一致の途中とみなす
+	if isNumberSeparator(r) && i >= 2 && text[i-2] >= '0' && text[i-2] <= '9' {
+		return false
+	}
+	return true
+}
+
+// boundaryAfter は位置の直後が英数字や数値の続きでないかを判定します
+func boundaryAfter(text string, i int) bool {
+	if i == len(text) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	if isASCIIWord(r) {
+		return false
+	}
+	// 10:30:15:20 や 2024/10/17/18 のように数値の区切りが続く場合は一致の途中とみなす
+	if isNumberSeparator(r) && i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9' {
+		return false
+	}
+	return true
+}
+
+func isNumberSeparator(r rune) bool {
+	return r == '.' || r == ',' || r == '/' || r == ':'
+}
+
+func isASCIIWord(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// readDate は 2024/10/17 を「2024年10月17日」と読みます。区切り文字は揃っている必要があります
+func readDate(m []string) (string, bool) {
+	if m[2] != m[4] {
+		return "", false
+	}
+	year, month, day := atoi(m[1]), atoi(m[3]), atoi(m[5])
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return "", false
+	}
+	return fmt.Sprintf("%d年%d月%d日", year, month, day), true
+}
+
+// readYearMonth は 2024/10 を「2024年10月」と読みます
+func readYearMonth(m []string) (string, bool) {
+	month := atoi(m[2])
+	if month < 1 || month > 12 {
+		return "", false
+	}
+	return fmt.Sprintf("%d年%d月", atoi(m[1]), month), true
+}
+
+// readTime は 10:30 を「10時30分」と読みます。0分は読みません
+func readTime(m []string) (string, bool) {
+	hour, minute := atoi(m[1]), atoi(m[2])
+	if hour > 24 || minute > 59 {
+		return "", false
+	}
+	reading := fmt.Sprintf("%d時", hour)
+	if minute > 0 || m[3] != "" {
+		reading += fmt.Sprintf("%d分", minute)
+	}
+	if m[3] != "" {
+		second := atoi(m[3])
+		if second > 59 {
+			return "", false
+		}
+		reading += fmt.Sprintf("%d秒", second)
+	}
+	return reading, true
+}
+
+// readVersion は v1.2.3 を「バージョン1点2点3」と読みます
+func readVersion(m []string) (string, bool) {
+	return "バージョン" + strings.ReplaceAll(m[1], ".", "点"), true
+}
+
+// readDotted は 1.2.3 のように点で区切られた数字を「1点2点3」と読みます
+func readDotted(m []string) (string, bool) {
+	return strings.ReplaceAll(m[0], ".", "点"), true
+}
+
+// readThousands は 1,000 の桁区切りを取り除きます
+func readThousands(m []string) (string, bool) {
+	return strings.ReplaceAll(m[0], ",", ""), true
+}
+
+// readPercent は 50% を「50パーセント」と読みます
+func readPercent(m []string) (string, bool) {
+	return m[1] + "パーセント", true
+}
+
+// readUnit は 3.5GB を「3.5ギガバイト」と読みます。
+// 1文字の単位は「3 x 4」のような式と区別するため、数値の直後にある場合だけ読みます
+func readUnit(m []string) (string, bool) {
+	if m[2] != "" && utf8.RuneCountInString(m[3]) == 1 {
+		return "", false
+	}
+	for _, unit := range units {
+		if unit.symbol == m[3] {
+			return m[1] + unit.reading, true
+		}
+	}
+	return "", false
+}
+
+// readNumberSign は #123 を「123番」と読みます
+func readNumberSign(m []string) (string, bool) {
+	return m[1] + "番", true
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,88 @@
+package preprocess
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		// 日付
+		{"date with slashes", "リリースは2024/10/17です", "リリースは2024年10月17日です"},
+		{"date with hyphens", "2024-01-05 に公開", "2024年1月5日 に公開"},
+		{"date with dots", "更新日: 2024.10.17", "更新日: 2024年10月17日"},
+		{"iso datetime", "2024-10-17T10:30:00Z", "2024年10月17日 10時30分0秒"},
+		{"iso datetime with fraction", "2024-10-17T09:05:30.123Z", "2024年10月17日 9時5分30秒"},
+		{"year and month", "2024/10 のリリース", "2024年10月 のリリース"},
+		{"mixed separators are not a date", "2024/10-17", "2024年10月-17"},
+		{"invalid month", "2024/13/01", "2024/13/01"},
+		{"invalid day", "2024-02-32", "2024-02-32"},
+
+		// 時刻
+		{"time", "10:30に開始", "10時30分に開始"},
+		{"time on the hour", "9:00から", "9時から"},
+		{"time with seconds", "残り01:02:03", "残り1時2分3秒"},
+		{"time range", "10:30-11:00", "10時30分-11時"},
+		{"time after label", "開始:10:30", "開始:10時30分"},
+		{"ratio is not a time", "16:9 の画面", "16:9 の画面"},
+		{"port is not a time", "localhost:8080", "localhost:8080"},
+		{"hour out of range", "99:10", "99:10"},
+
+		// バージョン
+		{"version", "v1.2.3 をリリース", "バージョン1点2点3 をリリース"},
+		{"version with suffix", "v2.0.0-beta", "バージョン2点0点0-beta"},
+		{"capital v", "V1.10", "バージョン1点10"},
+		{"name with digits is not a version", "V8 エンジン", "V8 エンジン"},
+		{"version inside a word", "dev1.2", "dev1.2"},
+		{"dotted numbers", "1.2.3 に更新", "1点2点3 に更新"},
+		{"ip address", "192.168.0.1", "192点168点0点1"},
+		{"decimal is kept", "3.14", "3.14"},
+
+		// 数値と単位
+		{"thousands separator", "1,000,000件", "1000000件"},
+		{"percent", "50%完了", "50パーセント完了"},
+		{"percent before letters", "50%OFF", "50パーセントOFF"},
+		{"decimal percent with space", "12.5 %", "12.5パーセント"},
+		{"gigabytes", "3.5GB のメモリ", "3.5ギガバイト のメモリ"},
+		{"mebibytes", "512MiB", "512メビバイト"},
+		{"milliseconds with space", "100 ms で応答", "100ミリ秒 で応答"},
+		{"bandwidth", "1Gbps の回線", "1ギガビーピーエス の回線"},
+		{"frequency", "2.4GHz", "2.4ギガヘルツ"},
+		{"seconds", "30s 待つ", "30秒 待つ"},
+		{"multiplier", "2x 速い", "2倍 速い"},
+		{"multiplication is not a unit", "3 x 4", "3 x 4"},
+		{"resolution is not a unit", "1920x1080", "1920x1080"},
+		{"unit inside a word", "5GBytes", "5GBytes"},
+		{"temperature", "25℃", "25度"},
+		{"fullwidth digits", "５０％", "50パーセント"},
+
+		// 記号
+		{"issue number", "PR #123 を確認", "PR 123番 を確認"},
+		{"color code is not a number", "#123abc", "#123abc"},
+
+		// URL
+		{"url is kept", "https://example.com/2024/10/17/v1.2.3 を参照", "https://example.com/2024/10/17/v1.2.3 を参照"},
+		{"text around url", "10:30 に https://example.com/a を公開", "10時30分 に https://example.com/a を公開"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.input); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcess_Normalize(t *testing.T) {
+	opts := DefaultOptions()
+	if got, want := Process("**v1.2.3** を 2024/10/17 に公開", opts), "バージョン1点2点3 を 2024年10月17日 に公開"; got != want {
+		t.Errorf("Process() = %q, want %q", got, want)
+	}
+
+	opts.Normalize = false
+	if got, want := Process("**v1.2.3** を 2024/10/17 に公開", opts), "v1.2.3 を 2024/10/17 に公開"; got != want {
+		t.Errorf("Process() without normalize = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,217 @@
+// Package preprocess は音声合成の前に読み上げ用のテキストを整えます。
+// エージェントが出力するMarkdownの記法やコードブロック、URLなどを読み上げやすい形に変換し、
+// 日付・時刻・単位などを日本語の読み方に、英単語をカタカナに書き換え、絵文字・顔文字を読み方やスタイルの切り替えにします
+package preprocess
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// コードブロックの扱い
+const (
+	// CodeSkip はコードブロックを読み上げません
+	CodeSkip = "skip"
+	// CodeSummary はコードブロックを「Goのコード12行は省略します。」のような要約に置き換えます
+	CodeSummary = "summary"
+	// CodeRead はコードブロックの中身をそのまま読み上げます
+	CodeRead = "read"
+)
+
+// URLの扱い
+const (
+	// URLDomain はURLをドメイン名だけにします
+	URLDomain = "domain"
+	// URLSkip はURLを読み上げません
+	URLSkip = "skip"
+	// URLRead はURLをそのまま読み上げます
+	URLRead = "read"
+)
+
+// 表の扱い
+const (
+	// TableRead は表を行ごとにセルを区切って読み上げます
+	TableRead = "read"
+	// TableSkip は表を読み上げません
+	TableSkip = "skip"
+	// TableSummary は表を「3行の表は省略します。」のような要約に置き換えます
+	TableSummary = "summary"
+)
+
+// Options は前処理のルールです
+type Options struct {
+	// Enabled が false の場合、前処理を行わずにテキストをそのまま返します
+	Enabled bool `json:"enabled"`
+	// Markdown が true の場合、見出し・強調・リスト・リンク・引用などの記法を取り除きます
+	Markdown bool `json:"markdown"`
+	// CodeBlocks はフェンスで囲まれたコードブロックの扱い（skip / summary / read）です
+	CodeBlocks string `json:"code_blocks"`
+	// URLs はURLの扱い（domain / skip / read）です
+	URLs string `json:"urls"`
+	// Tables はMarkdownの表の扱い（read / skip / summary）です
+	Tables string `json:"tables"`
+	// Normalize が true の場合、日付・時刻・バージョン・単位・記号を日本語の読み方に書き換えます
+	Normalize bool `json:"normalize"`
+	// English が true の場合、英単語・略語・識別子をカタカナの読み方に書き換えます。
+	// エンジンのユーザー辞書や既存の読み方を変えないよう、既定では無効です
+	English bool `json:"english"`
+	// Emoji は絵文字・顔文字の扱い（strip / read / style / keep）です
+	Emoji string `json:"emoji"`
+	// Dictionary は英単語の読み方の辞書です。nil の場合は組み込みの辞書を使います
+	Dictionary *Dictionary `json:"-"`
+	// EmojiTable は絵文字・顔文字の表です。nil の場合は組み込みの表を使います
+	EmojiTable *EmojiTable `json:"-"`
+}
+
+// DefaultOptions は既定の前処理ルールを返します
+func DefaultOptions() Options {
+	return Options{
+		Enabled:    true,
+		Markdown:   true,
+		CodeBlocks: CodeSummary,
+		URLs:       URLDomain,
+		Tables:     TableRead,
+		Normalize:  true,
+		English:    false,
+		Emoji:      EmojiStrip,
+	}
+}
+
+// optionValues はキーごとに指定できる値です
+var optionValues = map[string][]string{
+	"code_blocks": {CodeSkip, CodeSummary, CodeRead},
+	"urls":        {URLDomain, URLSkip, URLRead},
+	"tables":      {TableRead, TableSkip, TableSummary},
+	"emoji":       {EmojiStrip, EmojiRead, EmojiStyle, EmojiKeep},
+}
+
+// Set はキーと値を指定してルールを1つ変更します。
+// キーは enabled / markdown / normalize / english（true / false）、code_blocks / urls / tables / emoji です
+func (o *Options) Set(key, value string) error {
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "enabled", "markdown", "normalize", "english":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid preprocess value for %s: %s (expected true or false)", key, value)
+		}
+		switch key {
+		case "enabled":
+			o.Enabled = b
+		case "markdown":
+			o.Markdown = b
+		case "normalize":
+			o.Normalize = b
+		case "english":
+			o.English = b
+		}
+		return nil
+	case "code_blocks", "urls", "tables", "emoji":
+		if !contains(optionValues[key], value) {
+			return fmt.Errorf("invalid preprocess value for %s: %s (expected %s)", key, value, strings.Join(optionValues[key], ", "))
+		}
+		switch key {
+		case "code_blocks":
+			o.CodeBlocks = value
+		case "urls":
+			o.URLs = value
+		case "tables":
+			o.Tables = value
+		case "emoji":
+			o.Emoji = value
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown preprocess option: %s (expected %s)", key, strings.Join(optionKeys(), ", "))
+	}
+}
+
+// Apply は "code_blocks=skip,urls=domain" 形式のルール指定を適用します。
+// "off" / "false" は前処理の無効化、"on" / "true" は有効化として扱います
+func (o *Options) Apply(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			switch strings.ToLower(part) {
+			case "off", "false", "none":
+				o.Enabled = false
+				continue
+			case "on", "true":
+				o.Enabled = true
+				continue
+			}
+			return fmt.Errorf("invalid preprocess rule: %s (expected key=value)", part)
+		}
+		if err := o.Set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyArgs はツール引数の preprocess を適用します。
+// false で前処理を無効化し、{"code_blocks": "skip", "markdown": false} のようなオブジェクトで個別のルールを上書きします
+func (o *Options) ApplyArgs(arg interface{}) error {
+	switch v := arg.(type) {
+	case nil:
+		return nil
+	case bool:
+		o.Enabled = v
+		return nil
+	case map[string]interface{}:
+		for key, value := range v {
+			var s string
+			switch value := value.(type) {
+			case string:
+				s = value
+			case bool:
+				s = strconv.FormatBool(value)
+			default:
+				return fmt.Errorf("invalid preprocess value for %s: %v", key, value)
+			}
+			if err := o.Set(key, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("preprocess must be a boolean or an object")
+	}
+}
+
+// Validate はルールの値が正しいかを確認します
+func (o Options) Validate() error {
+	for key, value := range map[string]string{"code_blocks": o.CodeBlocks, "urls": o.URLs, "tables": o.Tables, "emoji": o.Emoji} {
+		if !contains(optionValues[key], value) {
+			return fmt.Errorf("invalid preprocess value for %s: %q (expected %s)", key, value, strings.Join(optionValues[key], ", "))
+		}
+	}
+	return nil
+}
+
+// optionKeys は指定できるキーの一覧を返します
+func optionKeys() []string {
+	keys := []string{"enabled", "markdown", "normalize", "english"}
+	for key := range optionValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys[4:])
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
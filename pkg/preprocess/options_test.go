@@ -0,0 +1,54 @@
+package preprocess
+
+import "testing"
+
+func TestOptions_Apply(t *testing.T) {
+	opts := DefaultOptions()
+	if err := opts.Apply("code_blocks=skip, urls=read,markdown=false"); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if opts.CodeBlocks != CodeSkip || opts.URLs != URLRead || opts.Markdown || !opts.Enabled {
+		t.Errorf("Apply() = %+v", opts)
+	}
+
+	if err := opts.Apply("off"); err != nil || opts.Enabled {
+		t.Errorf("Apply(off) = %+v, %v", opts, err)
+	}
+
+	for _, spec := range []string{"code_blocks=maybe", "colors=on", "markdown", "markdown=yes please"} {
+		opts := DefaultOptions()
+		if err := opts.Apply(spec); err == nil {
+			t.Errorf("Apply(%q) error = nil, want error", spec)
+		}
+	}
+}
+
+func TestOptions_ApplyArgs(t *testing.T) {
+	opts := DefaultOptions()
+	if err := opts.ApplyArgs(map[string]interface{}{"tables": "skip", "markdown": false}); err != nil {
+		t.Fatalf("ApplyArgs() error = %v", err)
+	}
+	if opts.Tables != TableSkip || opts.Markdown {
+		t.Errorf("ApplyArgs() = %+v", opts)
+	}
+
+	if err := opts.ApplyArgs(false); err != nil || opts.Enabled {
+		t.Errorf("ApplyArgs(false) = %+v, %v", opts, err)
+	}
+
+	for _, arg := range []interface{}{"skip", map[string]interface{}{"urls": 1.0}} {
+		opts := DefaultOptions()
+		if err := opts.ApplyArgs(arg); err == nil {
+			t.Errorf("ApplyArgs(%v) error = nil, want error", arg)
+		}
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	if err := DefaultOptions().Validate(); err != nil {
+		t.Errorf("DefaultOptions().Validate() error = %v", err)
+	}
+	if err := (Options{Enabled: true}).Validate(); err == nil {
+		t.Error("Validate() with empty modes error = nil, want error")
+	}
+}
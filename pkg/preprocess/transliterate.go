@@ -0,0 +1,345 @@
+package preprocess
+
+import "strings"
+
+// 綴りからカタカナの読み方を作る規則です。辞書にない単語に使うため、
+// 一般的な英語の綴りの規則（マジックe、r の付いた母音、二重母音、子音の組み合わせ）だけを扱います
+
+// phoneme は綴りから取り出した子音または母音です
+type phoneme struct {
+	consonant string
+	vowel     string
+	// geminate が true の子音の前には促音（ッ）を付けます
+	geminate bool
+}
+
+// kanaRows は子音ごとの ア・イ・ウ・エ・オ 段の読み方です
+var kanaRows = map[string][5]string{
+	"":   {"ア", "イ", "ウ", "エ", "オ"},
+	"k":  {"カ", "キ", "ク", "ケ", "コ"},
+	"g":  {"ガ", "ギ", "グ", "ゲ", "ゴ"},
+	"s":  {"サ", "シ", "ス", "セ", "ソ"},
+	"z":  {"ザ", "ジ", "ズ", "ゼ", "ゾ"},
+	"t":  {"タ", "ティ", "トゥ", "テ", "ト"},
+	"d":  {"ダ", "ディ", "ドゥ", "デ", "ド"},
+	"n":  {"ナ", "ニ", "ヌ", "ネ", "ノ"},
+	"h":  {"ハ", "ヒ", "フ", "ヘ", "ホ"},
+	"f":  {"ファ", "フィ", "フ", "フェ", "フォ"},
+	"b":  {"バ", "ビ", "ブ", "ベ", "ボ"},
+	"v":  {"バ", "ビ", "ブ", "ベ", "ボ"},
+	"p":  {"パ", "ピ", "プ", "ペ", "ポ"},
+	"m":  {"マ", "ミ", "ム", "メ", "モ"},
+	"y":  {"ヤ", "イ", "ユ", "イエ", "ヨ"},
+	"r":  {"ラ", "リ", "ル", "レ", "ロ"},
+	"l":  {"ラ", "リ", "ル", "レ", "ロ"},
+	"w":  {"ワ", "ウィ", "ウ", "ウェ", "ウォ"},
+	"j":  {"ジャ", "ジ", "ジュ", "ジェ", "ジョ"},
+	"ch": {"チャ", "チ", "チュ", "チェ", "チョ"},
+	"sh": {"シャ", "シ", "シュ", "シェ", "ショ"},
+	"ts": {"ツァ", "ツィ", "ツ", "ツェ", "ツォ"},
+	"kw": {"クア", "クイ", "ク", "クエ", "クオ"},
+	"ng": {"ンガ", "ンギ", "ング", "ンゲ", "ンゴ"},
+	"ks": {"クサ", "クシ", "クス", "クセ", "クソ"},
+}
+
+// codaReadings は母音が続かない子音の読み方です。ここにない子音はウ段で読みます
+var codaReadings = map[string]string{
+	"t":  "ト",
+	"d":  "ド",
+	"n":  "ン",
+	"h":  "",
+	"w":  "ウ",
+	"y":  "イ",
+	"ch": "チ",
+	"j":  "ジ",
+}
+
+// yoonReadings は子音に「ユー」が続く場合（music、new など）の読み方です
+var yoonReadings = map[string]string{
+	"k": "キュ", "g": "ギュ", "n": "ニュ", "h": "ヒュ", "f": "フュ", "b": "ビュ", "v": "ビュ",
+	"p": "ピュ", "m": "ミュ", "r": "リュ", "l": "リュ", "d": "デュ", "t": "テュ", "j": "ジュ", "ch": "チュ", "sh": "シュ",
+}
+
+// vowelIndex は母音の段です
+var vowelIndex = map[byte]int{'a': 0, 'i': 1, 'u': 2, 'e': 3, 'o': 4}
+
+// consonantDigraphs は2文字以上で1つの子音になる綴りです。長いものから照合します
+var consonantDigraphs = []struct {
+	spelling  string
+	consonant string
+	geminate  bool
+}{
+	{"tch", "ch", true},
+	{"ck", "k", true},
+	{"sh", "sh", false},
+	{"ch", "ch", false},
+	{"th", "s", false},
+	{"ph", "f", false},
+	{"wh", "w", false},
+	{"gh", "", false},
+	{"qu", "kw", false},
+	{"ng", "ng", false},
+	{"dg", "j", false},
+	{"ts", "ts", false},
+}
+
+// transliterate は綴りの規則で英単語をカタカナにします
+func transliterate(word string) string {
+	s := strings.ToLower(word)
+	s = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r
+		}
+		return -1
+	}, s)
+	if s == "" {
+		return word
+	}
+	return renderPhonemes(parsePhonemes(s))
+}
+
+// parsePhonemes は綴りを子音と母音の並びに分解します
+func parsePhonemes(s string) []phoneme {
+	var phonemes []phoneme
+	for i := 0; i < len(s); {
+		if vowel, n := vowelAt(s, i); n > 0 {
+			if vowel != "" {
+				phonemes = append(phonemes, phoneme{vowel: vowel})
+			}
+			i += n
+			continue
+		}
+		consonant, n, geminate := consonantAt(s, i)
+		if n > 0 && consonant != "" {
+			phonemes = append(phonemes, phoneme{consonant: consonant, geminate: geminate})
+		}
+		i += n
+	}
+	return phonemes
+}
+
+func isVowelByte(c byte) bool {
+	return c == 'a' || c == 'e' || c == 'i' || c == 'o' || c == 'u'
+}
+
+// isVowelAt は位置の文字が母音として読まれるかを判定します。y は子音の後ろでは母音として扱います
+func isVowelAt(s string, i int) bool {
+	if i >= len(s) {
+		return false
+	}
+	if s[i] == 'y' {
+		return i > 0 && !isVowelByte(s[i-1]) && (i+1 >= len(s) || !isVowelByte(s[i+1]))
+	}
+	return isVowelByte(s[i])
+}
+
+// consonantAt は位置から始まる子音を返します
+func consonantAt(s string, i int) (string, int, bool) {
+	if i == 0 {
+		for _, silent := range []string{"kn", "wr", "ps"} {
+			if strings.HasPrefix(s, silent) {
+				return string(silent[1]), 2, false
+			}
+		}
+	}
+	for _, d := range consonantDigraphs {
+		if strings.HasPrefix(s[i:], d.spelling) {
+			return d.consonant, len(d.spelling), d.geminate
+		}
+	}
+
+	c := s[i]
+	// 同じ子音が2つ続く場合は1つとして読み、t・p・k・c では促音を付ける
+	if i+1 < len(s) && s[i+1] == c {
+		return consonantLetter(s, i), 2, c == 't' || c == 'p' || c == 'k' || c == 'c'
+	}
+	return consonantLetter(s, i), 1, false
+}
+
+// consonantLetter は1文字の子音を返します
+func consonantLetter(s string, i int) string {
+	c := s[i]
+	next := byte(0)
+	if i+1 < len(s) {
+		next = s[i+1]
+	}
+	switch c {
+	case 'c':
+		if next == 'e' || next == 'i' || next == 'y' {
+			return "s"
+		}
+		return "k"
+	case 'g':
+		// page や image のように語末の ge は「ジ」と読む
+		if next == 'e' && i+2 == len(s) {
+			return "j"
+		}
+		return "g"
+	case 'x':
+		return "ks"
+	case 'q':
+		return "k"
+	}
+	return string(c)
+}
+
+// vowelAt は位置から始まる母音を返します。語末の e のように読まない母音は空文字列を返します
+func vowelAt(s string, i int) (string, int) {
+	if !isVowelAt(s, i) {
+		return "", 0
+	}
+	rest := s[i:]
+	end := len(s)
+
+	// 語末の e は読まない（table、code など）。be や the のような短い語は除く
+	if rest == "e" && i > 1 {
+		return "", 1
+	}
+
+	// night、light の igh は「アイ」と読む
+	if strings.HasPrefix(rest, "igh") {
+		return "ai", 3
+	}
+
+	// r の付いた母音と語末の re（core、fire、pure など）
+	if len(rest) >= 2 && rest[1] == 'r' {
+		if i+3 == end && rest[2] == 'e' && s[i] != 'y' {
+			return map[byte]string{'a': "ea", 'e': "ia", 'i': "aia", 'o': "oa", 'u': "yua"}[s[i]], 3
+		}
+		if i+2 == end || !isVowelAt(s, i+2) {
+			if s[i] == 'o' {
+				return "oo", 2
+			}
+			return "aa", 2
+		}
+	}
+
+	// 二重母音
+	if len(rest) >= 2 {
+		pair := rest[:2]
+		atEnd := i+2 == end
+		switch pair {
+		case "ee", "ea":
+			return "ii", 2
+		case "oo":
+			return "uu", 2
+		case "ou":
+			return "au", 2
+		case "ow":
+			if atEnd {
+				return "oo", 2
+			}
+			return "au", 2
+		case "ai", "ay", "ei":
+			return "ei", 2
+		case "ey":
+			if atEnd {
+				return "ii", 2
+			}
+			return "ei", 2
+		case "oa":
+			return "oo", 2
+		case "oi", "oy":
+			return "oi", 2
+		case "au", "aw":
+			return "oo", 2
+		case "ie":
+			if atEnd {
+				return "ai", 2
+			}
+			return "ii", 2
+		case "ue", "ew":
+			return "yuu", 2
+		case "ui":
+			return "i", 2
+		}
+	}
+
+	// マジックe（make、file、code、use など）: 母音、子音1つ、語末の e
+	if len(rest) == 3 && rest[2] == 'e' && !isVowelByte(rest[1]) && rest[1] != 'w' && rest[1] != 'x' && rest[1] != 'y' {
+		return map[byte]string{'a': "ei", 'e': "ii", 'i': "ai", 'o': "oo", 'u': "yuu", 'y': "ai"}[s[i]], 1
+	}
+
+	switch s[i] {
+	case 'y':
+		if i+1 == end && end <= 3 {
+			return "ai", 1
+		}
+		if i+1 == end {
+			return "ii", 1
+		}
+		return "i", 1
+	case 'u':
+		return "a", 1
+	}
+	return string(s[i]), 1
+}
+
+// renderPhonemes は子音と母音の並びをカタカナにします
+func renderPhonemes(phonemes []phoneme) string {
+	var b strings.Builder
+	for i := 0; i < len(phonemes); i++ {
+		p := phonemes[i]
+		if p.vowel != "" {
+			b.WriteString(kana("", p.vowel))
+			continue
+		}
+
+		vowel := ""
+		if i+1 < len(phonemes) && phonemes[i+1].vowel != "" {
+			vowel = phonemes[i+1].vowel
+		}
+		// 短い母音の後ろの語末の k・t・p・ch・sh・ks と、ck・tt などの綴りには促音を付ける
+		short := i > 0 && len(phonemes[i-1].vowel) == 1
+		final := i+1 == len(phonemes)
+		if short && (p.geminate || (final && vowel == "" && strings.Contains(" k t p ch sh ks ", " "+p.consonant+" "))) {
+			b.WriteString("ッ")
+		}
+
+		if vowel == "" {
+			if reading, ok := codaReadings[p.consonant]; ok {
+				b.WriteString(reading)
+			} else {
+				b.WriteString(kanaRows[p.consonant][2])
+			}
+			continue
+		}
+		b.WriteString(kana(p.consonant, vowel))
+		i++
+	}
+	return b.String()
+}
+
+// kana は子音と母音を1つのカタカナにします。長母音は「ー」、二重母音は2文字目を付けて表します
+func kana(consonant, vowel string) string {
+	if vowel == "yuu" || vowel == "yua" {
+		long := "ー"
+		if vowel == "yua" {
+			long = "ア"
+		}
+		if consonant == "" {
+			return "ユ" + long
+		}
+		if yoon, ok := yoonReadings[consonant]; ok {
+			return yoon + long
+		}
+		return kanaRows[consonant][2] + long
+	}
+
+	row, ok := kanaRows[consonant]
+	if !ok {
+		row = kanaRows[""]
+	}
+	first := row[vowelIndex[vowel[0]]]
+	if len(vowel) == 1 {
+		return first
+	}
+
+	switch vowel {
+	case "aa", "ii", "uu", "ee", "oo":
+		return first + "ー"
+	case "aia":
+		return first + "イア"
+	}
+	return first + kanaRows[""][vowelIndex[vowel[1]]]
+}
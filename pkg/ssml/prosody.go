@@ -0,0 +1,217 @@
+package ssml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/preprocess"
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+// Prosody は区間の話速・音高・音量・抑揚の調整です。音声クエリの値に重ねて使います
+type Prosody struct {
+	// Rate は話速の倍率です
+	Rate float64 `json:"rate"`
+	// Pitch は音高に加える値です
+	Pitch float64 `json:"pitch"`
+	// Volume は音量の倍率です
+	Volume float64 `json:"volume"`
+	// Intonation は抑揚の倍率です（<emphasis> で変わります）
+	Intonation float64 `json:"intonation"`
+}
+
+// NeutralProsody は何も調整しない Prosody を返します
+func NeutralProsody() Prosody {
+	return Prosody{Rate: 1, Volume: 1, Intonation: 1}
+}
+
+// Apply は音声クエリの話速・音高・抑揚・音量に調整を重ね、VOICEVOXで指定できる範囲に収めます
+func (p Prosody) Apply(q *voicevox.AudioQuery) {
+	q.SpeedScale = clamp(q.SpeedScale*p.Rate, 0.5, 2.0)
+	q.PitchScale = clamp(q.PitchScale+p.Pitch, -0.15, 0.15)
+	q.IntonationScale = clamp(q.IntonationScale*p.Intonation, 0.0, 2.0)
+	q.VolumeScale = clamp(q.VolumeScale*p.Volume, 0.0, 2.0)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+var (
+	// rateKeywords は <prosody rate> のキーワードの話速の倍率です
+	rateKeywords = map[string]float64{"x-slow": 0.6, "slow": 0.8, "medium": 1.0, "default": 1.0, "fast": 1.25, "x-fast": 1.5}
+	// pitchKeywords は <prosody pitch> のキーワードの音高に加える値です
+	pitchKeywords = map[string]float64{"x-low": -0.1, "low": -0.05, "medium": 0, "default": 0, "high": 0.05, "x-high": 0.1}
+	// volumeKeywords は <prosody volume> のキーワードの音量の倍率です
+	volumeKeywords = map[string]float64{"silent": 0, "x-soft": 0.25, "soft": 0.5, "medium": 1.0, "default": 1.0, "loud": 1.5, "x-loud": 2.0}
+	// emphasisLevels は <emphasis level> ごとの抑揚と音量の倍率です
+	emphasisLevels = map[string][2]float64{"strong": {1.5, 1.2}, "moderate": {1.25, 1.1}, "none": {1, 1}, "reduced": {0.75, 0.9}}
+	// breakStrengths は <break strength> ごとの無音の長さです
+	breakStrengths = map[string]time.Duration{
+		"none": 0, "x-weak": 100 * time.Millisecond, "weak": 250 * time.Millisecond, "medium": 500 * time.Millisecond,
+		"strong": 750 * time.Millisecond, "x-strong": time.Second,
+	}
+)
+
+// parseProsody は <prosody> の rate / pitch / volume を親要素の調整に重ねます。
+// rate は キーワード、"120%"（倍率）、"+20%"（相対）、"1.2"、
+// pitch は キーワード、"+0.05"（音高に加える値）、"+2st"（半音）、
+// volume は キーワード、"+6dB"、"80%"、"+20%"、"1.5" で指定します
+func parseProsody(base Prosody, attrs map[string]string) (Prosody, error) {
+	p := base
+	if v, ok := attrs["rate"]; ok {
+		rate, err := parseScale(v, rateKeywords, false)
+		if err != nil {
+			return p, fmt.Errorf("invalid prosody rate %q: %v", v, err)
+		}
+		if rate <= 0 {
+			return p, fmt.Errorf("invalid prosody rate %q: must be greater than 0", v)
+		}
+		p.Rate *= rate
+	}
+	if v, ok := attrs["pitch"]; ok {
+		pitch, err := parsePitch(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid prosody pitch %q: %v", v, err)
+		}
+		p.Pitch += pitch
+	}
+	if v, ok := attrs["volume"]; ok {
+		volume, err := parseScale(v, volumeKeywords, true)
+		if err != nil {
+			return p, fmt.Errorf("invalid prosody volume %q: %v", v, err)
+		}
+		if volume < 0 {
+			return p, fmt.Errorf("invalid prosody volume %q: must not be negative", v)
+		}
+		p.Volume *= volume
+	}
+	return p, nil
+}
+
+// parseScale は倍率の指定を解釈します。dB が true の場合は "+6dB" のようなデシベルも受け付けます
+func parseScale(v string, keywords map[string]float64, dB bool) (float64, error) {
+	v = strings.TrimSpace(v)
+	if scale, ok := keywords[v]; ok {
+		return scale, nil
+	}
+	if dB && strings.HasSuffix(v, "dB") {
+		n, err := strconv.ParseFloat(strings.TrimSuffix(v, "dB"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number of decibels")
+		}
+		return math.Pow(10, n/20), nil
+	}
+	if percent, ok := strings.CutSuffix(v, "%"); ok {
+		n, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a percentage")
+		}
+		// "+20%" と "-20%" は元の値からの増減、"120%" は倍率として扱う
+		if strings.HasPrefix(percent, "+") || strings.HasPrefix(percent, "-") {
+			return 1 + n/100, nil
+		}
+		return n / 100, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected %s, a percentage or a number", keywordList(keywords))
+	}
+	return n, nil
+}
+
+// parsePitch は音高の指定を音高に加える値にします。1半音は約0.058です
+func parsePitch(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	if pitch, ok := pitchKeywords[v]; ok {
+		return pitch, nil
+	}
+	if semitones, ok := strings.CutSuffix(v, "st"); ok {
+		n, err := strconv.ParseFloat(semitones, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number of semitones")
+		}
+		return n * math.Ln2 / 12, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected %s, semitones (+2st) or a number (+0.05)", keywordList(pitchKeywords))
+	}
+	return n, nil
+}
+
+// parseEmphasis は <emphasis level> で抑揚と音量を強めます。level を省略した場合は moderate です
+func parseEmphasis(base Prosody, level string) (Prosody, error) {
+	if level == "" {
+		level = "moderate"
+	}
+	scales, ok := emphasisLevels[level]
+	if !ok {
+		return base, fmt.Errorf("invalid emphasis level %q (expected strong, moderate, none or reduced)", level)
+	}
+	base.Intonation *= scales[0]
+	base.Volume *= scales[1]
+	return base, nil
+}
+
+// parseBreak は <break> の time（"500ms"、"1.5s"）または strength から無音の長さを求めます。
+// どちらも省略した場合は medium です
+func parseBreak(attrs map[string]string) (time.Duration, error) {
+	if v, ok := attrs["time"]; ok {
+		v = strings.TrimSpace(v)
+		if !strings.HasSuffix(v, "s") {
+			return 0, fmt.Errorf("invalid break time %q (expected a duration such as 500ms or 1.5s)", v)
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid break time %q (expected a duration such as 500ms or 1.5s)", v)
+		}
+		if d > MaxBreak {
+			return 0, fmt.Errorf("break time %q exceeds the maximum of %s", v, MaxBreak)
+		}
+		return d, nil
+	}
+
+	strength := attrs["strength"]
+	if strength == "" {
+		strength = "medium"
+	}
+	d, ok := breakStrengths[strength]
+	if !ok {
+		return 0, fmt.Errorf("invalid break strength %q (expected none, x-weak, weak, medium, strong or x-strong)", strength)
+	}
+	return d, nil
+}
+
+// sayAs は <say-as interpret-as> に従って読み方を決めます
+func sayAs(interpretAs, text string) (string, error) {
+	switch interpretAs {
+	case "characters", "spell-out", "verbatim", "digits":
+		return preprocess.Spell(strings.ReplaceAll(text, " ", "")), nil
+	case "cardinal", "number":
+		return strings.ReplaceAll(text, ",", ""), nil
+	case "ordinal":
+		return strings.ReplaceAll(text, ",", "") + "番目", nil
+	case "date", "time":
+		return preprocess.Normalize(text), nil
+	case "telephone":
+		return strings.NewReplacer("-", "、", "(", "", ")", "").Replace(preprocess.Spell(text)), nil
+	case "":
+		return "", fmt.Errorf("<say-as> requires an interpret-as attribute")
+	default:
+		return "", fmt.Errorf("unsupported interpret-as %q (supported: characters, spell-out, verbatim, digits, cardinal, number, ordinal, date, time, telephone)", interpretAs)
+	}
+}
+
+func keywordList(keywords map[string]float64) string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
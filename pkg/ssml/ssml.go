@@ -0,0 +1,332 @@
+// Package ssml は text_to_speech で使えるSSMLのサブセットを解析し、読み上げる区間に分けます。
+// <speak> の中で <break>、<prosody>、<say-as>、<sub>、<voice>、<emphasis> と、文章の区切りの <p>、<s> を扱います
+package ssml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxBreak は <break> で指定できる最長の無音です
+const MaxBreak = 10 * time.Second
+
+// Segment は同じ話者・同じ調整で読み上げる区間です
+type Segment struct {
+	// Text は読み上げるテキストです。空の場合は Break の無音だけを表します
+	Text string `json:"text,omitempty"`
+	// Verbatim が true の場合、<sub> や <say-as> で読み方が決まっているため前処理を行いません
+	Verbatim bool `json:"verbatim,omitempty"`
+	// Voice は <voice name> で指定された話者です。空の場合は呼び出し時の話者で読み上げます
+	Voice string `json:"voice,omitempty"`
+	// Prosody は区間の話速・音高・音量・抑揚の調整です
+	Prosody Prosody `json:"prosody"`
+	// Break は <break> で指定された無音の長さです
+	Break time.Duration `json:"break,omitempty"`
+}
+
+// SyntaxError はSSMLの記述の誤りです。Line と Column は1から数え、Column は文字単位です
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid SSML at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// IsSSML はテキストが <speak> またはXML宣言で始まるSSMLの文書かを判定します
+func IsSSML(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "<speak") || strings.HasPrefix(text, "<?xml")
+}
+
+// Parse はSSMLを解析して区間に分けます。SSMLの文書でないテキストは <speak> で囲んで解析します。
+// 記述に誤りがある場合は位置を含む *SyntaxError を返します
+func Parse(text string) ([]Segment, error) {
+	src, shift := text, 0
+	if !IsSSML(text) {
+		src = "<speak>" + text + "</speak>"
+		shift = len("<speak>")
+	}
+
+	p := &parser{text: text, shift: shift}
+	d := xml.NewDecoder(strings.NewReader(src))
+	d.Strict = true
+
+	for {
+		offset := int(d.InputOffset())
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			msg := err.Error()
+			var xmlErr *xml.SyntaxError
+			if errors.As(err, &xmlErr) {
+				msg = xmlErr.Msg
+			}
+			return nil, p.errorAt(int(d.InputOffset()), msg)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			err = p.start(t, offset)
+		case xml.EndElement:
+			err = p.end()
+		case xml.CharData:
+			err = p.chars(string(t), offset)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !p.seenRoot {
+		return nil, p.errorAt(shift, "missing <speak> element")
+	}
+	return p.finish(), nil
+}
+
+// frame は開いている要素です
+type frame struct {
+	name    string
+	voice   string
+	prosody Prosody
+	// collect が true の要素（<sub>、<say-as>）は中のテキストを集めてから読み方を決めます
+	collect bool
+	text    strings.Builder
+	attrs   map[string]string
+	offset  int
+}
+
+type parser struct {
+	text     string
+	shift    int
+	stack    []*frame
+	seenRoot bool
+	segments []Segment
+}
+
+// errorAt は解析中の位置（<speak> で囲んだ場合はその分を除いたバイト位置）から *SyntaxError を作成します
+func (p *parser) errorAt(offset int, msg string) error {
+	offset -= p.shift
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(p.text) {
+		offset = len(p.text)
+	}
+	before := p.text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+	return &SyntaxError{Line: line, Column: column, Msg: msg}
+}
+
+func (p *parser) top() *frame {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *parser) start(el xml.StartElement, offset int) error {
+	name := el.Name.Local
+	attrs := make(map[string]string, len(el.Attr))
+	for _, attr := range el.Attr {
+		attrs[attr.Name.Local] = attr.Value
+	}
+
+	if len(p.stack) == 0 {
+		if name != "speak" {
+			return p.errorAt(offset, fmt.Sprintf("root element must be <speak>, got <%s>", name))
+		}
+		if p.seenRoot {
+			return p.errorAt(offset, "only one <speak> element is allowed")
+		}
+		p.seenRoot = true
+		p.stack = append(p.stack, &frame{name: name, prosody: NeutralProsody(), offset: offset})
+		return nil
+	}
+
+	parent := p.top()
+	if parent.collect {
+		return p.errorAt(offset, fmt.Sprintf("<%s> cannot contain <%s>", parent.name, name))
+	}
+	f := &frame{name: name, voice: parent.voice, prosody: parent.prosody, attrs: attrs, offset: offset}
+
+	var err error
+	switch name {
+	case "p", "s":
+		err = checkAttrs(attrs)
+	case "break":
+		err = checkAttrs(attrs, "time", "strength")
+		if err == nil {
+			var d time.Duration
+			d, err = parseBreak(attrs)
+			if err == nil {
+				p.segments = append(p.segments, Segment{Break: d})
+			}
+		}
+	case "prosody":
+		err = checkAttrs(attrs, "rate", "pitch", "volume")
+		if err == nil {
+			f.prosody, err = parseProsody(f.prosody, attrs)
+		}
+	case "emphasis":
+		err = checkAttrs(attrs, "level")
+		if err == nil {
+			f.prosody, err = parseEmphasis(f.prosody, attrs["level"])
+		}
+	case "voice":
+		err = checkAttrs(attrs, "name")
+		if err == nil {
+			f.voice = strings.TrimSpace(attrs["name"])
+			if f.voice == "" {
+				err = fmt.Errorf("<voice> requires a name attribute")
+			}
+		}
+	case "sub":
+		err = checkAttrs(attrs, "alias")
+		if err == nil && strings.TrimSpace(attrs["alias"]) == "" {
+			err = fmt.Errorf("<sub> requires an alias attribute")
+		}
+		f.collect = true
+	case "say-as":
+		err = checkAttrs(attrs, "interpret-as", "format", "detail")
+		if err == nil {
+			_, err = sayAs(attrs["interpret-as"], "")
+		}
+		f.collect = true
+	case "speak":
+		err = fmt.Errorf("<speak> cannot be nested")
+	default:
+		err = fmt.Errorf("unsupported element <%s> (supported: speak, p, s, break, prosody, emphasis, voice, sub, say-as)", name)
+	}
+	if err != nil {
+		return p.errorAt(offset, err.Error())
+	}
+
+	p.stack = append(p.stack, f)
+	return nil
+}
+
+func (p *parser) end() error {
+	f := p.top()
+	p.stack = p.stack[:len(p.stack)-1]
+
+	switch f.name {
+	case "sub":
+		p.emit(strings.TrimSpace(f.attrs["alias"]), true, f)
+	case "say-as":
+		reading, err := sayAs(f.attrs["interpret-as"], collapseSpaces(f.text.String()))
+		if err != nil {
+			return p.errorAt(f.offset, err.Error())
+		}
+		p.emit(reading, true, f)
+	case "p":
+		// 段落の後ろには <break strength="medium"> と同じ無音を入れる
+		p.segments = append(p.segments, Segment{Break: breakStrengths["medium"]})
+	}
+	return nil
+}
+
+func (p *parser) chars(text string, offset int) error {
+	if len(p.stack) == 0 {
+		if strings.TrimSpace(text) != "" {
+			return p.errorAt(offset, "text outside <speak>")
+		}
+		return nil
+	}
+
+	f := p.top()
+	if f.collect {
+		f.text.WriteString(text)
+		return nil
+	}
+	if f.name == "break" {
+		if strings.TrimSpace(text) != "" {
+			return p.errorAt(offset, "<break> must be empty")
+		}
+		return nil
+	}
+	p.emit(collapseSpaces(text), false, f)
+	return nil
+}
+
+// emit はテキストを区間に追加します。直前の区間と話者・調整が同じ場合はつなげます
+func (p *parser) emit(text string, verbatim bool, f *frame) {
+	if strings.TrimSpace(text) == "" {
+		if text != "" && len(p.segments) > 0 && p.segments[len(p.segments)-1].Text != "" {
+			p.segments[len(p.segments)-1].Text += " "
+		}
+		return
+	}
+	if n := len(p.segments); n > 0 {
+		last := &p.segments[n-1]
+		if last.Text != "" && last.Verbatim == verbatim && last.Voice == f.voice && last.Prosody == f.prosody {
+			last.Text += text
+			return
+		}
+	}
+	p.segments = append(p.segments, Segment{Text: text, Verbatim: verbatim, Voice: f.voice, Prosody: f.prosody})
+}
+
+// finish は区間の前後の空白を整え、連続する無音をまとめます
+func (p *parser) finish() []Segment {
+	segments := make([]Segment, 0, len(p.segments))
+	for _, segment := range p.segments {
+		segment.Text = strings.TrimSpace(segment.Text)
+		if segment.Text == "" {
+			if segment.Break == 0 {
+				continue
+			}
+			if n := len(segments); n > 0 && segments[n-1].Text == "" {
+				segments[n-1].Break = min(segments[n-1].Break+segment.Break, MaxBreak)
+				continue
+			}
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// checkAttrs は要素に対応していない属性がないかを確認します
+func checkAttrs(attrs map[string]string, allowed ...string) error {
+	for name := range attrs {
+		if name == "lang" || name == "space" {
+			// xml:lang と xml:space は読み上げに影響しないため無視する
+			continue
+		}
+		found := false
+		for _, a := range allowed {
+			if a == name {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unsupported attribute %q", name)
+		}
+	}
+	return nil
+}
+
+// collapseSpaces は改行やインデントを含む連続した空白を1つの空白にします
+func collapseSpaces(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		if text != "" {
+			return " "
+		}
+		return ""
+	}
+	collapsed := strings.Join(fields, " ")
+	if first, _ := utf8.DecodeRuneInString(text); first == ' ' || first == '\n' || first == '\t' || first == '\r' {
+		collapsed = " " + collapsed
+	}
+	if last, _ := utf8.DecodeLastRuneInString(text); last == ' ' || last == '\n' || last == '\t' || last == '\r' {
+		collapsed += " "
+	}
+	return collapsed
+}
@@ -0,0 +1,157 @@
+package ssml
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+func TestParse(t *testing.T) {
+	neutral := NeutralProsody()
+	slow := neutral
+	slow.Rate = 0.8
+	loudHigh := neutral
+	loudHigh.Pitch = 0.05
+	loudHigh.Volume = math.Pow(10, 6.0/20)
+	strong := neutral
+	strong.Intonation = 1.5
+	strong.Volume = 1.2
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Segment
+	}{
+		{
+			"plain text",
+			"<speak>こんにちは</speak>",
+			[]Segment{{Text: "こんにちは", Prosody: neutral}},
+		},
+		{
+			"without speak element",
+			"こんにちは<break time=\"500ms\"/>世界",
+			[]Segment{{Text: "こんにちは", Prosody: neutral}, {Break: 500 * time.Millisecond}, {Text: "世界", Prosody: neutral}},
+		},
+		{
+			"break strength and merged breaks",
+			"<speak>A<break strength=\"weak\"/><break time=\"1s\"/>B<break/></speak>",
+			[]Segment{{Text: "A", Prosody: neutral}, {Break: 1250 * time.Millisecond}, {Text: "B", Prosody: neutral}, {Break: 500 * time.Millisecond}},
+		},
+		{
+			"prosody",
+			"<speak>普通に<prosody rate=\"slow\">ゆっくり</prosody>戻る</speak>",
+			[]Segment{{Text: "普通に", Prosody: neutral}, {Text: "ゆっくり", Prosody: slow}, {Text: "戻る", Prosody: neutral}},
+		},
+		{
+			"pitch and decibels",
+			"<speak><prosody pitch=\"high\" volume=\"+6dB\">大きく</prosody></speak>",
+			[]Segment{{Text: "大きく", Prosody: loudHigh}},
+		},
+		{
+			"emphasis",
+			"<speak>これは<emphasis level=\"strong\">重要</emphasis>です</speak>",
+			[]Segment{{Text: "これは", Prosody: neutral}, {Text: "重要", Prosody: strong}, {Text: "です", Prosody: neutral}},
+		},
+		{
+			"voice",
+			"<speak>わたしは<voice name=\"四国めたん\">わたくしは</voice></speak>",
+			[]Segment{{Text: "わたしは", Prosody: neutral}, {Text: "わたくしは", Voice: "四国めたん", Prosody: neutral}},
+		},
+		{
+			"sub",
+			"<speak><sub alias=\"ダブリュースリーシー\">W3C</sub>の仕様</speak>",
+			[]Segment{{Text: "ダブリュースリーシー", Verbatim: true, Prosody: neutral}, {Text: "の仕様", Prosody: neutral}},
+		},
+		{
+			"say-as characters",
+			"<speak>コードは<say-as interpret-as=\"characters\">AB12</say-as>です</speak>",
+			[]Segment{{Text: "コードは", Prosody: neutral}, {Text: "エービーイチニー", Verbatim: true, Prosody: neutral}, {Text: "です", Prosody: neutral}},
+		},
+		{
+			"say-as date",
+			"<speak><say-as interpret-as=\"date\">2024/10/17</say-as></speak>",
+			[]Segment{{Text: "2024年10月17日", Verbatim: true, Prosody: neutral}},
+		},
+		{
+			"paragraphs and whitespace",
+			"<speak>\n  <p>一段落目。</p>\n  <p>二段落目。</p>\n</speak>",
+			[]Segment{{Text: "一段落目。", Prosody: neutral}, {Break: 500 * time.Millisecond}, {Text: "二段落目。", Prosody: neutral}, {Break: 500 * time.Millisecond}},
+		},
+		{
+			"xml declaration and entities",
+			"<?xml version=\"1.0\"?><speak xml:lang=\"ja-JP\">A &amp; B</speak>",
+			[]Segment{{Text: "A & B", Prosody: neutral}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantLine   int
+		wantColumn int
+	}{
+		{"unclosed element", "<speak>\n  <prosody rate=\"slow\">ゆっくり\n</speak>", 3, 9},
+		{"unsupported element", "<speak>\n  あいう<audio src=\"a.wav\"/></speak>", 2, 6},
+		{"unsupported attribute", "<speak><prosody speed=\"fast\">速く</prosody></speak>", 1, 8},
+		{"invalid rate", "<speak>\n<prosody rate=\"quick\">速く</prosody></speak>", 2, 1},
+		{"invalid break time", "<speak>あ<break time=\"5\"/></speak>", 1, 9},
+		{"break too long", "<speak><break time=\"20s\"/></speak>", 1, 8},
+		{"missing alias", "<speak><sub>W3C</sub></speak>", 1, 8},
+		{"unknown interpret-as", "<speak><say-as interpret-as=\"currency\">100</say-as></speak>", 1, 8},
+		{"element inside sub", "<speak><sub alias=\"a\"><break/></sub></speak>", 1, 23},
+		{"root is not speak", "<?xml version=\"1.0\"?><voice name=\"a\">x</voice>", 1, 22},
+		{"two speak elements", "<speak>a</speak><speak>b</speak>", 1, 17},
+		{"text outside speak", "<speak>a</speak>b", 1, 17},
+		{"column counts characters", "あいう<emphasis level=\"max\">え</emphasis>", 1, 4},
+		{"unescaped ampersand", "<speak>A & B</speak>", 1, 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want *SyntaxError", tt.input, err)
+			}
+			if syntaxErr.Line != tt.wantLine || syntaxErr.Column != tt.wantColumn {
+				t.Errorf("Parse(%q) error at %d:%d (%s), want %d:%d", tt.input, syntaxErr.Line, syntaxErr.Column, syntaxErr.Msg, tt.wantLine, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestProsody_Apply(t *testing.T) {
+	q := &voicevox.AudioQuery{SpeedScale: 1.5, PitchScale: 0.1, IntonationScale: 1.0, VolumeScale: 1.0}
+	Prosody{Rate: 1.5, Pitch: 0.1, Volume: 0.5, Intonation: 1.5}.Apply(q)
+
+	if q.SpeedScale != 2.0 || q.PitchScale != 0.15 || q.IntonationScale != 1.5 || q.VolumeScale != 0.5 {
+		t.Errorf("Apply() = speed %v, pitch %v, intonation %v, volume %v", q.SpeedScale, q.PitchScale, q.IntonationScale, q.VolumeScale)
+	}
+}
+
+func TestIsSSML(t *testing.T) {
+	if !IsSSML("  <speak>あ</speak>") {
+		t.Error("IsSSML(<speak>) = false, want true")
+	}
+	if IsSSML("a < b") {
+		t.Error("IsSSML(a < b) = true, want false")
+	}
+}
@@ -0,0 +1,35 @@
+package timing
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SRT は字幕をSubRip形式にします
+func (t Track) SRT() string {
+	var b strings.Builder
+	for i, c := range t.Cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.Start, ","), timestamp(c.End, ","), c.Text)
+	}
+	return b.String()
+}
+
+// vttEscaper はWebVTTの字幕の文でタグとして扱われる文字を置き換えます
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// VTT は字幕をWebVTT形式にします
+func (t Track) VTT() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for i, c := range t.Cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.Start, "."), timestamp(c.End, "."), vttEscaper.Replace(c.Text))
+	}
+	return b.String()
+}
+
+// timestamp は秒数を時:分:秒とミリ秒の表記にします。SRT はミリ秒の前に ","、WebVTT は "." を使います
+func timestamp(seconds float64, sep string) string {
+	ms := int64(math.Round(math.Max(0, seconds) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,169 @@
+// Package timing は音声クエリのモーラの長さから、読み上げた音声のどこで何を話しているかを求めます。
+// 字幕（SRT・WebVTT）、単語（アクセント句）・モーラごとのタイミングのJSON、リップシンク用の口の形の区間を作ります
+package timing
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+// frameRate はエンジンが音素の長さを丸める単位（1秒あたりのフレーム数）です。24kHz を256サンプルごとに区切ります
+const frameRate = 24000.0 / 256
+
+// Cue は字幕の1つの区間です。時間は音声の先頭からの秒数です
+type Cue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Word はアクセント句の区間です。Text は読み（カタカナ）で、Cue は含まれる字幕の番号（0から）です
+type Word struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+	Cue   int     `json:"cue"`
+}
+
+// Mora はモーラの区間です。VowelStart は子音が終わり母音が始まる時刻で、子音のないモーラでは Start と同じです。
+// Vowel はエンジンの音素名（a・i・u・e・o、ん の N、促音の cl、無声化した A・I・U など）です
+type Mora struct {
+	Start      float64 `json:"start"`
+	VowelStart float64 `json:"vowel_start"`
+	End        float64 `json:"end"`
+	Text       string  `json:"text"`
+	Consonant  string  `json:"consonant,omitempty"`
+	Vowel      string  `json:"vowel"`
+	Word       int     `json:"word"`
+}
+
+// Track は音声全体のタイミングです。Duration は音声の長さ（秒）です
+type Track struct {
+	Duration float64 `json:"duration"`
+	Cues     []Cue   `json:"cues"`
+	Words    []Word  `json:"words"`
+	Moras    []Mora  `json:"moras"`
+}
+
+// FromQuery は1回の合成で読み上げた区間のタイミングを音声クエリから求めます。
+// 各音素の長さは話速で割り、エンジンと同じくフレーム単位に丸めます。
+// duration に合成したWAVの長さ（秒）を渡すと、計算した長さとの差を全体に割り振って合わせます。
+// text は字幕に表示する文で、話している最初のモーラから最後のモーラまでを1つの字幕にします
+func FromQuery(query *voicevox.AudioQuery, text string, duration float64) (Track, error) {
+	phrases, err := query.Phrases()
+	if err != nil {
+		return Track{}, err
+	}
+	speed := query.SpeedScale
+	if speed <= 0 {
+		speed = 1
+	}
+	length := func(seconds float64) float64 {
+		return math.Round(seconds*frameRate/speed) / frameRate
+	}
+
+	var track Track
+	pos := length(query.PrePhonemeLength)
+	for _, phrase := range phrases {
+		word := Word{Start: pos}
+		for _, m := range phrase.Moras {
+			mora := Mora{Start: pos, Text: m.Text, Vowel: m.Vowel, Word: len(track.Words)}
+			if m.Consonant != nil && m.ConsonantLength != nil {
+				mora.Consonant = *m.Consonant
+				pos += length(*m.ConsonantLength)
+			}
+			mora.VowelStart = pos
+			pos += length(m.VowelLength)
+			mora.End = pos
+			track.Moras = append(track.Moras, mora)
+			word.Text += m.Text
+		}
+		word.End = pos
+		if len(phrase.Moras) > 0 {
+			track.Words = append(track.Words, word)
+		}
+		if phrase.PauseMora != nil {
+			pos += length(phrase.PauseMora.Length())
+		}
+	}
+	pos += length(query.PostPhonemeLength)
+
+	if len(track.Moras) > 0 {
+		text = strings.Join(strings.Fields(text), " ")
+		track.Cues = []Cue{{Start: track.Moras[0].Start, End: track.Moras[len(track.Moras)-1].End, Text: text}}
+	}
+	track.Duration = pos
+	if duration > 0 && pos > 0 {
+		track.scale(duration / pos)
+		track.Duration = duration
+	}
+	return track, nil
+}
+
+// Append は続けて読み上げた区間のタイミングを後ろにつなげます
+func (t *Track) Append(next Track) {
+	next = next.clone()
+	next.Shift(t.Duration)
+	cues, words := len(t.Cues), len(t.Words)
+	t.Cues = append(t.Cues, next.Cues...)
+	for _, w := range next.Words {
+		w.Cue += cues
+		t.Words = append(t.Words, w)
+	}
+	for _, m := range next.Moras {
+		m.Word += words
+		t.Moras = append(t.Moras, m)
+	}
+	t.Duration += next.Duration
+}
+
+// Shift は全ての区間を seconds 秒ずらします。前に無音を足した場合は正、先頭を切り取った場合は負の値を渡し、
+// 0秒より前になる時刻は0秒にします。Duration は変えないため、音声の長さが変わった場合は呼び出し側で設定します
+func (t *Track) Shift(seconds float64) {
+	t.each(func(v float64) float64 { return math.Max(0, v+seconds) })
+}
+
+// scale は全ての時刻を factor 倍にします
+func (t *Track) scale(factor float64) {
+	t.each(func(v float64) float64 { return v * factor })
+}
+
+// each は全ての時刻を f で置き換えます
+func (t *Track) each(f func(float64) float64) {
+	for i := range t.Cues {
+		t.Cues[i].Start, t.Cues[i].End = f(t.Cues[i].Start), f(t.Cues[i].End)
+	}
+	for i := range t.Words {
+		t.Words[i].Start, t.Words[i].End = f(t.Words[i].Start), f(t.Words[i].End)
+	}
+	for i := range t.Moras {
+		m := &t.Moras[i]
+		m.Start, m.VowelStart, m.End = f(m.Start), f(m.VowelStart), f(m.End)
+	}
+}
+
+// JSON はタイミングをミリ秒の精度に丸めたJSONにします
+func (t Track) JSON() ([]byte, error) {
+	rounded := t.clone()
+	rounded.Duration = roundMillis(t.Duration)
+	rounded.each(roundMillis)
+	return json.MarshalIndent(rounded, "", "  ")
+}
+
+// clone は区間の一覧を複製したタイミングを返します。空の一覧はJSONで [] になるようにします
+func (t Track) clone() Track {
+	return Track{
+		Duration: t.Duration,
+		Cues:     append([]Cue{}, t.Cues...),
+		Words:    append([]Word{}, t.Words...),
+		Moras:    append([]Mora{}, t.Moras...),
+	}
+}
+
+// roundMillis は秒数をミリ秒の精度に丸めます
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
@@ -0,0 +1,115 @@
+package timing
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/metapox/mcp-voicevox-go/pkg/voicevox"
+)
+
+// query は「こんにちは、せかい」を読み上げる音声クエリです。各音素の長さはフレーム（1/93.75秒）の倍数にしています
+func query(t *testing.T, speed float64) *voicevox.AudioQuery {
+	t.Helper()
+	const f = 1 / frameRate
+	data := `{"accent_phrases":[
+		{"moras":[
+			{"text":"コ","consonant":"k","consonant_length":` + ftoa(4*f) + `,"vowel":"o","vowel_length":` + ftoa(8*f) + `,"pitch":5.5},
+			{"text":"ン","consonant":null,"consonant_length":null,"vowel":"N","vowel_length":` + ftoa(6*f) + `,"pitch":5.6}
+		],"accent":1,"pause_mora":{"text":"、","consonant":null,"consonant_length":null,"vowel":"pau","vowel_length":` + ftoa(20*f) + `,"pitch":0},"is_interrogative":false},
+		{"moras":[
+			{"text":"セ","consonant":"s","consonant_length":` + ftoa(6*f) + `,"vowel":"e","vowel_length":` + ftoa(10*f) + `,"pitch":5.8}
+		],"accent":1,"pause_mora":null,"is_interrogative":false}
+	],"speedScale":` + ftoa(speed) + `,"pitchScale":0,"intonationScale":1,"volumeScale":1,
+	"prePhonemeLength":` + ftoa(10*f) + `,"postPhonemeLength":` + ftoa(10*f) + `,"outputSamplingRate":24000,"outputStereo":false,"kana":""}`
+	var q voicevox.AudioQuery
+	if err := json.Unmarshal([]byte(data), &q); err != nil {
+		t.Fatal(err)
+	}
+	return &q
+}
+
+func ftoa(v float64) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestFromQuery(t *testing.T) {
+	const f = 1 / frameRate
+	track, err := FromQuery(query(t, 1), " こんにちは、\n せかい ", 0)
+	if err != nil {
+		t.Fatalf("FromQuery() error = %v", err)
+	}
+	if !near(track.Duration, 74*f) || len(track.Words) != 2 || len(track.Moras) != 3 || len(track.Cues) != 1 {
+		t.Fatalf("FromQuery() = %+v", track)
+	}
+	ko, n, se := track.Moras[0], track.Moras[1], track.Moras[2]
+	if !near(ko.Start, 10*f) || !near(ko.VowelStart, 14*f) || !near(ko.End, 22*f) || ko.Consonant != "k" {
+		t.Errorf("mora コ = %+v", ko)
+	}
+	if !near(n.Start, n.VowelStart) || !near(n.End, 28*f) || n.Vowel != "N" {
+		t.Errorf("mora ン = %+v", n)
+	}
+	// 読点の無音の後に次のアクセント句が始まる
+	if !near(se.Start, 48*f) || !near(se.End, 64*f) || se.Word != 1 {
+		t.Errorf("mora セ = %+v", se)
+	}
+	if w := track.Words[0]; w.Text != "コン" || !near(w.Start, 10*f) || !near(w.End, 28*f) {
+		t.Errorf("word = %+v", w)
+	}
+	if c := track.Cues[0]; c.Text != "こんにちは、 せかい" || !near(c.Start, 10*f) || !near(c.End, 64*f) {
+		t.Errorf("cue = %+v", c)
+	}
+
+	// 話速2倍では全体が半分になり、WAVの長さを渡すとそれに合わせる
+	fast, _ := FromQuery(query(t, 2), "", 0)
+	if !near(fast.Duration, 37*f) {
+		t.Errorf("Duration at speed 2 = %v, want %v", fast.Duration, 37*f)
+	}
+	scaled, _ := FromQuery(query(t, 1), "", 148*f)
+	if !near(scaled.Duration, 148*f) || !near(scaled.Moras[2].End, 128*f) {
+		t.Errorf("scaled = %+v", scaled)
+	}
+}
+
+func TestTrack_Append(t *testing.T) {
+	first, _ := FromQuery(query(t, 1), "一つ目", 1)
+	second, _ := FromQuery(query(t, 1), "二つ目", 2)
+	first.Append(second)
+	if first.Duration != 3 || len(first.Cues) != 2 || len(first.Words) != 4 || len(first.Moras) != 6 {
+		t.Fatalf("Append() = %+v", first)
+	}
+	if first.Words[2].Cue != 1 || first.Moras[5].Word != 3 || first.Cues[1].Start <= 1 {
+		t.Errorf("appended indexes = %+v / %+v / %+v", first.Words[2], first.Moras[5], first.Cues[1])
+	}
+
+	first.Shift(-1.2)
+	if first.Cues[0].Start != 0 || !near(first.Cues[1].Start, second.Cues[0].Start-0.2) {
+		t.Errorf("Shift() cues = %+v", first.Cues)
+	}
+}
+
+func TestTrack_Subtitles(t *testing.T) {
+	track := Track{Duration: 3725.5, Cues: []Cue{
+		{Start: 0.1, End: 1.2345, Text: "こんにちは。"},
+		{Start: 3723.4, End: 3725.0004, Text: "A<B & C"},
+	}}
+	wantSRT := "1\n00:00:00,100 --> 00:00:01,235\nこんにちは。\n\n" +
+		"2\n01:02:03,400 --> 01:02:05,000\nA<B & C\n\n"
+	if got := track.SRT(); got != wantSRT {
+		t.Errorf("SRT() = %q, want %q", got, wantSRT)
+	}
+	wantVTT := "WEBVTT\n\n1\n00:00:00.100 --> 00:00:01.235\nこんにちは。\n\n" +
+		"2\n01:02:03.400 --> 01:02:05.000\nA&lt;B &amp; C\n\n"
+	if got := track.VTT(); got != wantVTT {
+		t.Errorf("VTT() = %q, want %q", got, wantVTT)
+	}
+
+	data, err := track.JSON()
+	if err != nil || !strings.Contains(string(data), `"end": 1.235`) || !strings.Contains(string(data), `"words": []`) {
+		t.Errorf("JSON() = %s, %v", data, err)
+	}
+}
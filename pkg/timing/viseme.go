@@ -0,0 +1,152 @@
+package timing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 口の形（ビゼーム）の種類です。子音の間は次の母音の形にし、唇を閉じる子音（m・b・p）と促音は VisemeN にします
+const (
+	VisemeA       = "a"
+	VisemeI       = "i"
+	VisemeU       = "u"
+	VisemeE       = "e"
+	VisemeO       = "o"
+	VisemeN       = "N"
+	VisemeSilence = "sil"
+)
+
+// Viseme は同じ口の形が続く区間です
+type Viseme struct {
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Viseme string  `json:"viseme"`
+}
+
+// rhubarbShapes は口の形を Rhubarb Lip Sync の口の形（A〜H、X）に対応させます
+var rhubarbShapes = map[string]string{
+	VisemeA:       "D",
+	VisemeI:       "B",
+	VisemeU:       "F",
+	VisemeE:       "C",
+	VisemeO:       "E",
+	VisemeN:       "A",
+	VisemeSilence: "X",
+}
+
+// vrmExpressions は母音の口の形をVRM 1.0のリップシンクの表情名に対応させます（VRM 0.x の A・I・U・E・O にあたります）
+var vrmExpressions = []struct{ viseme, name string }{
+	{VisemeA, "aa"},
+	{VisemeI, "ih"},
+	{VisemeU, "ou"},
+	{VisemeE, "ee"},
+	{VisemeO, "oh"},
+}
+
+// bilabials は唇を閉じて発音する子音です
+var bilabials = map[string]bool{"m": true, "my": true, "b": true, "by": true, "p": true, "py": true}
+
+// vowelViseme はモーラの母音の口の形を返します。無声化した母音（A・I・U など）も同じ形にします
+func vowelViseme(vowel string) string {
+	switch v := strings.ToLower(vowel); v {
+	case VisemeA, VisemeI, VisemeU, VisemeE, VisemeO:
+		return v
+	case "n", "cl":
+		return VisemeN
+	default:
+		return VisemeSilence
+	}
+}
+
+// Visemes は音声の先頭から終わりまでの口の形の区間を返します。話していない間は VisemeSilence で、
+// 隣り合う同じ形の区間はまとめます
+func (t Track) Visemes() []Viseme {
+	var visemes []Viseme
+	add := func(start, end float64, viseme string) {
+		if end <= start {
+			return
+		}
+		if n := len(visemes); n > 0 && visemes[n-1].Viseme == viseme {
+			visemes[n-1].End = end
+			return
+		}
+		visemes = append(visemes, Viseme{Start: start, End: end, Viseme: viseme})
+	}
+
+	pos := 0.0
+	for _, m := range t.Moras {
+		add(pos, m.Start, VisemeSilence)
+		vowel := vowelViseme(m.Vowel)
+		if bilabials[m.Consonant] {
+			add(m.Start, m.VowelStart, VisemeN)
+		} else {
+			add(m.Start, m.VowelStart, vowel)
+		}
+		add(m.VowelStart, m.End, vowel)
+		pos = max(pos, m.End)
+	}
+	add(pos, t.Duration, VisemeSilence)
+	return visemes
+}
+
+// VisemeJSON は口の形の区間をミリ秒の精度に丸めたJSONにします
+func (t Track) VisemeJSON() ([]byte, error) {
+	visemes := t.Visemes()
+	for i := range visemes {
+		visemes[i].Start, visemes[i].End = roundMillis(visemes[i].Start), roundMillis(visemes[i].End)
+	}
+	return json.MarshalIndent(struct {
+		Duration float64  `json:"duration"`
+		Visemes  []Viseme `json:"visemes"`
+	}{roundMillis(t.Duration), append([]Viseme{}, visemes...)}, "", "  ")
+}
+
+// RhubarbTSV は口の形を Rhubarb Lip Sync の TSV 形式（開始時刻と口の形の行）にします。
+// 最後の行は音声の終わりの時刻と、口を閉じた X です
+func (t Track) RhubarbTSV() string {
+	var b strings.Builder
+	visemes := t.Visemes()
+	for _, v := range visemes {
+		fmt.Fprintf(&b, "%.2f\t%s\n", v.Start, rhubarbShapes[v.Viseme])
+	}
+	if n := len(visemes); n == 0 || visemes[n-1].Viseme != VisemeSilence {
+		fmt.Fprintf(&b, "%.2f\t%s\n", t.Duration, rhubarbShapes[VisemeSilence])
+	}
+	return b.String()
+}
+
+// VRMKeyframe はVRMの表情の重み（0〜1）を切り替える時刻です
+type VRMKeyframe struct {
+	Time    float64            `json:"time"`
+	Weights map[string]float64 `json:"weights"`
+}
+
+// VRMJSON は口の形をVRMのリップシンクの表情（aa・ih・ou・ee・oh）の重みのキーフレームにしたJSONにします。
+// 各キーフレームには5つの表情の重みを全て含め、ん や無音では全て0にします
+func (t Track) VRMJSON() ([]byte, error) {
+	visemes := t.Visemes()
+	keyframes := make([]VRMKeyframe, 0, len(visemes)+1)
+	for _, v := range visemes {
+		keyframes = append(keyframes, VRMKeyframe{Time: roundMillis(v.Start), Weights: vrmWeights(v.Viseme)})
+	}
+	if n := len(visemes); n == 0 || visemes[n-1].Viseme != VisemeSilence {
+		keyframes = append(keyframes, VRMKeyframe{Time: roundMillis(t.Duration), Weights: vrmWeights(VisemeSilence)})
+	}
+	return json.MarshalIndent(struct {
+		Duration  float64       `json:"duration"`
+		Keyframes []VRMKeyframe `json:"keyframes"`
+	}{roundMillis(t.Duration), keyframes}, "", "  ")
+}
+
+// vrmWeights は口の形に対応する表情を1、それ以外を0にした重みを返します
+func vrmWeights(viseme string) map[string]float64 {
+	weights := make(map[string]float64, len(vrmExpressions))
+	for _, e := range vrmExpressions {
+		weights[e.name] = 0
+		if e.viseme == viseme {
+			weights[e.name] = 1
+		}
+	}
+	return weights
+}
@@ -0,0 +1,59 @@
+package timing
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTrack_Visemes(t *testing.T) {
+	// 「まんま、っす」: m は唇を閉じ、ん・促音は N、話していない間は無音
+	track := Track{Duration: 1.2, Moras: []Mora{
+		{Start: 0.1, VowelStart: 0.15, End: 0.3, Consonant: "m", Vowel: "a"},
+		{Start: 0.3, VowelStart: 0.3, End: 0.4, Vowel: "N"},
+		{Start: 0.4, VowelStart: 0.45, End: 0.6, Consonant: "m", Vowel: "a"},
+		{Start: 0.8, VowelStart: 0.8, End: 0.9, Vowel: "cl"},
+		{Start: 0.9, VowelStart: 0.95, End: 1.1, Consonant: "s", Vowel: "U"},
+	}}
+	want := []Viseme{
+		{0, 0.1, VisemeSilence},
+		{0.1, 0.15, VisemeN},
+		{0.15, 0.3, VisemeA},
+		{0.3, 0.45, VisemeN},
+		{0.45, 0.6, VisemeA},
+		{0.6, 0.8, VisemeSilence},
+		{0.8, 0.9, VisemeN},
+		{0.9, 1.1, VisemeU},
+		{1.1, 1.2, VisemeSilence},
+	}
+	if got := track.Visemes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Visemes() = %v, want %v", got, want)
+	}
+
+	wantTSV := "0.00\tX\n0.10\tA\n0.15\tD\n0.30\tA\n0.45\tD\n0.60\tX\n0.80\tA\n0.90\tF\n1.10\tX\n"
+	if got := track.RhubarbTSV(); got != wantTSV {
+		t.Errorf("RhubarbTSV() = %q, want %q", got, wantTSV)
+	}
+
+	data, err := track.VRMJSON()
+	if err != nil {
+		t.Fatalf("VRMJSON() error = %v", err)
+	}
+	var vrm struct {
+		Keyframes []VRMKeyframe `json:"keyframes"`
+	}
+	if err := json.Unmarshal(data, &vrm); err != nil || len(vrm.Keyframes) != len(want) {
+		t.Fatalf("VRMJSON() = %s, %v", data, err)
+	}
+	if k := vrm.Keyframes[2]; k.Time != 0.15 || k.Weights["aa"] != 1 || k.Weights["ou"] != 0 || len(k.Weights) != 5 {
+		t.Errorf("keyframe at 0.15 = %+v", k)
+	}
+	if k := vrm.Keyframes[1]; k.Weights["aa"] != 0 || len(k.Weights) != 5 {
+		t.Errorf("keyframe for N = %+v", k)
+	}
+
+	// 話していない音声は全体が無音になる
+	if got := (Track{Duration: 0.5}).Visemes(); !reflect.DeepEqual(got, []Viseme{{0, 0.5, VisemeSilence}}) {
+		t.Errorf("Visemes(silence) = %v", got)
+	}
+}
@@ -0,0 +1,223 @@
+package voicevox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSpeakerCacheTTL は話者一覧キャッシュの既定の有効期間です
+	DefaultSpeakerCacheTTL = 10 * time.Minute
+	// DefaultVersionCheckInterval はエンジンのバージョン変更を確認する既定の間隔です
+	DefaultVersionCheckInterval = 30 * time.Second
+	// minRefreshInterval は未知のスタイルIDを検出したときに再取得を行う最小間隔です
+	minRefreshInterval = 5 * time.Second
+)
+
+// UnknownStyleError は指定されたスタイルIDがエンジンに存在しないことを表すエラーです
+type UnknownStyleError struct {
+	StyleID  int
+	ValidIDs []int
+}
+
+// Error はerrorインターフェースを実装します
+func (e *UnknownStyleError) Error() string {
+	ids := make([]string, 0, len(e.ValidIDs))
+	for _, id := range e.ValidIDs {
+		ids = append(ids, fmt.Sprintf("%d", id))
+	}
+	return fmt.Sprintf("unknown speaker style ID %d; valid IDs: %s", e.StyleID, strings.Join(ids, ", "))
+}
+
+// SpeakerCache は /speakers の結果をキャッシュし、名前による話者解決とIDの検証を提供します。
+// キャッシュは TTL ごとに再取得されるほか、エンジンのバージョンが変わった場合にも破棄されます
+type SpeakerCache struct {
+	client *Client
+	ttl    time.Duration
+
+	// VersionCheckInterval はエンジンのバージョン変更を確認する間隔です
+	VersionCheckInterval time.Duration
+
+	// mu はキャッシュの状態を守ります。エンジンへの問い合わせ中は保持しません
+	mu             sync.Mutex
+	speakers       []Speaker
+	version        string
+	fetchedAt      time.Time
+	versionChecked time.Time
+	// fetching は進行中の話者一覧の取得です。同時に参照されても取得は1回にまとめます
+	fetching *speakersFetch
+	// generation は Invalidate のたびに進み、破棄より前に始まった取得の結果を保存しないようにします
+	generation int
+}
+
+// speakersFetch は進行中の話者一覧の取得です。done が閉じられた後に speakers と err を参照できます
+type speakersFetch struct {
+	done     chan struct{}
+	speakers []Speaker
+	err      error
+}
+
+// NewSpeakerCache は新しいSpeakerCacheを作成します
+func NewSpeakerCache(client *Client, ttl time.Duration) *SpeakerCache {
+	return &SpeakerCache{
+		client:               client,
+		ttl:                  ttl,
+		VersionCheckInterval: DefaultVersionCheckInterval,
+	}
+}
+
+// Speakers はキャッシュ済みの話者一覧を返します。
+// 期限切れの場合やエンジンのバージョンが変わった場合はエンジンから取得し直します
+func (c *SpeakerCache) Speakers() ([]Speaker, error) {
+	c.mu.Lock()
+	speakers, version := c.speakers, c.version
+	fresh := speakers != nil && time.Since(c.fetchedAt) < c.ttl
+	checkVersion := fresh && time.Since(c.versionChecked) >= c.VersionCheckInterval
+	if checkVersion {
+		c.versionChecked = time.Now()
+	}
+	c.mu.Unlock()
+
+	if fresh && (!checkVersion || !c.versionChanged(version)) {
+		return speakers, nil
+	}
+	return c.refresh()
+}
+
+// Version は最後に話者一覧を取得したときのエンジンのバージョンを返します
+func (c *SpeakerCache) Version() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.version
+}
+
+// Invalidate はキャッシュを破棄し、次回の参照時に再取得させます
+func (c *SpeakerCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.speakers = nil
+	c.generation++
+}
+
+// Resolve は「キャラクター名 + スタイル名」形式の文字列からスタイルを特定します
+func (c *SpeakerCache) Resolve(query string) (StyleInfo, error) {
+	speakers, err := c.Speakers()
+	if err != nil {
+		return StyleInfo{}, err
+	}
+	return ResolveStyle(speakers, query)
+}
+
+// ValidateStyleID はスタイルIDがエンジンに存在するかを確認します。
+// 存在しない場合は一度だけ話者一覧を取得し直し、それでも見つからなければ *UnknownStyleError を返します
+func (c *SpeakerCache) ValidateStyleID(styleID int) error {
+	speakers, err := c.Speakers()
+	if err != nil {
+		return err
+	}
+	if hasStyleID(speakers, styleID) {
+		return nil
+	}
+
+	c.mu.Lock()
+	stale := time.Since(c.fetchedAt) >= minRefreshInterval
+	c.mu.Unlock()
+	if stale {
+		if speakers, err = c.refresh(); err != nil {
+			return err
+		}
+	}
+
+	if hasStyleID(speakers, styleID) {
+		return nil
+	}
+	return &UnknownStyleError{StyleID: styleID, ValidIDs: styleIDs(speakers)}
+}
+
+// refresh はエンジンのバージョンと話者一覧を取得し直し、取得した話者一覧を返します。
+// 取得はロックの外で行い、すでに取得中であればその結果を待ちます。ロックを保持せずに呼び出してください
+func (c *SpeakerCache) refresh() ([]Speaker, error) {
+	c.mu.Lock()
+	if f := c.fetching; f != nil {
+		c.mu.Unlock()
+		<-f.done
+		return f.speakers, f.err
+	}
+	f := &speakersFetch{done: make(chan struct{})}
+	c.fetching = f
+	generation := c.generation
+	c.mu.Unlock()
+
+	var version string
+	f.speakers, f.err = c.client.GetSpeakers()
+	if f.err == nil {
+		// バージョン取得に失敗しても話者一覧は更新する
+		if v, err := c.client.GetVersion(); err == nil {
+			version = v
+		}
+	}
+
+	c.mu.Lock()
+	c.fetching = nil
+	if f.err == nil && generation == c.generation {
+		if version != "" {
+			c.version = version
+		}
+		c.speakers = f.speakers
+		c.fetchedAt = time.Now()
+		c.versionChecked = c.fetchedAt
+	}
+	c.mu.Unlock()
+	close(f.done)
+
+	return f.speakers, f.err
+}
+
+// versionChanged はエンジンのバージョンを問い合わせ、キャッシュ取得時のバージョン cached から変わっているかを返します。
+// ロックを保持せずに呼び出してください
+func (c *SpeakerCache) versionChanged(cached string) bool {
+	version, err := c.client.GetVersion()
+	if err != nil {
+		return false
+	}
+	return cached != "" && version != cached
+}
+
+// hasStyleID は話者一覧にスタイルIDが含まれるかを返します
+func hasStyleID(speakers []Speaker, styleID int) bool {
+	for _, speaker := range speakers {
+		for _, style := range speaker.Styles {
+			if style.ID == styleID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// styleIDs は話者一覧に含まれるスタイルIDを昇順で返します
+func styleIDs(speakers []Speaker) []int {
+	var ids []int
+	for _, speaker := range speakers {
+		for _, style := range speaker.Styles {
+			ids = append(ids, style.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// SiblingStyle は styleID と同じキャラクターのスタイルのうち、names のいずれかの名前を持つものを返します
+func (c *SpeakerCache) SiblingStyle(styleID int, names []string) (StyleInfo, bool, error) {
+	speakers, err := c.Speakers()
+	if err != nil {
+		return StyleInfo{}, false, err
+	}
+	style, ok := SiblingStyle(speakers, styleID, names)
+	return style, ok, nil
+}
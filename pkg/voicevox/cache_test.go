@@ -0,0 +1,175 @@
+package voicevox
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEngine は /speakers と /version を返すテスト用のエンジンです
+type fakeEngine struct {
+	mu             sync.Mutex
+	version        string
+	speakers       string
+	speakerFetches int
+}
+
+func (e *fakeEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/version":
+		w.Write([]byte(`"` + e.version + `"`))
+	case "/speakers":
+		e.speakerFetches++
+		w.Write([]byte(e.speakers))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (e *fakeEngine) set(version, speakers string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.version = version
+	e.speakers = speakers
+}
+
+func (e *fakeEngine) fetches() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.speakerFetches
+}
+
+func TestSpeakerCache_ValidateStyleID(t *testing.T) {
+	engine := &fakeEngine{version: "0.15.0", speakers: speakersFixture}
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	cache := NewSpeakerCache(NewClient(srv.URL), time.Hour)
+
+	if err := cache.ValidateStyleID(3); err != nil {
+		t.Errorf("ValidateStyleID(3) error = %v", err)
+	}
+
+	err := cache.ValidateStyleID(999)
+	var unknownErr *UnknownStyleError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("ValidateStyleID(999) error = %v, want *UnknownStyleError", err)
+	}
+	want := []int{0, 1, 2, 3, 3000, 3002}
+	if len(unknownErr.ValidIDs) != len(want) {
+		t.Fatalf("ValidIDs = %v, want %v", unknownErr.ValidIDs, want)
+	}
+	for i := range want {
+		if unknownErr.ValidIDs[i] != want[i] {
+			t.Errorf("ValidIDs = %v, want %v", unknownErr.ValidIDs, want)
+		}
+	}
+
+	if cache.Version() != "0.15.0" {
+		t.Errorf("Version() = %q, want 0.15.0", cache.Version())
+	}
+}
+
+func TestSpeakerCache_RefreshOnVersionChange(t *testing.T) {
+	engine := &fakeEngine{version: "0.15.0", speakers: speakersFixture}
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	cache := NewSpeakerCache(NewClient(srv.URL), time.Hour)
+	cache.VersionCheckInterval = 0
+
+	if _, err := cache.Speakers(); err != nil {
+		t.Fatalf("Speakers() error = %v", err)
+	}
+	if _, err := cache.Speakers(); err != nil {
+		t.Fatalf("Speakers() error = %v", err)
+	}
+	if got := engine.fetches(); got != 1 {
+		t.Errorf("speaker fetches = %d, want 1 while version is unchanged", got)
+	}
+
+	engine.set("0.16.0", `[{"name": "新キャラ", "speaker_uuid": "x", "styles": [{"name": "ノーマル", "id": 100}]}]`)
+
+	speakers, err := cache.Speakers()
+	if err != nil {
+		t.Fatalf("Speakers() error = %v", err)
+	}
+	if got := engine.fetches(); got != 2 {
+		t.Errorf("speaker fetches = %d, want 2 after version change", got)
+	}
+	if len(speakers) != 1 || speakers[0].Styles[0].ID != 100 {
+		t.Errorf("Speakers() = %+v, want refreshed list", speakers)
+	}
+	if cache.Version() != "0.16.0" {
+		t.Errorf("Version() = %q, want 0.16.0", cache.Version())
+	}
+}
+
+func TestSpeakerCache_TTL(t *testing.T) {
+	engine := &fakeEngine{version: "0.15.0", speakers: speakersFixture}
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	cache := NewSpeakerCache(NewClient(srv.URL), 0)
+
+	cache.Speakers()
+	cache.Speakers()
+	if got := engine.fetches(); got != 2 {
+		t.Errorf("speaker fetches = %d, want 2 with zero TTL", got)
+	}
+}
+
+func TestSpeakerCache_FetchOutsideLock(t *testing.T) {
+	engine := &fakeEngine{version: "0.15.0", speakers: speakersFixture}
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/speakers" {
+			select {
+			case requested <- struct{}{}:
+			default:
+			}
+			<-release
+		}
+		engine.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	cache := NewSpeakerCache(NewClient(srv.URL), time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Speakers(); err != nil {
+				t.Errorf("Speakers() error = %v", err)
+			}
+		}()
+	}
+	<-requested
+
+	// 取得中でもキャッシュの参照は待たされない
+	versionDone := make(chan struct{})
+	go func() {
+		cache.Version()
+		close(versionDone)
+	}()
+	select {
+	case <-versionDone:
+	case <-time.After(time.Second):
+		t.Error("Version() blocked while speakers were being fetched")
+	}
+
+	close(release)
+	wg.Wait()
+	if got := engine.fetches(); got != 1 {
+		t.Errorf("speaker fetches = %d, want 1 for concurrent callers", got)
+	}
+}
@@ -2,13 +2,19 @@ package voicevox
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
+// DefaultTimeout はVOICEVOX APIリクエストの既定のタイムアウトです。
+// 初回合成時のモデル読み込みを考慮して長めに設定しています
+const DefaultTimeout = 2 * time.Minute
+
 // Client はVOICEVOX APIとの通信を担当する構造体です
 type Client struct {
 	BaseURL    string
@@ -19,30 +25,51 @@ type Client struct {
 func NewClient(baseURL string) *Client {
 	return &Client{
 		BaseURL:    baseURL,
-		HTTPClient: &http.Client{},
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
 	}
 }
 
-// Speaker はVOICEVOXの話者情報を表す構造体です
-type Speaker struct {
-	Name      string `json:"name"`
-	SpeakerID int    `json:"speaker_id"`
-	StyleID   int    `json:"style_id"`
-	StyleName string `json:"style_name"`
+// do はリクエストを送信し、失敗時は種類ごとのエラー型（UnreachableError, TimeoutError,
+// ValidationError, EngineError, UnsupportedEndpointError, StatusError）を返します。
+// 成功時はレスポンスボディを呼び出し側で閉じてください
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	endpoint := req.URL.Path
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, classifyRequestError(endpoint, err)
+	}
+
+	if err := checkResponse(endpoint, resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// get はGETリクエストを送信します
+func (c *Client) get(path string, params url.Values) (*http.Response, error) {
+	target := c.BaseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
 }
 
 // GetSpeakers は利用可能な話者の一覧を取得します
 func (c *Client) GetSpeakers() ([]Speaker, error) {
-	resp, err := c.HTTPClient.Get(c.BaseURL + "/speakers")
+	resp, err := c.get("/speakers", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
-	}
-
 	var speakers []Speaker
 	if err := json.NewDecoder(resp.Body).Decode(&speakers); err != nil {
 		return nil, err
@@ -51,6 +78,22 @@ func (c *Client) GetSpeakers() ([]Speaker, error) {
 	return speakers, nil
 }
 
+// GetVersion はVOICEVOXエンジンのバージョンを取得します
+func (c *Client) GetVersion() (string, error) {
+	resp, err := c.get("/version", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var version string
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
 // AudioQueryRequest は音声合成のためのクエリリクエストを表す構造体です
 type AudioQueryRequest struct {
 	Text      string `json:"text"`
@@ -76,36 +119,71 @@ func (c *Client) CreateAudioQuery(text string, speakerID int) (*AudioQuery, erro
 	return c.CreateAudioQueryWithOptions(text, speakerID, nil)
 }
 
+// 出力のサンプリングレートと前後の無音の長さの範囲
+const (
+	MinOutputSamplingRate = 8000
+	MaxOutputSamplingRate = 96000
+	MaxPhonemeLength      = 1.5
+)
+
 // AudioQueryOptions は音声合成のオプションを表す構造体です
 type AudioQueryOptions struct {
-	SpeedScale      *float64 `json:"speed_scale,omitempty"`      // 話速 (0.5-2.0)
-	PitchScale      *float64 `json:"pitch_scale,omitempty"`      // 音高 (-0.15-0.15)
-	IntonationScale *float64 `json:"intonation_scale,omitempty"` // 抑揚 (0.0-2.0)
-	VolumeScale     *float64 `json:"volume_scale,omitempty"`     // 音量 (0.0-2.0)
+	SpeedScale         *float64 `json:"speed_scale,omitempty"`          // 話速 (0.5-2.0)
+	PitchScale         *float64 `json:"pitch_scale,omitempty"`          // 音高 (-0.15-0.15)
+	IntonationScale    *float64 `json:"intonation_scale,omitempty"`     // 抑揚 (0.0-2.0)
+	VolumeScale        *float64 `json:"volume_scale,omitempty"`         // 音量 (0.0-2.0)
+	PrePhonemeLength   *float64 `json:"pre_phoneme_length,omitempty"`   // 音声の前の無音の秒数 (0.0-1.5)
+	PostPhonemeLength  *float64 `json:"post_phoneme_length,omitempty"`  // 音声の後の無音の秒数 (0.0-1.5)
+	OutputSamplingRate *int     `json:"output_sampling_rate,omitempty"` // 出力のサンプリングレート (8000-96000 Hz)
+	OutputStereo       *bool    `json:"output_stereo,omitempty"`        // ステレオで出力するか
+}
+
+// Validate は指定されたオプションの値が範囲内かを確認します
+func (o AudioQueryOptions) Validate() error {
+	for _, p := range []struct {
+		name     string
+		value    *float64
+		min, max float64
+	}{
+		{"speed_scale", o.SpeedScale, 0.5, 2.0},
+		{"pitch_scale", o.PitchScale, -0.15, 0.15},
+		{"intonation_scale", o.IntonationScale, 0.0, 2.0},
+		{"volume_scale", o.VolumeScale, 0.0, 2.0},
+		{"pre_phoneme_length", o.PrePhonemeLength, 0.0, MaxPhonemeLength},
+		{"post_phoneme_length", o.PostPhonemeLength, 0.0, MaxPhonemeLength},
+	} {
+		if p.value != nil && (*p.value < p.min || *p.value > p.max) {
+			return fmt.Errorf("%s must be between %v and %v, got %v", p.name, p.min, p.max, *p.value)
+		}
+	}
+	if o.OutputSamplingRate != nil && (*o.OutputSamplingRate < MinOutputSamplingRate || *o.OutputSamplingRate > MaxOutputSamplingRate) {
+		return fmt.Errorf("output_sampling_rate must be between %d and %d, got %d", MinOutputSamplingRate, MaxOutputSamplingRate, *o.OutputSamplingRate)
+	}
+	return nil
 }
 
 // CreateAudioQueryWithOptions はオプション付きで音声合成のためのクエリを作成します
 func (c *Client) CreateAudioQueryWithOptions(text string, speakerID int, options *AudioQueryOptions) (*AudioQuery, error) {
+	return c.CreateAudioQueryContext(context.Background(), text, speakerID, options)
+}
+
+// CreateAudioQueryContext は CreateAudioQueryWithOptions と同じクエリを作成します。ctx がキャンセルされるとリクエストを中断します
+func (c *Client) CreateAudioQueryContext(ctx context.Context, text string, speakerID int, options *AudioQueryOptions) (*AudioQuery, error) {
 	params := url.Values{}
 	params.Add("text", text)
 	params.Add("speaker", fmt.Sprintf("%d", speakerID))
 
-	req, err := http.NewRequest("POST", c.BaseURL+"/audio_query?"+params.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/audio_query?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s, body: %s", resp.Status, string(body))
-	}
-
 	var query AudioQuery
 	if err := json.NewDecoder(resp.Body).Decode(&query); err != nil {
 		return nil, err
@@ -125,6 +203,18 @@ func (c *Client) CreateAudioQueryWithOptions(text string, speakerID int, options
 		if options.VolumeScale != nil {
 			query.VolumeScale = *options.VolumeScale
 		}
+		if options.PrePhonemeLength != nil {
+			query.PrePhonemeLength = *options.PrePhonemeLength
+		}
+		if options.PostPhonemeLength != nil {
+			query.PostPhonemeLength = *options.PostPhonemeLength
+		}
+		if options.OutputSamplingRate != nil {
+			query.OutputSamplingRate = *options.OutputSamplingRate
+		}
+		if options.OutputStereo != nil {
+			query.OutputStereo = *options.OutputStereo
+		}
 	}
 
 	return &query, nil
@@ -132,6 +222,11 @@ func (c *Client) CreateAudioQueryWithOptions(text string, speakerID int, options
 
 // SynthesizeVoice は音声合成を実行し、音声データを返します
 func (c *Client) SynthesizeVoice(query *AudioQuery, speakerID int) ([]byte, error) {
+	return c.SynthesizeVoiceContext(context.Background(), query, speakerID)
+}
+
+// SynthesizeVoiceContext は SynthesizeVoice と同じく音声を合成します。ctx がキャンセルされるとリクエストを中断します
+func (c *Client) SynthesizeVoiceContext(ctx context.Context, query *AudioQuery, speakerID int) ([]byte, error) {
 	queryJSON, err := json.Marshal(query)
 	if err != nil {
 		return nil, err
@@ -140,22 +235,57 @@ func (c *Client) SynthesizeVoice(query *AudioQuery, speakerID int) ([]byte, erro
 	params := url.Values{}
 	params.Add("speaker", fmt.Sprintf("%d", speakerID))
 
-	req, err := http.NewRequest("POST", c.BaseURL+"/synthesis?"+params.Encode(), bytes.NewBuffer(queryJSON))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/synthesis?"+params.Encode(), bytes.NewBuffer(queryJSON))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "audio/wav")
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
+	return io.ReadAll(resp.Body)
+}
+
+// InitializeSpeaker は指定したスタイルのモデルを事前に読み込みます
+func (c *Client) InitializeSpeaker(styleID int) error {
+	params := url.Values{}
+	params.Add("speaker", fmt.Sprintf("%d", styleID))
+	params.Add("skip_reinit", "true")
+
+	req, err := http.NewRequest("POST", c.BaseURL+"/initialize_speaker?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// IsInitializedSpeaker は指定したスタイルのモデルが読み込み済みかを確認します
+func (c *Client) IsInitializedSpeaker(styleID int) (bool, error) {
+	params := url.Values{}
+	params.Add("speaker", fmt.Sprintf("%d", styleID))
+
+	resp, err := c.get("/is_initialized_speaker", params)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var initialized bool
+	if err := json.NewDecoder(resp.Body).Decode(&initialized); err != nil {
+		return false, err
 	}
 
-	return io.ReadAll(resp.Body)
+	return initialized, nil
 }
@@ -0,0 +1,102 @@
+package voicevox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateAudioQueryWithOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"accent_phrases":[],"speedScale":1,"pitchScale":0,"intonationScale":1,"volumeScale":1,` +
+			`"prePhonemeLength":0.1,"postPhonemeLength":0.1,"outputSamplingRate":24000,"outputStereo":false,"kana":""}`))
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL)
+
+	query, err := client.CreateAudioQueryWithOptions("こんにちは", 3, nil)
+	if err != nil {
+		t.Fatalf("CreateAudioQueryWithOptions() error = %v", err)
+	}
+	if query.OutputSamplingRate != 24000 || query.OutputStereo || query.PrePhonemeLength != 0.1 {
+		t.Errorf("query without options = %+v", query)
+	}
+
+	rate, stereo, pre, post := 48000, true, 0.0, 0.5
+	query, err = client.CreateAudioQueryWithOptions("こんにちは", 3, &AudioQueryOptions{
+		OutputSamplingRate: &rate,
+		OutputStereo:       &stereo,
+		PrePhonemeLength:   &pre,
+		PostPhonemeLength:  &post,
+	})
+	if err != nil {
+		t.Fatalf("CreateAudioQueryWithOptions() error = %v", err)
+	}
+	if query.OutputSamplingRate != 48000 || !query.OutputStereo || query.PrePhonemeLength != 0 || query.PostPhonemeLength != 0.5 {
+		t.Errorf("query with options = %+v", query)
+	}
+	if query.SpeedScale != 1 {
+		t.Errorf("speedScale = %v, want the engine's value", query.SpeedScale)
+	}
+}
+
+func TestClient_Context(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	client := NewClient(srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.CreateAudioQueryContext(ctx, "こんにちは", 3, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CreateAudioQueryContext() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.SynthesizeVoiceContext(canceled, &AudioQuery{}, 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("SynthesizeVoiceContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("canceled requests took %v", elapsed)
+	}
+}
+
+func TestAudioQueryOptions_Validate(t *testing.T) {
+	rate := func(v int) *int { return &v }
+	length := func(v float64) *float64 { return &v }
+
+	valid := []AudioQueryOptions{
+		{},
+		{OutputSamplingRate: rate(16000)},
+		{OutputSamplingRate: rate(48000), PrePhonemeLength: length(0), PostPhonemeLength: length(1.5)},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error = %v", o, err)
+		}
+	}
+
+	invalid := []AudioQueryOptions{
+		{OutputSamplingRate: rate(4000)},
+		{OutputSamplingRate: rate(192000)},
+		{PrePhonemeLength: length(-0.1)},
+		{PostPhonemeLength: length(2)},
+		{SpeedScale: length(3)},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%+v) error = nil, want error", o)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package voicevox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize はエラーレスポンスの本文として読み込む最大バイト数です
+const maxErrorBodySize = 64 * 1024
+
+// UnreachableError はVOICEVOXエンジンに接続できなかったことを表すエラーです
+type UnreachableError struct {
+	Endpoint string
+	Err      error
+}
+
+// Error はerrorインターフェースを実装します
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("VOICEVOX engine unreachable (%s): %v", e.Endpoint, e.Err)
+}
+
+// Unwrap はerrors.Unwrapに対応します
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// Kind はエラーの種類を返します
+func (e *UnreachableError) Kind() string {
+	return "unreachable"
+}
+
+// Retryable は再試行で成功する可能性があるかを返します
+func (e *UnreachableError) Retryable() bool {
+	return true
+}
+
+// ErrorData はMCPエラーの data に含める情報を返します
+func (e *UnreachableError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{"endpoint": e.Endpoint}
+}
+
+// TimeoutError はVOICEVOXエンジンへのリクエストがタイムアウトしたことを表すエラーです
+type TimeoutError struct {
+	Endpoint string
+	Err      error
+}
+
+// Error はerrorインターフェースを実装します
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("VOICEVOX request timed out (%s): %v", e.Endpoint, e.Err)
+}
+
+// Unwrap はerrors.Unwrapに対応します
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Kind はエラーの種類を返します
+func (e *TimeoutError) Kind() string {
+	return "timeout"
+}
+
+// Retryable は再試行で成功する可能性があるかを返します
+func (e *TimeoutError) Retryable() bool {
+	return true
+}
+
+// ErrorData はMCPエラーの data に含める情報を返します
+func (e *TimeoutError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{"endpoint": e.Endpoint}
+}
+
+// ValidationDetail はFastAPIのバリデーションエラー（detail[]）の1要素です
+type ValidationDetail struct {
+	Loc  []interface{} `json:"loc"`
+	Msg  string        `json:"msg"`
+	Type string        `json:"type"`
+}
+
+// String は "query.speaker: field required" 形式の文字列を返します
+func (d ValidationDetail) String() string {
+	parts := make([]string, 0, len(d.Loc))
+	for _, loc := range d.Loc {
+		parts = append(parts, fmt.Sprint(loc))
+	}
+	if len(parts) == 0 {
+		return d.Msg
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(parts, "."), d.Msg)
+}
+
+// ValidationError はエンジンがリクエストを不正と判断した（400/422）ことを表すエラーです。
+// FastAPIの detail が配列の場合は Details に、文字列の場合は Message に格納されます
+type ValidationError struct {
+	Endpoint   string
+	StatusCode int
+	Message    string
+	Details    []ValidationDetail
+}
+
+// Error はerrorインターフェースを実装します
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Details)+1)
+	if e.Message != "" {
+		msgs = append(msgs, e.Message)
+	}
+	for _, d := range e.Details {
+		msgs = append(msgs, d.String())
+	}
+	if len(msgs) == 0 {
+		return fmt.Sprintf("VOICEVOX rejected the request (%s, %d)", e.Endpoint, e.StatusCode)
+	}
+	return fmt.Sprintf("VOICEVOX rejected the request (%s, %d): %s", e.Endpoint, e.StatusCode, strings.Join(msgs, "; "))
+}
+
+// Kind はエラーの種類を返します
+func (e *ValidationError) Kind() string {
+	return "validation"
+}
+
+// Retryable は再試行で成功する可能性があるかを返します
+func (e *ValidationError) Retryable() bool {
+	return false
+}
+
+// ErrorData はMCPエラーの data に含める情報を返します。detail は Message、空の場合は Details です
+func (e *ValidationError) ErrorData() map[string]interface{} {
+	data := map[string]interface{}{"endpoint": e.Endpoint, "status": e.StatusCode}
+	if e.Message != "" {
+		data["detail"] = e.Message
+	} else {
+		data["detail"] = e.Details
+	}
+	return data
+}
+
+// EngineError はエンジン内部でエラーが発生した（5xx）ことを表すエラーです
+type EngineError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+// Error はerrorインターフェースを実装します
+func (e *EngineError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("VOICEVOX engine error (%s, %d)", e.Endpoint, e.StatusCode)
+	}
+	return fmt.Sprintf("VOICEVOX engine error (%s, %d): %s", e.Endpoint, e.StatusCode, e.Body)
+}
+
+// Kind はエラーの種類を返します
+func (e *EngineError) Kind() string {
+	return "engine"
+}
+
+// Retryable は再試行で成功する可能性があるかを返します
+func (e *EngineError) Retryable() bool {
+	return true
+}
+
+// ErrorData はMCPエラーの data に含める情報を返します
+func (e *EngineError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{"endpoint": e.Endpoint, "status": e.StatusCode}
+}
+
+// UnsupportedEndpointError はエンジンがエンドポイントに対応していない（404/405）ことを表すエラーです。
+// 古いバージョンのエンジンや、互換エンジンで発生します
+type UnsupportedEndpointError struct {
+	Endpoint   string
+	StatusCode int
+}
+
+// Error はerrorインターフェースを実装します
+func (e *UnsupportedEndpointError) Error() string {
+	return fmt.Sprintf("VOICEVOX engine does not support %s (%d)", e.Endpoint, e.StatusCode)
+}
+
+// Kind はエラーの種類を返します
+func (e *UnsupportedEndpointError) Kind() string {
+	return "unsupported_endpoint"
+}
+
+// Retryable は再試行で成功する可能性があるかを返します
+func (e *UnsupportedEndpointError) Retryable() bool {
+	return false
+}
+
+// ErrorData はMCPエラーの data に含める情報を返します
+func (e *UnsupportedEndpointError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{"endpoint": e.Endpoint, "status": e.StatusCode}
+}
+
+// StatusError は上記に分類されない想定外のステータスコードを表すエラーです
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+// Error はerrorインターフェースを実装します
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("VOICEVOX API error (%s, %d): %s", e.Endpoint, e.StatusCode, e.Body)
+}
+
+// Kind はエラーの種類を返します
+func (e *StatusError) Kind() string {
+	return "unexpected_status"
+}
+
+// Retryable は再試行で成功する可能性があるかを返します
+func (e *StatusError) Retryable() bool {
+	return false
+}
+
+// ErrorData はMCPエラーの data に含める情報を返します
+func (e *StatusError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{"endpoint": e.Endpoint, "status": e.StatusCode}
+}
+
+// classifyRequestError は送信時のエラーをタイムアウトと接続失敗に分類します
+func classifyRequestError(endpoint string, err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &TimeoutError{Endpoint: endpoint, Err: err}
+	}
+	return &UnreachableError{Endpoint: endpoint, Err: err}
+}
+
+// checkResponse はレスポンスのステータスコードを確認し、エラーであれば種類ごとのエラー型を返します
+func checkResponse(endpoint string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity:
+		validationErr := &ValidationError{Endpoint: endpoint, StatusCode: resp.StatusCode}
+		parseDetail(body, validationErr)
+		return validationErr
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return &UnsupportedEndpointError{Endpoint: endpoint, StatusCode: resp.StatusCode}
+	case resp.StatusCode >= 500:
+		return &EngineError{Endpoint: endpoint, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	default:
+		return &StatusError{Endpoint: endpoint, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+}
+
+// parseDetail はFastAPIのエラーレスポンス {"detail": ...} を解析します
+func parseDetail(body []byte, validationErr *ValidationError) {
+	var payload struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Detail) == 0 {
+		validationErr.Message = strings.TrimSpace(string(body))
+		return
+	}
+
+	if err := json.Unmarshal(payload.Detail, &validationErr.Details); err == nil {
+		return
+	}
+
+	var message string
+	if err := json.Unmarshal(payload.Detail, &message); err == nil {
+		validationErr.Message = message
+		return
+	}
+	validationErr.Message = string(payload.Detail)
+}
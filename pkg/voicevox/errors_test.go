@@ -0,0 +1,151 @@
+package voicevox
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_ErrorClassification(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("speaker") {
+		case "422":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"detail":[{"loc":["query","text"],"msg":"field required","type":"value_error.missing"}]}`))
+		case "400":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"detail":"該当する話者が見つかりません"}`))
+		case "404":
+			w.WriteHeader(http.StatusNotFound)
+		case "500":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Internal Server Error"))
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+
+	t.Run("422 with detail list", func(t *testing.T) {
+		_, err := client.IsInitializedSpeaker(422)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("error = %v, want *ValidationError", err)
+		}
+		if len(validationErr.Details) != 1 || validationErr.Details[0].String() != "query.text: field required" {
+			t.Errorf("Details = %+v", validationErr.Details)
+		}
+		if validationErr.Endpoint != "/is_initialized_speaker" {
+			t.Errorf("Endpoint = %s", validationErr.Endpoint)
+		}
+	})
+
+	t.Run("400 with detail string", func(t *testing.T) {
+		err := client.InitializeSpeaker(400)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("error = %v, want *ValidationError", err)
+		}
+		if validationErr.Message != "該当する話者が見つかりません" {
+			t.Errorf("Message = %q", validationErr.Message)
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		err := client.InitializeSpeaker(404)
+		var unsupportedErr *UnsupportedEndpointError
+		if !errors.As(err, &unsupportedErr) {
+			t.Fatalf("error = %v, want *UnsupportedEndpointError", err)
+		}
+	})
+
+	t.Run("500", func(t *testing.T) {
+		_, err := client.SynthesizeVoice(&AudioQuery{}, 500)
+		var engineErr *EngineError
+		if !errors.As(err, &engineErr) {
+			t.Fatalf("error = %v, want *EngineError", err)
+		}
+		if engineErr.Body != "Internal Server Error" {
+			t.Errorf("Body = %q", engineErr.Body)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		slow := NewClient(srv.URL)
+		slow.HTTPClient.Timeout = 20 * time.Millisecond
+
+		req, _ := http.NewRequest("GET", srv.URL+"/version?speaker=slow", nil)
+		_, err := slow.do(req)
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("error = %v, want *TimeoutError", err)
+		}
+	})
+}
+
+func TestClient_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	_, err := NewClient(url).GetSpeakers()
+	var unreachableErr *UnreachableError
+	if !errors.As(err, &unreachableErr) {
+		t.Fatalf("error = %v, want *UnreachableError", err)
+	}
+	if unreachableErr.Endpoint != "/speakers" {
+		t.Errorf("Endpoint = %s", unreachableErr.Endpoint)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  interface {
+			Kind() string
+			Retryable() bool
+			ErrorData() map[string]interface{}
+		}
+		wantKind      string
+		wantRetryable bool
+		wantStatus    interface{}
+	}{
+		{"unreachable", &UnreachableError{Endpoint: "/audio_query", Err: errors.New("connection refused")}, "unreachable", true, nil},
+		{"timeout", &TimeoutError{Endpoint: "/audio_query", Err: errors.New("deadline exceeded")}, "timeout", true, nil},
+		{"validation", &ValidationError{Endpoint: "/audio_query", StatusCode: 422, Message: "bad"}, "validation", false, 422},
+		{"engine", &EngineError{Endpoint: "/audio_query", StatusCode: 500}, "engine", true, 500},
+		{"unsupported endpoint", &UnsupportedEndpointError{Endpoint: "/audio_query", StatusCode: 404}, "unsupported_endpoint", false, 404},
+		{"unexpected status", &StatusError{Endpoint: "/audio_query", StatusCode: 302}, "unexpected_status", false, 302},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Kind(); got != tt.wantKind {
+				t.Errorf("Kind() = %v, want %v", got, tt.wantKind)
+			}
+			if got := tt.err.Retryable(); got != tt.wantRetryable {
+				t.Errorf("Retryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			data := tt.err.ErrorData()
+			if data["endpoint"] != "/audio_query" {
+				t.Errorf("ErrorData()[endpoint] = %v, want /audio_query", data["endpoint"])
+			}
+			if data["status"] != tt.wantStatus {
+				t.Errorf("ErrorData()[status] = %v, want %v", data["status"], tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestValidationError_ErrorData(t *testing.T) {
+	details := []ValidationDetail{{Loc: []interface{}{"query", "speaker"}, Msg: "field required"}}
+	err := &ValidationError{Endpoint: "/audio_query", StatusCode: 422, Details: details}
+	got, ok := err.ErrorData()["detail"].([]ValidationDetail)
+	if !ok || len(got) != 1 || got[0].String() != "query.speaker: field required" {
+		t.Errorf("ErrorData()[detail] = %v, want the details", err.ErrorData()["detail"])
+	}
+}
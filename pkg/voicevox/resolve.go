@@ -0,0 +1,259 @@
+package voicevox
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// defaultStyleName は話者名のみ指定されたときに優先して選ぶスタイル名です
+const defaultStyleName = "ノーマル"
+
+// maxLookupCandidates はエラーメッセージに列挙する候補の最大数です
+const maxLookupCandidates = 20
+
+// halfwidthKatakana は半角カタカナ（U+FF66〜U+FF9D）に対応する全角カタカナです
+var halfwidthKatakana = []rune("ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン")
+
+// SpeakerLookupError は話者名の解決に失敗したことを表すエラーです
+type SpeakerLookupError struct {
+	Query      string
+	Ambiguous  bool
+	Candidates []string
+}
+
+// Error はerrorインターフェースを実装します
+func (e *SpeakerLookupError) Error() string {
+	reason := "not found"
+	if e.Ambiguous {
+		reason = "is ambiguous"
+	}
+	if len(e.Candidates) == 0 {
+		return fmt.Sprintf("speaker %q %s", e.Query, reason)
+	}
+
+	candidates := e.Candidates
+	suffix := ""
+	if len(candidates) > maxLookupCandidates {
+		suffix = fmt.Sprintf(", ... (%d more)", len(candidates)-maxLookupCandidates)
+		candidates = candidates[:maxLookupCandidates]
+	}
+	return fmt.Sprintf("speaker %q %s; candidates: %s%s", e.Query, reason, strings.Join(candidates, ", "), suffix)
+}
+
+// NormalizeName は話者名・スタイル名の比較用キーを作成します。
+// 全角英数字と半角カタカナを揃え、カタカナをひらがなに、英字を小文字に変換し、
+// 空白や記号を取り除きます
+func NormalizeName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			r -= 0xFEE0
+		case r >= 0xFF66 && r <= 0xFF9D:
+			r = halfwidthKatakana[r-0xFF66]
+			if i+1 < len(runes) && runes[i+1] == 0xFF9E {
+				r++
+				i++
+			} else if i+1 < len(runes) && runes[i+1] == 0xFF9F {
+				r += 2
+				i++
+			}
+		}
+
+		if r >= 'ァ' && r <= 'ヶ' {
+			r -= 0x60
+		}
+
+		if unicode.IsSpace(r) || r == '・' || r == '･' || (unicode.IsPunct(r) && r != 'ー') {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// ResolveStyle は「キャラクター名 + スタイル名」形式の文字列からスタイルを特定します。
+// 例: "ずんだもん ノーマル", "四国めたん（あまあま）", "めたん あまあま", "ずんだもん"。
+// スタイル名を省略した場合は「ノーマル」、なければ最初の読み上げスタイルを選びます。
+// 一致しない、または候補が複数ある場合は *SpeakerLookupError を返します
+func ResolveStyle(speakers []Speaker, query string) (StyleInfo, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return StyleInfo{}, &SpeakerLookupError{Query: query, Candidates: speakerNames(speakers)}
+	}
+
+	if id, err := strconv.Atoi(query); err == nil {
+		for _, style := range FlattenSpeakers(speakers) {
+			if style.StyleID == id {
+				return style, nil
+			}
+		}
+		return StyleInfo{}, &SpeakerLookupError{Query: query, Candidates: speakerNames(speakers)}
+	}
+
+	tokens := strings.FieldsFunc(query, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("()（）「」[]/／:：", r)
+	})
+	normalized := NormalizeName(strings.Join(tokens, ""))
+
+	matched, styleKey := matchSpeaker(speakers, tokens, normalized)
+	if len(matched) == 0 {
+		return StyleInfo{}, &SpeakerLookupError{Query: query, Candidates: speakerNames(speakers)}
+	}
+	if len(matched) > 1 {
+		return StyleInfo{}, &SpeakerLookupError{Query: query, Ambiguous: true, Candidates: speakerNames(matched)}
+	}
+
+	return matchStyle(matched[0], styleKey, query)
+}
+
+// matchSpeaker は問い合わせに一致する話者と、残りのスタイル名部分を返します
+func matchSpeaker(speakers []Speaker, tokens []string, normalized string) ([]Speaker, string) {
+	// 話者名で始まる場合は最長一致を優先
+	var prefixMatched []Speaker
+	longest := 0
+	for _, speaker := range speakers {
+		key := NormalizeName(speaker.Name)
+		if key == "" || !strings.HasPrefix(normalized, key) {
+			continue
+		}
+		if len(key) > longest {
+			prefixMatched = []Speaker{speaker}
+			longest = len(key)
+		} else if len(key) == longest {
+			prefixMatched = append(prefixMatched, speaker)
+		}
+	}
+	if len(prefixMatched) > 0 {
+		return prefixMatched, normalized[longest:]
+	}
+
+	// 最初のトークンを話者名の一部として扱う（例: "めたん あまあま"）
+	if len(tokens) == 0 {
+		return nil, ""
+	}
+	nameKey := NormalizeName(tokens[0])
+	styleKey := NormalizeName(strings.Join(tokens[1:], ""))
+
+	var partial []Speaker
+	for _, speaker := range speakers {
+		if nameKey != "" && strings.Contains(NormalizeName(speaker.Name), nameKey) {
+			partial = append(partial, speaker)
+		}
+	}
+	return partial, styleKey
+}
+
+// matchStyle は話者のスタイルからスタイル名に一致するものを選びます
+func matchStyle(speaker Speaker, styleKey, query string) (StyleInfo, error) {
+	if styleKey == "" {
+		styleKey = NormalizeName(defaultStyleName)
+		if style, ok := preferTalk(filterStyles(speaker.Styles, func(key string) bool { return key == styleKey })); ok {
+			return newStyleInfo(speaker, style), nil
+		}
+		if style, ok := preferTalk(speaker.Styles); ok {
+			return newStyleInfo(speaker, style), nil
+		}
+		return StyleInfo{}, &SpeakerLookupError{Query: query}
+	}
+
+	exact := filterStyles(speaker.Styles, func(key string) bool { return key == styleKey })
+	if style, ok := preferTalk(exact); ok {
+		return newStyleInfo(speaker, style), nil
+	}
+
+	partial := filterStyles(speaker.Styles, func(key string) bool { return strings.Contains(key, styleKey) })
+	if style, ok := preferTalk(partial); ok {
+		return newStyleInfo(speaker, style), nil
+	}
+
+	candidates := styleCandidates(speaker, speaker.Styles)
+	ambiguous := len(partial) > 1
+	if ambiguous {
+		candidates = styleCandidates(speaker, partial)
+	}
+	return StyleInfo{}, &SpeakerLookupError{Query: query, Ambiguous: ambiguous, Candidates: candidates}
+}
+
+// filterStyles は正規化したスタイル名が条件を満たすスタイルを返します
+func filterStyles(styles []SpeakerStyle, match func(key string) bool) []SpeakerStyle {
+	var matched []SpeakerStyle
+	for _, style := range styles {
+		if match(NormalizeName(style.Name)) {
+			matched = append(matched, style)
+		}
+	}
+	return matched
+}
+
+// preferTalk は候補が1つに絞れる場合にそのスタイルを返します。
+// 同名のスタイルが読み上げ用と歌唱用にある場合は読み上げ用を優先します
+func preferTalk(styles []SpeakerStyle) (SpeakerStyle, bool) {
+	if len(styles) == 1 {
+		return styles[0], true
+	}
+
+	var talk []SpeakerStyle
+	for _, style := range styles {
+		if style.Category() == StyleCategoryTalk {
+			talk = append(talk, style)
+		}
+	}
+	if len(talk) == 1 {
+		return talk[0], true
+	}
+	return SpeakerStyle{}, false
+}
+
+// speakerNames は話者名の一覧を返します
+func speakerNames(speakers []Speaker) []string {
+	names := make([]string, 0, len(speakers))
+	for _, speaker := range speakers {
+		names = append(names, speaker.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// styleCandidates は「話者名 スタイル名 (ID)」形式の候補一覧を返します
+func styleCandidates(speaker Speaker, styles []SpeakerStyle) []string {
+	candidates := make([]string, 0, len(styles))
+	for _, style := range styles {
+		candidates = append(candidates, fmt.Sprintf("%s %s (%d)", speaker.Name, style.Name, style.ID))
+	}
+	return candidates
+}
+
+// SiblingStyle は styleID と同じキャラクターの読み上げスタイルのうち、names のいずれかに一致するものを返します。
+// names は先頭から順に照合し、比較には NormalizeName を使います
+func SiblingStyle(speakers []Speaker, styleID int, names []string) (StyleInfo, bool) {
+	for _, speaker := range speakers {
+		if !hasStyle(speaker, styleID) {
+			continue
+		}
+		for _, name := range names {
+			key := NormalizeName(name)
+			for _, style := range speaker.Styles {
+				if style.Category() == StyleCategoryTalk && NormalizeName(style.Name) == key {
+					return newStyleInfo(speaker, style), true
+				}
+			}
+		}
+		return StyleInfo{}, false
+	}
+	return StyleInfo{}, false
+}
+
+func hasStyle(speaker Speaker, styleID int) bool {
+	for _, style := range speaker.Styles {
+		if style.ID == styleID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package voicevox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"ずんだもん", "ずんだもん"},
+		{"ズンダモン", "ずんだもん"},
+		{"ｽﾞﾝﾀﾞﾓﾝ", "ずんだもん"},
+		{"ノーマル", "のーまる"},
+		{"No.7", "no7"},
+		{"Ｎｏ．７", "no7"},
+		{"四国 めたん", "四国めたん"},
+		{"WhiteCUL", "whitecul"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := NormalizeName(tt.in); got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveStyle(t *testing.T) {
+	speakers := loadSpeakersFixture(t)
+
+	tests := []struct {
+		query  string
+		wantID int
+	}{
+		{"ずんだもん ノーマル", 3},
+		{"ずんだもん", 3},
+		{"ずんだもん　あまあま", 1},
+		{"ズンダモン アマアマ", 1},
+		{"ずんだもんあまあま", 1},
+		{"四国めたん（あまあま）", 0},
+		{"めたん あまあま", 0},
+		{"めたん", 2},
+		{"四国めたん ノーマル", 2},
+		{"ずんだもん あま", 1},
+		{"3002", 3002},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ResolveStyle(speakers, tt.query)
+			if err != nil {
+				t.Fatalf("ResolveStyle(%q) error = %v", tt.query, err)
+			}
+			if got.StyleID != tt.wantID {
+				t.Errorf("ResolveStyle(%q) = %d (%s %s), want %d", tt.query, got.StyleID, got.SpeakerName, got.StyleName, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestResolveStyle_Errors(t *testing.T) {
+	speakers := loadSpeakersFixture(t)
+	speakers = append(speakers, Speaker{
+		Name:   "ずんだもん（ひそひそ版）",
+		Styles: []SpeakerStyle{{Name: "ヒソヒソ", ID: 38}},
+	})
+
+	tests := []struct {
+		query         string
+		wantAmbiguous bool
+		wantCandidate string
+	}{
+		{"春日部つむぎ", false, "四国めたん"},
+		{"ずんだもん セクシー", false, "ずんだもん あまあま (1)"},
+		{"9999", false, "ずんだもん"},
+		{"だもん", true, "ずんだもん（ひそひそ版）"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ResolveStyle(speakers, tt.query)
+			var lookupErr *SpeakerLookupError
+			if !errors.As(err, &lookupErr) {
+				t.Fatalf("ResolveStyle(%q) error = %v, want *SpeakerLookupError", tt.query, err)
+			}
+			if lookupErr.Ambiguous != tt.wantAmbiguous {
+				t.Errorf("Ambiguous = %v, want %v", lookupErr.Ambiguous, tt.wantAmbiguous)
+			}
+
+			found := false
+			for _, candidate := range lookupErr.Candidates {
+				if candidate == tt.wantCandidate {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Candidates = %v, want to contain %q", lookupErr.Candidates, tt.wantCandidate)
+			}
+		})
+	}
+}
+
+func TestSiblingStyle(t *testing.T) {
+	speakers := loadSpeakersFixture(t)
+
+	tests := []struct {
+		name    string
+		styleID int
+		names   []string
+		wantID  int
+		wantOK  bool
+	}{
+		{"same character", 3, []string{"あまあま"}, 1, true},
+		{"first matching name", 2, []string{"ツンツン", "アマアマ"}, 0, true},
+		{"singing style is skipped", 0, []string{"ノーマル"}, 2, true},
+		{"frame decode style is skipped", 3, []string{"ハミング"}, 0, false},
+		{"no such style", 1, []string{"ささやき"}, 0, false},
+		{"unknown style id", 999, []string{"あまあま"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SiblingStyle(speakers, tt.styleID, tt.names)
+			if ok != tt.wantOK || (ok && got.StyleID != tt.wantID) {
+				t.Errorf("SiblingStyle(%d, %v) = %d, %v, want %d, %v", tt.styleID, tt.names, got.StyleID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
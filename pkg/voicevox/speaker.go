@@ -0,0 +1,117 @@
+package voicevox
+
+import "strings"
+
+// スタイルの種類（/speakers の styles[].type）
+const (
+	StyleTypeTalk           = "talk"
+	StyleTypeSing           = "sing"
+	StyleTypeSingingTeacher = "singing_teacher"
+	StyleTypeFrameDecode    = "frame_decode"
+)
+
+// スタイル種類のフィルタで使うカテゴリ
+const (
+	StyleCategoryTalk    = "talk"
+	StyleCategorySinging = "singing"
+)
+
+// Speaker はVOICEVOXの話者（キャラクター）情報を表す構造体です
+type Speaker struct {
+	Name        string         `json:"name"`
+	SpeakerUUID string         `json:"speaker_uuid"`
+	Styles      []SpeakerStyle `json:"styles"`
+	Version     string         `json:"version"`
+}
+
+// SpeakerStyle は話者が持つスタイル情報を表す構造体です。
+// 音声合成APIの speaker パラメータにはこの ID を指定します
+type SpeakerStyle struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+	Type string `json:"type,omitempty"`
+}
+
+// Category はスタイルのカテゴリ（talk / singing）を返します。
+// type を返さない古いエンジンのスタイルは talk として扱います
+func (s SpeakerStyle) Category() string {
+	switch s.Type {
+	case StyleTypeSing, StyleTypeSingingTeacher, StyleTypeFrameDecode:
+		return StyleCategorySinging
+	default:
+		return StyleCategoryTalk
+	}
+}
+
+// matchesType はスタイルが指定された種類に一致するかを判定します。
+// styleType にはカテゴリ（talk / singing）またはエンジンの type 値を指定できます
+func (s SpeakerStyle) matchesType(styleType string) bool {
+	if styleType == "" {
+		return true
+	}
+	if styleType == s.Category() {
+		return true
+	}
+	return s.Type != "" && styleType == s.Type
+}
+
+// StyleInfo は話者とスタイルを1行にまとめた平坦な表現です
+type StyleInfo struct {
+	SpeakerName string `json:"speaker_name"`
+	SpeakerUUID string `json:"speaker_uuid"`
+	StyleName   string `json:"style_name"`
+	StyleID     int    `json:"style_id"`
+	StyleType   string `json:"style_type"`
+}
+
+// newStyleInfo は話者とスタイルからStyleInfoを作成します
+func newStyleInfo(speaker Speaker, style SpeakerStyle) StyleInfo {
+	styleType := style.Type
+	if styleType == "" {
+		styleType = StyleTypeTalk
+	}
+	return StyleInfo{
+		SpeakerName: speaker.Name,
+		SpeakerUUID: speaker.SpeakerUUID,
+		StyleName:   style.Name,
+		StyleID:     style.ID,
+		StyleType:   styleType,
+	}
+}
+
+// FlattenSpeakers は話者一覧をスタイル単位の一覧に展開します
+func FlattenSpeakers(speakers []Speaker) []StyleInfo {
+	var styles []StyleInfo
+	for _, speaker := range speakers {
+		for _, style := range speaker.Styles {
+			styles = append(styles, newStyleInfo(speaker, style))
+		}
+	}
+	return styles
+}
+
+// FilterSpeakers は名前（部分一致、ひらがな・カタカナを区別しない）とスタイル種類で
+// 話者一覧を絞り込みます。条件に一致するスタイルを持たない話者は結果から除かれます
+func FilterSpeakers(speakers []Speaker, name, styleType string) []Speaker {
+	name = NormalizeName(name)
+
+	var filtered []Speaker
+	for _, speaker := range speakers {
+		if name != "" && !strings.Contains(NormalizeName(speaker.Name), name) {
+			continue
+		}
+
+		matched := speaker
+		matched.Styles = nil
+		for _, style := range speaker.Styles {
+			if style.matchesType(styleType) {
+				matched.Styles = append(matched.Styles, style)
+			}
+		}
+
+		if len(matched.Styles) > 0 {
+			filtered = append(filtered, matched)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,109 @@
+package voicevox
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// speakersFixture は /speakers レスポンスの抜粋です
+const speakersFixture = `[
+  {
+    "name": "四国めたん",
+    "speaker_uuid": "7ffcb7ce-00ec-4bdc-82cd-45a8889e43ff",
+    "styles": [
+      {"name": "ノーマル", "id": 2, "type": "talk"},
+      {"name": "あまあま", "id": 0, "type": "talk"},
+      {"name": "ノーマル", "id": 3000, "type": "singing_teacher"}
+    ],
+    "version": "0.15.0"
+  },
+  {
+    "name": "ずんだもん",
+    "speaker_uuid": "388f246b-8c41-4ac1-8e2d-5d79f3ff56d9",
+    "styles": [
+      {"name": "ノーマル", "id": 3},
+      {"name": "あまあま", "id": 1},
+      {"name": "ハミング", "id": 3002, "type": "frame_decode"}
+    ],
+    "version": "0.15.0"
+  }
+]`
+
+func loadSpeakersFixture(t *testing.T) []Speaker {
+	t.Helper()
+	var speakers []Speaker
+	if err := json.Unmarshal([]byte(speakersFixture), &speakers); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+	return speakers
+}
+
+func TestSpeaker_Decode(t *testing.T) {
+	speakers := loadSpeakersFixture(t)
+
+	if len(speakers) != 2 {
+		t.Fatalf("len(speakers) = %d, want 2", len(speakers))
+	}
+	if speakers[0].SpeakerUUID != "7ffcb7ce-00ec-4bdc-82cd-45a8889e43ff" {
+		t.Errorf("SpeakerUUID = %s", speakers[0].SpeakerUUID)
+	}
+	if speakers[1].Styles[0].ID != 3 {
+		t.Errorf("Styles[0].ID = %d, want 3", speakers[1].Styles[0].ID)
+	}
+}
+
+func TestFlattenSpeakers(t *testing.T) {
+	styles := FlattenSpeakers(loadSpeakersFixture(t))
+
+	if len(styles) != 6 {
+		t.Fatalf("len(FlattenSpeakers()) = %d, want 6", len(styles))
+	}
+
+	got := styles[3]
+	want := StyleInfo{
+		SpeakerName: "ずんだもん",
+		SpeakerUUID: "388f246b-8c41-4ac1-8e2d-5d79f3ff56d9",
+		StyleName:   "ノーマル",
+		StyleID:     3,
+		StyleType:   StyleTypeTalk,
+	}
+	if got != want {
+		t.Errorf("FlattenSpeakers()[3] = %+v, want %+v", got, want)
+	}
+}
+
+func TestFilterSpeakers(t *testing.T) {
+	speakers := loadSpeakersFixture(t)
+
+	tests := []struct {
+		name      string
+		query     string
+		styleType string
+		wantIDs   []int
+	}{
+		{"no filter", "", "", []int{2, 0, 3000, 3, 1, 3002}},
+		{"by name", "ずんだ", "", []int{3, 1, 3002}},
+		{"talk only", "", "talk", []int{2, 0, 3, 1}},
+		{"singing category", "", "singing", []int{3000, 3002}},
+		{"exact engine type", "", "frame_decode", []int{3002}},
+		{"name and type", "めたん", "singing", []int{3000}},
+		{"no match", "春日部", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIDs []int
+			for _, style := range FlattenSpeakers(FilterSpeakers(speakers, tt.query, tt.styleType)) {
+				gotIDs = append(gotIDs, style.StyleID)
+			}
+			if len(gotIDs) != len(tt.wantIDs) {
+				t.Fatalf("FilterSpeakers() IDs = %v, want %v", gotIDs, tt.wantIDs)
+			}
+			for i := range gotIDs {
+				if gotIDs[i] != tt.wantIDs[i] {
+					t.Errorf("FilterSpeakers() IDs = %v, want %v", gotIDs, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package voicevox
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Mora は音声クエリのアクセント句に含まれるモーラ（拍）です
+type Mora struct {
+	Text            string   `json:"text"`
+	Consonant       *string  `json:"consonant"`
+	ConsonantLength *float64 `json:"consonant_length"`
+	Vowel           string   `json:"vowel"`
+	VowelLength     float64  `json:"vowel_length"`
+	Pitch           float64  `json:"pitch"`
+}
+
+// AccentPhrase は音声クエリのアクセント句です。PauseMora は句の後ろの無音（読点など）です
+type AccentPhrase struct {
+	Moras           []Mora `json:"moras"`
+	Accent          int    `json:"accent"`
+	PauseMora       *Mora  `json:"pause_mora"`
+	IsInterrogative bool   `json:"is_interrogative"`
+}
+
+// Length はモーラの子音と母音を合わせた長さ（秒）です
+func (m Mora) Length() float64 {
+	length := m.VowelLength
+	if m.ConsonantLength != nil {
+		length += *m.ConsonantLength
+	}
+	return length
+}
+
+// Phrases はアクセント句を型付きで返します。
+// エンジンに送り返すときに未知の項目を失わないよう、AccentPhrases 自体は読み込んだままの形で保持しています
+func (q *AudioQuery) Phrases() ([]AccentPhrase, error) {
+	data, err := json.Marshal(q.AccentPhrases)
+	if err != nil {
+		return nil, err
+	}
+	var phrases []AccentPhrase
+	if err := json.Unmarshal(data, &phrases); err != nil {
+		return nil, fmt.Errorf("invalid accent phrases: %w", err)
+	}
+	return phrases, nil
+}
@@ -0,0 +1,47 @@
+package voicevox
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestAudioQuery_Phrases(t *testing.T) {
+	var query AudioQuery
+	data := `{"accent_phrases":[{"moras":[
+		{"text":"ア","consonant":null,"consonant_length":null,"vowel":"a","vowel_length":0.12,"pitch":5.1},
+		{"text":"メ","consonant":"m","consonant_length":0.05,"vowel":"e","vowel_length":0.1,"pitch":5.4}
+	],"accent":1,"pause_mora":{"text":"、","consonant":null,"consonant_length":null,"vowel":"pau","vowel_length":0.3,"pitch":0},
+	"is_interrogative":true,"future_field":1}],"speedScale":1}`
+	if err := json.Unmarshal([]byte(data), &query); err != nil {
+		t.Fatal(err)
+	}
+
+	phrases, err := query.Phrases()
+	if err != nil {
+		t.Fatalf("Phrases() error = %v", err)
+	}
+	if len(phrases) != 1 || len(phrases[0].Moras) != 2 || !phrases[0].IsInterrogative || phrases[0].PauseMora == nil {
+		t.Fatalf("Phrases() = %+v", phrases)
+	}
+	a, me := phrases[0].Moras[0], phrases[0].Moras[1]
+	if a.Consonant != nil || a.Length() != 0.12 {
+		t.Errorf("mora ア = %+v", a)
+	}
+	if me.Consonant == nil || *me.Consonant != "m" || math.Abs(me.Length()-0.15) > 1e-9 {
+		t.Errorf("mora メ = %+v", me)
+	}
+
+	// エンジンに送り返すクエリは未知の項目を保持する
+	out, _ := json.Marshal(query.AccentPhrases)
+	var raw []map[string]interface{}
+	json.Unmarshal(out, &raw)
+	if raw[0]["future_field"] != 1.0 {
+		t.Errorf("accent phrases lost unknown fields: %s", out)
+	}
+
+	query.AccentPhrases = []interface{}{"broken"}
+	if _, err := query.Phrases(); err == nil {
+		t.Error("Phrases(invalid) error = nil, want error")
+	}
+}
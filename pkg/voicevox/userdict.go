@@ -0,0 +1,79 @@
+package voicevox
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultUserDictCacheTTL はユーザー辞書キャッシュの既定の有効期間です
+const DefaultUserDictCacheTTL = 5 * time.Minute
+
+// UserDictWord はVOICEVOXのユーザー辞書に登録された単語です。
+// Surface はエンジンが全角に変換した表記です
+type UserDictWord struct {
+	Surface       string `json:"surface"`
+	Pronunciation string `json:"pronunciation"`
+	AccentType    int    `json:"accent_type"`
+	Priority      int    `json:"priority"`
+}
+
+// GetUserDict はユーザー辞書に登録された単語を単語のUUIDをキーにして返します
+func (c *Client) GetUserDict() (map[string]UserDictWord, error) {
+	resp, err := c.get("/user_dict", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var words map[string]UserDictWord
+	if err := json.NewDecoder(resp.Body).Decode(&words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// UserDictCache はユーザー辞書に登録された単語の表記をキャッシュします。
+// 読み上げ前の英単語の変換で、ユーザー辞書に登録済みの単語をエンジンに任せるために使います
+type UserDictCache struct {
+	client *Client
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	surfaces  []string
+	fetchedAt time.Time
+	fetched   bool
+}
+
+// NewUserDictCache は新しいUserDictCacheを作成します
+func NewUserDictCache(client *Client, ttl time.Duration) *UserDictCache {
+	return &UserDictCache{client: client, ttl: ttl}
+}
+
+// Surfaces はユーザー辞書に登録された単語の表記を返します。
+// 取得に失敗した場合は前回の結果とエラーを返し、有効期間が過ぎるまで取得し直しません
+func (c *UserDictCache) Surfaces() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fetched && time.Since(c.fetchedAt) < c.ttl {
+		return c.surfaces, nil
+	}
+
+	// エンジンが停止している場合や /user_dict に対応していない場合も、毎回問い合わせないよう取得時刻を記録する
+	c.fetched = true
+	c.fetchedAt = time.Now()
+
+	words, err := c.client.GetUserDict()
+	if err != nil {
+		return c.surfaces, err
+	}
+	surfaces := make([]string, 0, len(words))
+	for _, word := range words {
+		surfaces = append(surfaces, word.Surface)
+	}
+	sort.Strings(surfaces)
+	c.surfaces = surfaces
+	return surfaces, nil
+}
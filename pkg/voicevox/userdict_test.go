@@ -0,0 +1,61 @@
+package voicevox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUserDictCache_Surfaces(t *testing.T) {
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user_dict" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&fetches, 1)
+		w.Write([]byte(`{
+			"a": {"surface": "ＧｉｔＨｕｂ", "pronunciation": "ギットハブ", "accent_type": 4, "priority": 5},
+			"b": {"surface": "ＡＰＩ", "pronunciation": "エーピーアイ", "accent_type": 5, "priority": 5}
+		}`))
+	}))
+	defer srv.Close()
+
+	cache := NewUserDictCache(NewClient(srv.URL), time.Hour)
+	for i := 0; i < 2; i++ {
+		surfaces, err := cache.Surfaces()
+		if err != nil {
+			t.Fatalf("Surfaces() error = %v", err)
+		}
+		if want := []string{"ＡＰＩ", "ＧｉｔＨｕｂ"}; !reflect.DeepEqual(surfaces, want) {
+			t.Errorf("Surfaces() = %v, want %v", surfaces, want)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+}
+
+func TestUserDictCache_Unsupported(t *testing.T) {
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cache := NewUserDictCache(NewClient(srv.URL), time.Hour)
+	if _, err := cache.Surfaces(); err == nil {
+		t.Error("Surfaces() error = nil, want error")
+	}
+	// 失敗した場合も有効期間中は問い合わせない
+	if surfaces, err := cache.Surfaces(); err != nil || len(surfaces) != 0 {
+		t.Errorf("Surfaces() = %v, %v", surfaces, err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+}
@@ -0,0 +1,118 @@
+package voicevox
+
+import (
+	"sync"
+	"time"
+)
+
+// WarmupState はスタイルの事前初期化状態を表します
+type WarmupState string
+
+const (
+	WarmupPending      WarmupState = "pending"
+	WarmupInitializing WarmupState = "initializing"
+	WarmupReady        WarmupState = "ready"
+	WarmupFailed       WarmupState = "failed"
+)
+
+// WarmupStatus は1スタイル分の事前初期化状態です
+type WarmupStatus struct {
+	StyleID   int         `json:"style_id"`
+	State     WarmupState `json:"state"`
+	Error     string      `json:"error,omitempty"`
+	ElapsedMs int64       `json:"elapsed_ms,omitempty"`
+}
+
+// WarmupReport は事前初期化全体の状態です
+type WarmupReport struct {
+	Ready  bool           `json:"ready"`
+	Styles []WarmupStatus `json:"styles"`
+}
+
+// Warmup は起動時にスタイルを事前初期化し、その進捗を保持します
+type Warmup struct {
+	client *Client
+	styles []int
+
+	mu       sync.RWMutex
+	statuses map[int]*WarmupStatus
+	once     sync.Once
+	done     chan struct{}
+}
+
+// NewWarmup は新しいWarmupを作成します
+func NewWarmup(client *Client, styles []int) *Warmup {
+	w := &Warmup{
+		client:   client,
+		statuses: make(map[int]*WarmupStatus, len(styles)),
+		done:     make(chan struct{}),
+	}
+	for _, id := range styles {
+		if _, exists := w.statuses[id]; exists {
+			continue
+		}
+		w.styles = append(w.styles, id)
+		w.statuses[id] = &WarmupStatus{StyleID: id, State: WarmupPending}
+	}
+	return w
+}
+
+// Start はバックグラウンドで事前初期化を開始します。2回目以降の呼び出しは無視されます
+func (w *Warmup) Start() {
+	w.once.Do(func() {
+		go w.run()
+	})
+}
+
+// Done は事前初期化が全て終了したときに閉じられるチャネルを返します
+func (w *Warmup) Done() <-chan struct{} {
+	return w.done
+}
+
+func (w *Warmup) run() {
+	defer close(w.done)
+
+	for _, id := range w.styles {
+		w.setState(id, WarmupInitializing, "", 0)
+		started := time.Now()
+
+		initialized, err := w.client.IsInitializedSpeaker(id)
+		if err == nil && !initialized {
+			err = w.client.InitializeSpeaker(id)
+		}
+
+		elapsed := time.Since(started).Milliseconds()
+		if err != nil {
+			w.setState(id, WarmupFailed, err.Error(), elapsed)
+			continue
+		}
+		w.setState(id, WarmupReady, "", elapsed)
+	}
+}
+
+func (w *Warmup) setState(id int, state WarmupState, errMsg string, elapsedMs int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := w.statuses[id]
+	status.State = state
+	status.Error = errMsg
+	status.ElapsedMs = elapsedMs
+}
+
+// Report は現在の事前初期化状態を返します。
+// 対象スタイルが全て ready になっている場合のみ Ready が true になります
+func (w *Warmup) Report() WarmupReport {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	report := WarmupReport{Ready: true, Styles: make([]WarmupStatus, 0, len(w.styles))}
+	for _, id := range w.styles {
+		status := *w.statuses[id]
+		if status.State != WarmupReady {
+			report.Ready = false
+		}
+		report.Styles = append(report.Styles, status)
+	}
+	return report
+}
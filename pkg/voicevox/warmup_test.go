@@ -0,0 +1,86 @@
+package voicevox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWarmup(t *testing.T) {
+	var mu sync.Mutex
+	initialized := map[string]bool{"1": true}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		speaker := r.URL.Query().Get("speaker")
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/is_initialized_speaker":
+			if initialized[speaker] {
+				w.Write([]byte("true"))
+			} else {
+				w.Write([]byte("false"))
+			}
+		case "/initialize_speaker":
+			if speaker == "99" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			initialized[speaker] = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	w := NewWarmup(NewClient(srv.URL), []int{1, 3, 99, 3})
+
+	report := w.Report()
+	if report.Ready {
+		t.Error("Report().Ready = true before Start, want false")
+	}
+	if len(report.Styles) != 3 {
+		t.Fatalf("len(Report().Styles) = %d, want 3 (duplicates removed)", len(report.Styles))
+	}
+
+	w.Start()
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("warmup did not finish")
+	}
+
+	report = w.Report()
+	if report.Ready {
+		t.Error("Report().Ready = true with a failed style, want false")
+	}
+
+	want := map[int]WarmupState{1: WarmupReady, 3: WarmupReady, 99: WarmupFailed}
+	for _, status := range report.Styles {
+		if status.State != want[status.StyleID] {
+			t.Errorf("style %d state = %s, want %s", status.StyleID, status.State, want[status.StyleID])
+		}
+	}
+
+	if !initialized["3"] {
+		t.Error("style 3 was not initialized on the engine")
+	}
+}
+
+func TestWarmup_NoStyles(t *testing.T) {
+	w := NewWarmup(NewClient("http://127.0.0.1:0"), nil)
+	w.Start()
+	<-w.Done()
+
+	report := w.Report()
+	if !report.Ready {
+		t.Error("Report().Ready = false with no styles, want true")
+	}
+	if len(report.Styles) != 0 {
+		t.Errorf("len(Report().Styles) = %d, want 0", len(report.Styles))
+	}
+}